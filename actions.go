@@ -0,0 +1,458 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Pages of the action menu that Enter opens on the selection.
+const (
+	actionMenuMain    = "main"
+	actionMenuArchive = "archive"
+	actionMenuWindow  = "window"
+	actionMenuCopy    = "copy"
+	actionMenuTag     = "tag"  // Typing a tag
+	actionMenuPath    = "path" // Typing a new archive file
+)
+
+// An action is an entry of an action menu page.
+type action struct {
+	label string
+	run   func(m *model, tabs []Tab) tea.Cmd
+}
+
+// tabsMovedMsg reports tabs moved to another window.
+type tabsMovedMsg struct {
+	count int
+	err   error
+}
+
+// typing reports whether a text prompt has the keyboard.
+func (m model) typing() bool {
+	return m.naming || m.actionMenu == actionMenuTag || m.actionMenu == actionMenuPath
+}
+
+// openActionMenu shows the actions for the selected tabs.
+func (m *model) openActionMenu() {
+	m.actionMenu = actionMenuMain
+	m.actionInput = ""
+}
+
+// actions returns the entries of the current action menu page.
+func (m model) actions() []action {
+	switch m.actionMenu {
+	case actionMenuArchive:
+		actions := []action{{tr("New file..."), func(m *model, _ []Tab) tea.Cmd {
+			m.actionMenu = actionMenuPath
+			return nil
+		}}, {tr("Watch later (videos only)"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.watchLater(tabs)
+		}}, {tr("Doc index (documentation only)"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.exportDocs(tabs)
+		}}, {tr("Wishlist (product pages only)"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.wishlist(tabs)
+		}}}
+		for _, target := range m.archiveTargets() {
+			actions = append(actions, action{target, func(m *model, tabs []Tab) tea.Cmd {
+				return m.archiveTo(target, tabs)
+			}})
+		}
+		return actions
+
+	case actionMenuWindow:
+		actions := []action{{tr("New window"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.moveTo(0, tabs)
+		}}}
+		counts := make(map[int]int)
+		var windows []int
+		for _, tab := range openTabs(m.tabs) {
+			if counts[tab.WindowIndex] == 0 {
+				windows = append(windows, tab.WindowIndex)
+			}
+			counts[tab.WindowIndex]++
+		}
+		sort.Ints(windows)
+		for _, window := range windows {
+			actions = append(actions, action{tr("Window %d (%d tabs)", window, counts[window]), func(m *model, tabs []Tab) tea.Cmd {
+				return m.moveTo(window, tabs)
+			}})
+		}
+		return actions
+
+	case actionMenuCopy:
+		return []action{{tr("URLs"), func(m *model, tabs []Tab) tea.Cmd {
+			lines := make([]string, len(tabs))
+			for i, tab := range tabs {
+				lines[i] = tab.URL
+			}
+			return m.copyLines(lines)
+		}}, {tr("Markdown links"), func(m *model, tabs []Tab) tea.Cmd {
+			lines := make([]string, len(tabs))
+			for i, tab := range tabs {
+				lines[i] = "- " + markdownLink(tab)
+			}
+			return m.copyLines(lines)
+		}}}
+	}
+
+	return []action{
+		{tr("Close"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.closeTabs(tabs)
+		}},
+		{tr("Archive to..."), func(m *model, _ []Tab) tea.Cmd {
+			m.actionMenu = actionMenuArchive
+			return nil
+		}},
+		{tr("Move to window..."), func(m *model, _ []Tab) tea.Cmd {
+			m.actionMenu = actionMenuWindow
+			return nil
+		}},
+		{tr("Tag..."), func(m *model, _ []Tab) tea.Cmd {
+			m.actionMenu = actionMenuTag
+			return nil
+		}},
+		{tr("Block these sites"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.blockSites(tabs)
+		}},
+		{tr("Export as session"), func(m *model, tabs []Tab) tea.Cmd {
+			m.actionMenu = ""
+			saved, err := saveSession("", tabs)
+			if err != nil {
+				return m.showToast(tr("Could not save session: %v", err))
+			}
+			return m.showToast(tr("Saved %d tabs as session %q.", len(saved.Tabs), saved.Name))
+		}},
+		{tr("Share as link list"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.shareTabs(tabs)
+		}},
+		{tr("Copy..."), func(m *model, _ []Tab) tea.Cmd {
+			m.actionMenu = actionMenuCopy
+			return nil
+		}},
+		{tr("Suspend"), func(m *model, tabs []Tab) tea.Cmd {
+			return m.suspend(tabs)
+		}},
+	}
+}
+
+// updateActionMenu handles a key while the action menu is open. A number
+// runs an action; esc goes back a page.
+func (m *model) updateActionMenu(msg tea.KeyMsg) tea.Cmd {
+	tabs := m.selectedTabs()
+
+	if m.actionMenu == actionMenuTag || m.actionMenu == actionMenuPath {
+		switch msg.Type {
+		case tea.KeyEsc:
+			if m.actionMenu == actionMenuPath {
+				m.actionMenu = actionMenuArchive
+			} else {
+				m.actionMenu = actionMenuMain
+			}
+			m.actionInput = ""
+		case tea.KeyEnter:
+			input := strings.TrimSpace(m.actionInput)
+			m.actionInput = ""
+			if input == "" {
+				return nil
+			}
+			if m.actionMenu == actionMenuTag {
+				return m.tagTabs(strings.TrimPrefix(input, "#"), tabs)
+			}
+			return m.archiveToNewFile(input, tabs)
+		default:
+			m.actionInput = editText(m.actionInput, msg)
+		}
+		return nil
+	}
+
+	name := msg.String()
+	switch {
+	case name == "esc" && m.actionMenu != actionMenuMain:
+		m.actionMenu = actionMenuMain
+	case name == "esc" || name == "enter":
+		m.actionMenu = ""
+	case len(name) == 1 && name >= "1" && name <= "9":
+		actions := m.actions()
+		n := int(name[0] - '1')
+		if n >= len(actions) {
+			return nil
+		}
+		if len(tabs) == 0 {
+			m.actionMenu = ""
+			return m.showToast(tr("No tabs selected."))
+		}
+		return actions[n].run(m, tabs)
+	}
+	return nil
+}
+
+// startClosing switches to the progress view for closing count tabs.
+func (m *model) startClosing(count int) {
+	m.actionMenu = ""
+	m.closing = true
+	m.closingTotal = count
+	m.closingCurrent = 0
+	m.closingDone = false
+	m.announce(tr("Closing %d tabs.", count))
+}
+
+// archiveTargets lists the files tabs can be archived to: the default
+// archive file, archive_targets from the config and the targets rules route
+// tabs to, so targets show up as soon as they are used.
+func (m model) archiveTargets() []string {
+	targets := []string{archiveFile}
+	add := func(target string) {
+		if target != "" && !slices.Contains(targets, expandHome(target)) {
+			targets = append(targets, expandHome(target))
+		}
+	}
+	for _, target := range m.extraArchiveTargets {
+		add(target)
+	}
+	for _, tab := range m.tabs {
+		add(tab.ArchiveTarget)
+	}
+	return targets
+}
+
+// archiveTo archives tabs to target, whatever targets rules gave them, and
+// closes them.
+func (m *model) archiveTo(target string, tabs []Tab) tea.Cmd {
+	routed := make([]Tab, len(tabs))
+	for i, tab := range tabs {
+		tab.ArchiveTarget = ""
+		routed[i] = tab
+	}
+	return m.archiveTabs(archiverFor(target), routed)
+}
+
+// archiveOnly archives the tabs among tabs that match to a, and closes them,
+// leaving the others alone. It shows none if there are no such tabs.
+func (m *model) archiveOnly(a archiver, tabs []Tab, match func(Tab) bool, none string) tea.Cmd {
+	var matching []Tab
+	for _, tab := range tabs {
+		if match(tab) {
+			tab.ArchiveTarget = ""
+			matching = append(matching, tab)
+		}
+	}
+	if len(matching) == 0 {
+		m.actionMenu = ""
+		return m.showToast(none)
+	}
+	return m.archiveTabs(a, matching)
+}
+
+// archiveToNewFile adds path to archive_targets in the config, so it is
+// offered from now on, and archives tabs to it.
+func (m *model) archiveToNewFile(path string, tabs []Tab) tea.Cmd {
+	if !strings.HasSuffix(path, ".md") && !isWebhook(path) {
+		path += ".md"
+	}
+	if !slices.Contains(m.extraArchiveTargets, path) {
+		m.extraArchiveTargets = append(m.extraArchiveTargets, path)
+		err := editConfig(func(settings map[string]json.RawMessage) error {
+			var targets []string
+			if raw, ok := settings["archive_targets"]; ok {
+				if err := json.Unmarshal(raw, &targets); err != nil {
+					return fmt.Errorf("could not read archive_targets in config.json: %w", err)
+				}
+			}
+			raw, err := json.Marshal(append(targets, path))
+			settings["archive_targets"] = raw
+			return err
+		})
+		if err != nil {
+			log.Printf("Warning: could not save archive target: %v", err)
+		}
+	}
+	return m.archiveTo(expandHome(path), tabs)
+}
+
+// tagTabs adds a tag to tabs. Tags set here are kept by URL in the state
+// store, on top of the tags rules set, so they come back with their pages.
+func (m *model) tagTabs(tag string, tabs []Tab) tea.Cmd {
+	m.actionMenu = ""
+	urls := make([]string, len(tabs))
+	for i, tab := range tabs {
+		urls[i] = tab.URL
+		if !slices.Contains(m.manualTags[tab.URL], tag) {
+			m.manualTags[tab.URL] = append(m.manualTags[tab.URL], tag)
+		}
+	}
+	if err := stateStore.AddTags(tag, urls); err != nil {
+		log.Printf("Warning: could not save tags: %v", err)
+	}
+	m.applyManualTags()
+	m.refreshItems()
+	return m.showToast(tr("Tagged %d tabs #%s.", len(tabs), tag))
+}
+
+// loadManualTags reads the tags set from the action menu before. Without
+// them the list still works, so errors are only logged.
+func loadManualTags() map[string][]string {
+	tags, err := stateStore.LoadTags()
+	if err != nil {
+		log.Printf("Warning: could not read tags: %v", err)
+		return make(map[string][]string)
+	}
+	return tags
+}
+
+// applyManualTags adds the tags set from the action menu to m.tabs.
+func (m *model) applyManualTags() {
+	for i := range m.tabs {
+		for _, tag := range m.manualTags[m.tabs[i].URL] {
+			if !slices.Contains(m.tabs[i].Tags, tag) {
+				m.tabs[i].Tags = append(m.tabs[i].Tags, tag)
+			}
+		}
+	}
+}
+
+// copyLines puts lines on the clipboard.
+func (m *model) copyLines(lines []string) tea.Cmd {
+	m.actionMenu = ""
+	cmd := exec.Command("pbcopy")
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	if err := cmd.Run(); err != nil {
+		return m.showToast(tr("Could not copy to the clipboard: %v", err))
+	}
+	return m.showToast(tr("Copied %d tabs to the clipboard.", len(lines)))
+}
+
+// moveTo moves tabs to the end of a window, or to a new window if window
+// is 0.
+func (m *model) moveTo(window int, tabs []Tab) tea.Cmd {
+	m.actionMenu = ""
+	m.moving = true
+	return moveTabsAsync(window, tabs)
+}
+
+func moveTabsAsync(window int, tabsToMove []Tab) tea.Cmd {
+	return func() tea.Msg {
+		// Find the tabs as closing does, before a new window shifts the
+		// window indexes
+		matched, err := matchOpenTabs(tabsToMove)
+		if err != nil {
+			return tabsMovedMsg{err: err}
+		}
+		var moving []windowTab
+		for _, tab := range matched {
+			if window == 0 || tab.window != window {
+				moving = append(moving, tab)
+			}
+		}
+
+		// Windows are only stable by id; indexes follow the window order,
+		// which a new window changes
+		script := fmt.Sprintf(`tell application "%s" to get id of window %d`, safariApp, window)
+		if window == 0 {
+			script = fmt.Sprintf(`
+			tell application "%s"
+				make new document
+				return id of front window
+			end tell
+			`, safariApp)
+		}
+		output, err := exec.Command("osascript", "-e", script).Output()
+		if err != nil {
+			return tabsMovedMsg{err: fmt.Errorf("could not find the window: %w", err)}
+		}
+		var windowID int
+		fmt.Sscanf(strings.TrimSpace(string(output)), "%d", &windowID)
+		if window == 0 {
+			// The new window came to the front
+			for i := range moving {
+				moving[i].window++
+			}
+		}
+
+		moved := 0
+		p := newPacer()
+		var took time.Duration
+		// matchOpenTabs has them from the back, so earlier indexes stay valid
+		for i, tab := range moving {
+			if i > 0 {
+				p.pace(took)
+			}
+			began := time.Now()
+			script := fmt.Sprintf(`
+			tell application "%s"
+				move tab %d of window %d to end of tabs of (first window whose id is %d)
+			end tell
+			`, safariApp, tab.tab, tab.window, windowID)
+			err := exec.Command("osascript", "-e", script).Run()
+			took = time.Since(began)
+			if err != nil {
+				log.Printf("Warning: failed to move tab %d in window %d: %v", tab.tab, tab.window, err)
+				continue
+			}
+			moved++
+		}
+
+		// A new window starts with a blank tab of its own
+		if window == 0 && moved > 0 {
+			script := fmt.Sprintf(`
+			tell application "%s"
+				close tab 1 of (first window whose id is %d)
+			end tell
+			`, safariApp, windowID)
+			if err := exec.Command("osascript", "-e", script).Run(); err != nil {
+				log.Printf("Warning: failed to close the new window's blank tab: %v", err)
+			}
+		}
+
+		return tabsMovedMsg{count: moved}
+	}
+}
+
+// actionMenuView shows the current action menu page.
+func (m model) actionMenuView() string {
+	selected := m.countSelected()
+	title := tr("%d selected tabs", selected)
+	switch m.actionMenu {
+	case actionMenuArchive, actionMenuPath:
+		title = tr("Archive %d tabs to", selected)
+	case actionMenuWindow:
+		title = tr("Move %d tabs to", selected)
+	case actionMenuCopy:
+		title = tr("Copy %d tabs as", selected)
+	case actionMenuTag:
+		title = tr("Tag %d tabs", selected)
+	}
+	lines := []string{titleStyle.Render(title), ""}
+
+	var hints []string
+	switch m.actionMenu {
+	case actionMenuTag:
+		lines = append(lines, "  "+tr("Tag: %s", "#"+m.actionInput+"_"))
+		hints = []string{tr("enter: tag"), tr("esc: back")}
+	case actionMenuPath:
+		lines = append(lines, "  "+tr("File: %s", m.actionInput+"_"))
+		hints = []string{tr("enter: archive"), tr("esc: back")}
+	default:
+		for i, action := range m.actions() {
+			if i == 9 {
+				break // Only single digits can pick an action
+			}
+			lines = append(lines, fmt.Sprintf("  %d. %s", i+1, action.label))
+		}
+		hints = []string{tr("1-9: pick"), tr("esc: back")}
+	}
+
+	lines = append(lines, "", helpStyle.Render(" "+strings.Join(hints, sym.separator)))
+	if m.toast != "" {
+		lines = append(lines, messageStyle.Render(" "+m.toast))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// History says when a URL was last visited anywhere, in any tab or on any
+// device, not when a tab itself was last looked at: a tab left alone for
+// months stays fresh as long as its page is visited elsewhere. Safari keeps
+// the time each tab was last active in its session state,
+// LastSession.plist, which it writes as it saves the session. Where a tab is
+// found there, that time decides whether it's old, and history only where
+// it isn't.
+
+// sessionStatePaths returns the places Safari keeps LastSession.plist: the
+// library and, for newer versions, the sandbox container.
+func sessionStatePaths() ([]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	library, container := "Safari", "com.apple.Safari"
+	if safariApp == "Safari Technology Preview" {
+		library, container = "SafariTechnologyPreview", "com.apple.SafariTechnologyPreview"
+	}
+	return []string{
+		filepath.Join(homeDir, "Library", library, "LastSession.plist"),
+		filepath.Join(homeDir, "Library", "Containers", container, "Data", "Library", library, "LastSession.plist"),
+	}, nil
+}
+
+// loadTabActivity reads when each tab in Safari's session state was last
+// active, by URL, in the order the session lists the tabs. It reads the
+// most recently saved session state there is.
+func loadTabActivity() (map[string][]time.Time, error) {
+	paths, err := sessionStatePaths()
+	if err != nil {
+		return nil, err
+	}
+	var path string
+	var saved time.Time
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(saved) {
+			path, saved = p, info.ModTime()
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	output, err := exec.Command("plutil", "-convert", "xml1", "-o", "-", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var root plistNode
+	if err := xml.Unmarshal(output, &root); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if len(root.Nodes) == 0 {
+		return nil, nil
+	}
+
+	activity := make(map[string][]time.Time)
+	windows := root.Nodes[0].value("SessionWindows")
+	if windows == nil {
+		return activity, nil
+	}
+	for i := range windows.Nodes {
+		states := windows.Nodes[i].value("TabStates")
+		if states == nil {
+			continue
+		}
+		for j := range states.Nodes {
+			url, last := states.Nodes[j].value("TabURL"), states.Nodes[j].value("LastVisitTime")
+			if url == nil || last == nil {
+				continue
+			}
+			seconds, err := strconv.ParseFloat(last.Text, 64)
+			if err != nil || seconds <= 0 {
+				continue
+			}
+			activity[url.Text] = append(activity[url.Text], cfAbsoluteTime(seconds))
+		}
+	}
+	return activity, nil
+}
+
+// cfAbsoluteTime converts Core Foundation Absolute Time, seconds since Jan
+// 1, 2001, to a time.
+func cfAbsoluteTime(seconds float64) time.Time {
+	return time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(seconds * float64(time.Second))).Local()
+}
+
+// addTabActivity sets LastActivated on the tabs found in Safari's session
+// state, and decides from it whether they are old. Tabs with the same URL
+// are matched up in order.
+func addTabActivity(tabs []Tab, ageDays int) []Tab {
+	if !capSession.available() {
+		return tabs
+	}
+	activity, err := loadTabActivity()
+	if err != nil {
+		log.Printf("Warning: could not read Safari's session state: %v", err)
+		return tabs
+	}
+
+	ageThreshold := time.Now().AddDate(0, 0, -ageDays)
+	for i := range tabs {
+		times := activity[tabs[i].URL]
+		if len(times) == 0 {
+			continue
+		}
+		tabs[i].LastActivated = times[0]
+		activity[tabs[i].URL] = times[1:]
+		tabs[i].IsOld = tabs[i].LastActivated.Before(ageThreshold)
+	}
+	return tabs
+}
+
+// lastActive returns when a tab was last active, or failing that, when its
+// page was last visited.
+func (t Tab) lastActive() time.Time {
+	if !t.LastActivated.IsZero() {
+		return t.LastActivated
+	}
+	return t.LastVisit
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A selection shows as a color and a checkbox, and a toast is redrawn in
+// place in the status bar, neither of which screen readers follow well.
+// With -announce, every action is also described in a line of its own,
+// printed above the list into the terminal's scrollback, which VoiceOver
+// reads as it appears: the key pressed, followed by what it did, like
+// "[a] Selected 12 duplicates" or "Closed tab: <title>". Lines can't be
+// printed above the alternate screen, so the list then runs in the normal
+// one.
+
+var announceActions bool // Set by -announce
+
+// announce describes an action, printed once the current update is done.
+func (m *model) announce(text string) {
+	if announceActions {
+		m.announcements = append(m.announcements, text)
+	}
+}
+
+// announceTab describes the focused tab after the cursor moved.
+func (m *model) announceTab() {
+	if i, ok := m.list.SelectedItem().(item); ok {
+		m.announce(tr("Tab %d of %d: %s", m.list.Index()+1, len(m.list.Items()), m.tabs[i.index].Title))
+	}
+}
+
+// announceSelection describes a tab selected or deselected by hand.
+func (m *model) announceSelection(i int) {
+	if m.selected.has(i) {
+		m.announce(tr("Selected: %s", m.tabs[i].Title))
+	} else {
+		m.announce(tr("Deselected: %s", m.tabs[i].Title))
+	}
+}
+
+// Update handles msg with update, then prints what it announced, after the
+// key that was pressed.
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	m = next.(model)
+	if len(m.announcements) == 0 {
+		return m, cmd
+	}
+	lines := m.announcements
+	m.announcements = nil
+	if key, ok := msg.(tea.KeyMsg); ok {
+		lines[0] = fmt.Sprintf("[%s] %s", keyName(key), lines[0])
+	}
+	return m, tea.Batch(cmd, tea.Println(strings.Join(lines, "\n")))
+}
+
+// keyName spells out keys that print as blanks.
+func keyName(key tea.KeyMsg) string {
+	if key.Type == tea.KeySpace || key.String() == " " {
+		return tr("space")
+	}
+	return key.String()
+}
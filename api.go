@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiTab is a tab as served by the REST API.
+type apiTab struct {
+	tabRecord
+	Suggested bool `json:"suggested"` // Would be preselected in the interactive list
+}
+
+type apiTabsResponse struct {
+	Tabs      []apiTab  `json:"tabs"`
+	AgeDays   int       `json:"age_days"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+type apiCloseRequest struct {
+	URLs    []string `json:"urls"`
+	Archive bool     `json:"archive"` // Archive before closing
+}
+
+type apiCloseResponse struct {
+	Closed int `json:"closed"`
+}
+
+type apiSessionRequest struct {
+	Name string `json:"name"`
+}
+
+type apiSessionResponse struct {
+	Name string `json:"name"`
+	Tabs int    `json:"tabs"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// handleTabs serves the latest scan.
+func (s *scanner) handleTabs(w http.ResponseWriter, r *http.Request) {
+	tabs, scannedAt, _ := s.snapshot()
+	response := apiTabsResponse{Tabs: make([]apiTab, len(tabs)), AgeDays: s.ageDays, ScannedAt: scannedAt}
+	for i, tab := range tabs {
+		response.Tabs[i] = apiTab{tabRecord: newTabRecord(tab), Suggested: tab.Selected}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleClose closes the tabs with the URLs in the request body.
+func (s *scanner) handleClose(w http.ResponseWriter, r *http.Request) {
+	if err := checkSameOrigin(r); err != nil {
+		writeJSON(w, http.StatusForbidden, apiError{Error: err.Error()})
+		return
+	}
+
+	var request apiCloseRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+
+	closed, err := s.closeURLs(request.URLs, request.Archive)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, apiError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiCloseResponse{Closed: closed})
+}
+
+// handleSaveSession saves the latest scan as a session.
+func (s *scanner) handleSaveSession(w http.ResponseWriter, r *http.Request) {
+	if err := checkSameOrigin(r); err != nil {
+		writeJSON(w, http.StatusForbidden, apiError{Error: err.Error()})
+		return
+	}
+
+	var request apiSessionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+
+	saved, err := s.saveSession(request.Name)
+	if errors.Is(err, errNoScan) {
+		writeJSON(w, http.StatusServiceUnavailable, apiError{Error: err.Error()})
+		return
+	} else if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiSessionResponse{Name: saved.Name, Tabs: len(saved.Tabs)})
+}
+
+// checkSameOrigin refuses requests web pages could forge. Browsers can't
+// send a JSON body cross-origin without a preflight, which is never
+// answered, and they always send Origin with one.
+func checkSameOrigin(r *http.Request) error {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		return errors.New("content type must be application/json")
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return errors.New("cross-origin requests are not allowed")
+		}
+	}
+	return nil
+}
+
+// localOnly refuses requests for any host but this Mac's own. A page whose
+// domain is made to resolve to 127.0.0.1, DNS rebinding, is same-origin
+// with serve mode as far as the browser is concerned, but still names its
+// own domain in Host.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		switch host {
+		case "localhost", "127.0.0.1", "::1":
+			next.ServeHTTP(w, r)
+		default:
+			writeJSON(w, http.StatusForbidden, apiError{Error: "only requests for localhost, 127.0.0.1 or [::1] are allowed"})
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Warning: could not write response: %v", err)
+	}
+}
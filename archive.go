@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// An archiver saves tabs somewhere before they are closed, so closing a
+// tab doesn't mean losing it.
+type archiver interface {
+	Name() string
+	Target() string // The archive target it is for, see archiverFor
+	Archive(tabs []Tab) error
+}
+
+var archiveFile string // Set by the -archive-file flag
+
+// markdownArchiver appends archived tabs to a markdown file as a dated list
+// of links.
+type markdownArchiver struct {
+	path string
+}
+
+func (a markdownArchiver) Name() string {
+	return filepath.Base(a.path)
+}
+
+func (a markdownArchiver) Target() string {
+	return a.path
+}
+
+func (a markdownArchiver) Archive(tabs []Tab) error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", tr("Archived %s", time.Now().Format("2006-01-02 15:04")))
+	for _, tab := range tabs {
+		fmt.Fprintf(&b, "- %s\n", markdownLink(tab))
+	}
+
+	_, err = f.WriteString(b.String())
+	return err
+}
+
+// markdownLink formats a tab as a Markdown link, titled with its URL if it
+// has no title.
+func markdownLink(tab Tab) string {
+	title := tab.Title
+	if title == "" {
+		title = tab.URL
+	}
+	return fmt.Sprintf("[%s](%s)", strings.ReplaceAll(title, "]", `\]`), tab.URL)
+}
+
+// defaultArchiveFile is where archived tabs go unless -archive-file is set.
+func defaultArchiveFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "safari-tab-archive.md"
+	}
+	return filepath.Join(home, "Documents", "Safari Tab Archive.md")
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// archiveFailedMsg aborts an archive-and-close before any tab is closed.
+type archiveFailedMsg struct {
+	err error
+}
+
+// archiveBatch is the tabs going to one archive target.
+type archiveBatch struct {
+	to   archiver
+	tabs []Tab
+}
+
+// archiveBatches groups tabs by archive target, in the order the targets
+// first appear: the target a rule gave a tab, or a.
+func archiveBatches(a archiver, tabs []Tab) []archiveBatch {
+	var batches []archiveBatch
+	index := make(map[string]int)
+	for _, tab := range tabs {
+		i, ok := index[tab.ArchiveTarget]
+		if !ok {
+			to := a
+			if tab.ArchiveTarget != "" {
+				to = archiverFor(tab.ArchiveTarget)
+			}
+			i = len(batches)
+			index[tab.ArchiveTarget] = i
+			batches = append(batches, archiveBatch{to: to})
+		}
+		batches[i].tabs = append(batches[i].tabs, tab)
+	}
+	return batches
+}
+
+// archiveProgressMsg reports that batch index of an archive is done, or
+// was queued to retry after failing with err.
+type archiveProgressMsg struct {
+	index int
+	err   error
+}
+
+// archiveTabsAsync archives tabs and then closes them, calling progress, if
+// not nil, as each target is done. Tabs with an archive target from a rule
+// go to that file or webhook instead of a. A target that fails, say for a
+// network error or a rate limit, has its tabs queued for archive
+// -retry-failed, and they are closed with the rest; nothing is closed if
+// even queueing them fails.
+func archiveTabsAsync(a archiver, tabsToArchive []Tab, emptyWindows []int, progress func(archiveProgressMsg)) tea.Cmd {
+	return func() tea.Msg {
+		var failed []failedArchive
+		queued := 0
+		for i, batch := range archiveBatches(a, tabsToArchive) {
+			err := batch.to.Archive(batch.tabs)
+			if err != nil {
+				// A webhook may have got some of its messages already
+				unsent := archiveBatch{to: batch.to, tabs: unsentTabs(err, batch.tabs)}
+				log.Printf("Warning: could not archive %d tabs to %s, queueing them to retry: %v", len(unsent.tabs), batch.to.Name(), err)
+				failed = append(failed, failedArchive{batch: unsent, err: err})
+				queued += len(unsent.tabs)
+			}
+			if progress != nil {
+				progress(archiveProgressMsg{index: i, err: err})
+			}
+		}
+		if err := queueArchives(failed); err != nil {
+			return archiveFailedMsg{err: err}
+		}
+
+		recordStats(0, len(tabsToArchive))
+		logActions("archive", tabsToArchive)
+		runHookAndLog("on_archive", hooks.OnArchive, tabsToArchive)
+		msg := closeTabsAsync(tabsToArchive, emptyWindows)()
+		if complete, ok := msg.(closingCompleteMsg); ok {
+			complete.queued = queued
+			return complete
+		}
+		return msg
+	}
+}
+
+// archiveStatus is how one target of an archive in progress is doing, for
+// the list shown while archiving.
+type archiveStatus struct {
+	name string
+	tabs int
+	done bool
+	err  error
+}
+
+// archiveTabs archives tabs to a, showing each target's progress, and closes
+// them.
+func (m *model) archiveTabs(a archiver, tabs []Tab) tea.Cmd {
+	batches := archiveBatches(a, tabs)
+	m.archiving = make([]archiveStatus, len(batches))
+	for i, batch := range batches {
+		m.archiving[i] = archiveStatus{name: batch.to.Name(), tabs: len(batch.tabs)}
+	}
+	updates := make(chan archiveProgressMsg, len(batches))
+	m.archiveUpdates = updates
+	m.startClosing(len(tabs))
+
+	archive := archiveTabsAsync(a, tabs, m.emptyPinnedOnlyWindows, func(msg archiveProgressMsg) { updates <- msg })
+	return tea.Batch(func() tea.Msg {
+		defer close(updates)
+		return archive()
+	}, waitArchiveProgress(updates))
+}
+
+// waitArchiveProgress waits for the next archiveProgressMsg, or nothing once
+// archiving is over.
+func waitArchiveProgress(updates <-chan archiveProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := <-updates; ok {
+			return msg
+		}
+		return nil
+	}
+}
+
+// archivingView lists the targets of the archive in progress, with how
+// each is doing.
+func (m model) archivingView() string {
+	var b strings.Builder
+	waiting := false
+	for _, status := range m.archiving {
+		var line string
+		switch {
+		case status.err != nil:
+			line = duplicateStyle.Render(tr("%s: %d tabs queued to retry (%v)", status.name, status.tabs, status.err))
+		case status.done:
+			line = normalStyle.Render(tr("%s: %d tabs archived", status.name, status.tabs))
+		case !waiting:
+			line = normalStyle.Render(tr("%s: archiving %d tabs...", status.name, status.tabs))
+			waiting = true
+		default:
+			line = helpStyle.Render(tr("%s: %d tabs waiting", status.name, status.tabs))
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String()
+}
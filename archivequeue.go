@@ -0,0 +1,308 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Tabs that couldn't be archived, because the Mac was offline, a webhook
+// was down or rate limited or a file couldn't be written, are closed anyway
+// but kept in a queue in the cache directory, by target. archive lists the
+// queue and archive -retry-failed tries each target again, keeping what
+// still fails. Serve mode flushes the queue by itself, trying each webhook
+// again once its host can be reached, so archiving works offline.
+
+// queuedArchive is the tabs waiting to be archived to one target.
+type queuedArchive struct {
+	Target   string      `json:"target"`
+	Error    string      `json:"error"` // Why the last try failed
+	QueuedAt time.Time   `json:"queued_at"`
+	Tabs     []tabRecord `json:"tabs"`
+}
+
+// failedArchive is a batch that failed, to be queued.
+type failedArchive struct {
+	batch archiveBatch
+	err   error
+}
+
+var archiveQueueMu sync.Mutex
+
+func archiveQueuePath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "archive-queue.json"), nil
+}
+
+// lockArchiveQueue locks the queue at path until the returned function is
+// called. Serve mode flushes it while the list may be adding to it, so
+// besides archiveQueueMu it takes a lock on archive-queue.json.lock that
+// other processes wait for too.
+func lockArchiveQueue(path string) (func(), error) {
+	archiveQueueMu.Lock()
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		archiveQueueMu.Unlock()
+		return nil, fmt.Errorf("could not lock the archive queue: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		archiveQueueMu.Unlock()
+		return nil, fmt.Errorf("could not lock the archive queue: %w", err)
+	}
+	return func() {
+		f.Close() // Releases the lock
+		archiveQueueMu.Unlock()
+	}, nil
+}
+
+// loadArchiveQueue reads the queue. A missing queue is empty.
+func loadArchiveQueue() ([]queuedArchive, error) {
+	path, err := archiveQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lockArchiveQueue(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var queue []queuedArchive
+	return queue, loadJSON(path, &queue)
+}
+
+// queueArchives adds failed batches to the queue, joining tabs queued for
+// the same target.
+func queueArchives(failed []failedArchive) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	path, err := archiveQueuePath()
+	if err != nil {
+		return fmt.Errorf("could not queue tabs to archive later: %w", err)
+	}
+
+	unlock, err := lockArchiveQueue(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var queue []queuedArchive
+	if err := loadJSON(path, &queue); err != nil {
+		return fmt.Errorf("could not read the archive queue: %w", err)
+	}
+	for _, f := range failed {
+		target := f.batch.to.Target()
+		i := 0
+		for i < len(queue) && queue[i].Target != target {
+			i++
+		}
+		if i == len(queue) {
+			queue = append(queue, queuedArchive{Target: target, QueuedAt: time.Now()})
+		}
+		queue[i].Error = f.err.Error()
+		for _, tab := range f.batch.tabs {
+			queue[i].Tabs = append(queue[i].Tabs, newTabRecord(tab))
+		}
+	}
+	if err := saveJSON(path, queue); err != nil {
+		return fmt.Errorf("could not queue tabs to archive later: %w", err)
+	}
+	return nil
+}
+
+// queuedArchiver returns the archiver for a queued target: an archive file
+// or webhook, or one of the special archives, whose targets are the kind
+// and path.
+func queuedArchiver(target string) archiver {
+	kind, path, _ := strings.Cut(target, ":")
+	switch kind {
+	case "docs":
+		return docIndexArchiver{dir: path}
+	case "wishlist":
+		return wishlistArchiver{path: path}
+	case "watch-later":
+		return watchLaterArchiver{path: path}
+	}
+	return archiverFor(target)
+}
+
+// reachable reports whether an archive target can be reached: whether a
+// webhook's host accepts connections. Files always can.
+func reachable(target string) bool {
+	if !isWebhook(target) {
+		return true
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), reachTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+const reachTimeout = 3 * time.Second
+
+// retryArchives archives the queued tabs again, target by target, calling
+// done for each, and keeps those that fail again in the queue. With ready,
+// only the targets it accepts are tried; the rest wait as they are. It
+// returns how many tabs are still queued.
+func retryArchives(ready func(entry queuedArchive) bool, done func(entry queuedArchive, err error)) (int, error) {
+	path, err := archiveQueuePath()
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := lockArchiveQueue(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var queue []queuedArchive
+	if err := loadJSON(path, &queue); err != nil {
+		return 0, fmt.Errorf("could not read the archive queue: %w", err)
+	}
+	var remaining []queuedArchive
+	left := 0
+	for _, entry := range queue {
+		if ready != nil && !ready(entry) {
+			remaining = append(remaining, entry)
+			left += len(entry.Tabs)
+			continue
+		}
+		tabs := make([]Tab, len(entry.Tabs))
+		for i, record := range entry.Tabs {
+			tabs[i] = record.tab()
+		}
+		// Numbered to find the records of the tabs a webhook didn't get
+		numberTabs(tabs)
+		err := queuedArchiver(entry.Target).Archive(tabs)
+		if err != nil {
+			var unsent []tabRecord
+			for _, tab := range unsentTabs(err, tabs) {
+				unsent = append(unsent, entry.Tabs[tab.ID-1])
+			}
+			entry.Tabs = unsent
+			entry.Error = err.Error()
+			remaining = append(remaining, entry)
+			left += len(entry.Tabs)
+		}
+		done(entry, err)
+	}
+
+	if len(remaining) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return left, err
+		}
+		return 0, nil
+	}
+	return left, saveJSON(path, remaining)
+}
+
+// flushArchiveQueue archives the queued tabs whose targets can be reached
+// again. Serve mode calls it after every scan.
+func (s *scanner) flushArchiveQueue() {
+	queue, err := loadArchiveQueue()
+	if err != nil {
+		log.Printf("Warning: could not read the archive queue: %v", err)
+		return
+	}
+	if len(queue) == 0 {
+		return
+	}
+	left, err := retryArchives(func(entry queuedArchive) bool {
+		return reachable(entry.Target)
+	}, func(entry queuedArchive, err error) {
+		name := queuedArchiver(entry.Target).Name()
+		if err != nil {
+			log.Printf("Warning: could not archive %d queued tabs to %s: %v", len(entry.Tabs), name, err)
+			return
+		}
+		log.Print(tr("%s: %d tabs archived", name, len(entry.Tabs)))
+	})
+	if err != nil {
+		log.Printf("Warning: could not flush the archive queue: %v", err)
+	} else if left == 0 {
+		log.Print(tr("The archive queue is empty."))
+	}
+}
+
+// queuedNote tells how many of the tabs just closed wait in the queue, if
+// any, to follow a message about closing them.
+func queuedNote(queued int) string {
+	if queued == 0 {
+		return ""
+	}
+	return " " + tr("%d tabs couldn't be archived and are queued; run archive -retry-failed to try again.", queued)
+}
+
+// runArchive is the archive subcommand: it lists the queue, or with
+// -retry-failed flushes it.
+func runArchive(args []string) {
+	flags := flag.NewFlagSet("archive", flag.ExitOnError)
+	retry := flags.Bool("retry-failed", false, tr("Archive the tabs queued after failing, keeping those that fail again"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args)
+
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	if !*retry {
+		queue, err := loadArchiveQueue()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		if len(queue) == 0 {
+			fmt.Println(tr("No tabs are waiting to be archived."))
+			return
+		}
+		for _, entry := range queue {
+			fmt.Println(tr("%s: %d tabs, queued %s (%s)", queuedArchiver(entry.Target).Name(), len(entry.Tabs), shortDateTime(entry.QueuedAt), entry.Error))
+		}
+		fmt.Println(tr("Run archive -retry-failed to archive them."))
+		return
+	}
+
+	left, err := retryArchives(nil, func(entry queuedArchive, err error) {
+		name := queuedArchiver(entry.Target).Name()
+		if err != nil {
+			fmt.Println(tr("%s: %d tabs still queued (%v)", name, len(entry.Tabs), err))
+		} else {
+			fmt.Println(tr("%s: %d tabs archived", name, len(entry.Tabs)))
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if left > 0 {
+		os.Exit(1)
+	}
+}
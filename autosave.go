@@ -0,0 +1,522 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// With autosave on, serve mode saves the open tabs on a schedule, hourly
+// or daily, into a SQLite database of its own, so "what was open last
+// Tuesday?" has an answer. Between two saves most tabs stay the same, so
+// storage is deduplicated twice over: a page, a URL with its title, is
+// stored once however many saves it is in, and a save of tabs that didn't
+// change at all shares its state with the earlier one:
+//
+//	pages       every page seen, referenced by states
+//	states      a set of open tabs, by a hash of their pages and places
+//	state_tabs  the tabs of each state
+//	autosaves   one row per save, referencing its state
+//
+// Old saves thin out like Time Machine's backups: every save of the last
+// keep_hourly hours is kept, then the last of each day for keep_daily
+// days, and the last of each week for keep_weekly weeks.
+
+const autosaveSchema = `
+CREATE TABLE IF NOT EXISTS pages (
+	id INTEGER PRIMARY KEY,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL,
+	UNIQUE (url, title)
+);
+CREATE TABLE IF NOT EXISTS states (
+	id INTEGER PRIMARY KEY,
+	hash TEXT NOT NULL UNIQUE,
+	tabs INTEGER NOT NULL,
+	windows INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS state_tabs (
+	state_id INTEGER NOT NULL REFERENCES states(id),
+	window INTEGER NOT NULL,
+	tab INTEGER NOT NULL,
+	page_id INTEGER NOT NULL REFERENCES pages(id),
+	PRIMARY KEY (state_id, window, tab)
+);
+CREATE TABLE IF NOT EXISTS autosaves (
+	id INTEGER PRIMARY KEY,
+	saved_at TEXT NOT NULL,
+	state_id INTEGER NOT NULL REFERENCES states(id)
+);
+CREATE INDEX IF NOT EXISTS autosaves_saved_at ON autosaves(saved_at);
+CREATE INDEX IF NOT EXISTS state_tabs_page ON state_tabs(page_id);
+`
+
+// autosaveConfig is how often serve mode saves the open tabs, and how long
+// the saves are kept.
+type autosaveConfig struct {
+	Every      string `json:"every"`       // "hourly" or "daily"; empty turns autosaves off
+	KeepHourly int    `json:"keep_hourly"` // Hours every save is kept for, 24 by default
+	KeepDaily  int    `json:"keep_daily"`  // Days the last save of each is kept for, 30 by default
+	KeepWeekly int    `json:"keep_weekly"` // Weeks the last save of each is kept for, 52 by default
+}
+
+var autosave = autosaveConfig{KeepHourly: 24, KeepDaily: 30, KeepWeekly: 52} // Overridden from config.json and -autosave
+
+// validate checks the interval.
+func (c autosaveConfig) validate() error {
+	if c.Every != "" && c.Every != "hourly" && c.Every != "daily" {
+		return fmt.Errorf("unknown autosave interval %q, want hourly or daily", c.Every)
+	}
+	return nil
+}
+
+// interval is how long after a save the next one is due.
+func (c autosaveConfig) interval() time.Duration {
+	if c.Every == "daily" {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// An autosaveEntry is one save of the open tabs.
+type autosaveEntry struct {
+	id      int64
+	savedAt time.Time
+	state   int64
+	tabs    int
+	windows int
+}
+
+// autosavesPath returns the path of the autosave database.
+func autosavesPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "autosaves.db"), nil
+}
+
+// openAutosaves opens the autosave database in the config directory,
+// creating it if needed.
+func openAutosaves() (*sql.DB, error) {
+	path, err := autosavesPath()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	if _, err := db.Exec(autosaveSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create tables in %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// stateHash identifies a set of tabs by their pages and places.
+func stateHash(tabs []Tab) string {
+	h := sha256.New()
+	for _, tab := range tabs {
+		fmt.Fprintf(h, "%d\t%d\t%s\t%s\n", tab.WindowIndex, tab.TabIndex, tab.URL, tab.Title)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addAutosave saves tabs as of now. Tabs that were saved before exactly as
+// they are share the earlier save's state, which is reported as unchanged.
+func addAutosave(db *sql.DB, tabs []Tab, now time.Time) (unchanged bool, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	hash := stateHash(tabs)
+	var stateID int64
+	err = tx.QueryRow(`SELECT id FROM states WHERE hash = ?`, hash).Scan(&stateID)
+	switch {
+	case err == nil:
+		unchanged = true
+	case errors.Is(err, sql.ErrNoRows):
+		if stateID, err = addState(tx, hash, tabs); err != nil {
+			return false, err
+		}
+	default:
+		return false, err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO autosaves (saved_at, state_id) VALUES (?, ?)`, sqlTime(now), stateID); err != nil {
+		return false, err
+	}
+	return unchanged, tx.Commit()
+}
+
+// addState stores a new state of tabs, adding the pages not stored yet.
+func addState(tx *sql.Tx, hash string, tabs []Tab) (int64, error) {
+	windows := make(map[int]bool)
+	for _, tab := range tabs {
+		windows[tab.WindowIndex] = true
+	}
+	result, err := tx.Exec(`INSERT INTO states (hash, tabs, windows) VALUES (?, ?, ?)`, hash, len(tabs), len(windows))
+	if err != nil {
+		return 0, err
+	}
+	stateID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, tab := range tabs {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO pages (url, title) VALUES (?, ?)`, tab.URL, tab.Title); err != nil {
+			return 0, err
+		}
+		var pageID int64
+		if err := tx.QueryRow(`SELECT id FROM pages WHERE url = ? AND title = ?`, tab.URL, tab.Title).Scan(&pageID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO state_tabs (state_id, window, tab, page_id) VALUES (?, ?, ?, ?)`,
+			stateID, tab.WindowIndex, tab.TabIndex, pageID); err != nil {
+			return 0, err
+		}
+	}
+	return stateID, nil
+}
+
+// keptAutosaves decides which saves the retention policy keeps, given
+// their times newest first.
+func keptAutosaves(times []time.Time, now time.Time, policy autosaveConfig) []bool {
+	kept := make([]bool, len(times))
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for i, t := range times {
+		if now.Sub(t) < time.Duration(policy.KeepHourly)*time.Hour {
+			kept[i] = true
+		}
+		// Newest first, so the first save seen of a day or week is its last
+		ago := calendarDaysBetween(t, now)
+		day := t.Local().Format("2006-01-02")
+		if ago < policy.KeepDaily && !days[day] {
+			kept[i] = true
+		}
+		days[day] = true
+		year, week := t.Local().ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+		if ago < policy.KeepWeekly*7 && !weeks[weekKey] {
+			kept[i] = true
+		}
+		weeks[weekKey] = true
+	}
+	return kept
+}
+
+// pruneAutosaves deletes the saves the retention policy doesn't keep, and
+// then the states and pages no save needs anymore. It returns how many
+// saves it deleted.
+func pruneAutosaves(db *sql.DB, now time.Time) (int, error) {
+	entries, err := loadAutosaves(db)
+	if err != nil {
+		return 0, err
+	}
+	times := make([]time.Time, len(entries))
+	for i, entry := range entries {
+		times[i] = entry.savedAt
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deleted int
+	for i, kept := range keptAutosaves(times, now, autosave) {
+		if kept {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM autosaves WHERE id = ?`, entries[i].id); err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	for _, query := range []string{
+		`DELETE FROM state_tabs WHERE state_id NOT IN (SELECT state_id FROM autosaves)`,
+		`DELETE FROM states WHERE id NOT IN (SELECT state_id FROM autosaves)`,
+		`DELETE FROM pages WHERE id NOT IN (SELECT page_id FROM state_tabs)`,
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return 0, err
+		}
+	}
+	return deleted, tx.Commit()
+}
+
+const autosaveColumns = `a.id, a.saved_at, a.state_id, s.tabs, s.windows FROM autosaves a JOIN states s ON s.id = a.state_id`
+
+// loadAutosaves lists the saves, the most recent first.
+func loadAutosaves(db *sql.DB) ([]autosaveEntry, error) {
+	rows, err := db.Query(`SELECT ` + autosaveColumns + ` ORDER BY a.saved_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []autosaveEntry
+	for rows.Next() {
+		entry, err := scanAutosave(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// autosaveAt finds the last save at or before t.
+func autosaveAt(db *sql.DB, t time.Time) (autosaveEntry, error) {
+	row := db.QueryRow(`SELECT `+autosaveColumns+` WHERE a.saved_at <= ? ORDER BY a.saved_at DESC LIMIT 1`, sqlTime(t))
+	entry, err := scanAutosave(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, errors.New(tr("no autosave at or before %s", fullDateTime(t)))
+	}
+	return entry, err
+}
+
+// scanAutosave reads a save selected with autosaveColumns.
+func scanAutosave(row interface{ Scan(...any) error }) (autosaveEntry, error) {
+	var entry autosaveEntry
+	var savedAt string
+	if err := row.Scan(&entry.id, &savedAt, &entry.state, &entry.tabs, &entry.windows); err != nil {
+		return entry, err
+	}
+	t, err := parseSQLTime(savedAt)
+	entry.savedAt = t
+	return entry, err
+}
+
+// autosaveTabs reads the tabs of a save, by window and tab.
+func autosaveTabs(db *sql.DB, entry autosaveEntry) ([]Tab, error) {
+	rows, err := db.Query(`SELECT t.window, t.tab, p.url, p.title FROM state_tabs t JOIN pages p ON p.id = t.page_id
+		WHERE t.state_id = ? ORDER BY t.window, t.tab`, entry.state)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tabs []Tab
+	for rows.Next() {
+		var tab Tab
+		if err := rows.Scan(&tab.WindowIndex, &tab.TabIndex, &tab.URL, &tab.Title); err != nil {
+			return nil, err
+		}
+		tabs = append(tabs, tab)
+	}
+	return tabs, rows.Err()
+}
+
+// checkAutosave saves the latest scan when an autosave is due, and prunes
+// the saves past the retention policy.
+func (s *scanner) checkAutosave() {
+	if autosave.Every == "" {
+		return
+	}
+	if !s.autosavedAt.IsZero() && time.Since(s.autosavedAt) < autosave.interval() {
+		return
+	}
+	tabs, scannedAt, _ := s.snapshot()
+	if scannedAt.IsZero() {
+		return
+	}
+
+	db, err := openAutosaves()
+	if err != nil {
+		log.Printf("Warning: could not autosave: %v", err)
+		return
+	}
+	defer db.Close()
+
+	// After a restart, the schedule carries on from the last save
+	if s.autosavedAt.IsZero() {
+		if last, err := autosaveAt(db, time.Now()); err == nil {
+			s.autosavedAt = last.savedAt
+			if time.Since(last.savedAt) < autosave.interval() {
+				return
+			}
+		}
+	}
+
+	now := time.Now()
+	if _, err := addAutosave(db, tabs, now); err != nil {
+		log.Printf("Warning: could not autosave: %v", err)
+		return
+	}
+	s.autosavedAt = now
+	if _, err := pruneAutosaves(db, now); err != nil {
+		log.Printf("Warning: could not prune autosaves: %v", err)
+	}
+}
+
+// weekdays are the day names -at takes, in time.Weekday order.
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// parseWhen reads the time -at looks back to: "yesterday", a weekday like
+// "tuesday" or "last tuesday" for the most recent one before today, a date
+// like 2024-05-03, or a date and time like "2024-05-03 15:00". A day on
+// its own means its end, so it finds the last save that day.
+func parseWhen(text string, now time.Time) (time.Time, error) {
+	text = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(text)), "last ")
+	now = now.Local()
+	endOfDay := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.Local).Add(-time.Millisecond)
+	}
+
+	switch text {
+	case "now", "today":
+		return now, nil
+	case "yesterday":
+		return endOfDay(now.AddDate(0, 0, -1)), nil
+	}
+	for day, name := range weekdays {
+		if text == name {
+			ago := (int(now.Weekday()) - day + 7) % 7
+			if ago == 0 {
+				ago = 7
+			}
+			return endOfDay(now.AddDate(0, 0, -ago)), nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", text, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", text, time.Local); err == nil {
+		return endOfDay(t), nil
+	}
+	return time.Time{}, fmt.Errorf("can't read %q as a time, try yesterday, tuesday, 2024-05-03 or \"2024-05-03 15:00\"", text)
+}
+
+// runAutosaves is the autosaves subcommand: it lists the saves serve mode
+// made, or with -at shows, saves as a session or restores the tabs of the
+// last one at or before a time.
+func runAutosaves(args []string) {
+	flags := flag.NewFlagSet("autosaves", flag.ExitOnError)
+	at := flags.String("at", "", tr("Show the tabs of the last autosave at or before this time: yesterday, tuesday, 2024-05-03 or \"2024-05-03 15:00\""))
+	save := flags.String("save", "", tr("Save the tabs of the autosave as a session with this name"))
+	restore := flags.Bool("restore", false, tr("Open the tabs of the autosave in a new window"))
+	background := flags.Bool("background", false, tr("Open the tabs without bringing Safari to the front, in a window behind the others"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args)
+
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	db, err := openAutosaves()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if *at == "" && *save == "" && !*restore {
+		if err := printAutosaves(db); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	when := time.Now()
+	if *at != "" {
+		if when, err = parseWhen(*at, when); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+	}
+	entry, err := autosaveAt(db, when)
+	if err == nil {
+		err = showAutosave(db, entry, *save, *restore, *background)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+}
+
+// printAutosaves lists the saves, marking those that found the tabs as the
+// save before them left them.
+func printAutosaves(db *sql.DB) error {
+	entries, err := loadAutosaves(db)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println(tr("No autosaves yet. Set \"autosave\" in the config or run serve with -autosave hourly."))
+		return nil
+	}
+	for i, entry := range entries {
+		line := fmt.Sprintf("%s  %s", fullDateTime(entry.savedAt), tr("%d tabs in %d windows", entry.tabs, entry.windows))
+		if i+1 < len(entries) && entries[i+1].state == entry.state {
+			line += sym.separator + tr("unchanged")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// showAutosave prints the tabs of a save by window, unless they're saved
+// as a session or restored instead.
+func showAutosave(db *sql.DB, entry autosaveEntry, name string, restore, background bool) error {
+	tabs, err := autosaveTabs(db, entry)
+	if err != nil {
+		return err
+	}
+
+	if name != "" {
+		s, err := saveSession(name, tabs)
+		if err != nil {
+			return err
+		}
+		fmt.Println(tr("Saved %d tabs of the autosave of %s as session %q.", len(s.Tabs), fullDateTime(entry.savedAt), s.Name))
+	}
+	if restore {
+		urls := make([]string, len(tabs))
+		for i, tab := range tabs {
+			urls[i] = tab.URL
+		}
+		opened, err := openURLs(urls, background)
+		if err != nil {
+			return err
+		}
+		fmt.Println(tr("Opened %d tabs of the autosave of %s.", opened, fullDateTime(entry.savedAt)))
+	}
+	if name != "" || restore {
+		return nil
+	}
+
+	fmt.Println(tr("Autosave of %s: %d tabs in %d windows", fullDateTime(entry.savedAt), entry.tabs, entry.windows))
+	window := -1
+	for _, tab := range tabs {
+		if tab.WindowIndex != window {
+			window = tab.WindowIndex
+			fmt.Println("\n" + tr("Window %d", window))
+		}
+		fmt.Printf("  %s\n    %s\n", singleLine(tab.Title), tab.URL)
+	}
+	return nil
+}
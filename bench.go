@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// runBench is the bench subcommand: it times each phase of loading the
+// current Safari tabs into the list, to show where huge tab counts spend
+// their time. Nothing is closed or changed.
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	runs := flags.Int("runs", 3, tr("Number of times to run each phase"))
+	cpuProfile := flags.String("cpuprofile", "", tr("Write a CPU profile to this file"))
+	memProfile := flags.String("memprofile", "", tr("Write a memory profile to this file"))
+	flags.Parse(args)
+
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flags, cfg, ageDays)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	if *runs < 1 {
+		fmt.Fprintln(os.Stderr, tr("Error: runs must be at least 1"))
+		os.Exit(1)
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	report, tabCount, err := benchPhases(*runs, *ageDays, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	fmt.Println(tr("Safari Tab Manager %s, %d tabs, %d runs", Version, tabCount, *runs))
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", tr("phase"), tr("best"), tr("mean"), tr("per tab"))
+	var total time.Duration
+	for _, phase := range report {
+		best, mean := phase.best(), phase.mean()
+		total += mean
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", phase.name, roundDuration(best), roundDuration(mean), roundDuration(mean/time.Duration(max(tabCount, 1))))
+	}
+	fmt.Fprintf(w, "%s\t\t%s\t\t\n", tr("total"), roundDuration(total))
+	w.Flush()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Println()
+	fmt.Println(tr("Heap in use: %.1f MB, allocated in total: %.1f MB", float64(mem.HeapInuse)/(1<<20), float64(mem.TotalAlloc)/(1<<20)))
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+	}
+}
+
+// A benchPhase holds how long each run of one phase took.
+type benchPhase struct {
+	name  string
+	times []time.Duration
+}
+
+func (p benchPhase) best() time.Duration {
+	best := p.times[0]
+	for _, t := range p.times[1:] {
+		if t < best {
+			best = t
+		}
+	}
+	return best
+}
+
+func (p benchPhase) mean() time.Duration {
+	var sum time.Duration
+	for _, t := range p.times {
+		sum += t
+	}
+	return sum / time.Duration(len(p.times))
+}
+
+// benchPhases runs the phases getSafariTabs and the list go through, in
+// order, runs times over, each run starting from a fresh enumeration.
+func benchPhases(runs, ageDays int, cfg config) ([]benchPhase, int, error) {
+	names := []string{tr("enumeration"), tr("pinned detection"), tr("history enrichment"), tr("categories"), tr("projects"), tr("dedupe"), tr("rules"), tr("list build"), tr("render")}
+	report := make([]benchPhase, len(names))
+	for i, name := range names {
+		report[i].name = name
+	}
+
+	tabCount := 0
+	for run := 0; run < runs; run++ {
+		phase := 0
+		timed := func(f func()) {
+			began := time.Now()
+			f()
+			report[phase].times = append(report[phase].times, time.Since(began))
+			phase++
+		}
+
+		var tabs []Tab
+		var err error
+		timed(func() { tabs, err = getSafariTabsRaw() })
+		if err != nil {
+			return nil, 0, err
+		}
+		tabCount = len(tabs)
+		timed(func() {
+			tabs, _ = markPinnedTabs(tabs)
+			markVideos(tabs)
+			markDocSets(tabs)
+			markProducts(tabs)
+		})
+		timed(func() {
+			tabs = enrichWithVisitData(tabs, ageDays)
+			tabs = addTabActivity(tabs, ageDays)
+			tabs = applyOldRule(tabs, ageDays)
+			markExpired(tabs)
+		})
+		timed(func() {
+			tabs = categorizeTabs(tabs, loadCategories())
+			markLanguages(tabs)
+		})
+		timed(func() { markProjects(tabs) })
+		numberTabs(tabs)
+		timed(func() { tabs = findDuplicates(tabs) })
+		timed(func() { tabs = applyRules(tabs) })
+
+		var m model
+		timed(func() {
+			delegate := itemDelegate{badges: cfg.Badges, layout: cfg.Layout}
+			ids := new(tabIDs)
+			ids.assign(tabs)
+			m = model{list: list.New(nil, delegate, 120, 40), tabs: tabs, selected: suggestedSelection(tabs), ids: ids, ageDays: ageDays, delegate: delegate, manualTags: make(map[string][]string)}
+			m.refreshItems()
+		})
+		timed(func() { m.View() })
+	}
+	return report, tabCount, nil
+}
+
+// roundDuration keeps three significant digits or so, enough to compare.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(10 * time.Nanosecond)
+}
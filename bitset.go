@@ -0,0 +1,32 @@
+package main
+
+import "math/bits"
+
+// A bitset holds one bit per tab, by the tab's index in the model's tabs.
+// The list items share it with the model, so selecting a tab flips a bit
+// that the next render picks up, without rebuilding a single item.
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<(i%64)) != 0
+}
+
+func (b bitset) set(i int, on bool) {
+	if on {
+		b[i/64] |= 1 << (i % 64)
+	} else {
+		b[i/64] &^= 1 << (i % 64)
+	}
+}
+
+func (b bitset) count() int {
+	n := 0
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
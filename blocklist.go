@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The blocklist holds sites that should never stay open: the TUI preselects
+// their tabs and marks them blocked, and serve mode closes them on sight.
+// It lives in config.json and can be edited with the block subcommand or
+// from the action menu.
+type blocklistConfig struct {
+	Sites  []string `json:"sites"`  // Domains, which include their subdomains, or URL prefixes like "youtube.com/shorts"
+	Action string   `json:"action"` // What serve mode does with blocked tabs: "close" (the default), "archive" or "warn"
+}
+
+var blocklist blocklistConfig // Set from config.json
+
+// blocked reports whether a URL is on the blocklist.
+func blocked(rawURL string) bool {
+	for _, site := range blocklist.Sites {
+		if blockedBy(rawURL, site) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedBy reports whether a URL matches one blocklist entry. An entry with
+// a path matches URLs on its domain whose path starts with it.
+func blockedBy(rawURL, site string) bool {
+	site = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(site, "https://"), "http://"), "/")
+	domain, path, hasPath := strings.Cut(site, "/")
+	if !onDomain(rawURL, []string{domain}) {
+		return false
+	}
+	if !hasPath {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimPrefix(u.Path, "/"), path)
+}
+
+// editBlocklist changes the blocklist in config.json, keeping its action.
+func editBlocklist(edit func(sites []string) []string) ([]string, error) {
+	var list blocklistConfig
+	err := editConfig(func(settings map[string]json.RawMessage) error {
+		if raw, ok := settings["blocklist"]; ok {
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return fmt.Errorf("could not read blocklist in config.json: %w", err)
+			}
+		}
+		list.Sites = edit(list.Sites)
+		raw, err := json.Marshal(list)
+		settings["blocklist"] = raw
+		return err
+	})
+	return list.Sites, err
+}
+
+// addToBlocklist adds sites that aren't on the blocklist yet.
+func addToBlocklist(sites []string) ([]string, error) {
+	return editBlocklist(func(list []string) []string {
+		for _, site := range sites {
+			if !slices.Contains(list, site) {
+				list = append(list, site)
+			}
+		}
+		return list
+	})
+}
+
+// removeFromBlocklist takes sites off the blocklist.
+func removeFromBlocklist(sites []string) ([]string, error) {
+	return editBlocklist(func(list []string) []string {
+		return slices.DeleteFunc(list, func(site string) bool {
+			return slices.Contains(sites, site)
+		})
+	})
+}
+
+// blockSites puts the domains of the tabs on the blocklist, and marks and
+// selects every open tab on them.
+func (m *model) blockSites(tabs []Tab) tea.Cmd {
+	m.actionMenu = ""
+	var sites []string
+	for _, tab := range tabs {
+		if site := extractDomain(tab.URL); site != "" && !slices.Contains(sites, site) {
+			sites = append(sites, site)
+		}
+	}
+	if _, err := addToBlocklist(sites); err != nil {
+		return m.showToast(tr("Could not update the blocklist: %v", err))
+	}
+	for _, site := range sites {
+		if !slices.Contains(blocklist.Sites, site) {
+			blocklist.Sites = append(blocklist.Sites, site)
+		}
+	}
+
+	for i := range m.tabs {
+		if blocked(m.tabs[i].URL) {
+			m.tabs[i].Blocked = true
+			m.selected.set(i, !m.tabs[i].locked())
+		}
+	}
+	return m.showToast(tr("Blocked %s; tabs on the blocklist are selected.", strings.Join(sites, ", ")))
+}
+
+// runBlock is the block subcommand: it lists the blocklist, or adds sites
+// to it or removes them with -remove.
+func runBlock(args []string) {
+	flags := flag.NewFlagSet("block", flag.ExitOnError)
+	remove := flags.Bool("remove", false, tr("Remove the sites from the blocklist instead of adding them"))
+	flags.Parse(args)
+
+	if _, err := setupConfig(""); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	list := blocklist.Sites
+	var err error
+	switch sites := flags.Args(); {
+	case len(sites) == 0:
+	case *remove:
+		list, err = removeFromBlocklist(sites)
+	default:
+		list, err = addToBlocklist(sites)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	if len(list) == 0 {
+		fmt.Println(tr("The blocklist is empty."))
+		return
+	}
+	for _, site := range list {
+		fmt.Println(site)
+	}
+}
+
+// closeBlocked closes, or archives, the blocked tabs of the latest scan,
+// except those playing audio or video. With the warn action they are left
+// open; evaluateOpened warns as they open.
+func (s *scanner) closeBlocked() {
+	if blocklist.Action == "warn" {
+		return
+	}
+	tabs, _, _ := s.snapshot()
+	var blocked []Tab
+	for _, tab := range tabs {
+		if tab.Blocked && !tab.locked() && !tab.playing() {
+			blocked = append(blocked, tab)
+		}
+	}
+	// By place, so a copy of the page that's playing stays open
+	closed, err := s.closeTabs(blocked, blocklist.Action == "archive")
+	if err != nil {
+		log.Printf("Warning: could not close blocked tabs: %v", err)
+		return
+	}
+	log.Print(tr("Closed %d blocked tabs.", closed))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// A tab budget caps how many tabs stay open. The list's header shows how
+// much of it is used, serve mode warns when a scan goes over it, and with
+// -enforce archives the tabs left alone longest until the rest fit. Tabs
+// go to the archive target a rule gave them, or to the archive file.
+
+var tabBudget int // Set by -budget or from config.json; 0 for no budget
+
+// budgetTabs returns the tabs that count against the budget: every tab but
+// pinned ones.
+func budgetTabs(tabs []Tab) int {
+	return len(withoutPinned(tabs))
+}
+
+// staleness returns when a tab was last used for ordering by age: when it
+// was last active or visited, or failing that when it was first seen.
+func staleness(tab Tab) time.Time {
+	if last := tab.lastActive(); !last.IsZero() {
+		return last
+	}
+	return tab.FirstSeen
+}
+
+// overBudget returns the tabs to archive to get back within budget, the
+// ones left alone longest first. Locked tabs and tabs playing media are
+// never picked, so fewer may come back than needed.
+func overBudget(tabs []Tab, budget int) []Tab {
+	excess := budgetTabs(tabs) - budget
+	if budget <= 0 || excess <= 0 {
+		return nil
+	}
+
+	var candidates []Tab
+	for _, tab := range tabs {
+		if !tab.locked() && !tab.playing() {
+			candidates = append(candidates, tab)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return staleness(candidates[i]).Before(staleness(candidates[j]))
+	})
+	return candidates[:min(excess, len(candidates))]
+}
+
+// budgetBar shows how much of the budget count tabs use.
+func (m model) budgetBar(count int) string {
+	bar := m.progress.ViewAs(math.Min(1, float64(count)/float64(tabBudget)))
+	if count > tabBudget {
+		return bar + " " + duplicateStyle.Render(tr("%d/%d tabs, %d over budget", count, tabBudget, count-tabBudget))
+	}
+	return bar + " " + helpStyle.Render(tr("%d/%d tabs of the budget", count, tabBudget))
+}
+
+// checkBudget warns when the latest scan goes over budget, once until it's
+// back within it, and with enforce archives the oldest tabs down to it.
+func (s *scanner) checkBudget(enforce bool) {
+	if tabBudget <= 0 {
+		return
+	}
+	tabs, _, _ := s.snapshot()
+	count := budgetTabs(tabs)
+	if count <= tabBudget {
+		s.overBudget = false
+		return
+	}
+
+	if !enforce {
+		if !s.overBudget {
+			notify(tr("%d tabs open, %d over the budget of %d.", count, count-tabBudget, tabBudget))
+		}
+		s.overBudget = true
+		return
+	}
+
+	archived, err := s.closeTabs(overBudget(tabs, tabBudget), true)
+	if err != nil {
+		log.Printf("Warning: could not archive tabs over budget: %v", err)
+		return
+	}
+	log.Print(tr("Archived %d tabs to stay within the budget of %d.", archived, tabBudget))
+}
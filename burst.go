@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A burst is a run of tabs opened within minutes of each other, like the
+// tabs of one research session. They usually belong to one task, and once
+// it's done they can go together.
+
+// burstGap is the longest pause between two tabs of the same burst.
+const burstGap = 10 * time.Minute
+
+// burstMinTabs is how many tabs it takes to make a burst.
+const burstMinTabs = 3
+
+// markBursts sets the Burst of tabs opened in a burst to the time the burst
+// started, going by when each tab was first visited.
+func markBursts(tabs []Tab) {
+	var order []int
+	for i := range tabs {
+		tabs[i].Burst = time.Time{}
+		if !tabs[i].FirstVisit.IsZero() && !tabs[i].Pinned {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return tabs[order[a]].FirstVisit.Before(tabs[order[b]].FirstVisit)
+	})
+
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && tabs[order[end]].FirstVisit.Sub(tabs[order[end-1]].FirstVisit) <= burstGap {
+			end++
+		}
+		if end-start >= burstMinTabs {
+			for _, i := range order[start:end] {
+				tabs[i].Burst = tabs[order[start]].FirstVisit
+			}
+		}
+		start = end
+	}
+}
+
+// burstLabel names a burst by when it started.
+func burstLabel(burst time.Time) string {
+	if burst.IsZero() {
+		return tr("no burst")
+	}
+	return tr("burst from %s", shortDateTime(burst))
+}
+
+// selectBurst selects every tab opened in the same burst as the focused one.
+func (m *model) selectBurst() tea.Cmd {
+	focused, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return nil
+	}
+	burst := focused.tab().Burst
+	if burst.IsZero() {
+		return m.showToast(tr("This tab wasn't opened in a burst of tabs."))
+	}
+	return m.selectWhere("Selected %d tabs of this burst", func(t Tab) bool { return t.Burst.Equal(burst) })
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Safari keeps its history, session state and bookmarks where only apps
+// with Full Disk Access may read them. Each feature that reads them checks
+// its files up front, so a missing permission turns off just the features
+// that need it, and the list's header names them, instead of those
+// features quietly finding nothing. "permissions" shows every feature with
+// what it needs.
+
+// A capability is a file features read, which a permission may withhold.
+type capability struct {
+	features string                   // What it enables, for people
+	paths    func() ([]string, error) // Where it may be; any one readable will do
+	inUse    func() bool              // Whether the current flags use it, nil for always
+}
+
+// Capability states.
+const (
+	capAvailable   = "available"
+	capNeedsAccess = "needs Full Disk Access"
+	capNotFound    = "not found"
+)
+
+var (
+	capHistory = capability{
+		features: "visit history: old tabs, read elsewhere, bursts",
+		paths:    onePath(safariHistoryPath),
+	}
+	capSession = capability{
+		features: "last active times",
+		paths:    sessionStatePaths,
+	}
+	capBookmarks = capability{
+		features: "Reading List and bookmarks, tabs saved elsewhere",
+		paths:    onePath(bookmarksPath),
+		inUse:    func() bool { return includeReadingList || bookmarksFolder != "" || savedElsewhere.on() },
+	}
+	capDownloads = capability{
+		features: "downloads cleanup",
+		paths:    onePath(downloadsPath),
+		inUse:    func() bool { return false }, // Only the downloads command reads them, and checks itself
+	}
+)
+
+// capabilities are the capabilities in the order they are listed.
+var capabilities = []capability{capHistory, capSession, capBookmarks, capDownloads}
+
+// onePath adapts a function returning one path to capability.paths.
+func onePath(path func() (string, error)) func() ([]string, error) {
+	return func() ([]string, error) {
+		p, err := path()
+		if err != nil {
+			return nil, err
+		}
+		return []string{p}, nil
+	}
+}
+
+// safariHistoryPath returns the path of Safari's History.db.
+func safariHistoryPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "Library", "Safari", "History.db"), nil
+}
+
+// state tells whether the capability's files can be read. Without Full
+// Disk Access, macOS refuses to open them, or even to look into the
+// folders they are in.
+func (c capability) state() string {
+	paths, err := c.paths()
+	if err != nil {
+		return capNotFound
+	}
+	state := capNotFound
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err == nil {
+			f.Close()
+			return capAvailable
+		}
+		if errors.Is(err, fs.ErrPermission) {
+			state = capNeedsAccess
+		}
+	}
+	return state
+}
+
+// available reports whether the capability's files can be read, so
+// features needing it can be skipped rather than fail.
+func (c capability) available() bool {
+	return c.state() == capAvailable
+}
+
+// unavailableNote names the features in use that are turned off for want
+// of Full Disk Access, for the list's header, or returns "" if none are.
+func unavailableNote() string {
+	var features []string
+	for _, c := range capabilities {
+		if (c.inUse == nil || c.inUse()) && c.state() == capNeedsAccess {
+			features = append(features, tr(c.features))
+		}
+	}
+	if len(features) == 0 {
+		return ""
+	}
+	return tr("Unavailable, needs Full Disk Access: %s", strings.Join(features, "; "))
+}
+
+// runPermissions is the permissions subcommand: it lists each feature
+// reading Safari's files, and whether it can.
+func runPermissions(args []string) {
+	flags := flag.NewFlagSet("permissions", flag.ExitOnError)
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	missing := false
+	for _, c := range capabilities {
+		state := c.state()
+		missing = missing || state == capNeedsAccess
+		fmt.Println(runewidth.FillRight(tr(c.features), 50), tr(state))
+	}
+	if missing {
+		fmt.Println()
+		fmt.Println(tr("Grant Full Disk Access to your terminal app in System Settings → Privacy & Security → Full Disk Access, then start it again."))
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// builtinCategories maps well-known domains to a category. Subdomains
+// inherit their parent's category, so "edition.cnn.com" is news too.
+var builtinCategories = map[string]string{
+	// News
+	"nytimes.com":          "news",
+	"washingtonpost.com":   "news",
+	"theguardian.com":      "news",
+	"bbc.com":              "news",
+	"bbc.co.uk":            "news",
+	"cnn.com":              "news",
+	"reuters.com":          "news",
+	"apnews.com":           "news",
+	"bloomberg.com":        "news",
+	"wsj.com":              "news",
+	"ft.com":               "news",
+	"economist.com":        "news",
+	"theverge.com":         "news",
+	"arstechnica.com":      "news",
+	"techcrunch.com":       "news",
+	"wired.com":            "news",
+	"npr.org":              "news",
+	"news.ycombinator.com": "news",
+	"news.google.com":      "news",
+
+	// Docs
+	"developer.apple.com":   "docs",
+	"developer.mozilla.org": "docs",
+	"docs.python.org":       "docs",
+	"pkg.go.dev":            "docs",
+	"go.dev":                "docs",
+	"docs.rs":               "docs",
+	"readthedocs.io":        "docs",
+	"readthedocs.org":       "docs",
+	"learn.microsoft.com":   "docs",
+	"docs.github.com":       "docs",
+	"cloud.google.com":      "docs",
+	"docs.aws.amazon.com":   "docs",
+	"stackoverflow.com":     "docs",
+	"wikipedia.org":         "docs",
+
+	// Shopping
+	"amazon.com":     "shopping",
+	"amazon.co.uk":   "shopping",
+	"amazon.de":      "shopping",
+	"ebay.com":       "shopping",
+	"etsy.com":       "shopping",
+	"walmart.com":    "shopping",
+	"target.com":     "shopping",
+	"bestbuy.com":    "shopping",
+	"aliexpress.com": "shopping",
+	"ikea.com":       "shopping",
+
+	// Social
+	"twitter.com":     "social",
+	"x.com":           "social",
+	"facebook.com":    "social",
+	"instagram.com":   "social",
+	"linkedin.com":    "social",
+	"reddit.com":      "social",
+	"mastodon.social": "social",
+	"bsky.app":        "social",
+	"threads.net":     "social",
+	"tiktok.com":      "social",
+
+	// Video
+	"youtube.com":     "video",
+	"youtu.be":        "video",
+	"vimeo.com":       "video",
+	"twitch.tv":       "video",
+	"netflix.com":     "video",
+	"dailymotion.com": "video",
+}
+
+// loadCategories returns the built-in domain categories merged with the
+// user's mapping file, which may override built-ins or add new categories.
+func loadCategories() map[string]string {
+	categories := make(map[string]string, len(builtinCategories))
+	for domain, category := range builtinCategories {
+		categories[domain] = category
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		log.Printf("Warning: could not get config directory: %v", err)
+		return categories
+	}
+
+	user := make(map[string]string)
+	if err := loadJSON(filepath.Join(dir, "categories.json"), &user); err != nil {
+		log.Printf("Warning: could not read categories.json: %v", err)
+	}
+	for domain, category := range user {
+		categories[strings.ToLower(domain)] = strings.ToLower(category)
+	}
+
+	return categories
+}
+
+// categorize returns the category for a URL, checking the domain and then
+// each parent domain. Documentation subdomains are recognized by name.
+func categorize(url string, categories map[string]string) string {
+	domain := extractDomain(url)
+	for d := domain; d != ""; {
+		if category, ok := categories[d]; ok {
+			return category
+		}
+		dot := strings.Index(d, ".")
+		if dot < 0 {
+			break
+		}
+		d = d[dot+1:]
+	}
+
+	if strings.HasPrefix(domain, "docs.") || strings.HasPrefix(domain, "developer.") {
+		return "docs"
+	}
+	return ""
+}
+
+func categorizeTabs(tabs []Tab, categories map[string]string) []Tab {
+	for i := range tabs {
+		tabs[i].Category = categorize(tabs[i].URL, categories)
+	}
+	return tabs
+}
+
+// tabCategories returns the categories present in tabs, sorted by name.
+func tabCategories(tabs []Tab) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tab := range tabs {
+		if tab.Category != "" && !seen[tab.Category] {
+			seen[tab.Category] = true
+			result = append(result, tab.Category)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// What Safari lets scripts do, and where its settings are, changes between
+// versions. The installed version is read from its bundle once, and the
+// compatibility table below decides how each feature goes about its work
+// on it, or, on versions it doesn't support, that it fails with an error
+// naming the version needed. "compat" prints the table for the installed
+// version.
+
+// minSafariMajor is the oldest Safari the app supports.
+const minSafariMajor = 14
+
+// A safariFeature is something the app does that depends on the Safari
+// version.
+type safariFeature struct {
+	name  string // For people
+	since int    // First major version that supports it
+	note  string // How it works on supported versions, for people
+}
+
+var (
+	featureTabs = safariFeature{
+		name:  "listing, closing and moving tabs",
+		since: minSafariMajor,
+	}
+	featureTabState = safariFeature{
+		name:  "loading, audio and video state",
+		since: minSafariMajor,
+		note:  "needs Allow JavaScript from Apple Events",
+	}
+	featureTabGroups = safariFeature{
+		name:  "Tab Groups",
+		since: 15,
+		note:  "only the current Tab Group of each window is read",
+	}
+	featureWebDeveloper = safariFeature{
+		name:  "Show features for web developers setting",
+		since: 17,
+		note:  "replaces Show Develop menu in menu bar",
+	}
+	featureProfiles = safariFeature{
+		name:  "Profiles",
+		since: 17,
+		note:  "only the default profile's history and session are read",
+	}
+)
+
+// safariFeatures are the features in the order they are listed.
+var safariFeatures = []safariFeature{featureTabs, featureTabState, featureTabGroups, featureWebDeveloper, featureProfiles}
+
+var (
+	detectOnce      sync.Once
+	detectedVersion string // Empty if it couldn't be read
+	detectedMajor   int
+)
+
+// installedSafari returns the installed Safari's version and major version,
+// read once, or "" and 0 if it can't be read.
+func installedSafari() (string, int) {
+	detectOnce.Do(func() {
+		version, err := safariVersion()
+		if err != nil {
+			return
+		}
+		major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+		if err != nil {
+			return
+		}
+		detectedVersion, detectedMajor = version, major
+	})
+	return detectedVersion, detectedMajor
+}
+
+// safariVersion reads Safari's version from its bundle, without launching
+// it.
+func safariVersion() (string, error) {
+	plist := filepath.Join("/Applications", safariApp+".app", "Contents", "Info.plist")
+	output, err := exec.Command("plutil", "-extract", "CFBundleShortVersionString", "raw", "-o", "-", plist).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", plist, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// supported reports whether the installed Safari supports the feature. A
+// version that can't be read is taken to support everything, so the app
+// doesn't refuse to work for want of knowing.
+func (f safariFeature) supported() bool {
+	_, major := installedSafari()
+	return major == 0 || major >= f.since
+}
+
+// require returns an error naming the version needed if the installed
+// Safari doesn't support the feature.
+func (f safariFeature) require() error {
+	if f.supported() {
+		return nil
+	}
+	version, _ := installedSafari()
+	return fmt.Errorf("%s needs %s %d or later, this is %s", f.name, safariApp, f.since, version)
+}
+
+// javascriptSettingFix says how to allow JavaScript from Apple Events,
+// which moved in Safari 17.
+func javascriptSettingFix() string {
+	if featureWebDeveloper.supported() {
+		return tr("In %s, turn on Settings → Advanced → Show features for web developers, then Develop → Allow JavaScript from Apple Events.", safariApp)
+	}
+	return tr("In %s, turn on Settings → Advanced → Show Develop menu in menu bar, then Develop → Allow JavaScript from Apple Events.", safariApp)
+}
+
+// runCompat is the compat subcommand: it prints the compatibility table
+// for the installed Safari.
+func runCompat(args []string) {
+	flags := flag.NewFlagSet("compat", flag.ExitOnError)
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	version, _ := installedSafari()
+	if version == "" {
+		fmt.Println(tr("Could not read the version of %s; the table assumes the latest.", safariApp))
+	} else {
+		fmt.Println(safariApp, version)
+	}
+	fmt.Println()
+	for _, f := range safariFeatures {
+		state := tr("yes")
+		if !f.supported() {
+			state = tr("no, needs %d", f.since)
+		}
+		line := runewidth.FillRight(tr(f.name), 45) + runewidth.FillRight(state, 16)
+		if f.note != "" && f.supported() {
+			line += tr(f.note)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	if err := featureTabs.require(); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// config holds the user's settings from config.json in the config directory.
+// Every field is optional; the zero value is the default behavior.
+type config struct {
+	Theme  string     `json:"theme"`  // Color theme, see themes
+	Badges bool       `json:"badges"` // Show [DUP]/[OLD] badges so state doesn't rely on color
+	Layout itemLayout `json:"layout"` // Fields on each line of a list item, see itemLayout
+	Store  string     `json:"store"`  // Where the tool's own state is kept: "files" or "sqlite", see store
+
+	Hooks       hooksConfig `json:"hooks"`
+	RulesScript string      `json:"rules_script"` // Starlark rules, see rules.go
+	Old         string      `json:"old"`          // Starlark expression deciding which tabs are old, see old.go
+
+	Menubar     menubarConfig   `json:"menubar"`
+	GrowthAlert growthConfig    `json:"growth_alert"` // When to warn that tabs are piling up
+	Autosave    autosaveConfig  `json:"autosave"`     // When serve mode saves the open tabs, and for how long
+	Retention   retentionConfig `json:"retention"`    // How long gc keeps the action log and run history
+
+	Age              int      `json:"age"`               // Default for -age
+	Budget           int      `json:"budget"`            // Default for -budget
+	ArchiveFile      string   `json:"archive_file"`      // Default for -archive-file
+	ArchiveTargets   []string `json:"archive_targets"`   // More files offered by "Archive to..."
+	ProtectedDomains []string `json:"protected_domains"` // Tabs on these domains are never selected
+
+	Pinned     pinnedConfig     `json:"pinned"`
+	Pacing     pacingConfig     `json:"pacing"`
+	LargeClose largeCloseConfig `json:"large_close"` // When a close needs its count typed, see largeClose
+
+	Blocklist blocklistConfig `json:"blocklist"` // Sites whose tabs are preselected, and closed by serve mode
+	Focus     focusConfig     `json:"focus"`     // Sites the focus command closes
+	Share     shareConfig     `json:"share"`     // Where "Share as link list" posts the selection
+
+	DuplicateOnOpen string `json:"duplicate_on_open"` // What serve mode does when a page is opened again: "warn" or "switch"
+	HistoryMatch    string `json:"history_match"`     // Which history variant of a URL visit times come from, see historyMatch
+
+	Subdomains   subdomainsConfig    `json:"subdomains"`    // Which hosts count as one domain
+	IgnoreParams map[string][]string `json:"ignore_params"` // Query parameters dedupe ignores, by domain
+
+	SavedElsewhere savedElsewhereConfig `json:"saved_elsewhere"` // What to do with tabs whose page is saved, by place
+
+	CloseWebhook string `json:"close_webhook"` // Slack or Discord webhook that closed tabs are posted to
+	WatchLater   string `json:"watch_later"`   // Markdown file "Watch later" sends videos to
+	DocIndexDir  string `json:"doc_index_dir"` // Directory of the Markdown indexes "Doc index" adds to
+	Wishlist     string `json:"wishlist"`      // Markdown file "Wishlist" sends product pages to
+
+	Forge    forgeConfig   `json:"forge"`    // API tokens for -check-resolved
+	Trackers trackerConfig `json:"trackers"` // Jira sites and Linear key for -check-resolved
+
+	Views []savedView `json:"views"` // Saved filter, sort and grouping combinations
+
+	WindowNotes map[string]string `json:"window_notes"` // Pages that label windows, by project, instead of a generated one
+}
+
+// A theme sets the colors that distinguish duplicate, old and normal tabs.
+type theme struct {
+	duplicate lipgloss.Color
+	old       lipgloss.Color
+	normal    lipgloss.Color
+}
+
+// themes are the built-in palettes. The color-blind safe ones are taken
+// from the Okabe-Ito palette, replacing the red/orange pair of the default.
+var themes = map[string]theme{
+	"default":      {duplicate: "203", old: "214", normal: "246"},
+	"deuteranopia": {duplicate: "#0072B2", old: "#E69F00", normal: "246"},
+	"protanopia":   {duplicate: "#56B4E9", old: "#F0E442", normal: "246"},
+}
+
+// configDir returns the directory holding user configuration, creating it
+// if needed.
+func configDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "safari-tab-manager")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// setupConfig loads config.json with the named profile, if any, and applies
+// the settings that live in globals, like the theme, hooks and rules
+// script, checking those with a fixed set of values.
+func setupConfig(profile string) (config, error) {
+	cfg, err := loadConfig(profile)
+	if err != nil {
+		return cfg, err
+	}
+	configProfile = profile
+	applyTheme(cfg.Theme)
+	hooks = cfg.Hooks
+	protectedDomains = cfg.ProtectedDomains
+	if action := cfg.Blocklist.Action; action != "" && action != "close" && action != "archive" && action != "warn" {
+		return cfg, fmt.Errorf("unknown blocklist action %q, want close, archive or warn", action)
+	}
+	blocklist = cfg.Blocklist
+	if action := cfg.DuplicateOnOpen; action != "" && action != "warn" && action != "switch" {
+		return cfg, fmt.Errorf("unknown duplicate_on_open action %q, want warn or switch", action)
+	}
+	duplicateOnOpen = cfg.DuplicateOnOpen
+	if policy := cfg.HistoryMatch; policy != "" && !validHistoryMatch(policy) {
+		return cfg, fmt.Errorf("unknown history_match policy %q, want exact-first or most-recent", policy)
+	}
+	if cfg.HistoryMatch != "" {
+		historyMatch = cfg.HistoryMatch
+	}
+	if err := cfg.Layout.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Subdomains.validate(); err != nil {
+		return cfg, err
+	}
+	subdomains = cfg.Subdomains
+	ignoredParams = cfg.IgnoreParams
+	if err := cfg.SavedElsewhere.validate(); err != nil {
+		return cfg, err
+	}
+	savedElsewhere = cfg.SavedElsewhere
+	share = cfg.Share
+	closeWebhook = cfg.CloseWebhook
+	watchLaterFile = expandHome(cfg.WatchLater)
+	docIndexDir = expandHome(cfg.DocIndexDir)
+	wishlistFile = expandHome(cfg.Wishlist)
+	forges = cfg.Forge
+	growthAlert = cfg.GrowthAlert
+	windowNotes = cfg.WindowNotes
+	if growthAlert.Days <= 0 {
+		growthAlert.Days = defaultGrowthDays
+	}
+	trackers = cfg.Trackers
+	if cfg.Pinned.MaxPosition > 0 {
+		pinnedHeuristic.MaxPosition = cfg.Pinned.MaxPosition
+	}
+	if cfg.Pinned.MinWindows > 0 {
+		pinnedHeuristic.MinWindows = cfg.Pinned.MinWindows
+	}
+	if cfg.Pacing.BatchSize > 0 {
+		pacing.BatchSize = cfg.Pacing.BatchSize
+	}
+	if cfg.Pacing.DelayMS > 0 {
+		pacing.DelayMS = cfg.Pacing.DelayMS
+	}
+	if cfg.Pacing.SlowMS > 0 {
+		pacing.SlowMS = cfg.Pacing.SlowMS
+	}
+	if cfg.LargeClose.Threshold != 0 {
+		largeClose.Threshold = cfg.LargeClose.Threshold
+	}
+	if cfg.LargeClose.ChunkSize > 0 {
+		largeClose.ChunkSize = cfg.LargeClose.ChunkSize
+	}
+	if stateStore, err = openStore(cfg.Store); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Autosave.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Retention.validate(); err != nil {
+		return cfg, err
+	}
+	retention = cfg.Retention
+	if cfg.Autosave.KeepHourly > 0 {
+		autosave.KeepHourly = cfg.Autosave.KeepHourly
+	}
+	if cfg.Autosave.KeepDaily > 0 {
+		autosave.KeepDaily = cfg.Autosave.KeepDaily
+	}
+	if cfg.Autosave.KeepWeekly > 0 {
+		autosave.KeepWeekly = cfg.Autosave.KeepWeekly
+	}
+	if cfg.Old != "" {
+		if oldRule, err = compileOldRule(cfg.Old); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.RulesScript != "" {
+		if rules, err = loadRuleScript(cfg.RulesScript); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// applyConfigDefaults fills in flags that weren't given on the command line
+// from the config.
+func applyConfigDefaults(flags *flag.FlagSet, cfg config, ageDays *int) {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	if !given["age"] && cfg.Age > 0 {
+		*ageDays = cfg.Age
+	}
+	if !given["archive-file"] && cfg.ArchiveFile != "" {
+		archiveFile = expandHome(cfg.ArchiveFile)
+	}
+	if !given["budget"] && cfg.Budget > 0 {
+		tabBudget = cfg.Budget
+	}
+	if !given["autosave"] && cfg.Autosave.Every != "" {
+		autosave.Every = cfg.Autosave.Every
+	}
+}
+
+var configProfile string // The profile setupConfig loaded, "" for none; see editConfig
+
+// loadConfig reads config.json. A missing file yields the default config.
+// A profile's settings, from the "profiles" object, replace the top-level
+// settings of the same name.
+func loadConfig(profile string) (config, error) {
+	var cfg config
+	dir, err := configDir()
+	if err != nil {
+		return cfg, err
+	}
+
+	var settings map[string]json.RawMessage
+	if err := loadJSON(filepath.Join(dir, "config.json"), &settings); err != nil {
+		return cfg, fmt.Errorf("could not read config.json: %w", err)
+	}
+
+	if profile != "" {
+		var profiles map[string]map[string]json.RawMessage
+		if raw, ok := settings["profiles"]; ok {
+			if err := json.Unmarshal(raw, &profiles); err != nil {
+				return cfg, fmt.Errorf("could not read profiles in config.json: %w", err)
+			}
+		}
+		overrides, ok := profiles[profile]
+		if !ok {
+			return cfg, fmt.Errorf("no profile %q in config.json", profile)
+		}
+		for name, value := range overrides {
+			settings[name] = value
+		}
+	}
+	delete(settings, "profiles")
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("could not read config.json: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyTheme switches the list styles to the named theme.
+func applyTheme(name string) {
+	if name == "" {
+		return
+	}
+	t, ok := themes[name]
+	if !ok {
+		log.Printf("Warning: unknown theme %q, using default", name)
+		return
+	}
+	duplicateStyle = duplicateStyle.Foreground(t.duplicate)
+	oldTabStyle = oldTabStyle.Foreground(t.old)
+	normalStyle = normalStyle.Foreground(t.normal)
+}
+
+// editConfig changes config.json in place. edit gets the settings as the
+// run has them, with those of its profile, if any, in place of the
+// top-level ones. A setting the profile has is changed in the profile,
+// where it takes effect; others are changed at the top level. Settings edit
+// doesn't touch are written back as they were.
+func editConfig(edit func(settings map[string]json.RawMessage) error) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "config.json")
+
+	settings := make(map[string]json.RawMessage)
+	if err := loadJSON(path, &settings); err != nil {
+		return fmt.Errorf("could not read config.json: %w", err)
+	}
+	var profiles map[string]map[string]json.RawMessage
+	var overrides map[string]json.RawMessage
+	if configProfile != "" {
+		if raw, ok := settings["profiles"]; ok {
+			if err := json.Unmarshal(raw, &profiles); err != nil {
+				return fmt.Errorf("could not read profiles in config.json: %w", err)
+			}
+		}
+		overrides = profiles[configProfile]
+	}
+
+	effective := maps.Clone(settings)
+	delete(effective, "profiles")
+	maps.Copy(effective, overrides)
+	if err := edit(effective); err != nil {
+		return err
+	}
+	for name, value := range effective {
+		if _, ok := overrides[name]; ok {
+			overrides[name] = value
+		} else {
+			settings[name] = value
+		}
+	}
+	if overrides != nil {
+		raw, err := json.Marshal(profiles)
+		if err != nil {
+			return err
+		}
+		settings["profiles"] = raw
+	}
+	if err := saveJSON(path, settings); err != nil {
+		return fmt.Errorf("could not save config.json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The tab counts are kept in view outside the list too: the interactive
+// mode puts them in the terminal title, and the count subcommand prints them
+// once for a tmux status line or a shell prompt. Status lines refresh every
+// few seconds, so count reuses a recent scan instead of reading every tab
+// each time.
+
+// tabCounts are the numbers the title and count show.
+type tabCounts struct {
+	Tabs       int `json:"tabs"`
+	Duplicates int `json:"duplicates"`
+	Old        int `json:"old"`
+}
+
+// cachedCounts is the last scan count made, with the options it was made
+// with.
+type cachedCounts struct {
+	Options   string    `json:"options"`
+	CountedAt time.Time `json:"counted_at"`
+	Counts    tabCounts `json:"counts"`
+}
+
+// countTabs counts tabs, duplicates and old tabs.
+func countTabs(tabs []Tab) tabCounts {
+	counts := tabCounts{Tabs: len(tabs)}
+	for _, tab := range tabs {
+		if tab.DuplicateOf != 0 {
+			counts.Duplicates++
+		}
+		if tab.IsOld {
+			counts.Old++
+		}
+	}
+	return counts
+}
+
+// windowTitle is the terminal title for the interactive mode.
+func windowTitle(counts tabCounts) string {
+	return tr("Safari: %d tabs, %d duplicates, %d old", counts.Tabs, counts.Duplicates, counts.Old)
+}
+
+// setWindowTitle sets the terminal title to the tab counts, leaving out
+// pinned tabs like count does.
+func (m model) setWindowTitle() tea.Cmd {
+	return tea.SetWindowTitle(windowTitle(countTabs(withoutPinned(m.tabs))))
+}
+
+// runCount is the count subcommand.
+func runCount(args []string) {
+	flags := flag.NewFlagSet("count", flag.ExitOnError)
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	format := flags.String("format", "plain", tr("Output format: plain, tmux or json"))
+	maxAge := flags.Duration("max-age", time.Minute, tr("Reuse counts from a scan at most this old; 0 always scans"))
+	flags.Parse(args)
+
+	if *format != "plain" && *format != "tmux" && *format != "json" {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", fmt.Errorf("unknown count format %q, want plain, tmux or json", *format)))
+		os.Exit(1)
+	}
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flags, cfg, ageDays)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	readTabState = false // Counting doesn't look at what tabs are playing
+
+	counts, err := recentCounts(fmt.Sprintf("%s|%d|%s", safariApp, *ageDays, *profile), *maxAge, func() (tabCounts, error) {
+		tabs, _, err := getSafariTabs(*ageDays)
+		if err != nil {
+			return tabCounts{}, err
+		}
+		return countTabs(findDuplicates(withoutPinned(tabs))), nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	printCounts(os.Stdout, counts, *format, cfg.Menubar)
+}
+
+// recentCounts returns the cached counts if they were made with the same
+// options within maxAge, and otherwise counts again and caches the result.
+func recentCounts(options string, maxAge time.Duration, count func() (tabCounts, error)) (tabCounts, error) {
+	var path string
+	if dir, err := cacheDir(); err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+	} else {
+		path = filepath.Join(dir, "counts.json")
+	}
+
+	var cached cachedCounts
+	if path != "" {
+		if err := loadJSON(path, &cached); err != nil {
+			log.Printf("Warning: could not read cached counts: %v", err)
+		}
+	}
+	if cached.Options == options && time.Since(cached.CountedAt) < maxAge {
+		return cached.Counts, nil
+	}
+
+	counts, err := count()
+	if err != nil {
+		return counts, err
+	}
+	if path != "" {
+		cached = cachedCounts{Options: options, CountedAt: time.Now(), Counts: counts}
+		if err := saveJSON(path, cached); err != nil {
+			log.Printf("Warning: could not write cached counts: %v", err)
+		}
+	}
+	return counts, nil
+}
+
+// printCounts prints the counts in a format. The tmux format colors the
+// counts that reached a menu bar alert threshold red.
+func printCounts(w io.Writer, counts tabCounts, format string, alerts menubarConfig) {
+	switch format {
+	case "json":
+		json.NewEncoder(w).Encode(counts)
+	case "tmux":
+		colored := func(text string, n, threshold int) string {
+			if threshold > 0 && n >= threshold {
+				return "#[fg=red]" + text + "#[default]"
+			}
+			return text
+		}
+		fmt.Fprintf(w, "%s %s %s\n",
+			colored(tr("%d tabs", counts.Tabs), counts.Tabs, alerts.AlertTabs),
+			colored(tr("%d dup", counts.Duplicates), counts.Duplicates, alerts.AlertDuplicates),
+			colored(tr("%d old", counts.Old), counts.Old, alerts.AlertOld))
+	default:
+		fmt.Fprintln(w, tr("%d tabs, %d duplicates, %d old", counts.Tabs, counts.Duplicates, counts.Old))
+	}
+}
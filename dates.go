@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// Dates are shown in the local time zone: recent ones relative to now, like
+// "3 wk ago" or "yesterday 14:05", and in full where there's room. Layouts
+// are translated like any other string, so dates read the way the locale
+// writes them.
+
+// calendarDaysBetween returns how many midnights lie between a and b in the
+// local time zone, whatever the hours in between, so daylight saving time
+// doesn't shift it.
+func calendarDaysBetween(a, b time.Time) int {
+	a, b = a.Local(), b.Local()
+	dayA := time.Date(a.Year(), a.Month(), a.Day(), 12, 0, 0, 0, time.UTC)
+	dayB := time.Date(b.Year(), b.Month(), b.Day(), 12, 0, 0, 0, time.UTC)
+	return int(dayB.Sub(dayA).Hours() / 24)
+}
+
+// humanizeTime describes t relative to now: the time of day for today and
+// yesterday, and the days, weeks, months or years ago before that.
+func humanizeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return tr("never")
+	}
+	elapsed := now.Sub(t)
+	days := calendarDaysBetween(t, now)
+	switch {
+	case elapsed < time.Minute:
+		return tr("just now")
+	case elapsed < time.Hour:
+		return tr("%d min ago", int(elapsed.Minutes()))
+	case days == 0:
+		return tr("today %s", t.Local().Format(tr("15:04")))
+	case days == 1:
+		return tr("yesterday %s", t.Local().Format(tr("15:04")))
+	case days < 14:
+		return tr("%d days ago", days)
+	case days < 60:
+		return tr("%d wk ago", days/7)
+	case days < 365:
+		return tr("%d mo ago", days/30)
+	}
+	return tr("%d yr ago", days/365)
+}
+
+// shortDateTime formats t with its date and time of day, for labels.
+func shortDateTime(t time.Time) string {
+	return t.Local().Format(tr("Jan 2 15:04"))
+}
+
+// fullDateTime formats t with the year and time zone, for details.
+func fullDateTime(t time.Time) string {
+	return t.Local().Format(tr("Mon Jan 2 2006, 15:04 MST"))
+}
@@ -0,0 +1,170 @@
+package main
+
+import "sync"
+
+// Comparing every tab with every other is what makes duplicate detection
+// slow with thousands of tabs, and a refresh after closing a few would
+// redo all of it. Duplicates can only be on the same site, so the list
+// keeps what it found per site in a dedupeCache: a site whose tabs didn't
+// change keeps its duplicates as they were, and of one whose tabs did,
+// only the tabs a close or a new tab could affect are compared again.
+
+// dedupeCache keeps the duplicates found among each site's tabs from one
+// call to the next. The zero value is empty, and the cache is safe to use
+// from the stages and refreshes at once.
+type dedupeCache struct {
+	mu    sync.Mutex
+	sites map[string]siteDuplicates
+}
+
+// siteDuplicates is what was found among one site's tabs.
+type siteDuplicates struct {
+	urls      []string // Canonical URLs of the site's tabs, in window order
+	originals []int    // Position in urls of each one's original, -1 if none
+}
+
+// findDuplicates is the package's findDuplicates, reusing what the cache
+// has.
+func (c *dedupeCache) findDuplicates(tabs []Tab) []Tab {
+	markDuplicates(tabs, c.originals(tabs, nil))
+	return tabs
+}
+
+// originals finds the original of each tab that duplicates an earlier
+// one, by index, or -1 for tabs that don't, reusing what the last call
+// found. It only goes by URLs, so it can run before the history is in;
+// searches are left to markStaleSearches.
+func (c *dedupeCache) originals(tabs []Tab, progress *stageProgress) []int {
+	progress.start(len(tabs))
+	// Compare URLs as written one way, so an IDN page opened as Unicode and
+	// as punycode is the same page, and without the query parameters the
+	// config ignores
+	originals := make([]int, len(tabs))
+	urls := make([]string, len(tabs))
+	bySite := make(map[string][]int) // Tab indexes
+	for i := range tabs {
+		originals[i] = -1
+		if tabs[i].Pinned || searchQuery(tabs[i].URL) != "" {
+			progress.add(1)
+			continue
+		}
+		urls[i] = canonicalURL(withoutIgnoredParams(tabs[i].URL))
+		site := siteOf(urls[i])
+		bySite[site] = append(bySite[site], i)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sites := make(map[string]siteDuplicates, len(bySite))
+	for site, indexes := range bySite {
+		siteURLs := make([]string, len(indexes))
+		for k, i := range indexes {
+			siteURLs[k] = urls[i]
+		}
+		found := siteDuplicates{urls: siteURLs, originals: c.sites[site].update(siteURLs)}
+		for k, original := range found.originals {
+			if original >= 0 {
+				originals[indexes[k]] = indexes[original]
+			}
+		}
+		sites[site] = found
+		progress.add(len(indexes))
+	}
+	c.sites = sites
+	return originals
+}
+
+// duplicateURL reports whether the page at url duplicates the one at an
+// earlier tab's: the same URL, or a similar one (same domain and similar
+// path).
+func duplicateURL(url, earlier string) bool {
+	return url == earlier || areSimilarURLs(url, earlier)
+}
+
+// update returns the originals among urls, a site's tabs now, by position.
+// Each tab's original is the first earlier one it duplicates. Tabs kept
+// from before, taken like tabIDs does, are only compared with the tabs
+// that are new, or with the rest of the earlier ones when their original
+// was closed; new tabs are compared with every earlier one. Tabs moved
+// out of order have everything compared again.
+func (prev siteDuplicates) update(urls []string) []int {
+	before := make(map[string][]int, len(prev.urls)) // Positions in prev.urls
+	for p, url := range prev.urls {
+		before[url] = append(before[url], p)
+	}
+	was := make([]int, len(urls))      // Position in prev.urls, -1 for a new tab
+	now := make([]int, len(prev.urls)) // Position in urls, -1 for a closed tab
+	for p := range now {
+		now[p] = -1
+	}
+	var added []int
+	last := -1
+	for k, url := range urls {
+		was[k] = -1
+		positions := before[url]
+		if len(positions) == 0 {
+			added = append(added, k)
+			continue
+		}
+		p := positions[0]
+		before[url] = positions[1:]
+		if p < last {
+			return siteDuplicates{}.update(urls)
+		}
+		was[k], now[p], last = p, k, p
+	}
+
+	// firstAdded is the first new tab before position end that url
+	// duplicates, or -1
+	firstAdded := func(url string, end int) int {
+		for _, a := range added {
+			if a >= end {
+				break
+			}
+			if duplicateURL(url, urls[a]) {
+				return a
+			}
+		}
+		return -1
+	}
+
+	originals := make([]int, len(urls))
+	for k, url := range urls {
+		originals[k] = -1
+		p := was[k]
+		switch {
+		case p < 0:
+			// A new tab could duplicate any earlier one
+			for j := range k {
+				if duplicateURL(url, urls[j]) {
+					originals[k] = j
+					break
+				}
+			}
+		case prev.originals[p] < 0:
+			// None of the earlier tabs kept was a duplicate before
+			originals[k] = firstAdded(url, k)
+		case now[prev.originals[p]] >= 0:
+			// The original is still open; only a new tab before it
+			// comes first
+			original := now[prev.originals[p]]
+			if a := firstAdded(url, original); a >= 0 {
+				original = a
+			}
+			originals[k] = original
+		default:
+			// The original was closed; the tabs kept from before it
+			// didn't match
+			for j := range k {
+				if was[j] >= 0 && was[j] < prev.originals[p] {
+					continue
+				}
+				if duplicateURL(url, urls[j]) {
+					originals[k] = j
+					break
+				}
+			}
+		}
+	}
+	return originals
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestSiteDuplicatesUpdate checks that the incremental update finds the
+// same originals as comparing every tab with every other, over runs of
+// random closes, opens and reorders of one site's tabs.
+func TestSiteDuplicatesUpdate(t *testing.T) {
+	// Similar paths, exact copies and pages like neither, so that closing
+	// an original can hand its duplicates to any of the others
+	pages := []string{
+		"https://example.com",
+		"https://example.com/docs/intro",
+		"https://example.com/docs/intro2",
+		"https://example.com/docs/install",
+		"https://example.com/docs/installing",
+		"https://example.com/blog/2024/release-notes",
+		"https://example.com/blog/2024/release-note",
+		"https://example.com/pricing",
+		"https://example.com/about",
+		"https://example.com/about?lang=de",
+	}
+
+	tests := []struct {
+		name                  string
+		seed                  uint64
+		closes, opens, shifts int // Relative weights of the changes
+		steps                 int
+	}{
+		{name: "closes", seed: 1, closes: 1, steps: 40},
+		{name: "opens", seed: 2, opens: 1, steps: 40},
+		{name: "closes and opens", seed: 3, closes: 1, opens: 1, steps: 200},
+		{name: "mostly closes", seed: 4, closes: 3, opens: 1, steps: 200},
+		{name: "reorders", seed: 5, closes: 1, opens: 1, shifts: 1, steps: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(tt.seed, 0))
+			var urls []string
+			for range 30 {
+				urls = append(urls, pages[r.IntN(len(pages))])
+			}
+			var prev siteDuplicates
+			for step := range tt.steps {
+				urls = slices.Clone(urls)
+				switch n := r.IntN(tt.closes + tt.opens + tt.shifts); {
+				case n < tt.closes && len(urls) > 0:
+					for range 1 + r.IntN(3) {
+						if len(urls) > 0 {
+							i := r.IntN(len(urls))
+							urls = slices.Delete(urls, i, i+1)
+						}
+					}
+				case n < tt.closes+tt.opens:
+					for range 1 + r.IntN(3) {
+						urls = slices.Insert(urls, r.IntN(len(urls)+1), pages[r.IntN(len(pages))])
+					}
+				case len(urls) > 1:
+					i, j := r.IntN(len(urls)), r.IntN(len(urls))
+					urls[i], urls[j] = urls[j], urls[i]
+				}
+
+				got := prev.update(urls)
+				want := siteDuplicates{}.update(urls)
+				if !slices.Equal(got, want) {
+					t.Fatalf("step %d: update(%q) = %v after %q, want %v", step, urls, got, prev.urls, want)
+				}
+				prev = siteDuplicates{urls: urls, originals: got}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	maxDiffCells = 1 << 16 // Largest LCS table worth building; longer URLs are compared by prefix and suffix only
+	diffContext  = 8       // Unchanged runes kept on either side of a change when a URL has to be shortened
+)
+
+// urlDiff marks the runes of url that are not in other, character by
+// character, so a changed tracking parameter stands out from the rest of an
+// otherwise equal URL.
+func urlDiff(url, other string) []bool {
+	a, b := []rune(url), []rune(other)
+	mask := make([]bool, len(a))
+
+	// The common prefix and suffix never differ, which keeps the table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for i := range a {
+			mask[prefix+i] = true
+		}
+		return mask
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	for i, j := 0, 0; i < len(a); {
+		switch {
+		case j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && lcs[i][j+1] >= lcs[i+1][j]:
+			j++
+		default:
+			mask[prefix+i] = true
+			i++
+		}
+	}
+	return mask
+}
+
+// highlightDiff hard-wraps s over at most maxLines lines of width cells,
+// rendering the runes marked in mask in the duplicate color and the rest
+// dimmed. When s doesn't fit, unchanged stretches are shortened to an
+// ellipsis, keeping a little context around each change.
+func highlightDiff(s string, mask []bool, width, maxLines int) []string {
+	runes := []rune(s)
+	if runewidth.StringWidth(s) > width*maxLines {
+		runes, mask = elideUnchanged(runes, mask)
+	}
+
+	// Break into lines first, so the last line can make room for an ellipsis
+	type span struct{ start, end int }
+	var spans []span
+	start, lineWidth := 0, 0
+	for i, r := range runes {
+		w := runewidth.RuneWidth(r)
+		if lineWidth+w > width && lineWidth > 0 {
+			spans = append(spans, span{start, i})
+			start, lineWidth = i, 0
+		}
+		lineWidth += w
+	}
+	spans = append(spans, span{start, len(runes)})
+
+	var ellipsis string
+	if len(spans) > maxLines {
+		spans = spans[:maxLines]
+		ellipsis = sym.ellipsis
+		last := &spans[maxLines-1]
+		for runewidth.StringWidth(string(runes[last.start:last.end])+ellipsis) > width && last.end > last.start {
+			last.end--
+		}
+	}
+
+	lines := make([]string, len(spans))
+	for n, sp := range spans {
+		var line strings.Builder
+		for i := sp.start; i < sp.end; {
+			j := i
+			for j < sp.end && mask[j] == mask[i] {
+				j++
+			}
+			if mask[i] {
+				line.WriteString(duplicateStyle.Bold(true).Render(string(runes[i:j])))
+			} else {
+				line.WriteString(helpStyle.Render(string(runes[i:j])))
+			}
+			i = j
+		}
+		lines[n] = line.String()
+	}
+	if ellipsis != "" {
+		lines[len(lines)-1] += helpStyle.Render(ellipsis)
+	}
+	return lines
+}
+
+// elideUnchanged replaces the stretches of runes far from any change with an
+// ellipsis. The start of the URL, with its domain, is kept as context too.
+func elideUnchanged(runes []rune, mask []bool) ([]rune, []bool) {
+	keep := make([]bool, len(runes))
+	for i := range runes {
+		if i < 2*diffContext {
+			keep[i] = true
+		}
+		if mask[i] {
+			for j := max(0, i-diffContext); j < min(len(runes), i+diffContext+1); j++ {
+				keep[j] = true
+			}
+		}
+	}
+
+	var outRunes []rune
+	var outMask []bool
+	ellipsis := []rune(sym.ellipsis)
+	for i := range runes {
+		if keep[i] {
+			outRunes = append(outRunes, runes[i])
+			outMask = append(outMask, mask[i])
+		} else if keep[i-1] {
+			outRunes = append(outRunes, ellipsis...)
+			outMask = append(outMask, make([]bool, len(ellipsis))...)
+		}
+	}
+	return outRunes, outMask
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Each window is placed on the display its center is on, going by its
+// bounds, so windows and their tabs can be told apart by display. macOS
+// moves the windows of a display that is unplugged onto another one, so
+// while more than one display is connected the display of every window is
+// remembered in the cache directory; once a display is gone, its windows
+// keep its name, marked as gone, and "-only display:gone" finds them.
+// Spaces have no public interface, so windows can't be told apart by Space.
+
+// display is a connected display, in the coordinates of window bounds:
+// from the top left corner of the main display, y growing downwards.
+type display struct {
+	Name          string
+	X, Y          float64
+	Width, Height float64
+}
+
+// displaysScript lists the displays with NSScreen, through JavaScript for
+// Automation, since the release builds don't use cgo. NSScreen counts y
+// from the bottom of the main display, the first screen, upwards.
+const displaysScript = `ObjC.import('AppKit');
+var screens = $.NSScreen.screens, out = [];
+for (var i = 0; i < screens.count; i++) {
+	var s = screens.objectAtIndex(i), f = s.frame;
+	out.push({Name: s.localizedName.js, X: f.origin.x, Y: f.origin.y, Width: f.size.width, Height: f.size.height});
+}
+JSON.stringify(out);`
+
+// listDisplays returns the connected displays, the main one first.
+func listDisplays() ([]display, error) {
+	output, err := exec.Command("osascript", "-l", "JavaScript", "-e", displaysScript).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list displays: %w", err)
+	}
+	var displays []display
+	if err := json.Unmarshal(output, &displays); err != nil {
+		return nil, fmt.Errorf("could not list displays: %w", err)
+	}
+	if len(displays) == 0 {
+		return nil, nil
+	}
+	mainHeight := displays[0].Height
+	for i := range displays {
+		displays[i].Y = mainHeight - displays[i].Y - displays[i].Height
+	}
+	return displays, nil
+}
+
+// displayAt returns the name of the display containing a point, or "" if
+// none does.
+func displayAt(displays []display, x, y float64) string {
+	for _, d := range displays {
+		if x >= d.X && x < d.X+d.Width && y >= d.Y && y < d.Y+d.Height {
+			return d.Name
+		}
+	}
+	return ""
+}
+
+// windowBounds is where a window is, by its number.
+type windowBounds struct {
+	index, id                int
+	left, top, right, bottom float64
+}
+
+// listWindowBounds returns the bounds of Safari's windows.
+func listWindowBounds() ([]windowBounds, error) {
+	script := fmt.Sprintf(`
+	tell application %s
+		set output to ""
+		repeat with w from 1 to count of windows
+			try
+				set b to bounds of window w
+				set output to output & w & "," & (id of window w) & "," & (item 1 of b) & "," & (item 2 of b) & "," & (item 3 of b) & "," & (item 4 of b) & linefeed
+			end try
+		end repeat
+		return output
+	end tell
+	`, appleScriptString(safariApp))
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read window bounds: %w", err)
+	}
+
+	var windows []windowBounds
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 6 {
+			continue
+		}
+		var w windowBounds
+		w.index, _ = strconv.Atoi(fields[0])
+		w.id, _ = strconv.Atoi(fields[1])
+		w.left, _ = strconv.ParseFloat(fields[2], 64)
+		w.top, _ = strconv.ParseFloat(fields[3], 64)
+		w.right, _ = strconv.ParseFloat(fields[4], 64)
+		w.bottom, _ = strconv.ParseFloat(fields[5], 64)
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// windowDisplay is the display a window is on.
+type windowDisplay struct {
+	name string // "" if the window is on none
+	gone bool   // The window was on this display, which isn't connected any more
+}
+
+func windowDisplaysPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "window-displays.json"), nil
+}
+
+// windowDisplays finds the display of every window, by window number. While
+// more than one display is connected it remembers them by window id, and
+// reports windows last seen on a display that is gone as still on it.
+func windowDisplays() (map[int]windowDisplay, error) {
+	displays, err := listDisplays()
+	if err != nil {
+		return nil, err
+	}
+	windows, err := listWindowBounds()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := windowDisplaysPath()
+	if err != nil {
+		return nil, err
+	}
+	remembered := make(map[string]string) // Display by window id
+	if err := loadJSON(path, &remembered); err != nil {
+		log.Printf("Warning: could not read window displays: %v", err)
+	}
+	connected := make(map[string]bool)
+	for _, d := range displays {
+		connected[d.Name] = true
+	}
+
+	result := make(map[int]windowDisplay)
+	seen := make(map[string]string)
+	for _, w := range windows {
+		id := strconv.Itoa(w.id)
+		current := displayAt(displays, (w.left+w.right)/2, (w.top+w.bottom)/2)
+		if last, ok := remembered[id]; ok && last != "" && !connected[last] {
+			result[w.index] = windowDisplay{name: last, gone: true}
+			seen[id] = last
+			continue
+		}
+		result[w.index] = windowDisplay{name: current}
+		if len(displays) > 1 {
+			seen[id] = current
+		} else if last, ok := remembered[id]; ok {
+			seen[id] = last
+		}
+	}
+	// Windows that were closed are forgotten
+	if err := saveJSON(path, seen); err != nil {
+		log.Printf("Warning: could not save window displays: %v", err)
+	}
+	return result, nil
+}
+
+// markDisplays sets the display of every tab, if the windows are on more
+// than one, or on one that is gone. Without it tabs are simply not told
+// apart by display, so errors are only logged.
+func markDisplays(tabs []Tab) {
+	displays, err := windowDisplays()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if !spread(displays) {
+		return
+	}
+	for i := range tabs {
+		if tabs[i].Source != "" {
+			continue
+		}
+		d := displays[tabs[i].WindowIndex]
+		tabs[i].Display, tabs[i].DisplayGone = d.name, d.gone
+	}
+}
+
+// spread reports whether windows are on more than one display, or on one
+// that is gone, which makes their displays worth showing.
+func spread(displays map[int]windowDisplay) bool {
+	names := make(map[string]bool)
+	for _, d := range displays {
+		if d.gone {
+			return true
+		}
+		names[d.name] = true
+	}
+	return len(names) > 1
+}
+
+// displayLabel names a display for the windows view.
+func displayLabel(name string, gone bool) string {
+	switch {
+	case gone:
+		return tr("%s, disconnected", name)
+	case name == "":
+		return tr("off screen")
+	}
+	return name
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Documentation tabs multiply: every lookup opens another page of the same
+// docs. Tabs on documentation sites are gathered into doc sets by the
+// project they document, like the Python docs or a Go module, and a doc set
+// can be exported to a Markdown index of its own, kept across cleanups, and
+// closed.
+
+var docIndexDir string // Set from config.json
+
+var (
+	// Characters left out of index file names
+	unsafeFileChars = regexp.MustCompile(`[/\\:*?"<>|]+`)
+	// Markdown links already in an index
+	indexLinkRegexp = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+)
+
+// docSet returns the project a documentation URL documents, or an empty
+// string if it's not on a documentation site.
+func docSet(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	segment := func(i int) string {
+		if i < len(segments) {
+			return segments[i]
+		}
+		return ""
+	}
+
+	switch {
+	case host == "pkg.go.dev":
+		// Module paths have a dot in their first element; the rest is the
+		// standard library
+		if !strings.Contains(segment(0), ".") {
+			return "go"
+		}
+		return strings.Join(segments[:min(3, len(segments))], "/")
+	case host == "docs.rs":
+		return segment(0)
+	case host == "developer.apple.com" && segment(0) == "documentation":
+		return strings.ToLower(segment(1))
+	case host == "developer.mozilla.org":
+		return "mdn"
+	case host == "learn.microsoft.com":
+		return segment(1)
+	case strings.HasSuffix(host, ".readthedocs.io") || strings.HasSuffix(host, ".readthedocs.org"):
+		name, _, _ := strings.Cut(host, ".")
+		return name
+	case host == "docs.google.com":
+		// Google Docs documents, not documentation
+		return ""
+	case strings.HasPrefix(host, "docs.") || strings.HasPrefix(host, "developer."):
+		// docs.python.org is python, docs.github.com github
+		_, rest, _ := strings.Cut(host, ".")
+		name, _, _ := strings.Cut(rest, ".")
+		return name
+	}
+	return ""
+}
+
+// markDocSets sets DocSet on every documentation tab.
+func markDocSets(tabs []Tab) {
+	for i := range tabs {
+		tabs[i].DocSet = docSet(tabs[i].URL)
+	}
+}
+
+// docSetLabel names a doc set group.
+func docSetLabel(set string) string {
+	if set == "" {
+		return tr("not documentation")
+	}
+	return tr("docs %s", set)
+}
+
+// selectDocSet selects every tab of the focused tab's doc set.
+func (m *model) selectDocSet() tea.Cmd {
+	focused, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return nil
+	}
+	set := focused.tab().DocSet
+	if set == "" {
+		return m.showToast(tr("This tab isn't documentation."))
+	}
+	return m.selectWhere("Selected %d tabs of these docs", func(t Tab) bool { return t.DocSet == set })
+}
+
+// defaultDocIndexDir is where doc set indexes go unless doc_index_dir is
+// set.
+func defaultDocIndexDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "doc-index"
+	}
+	return filepath.Join(home, "Documents", "Doc Index")
+}
+
+// docIndexArchiver adds documentation tabs to one Markdown index per doc
+// set, skipping pages the index already links to.
+type docIndexArchiver struct {
+	dir string
+}
+
+func (a docIndexArchiver) Name() string {
+	return filepath.Base(a.dir)
+}
+
+func (a docIndexArchiver) Target() string {
+	return "docs:" + a.dir
+}
+
+// path returns the index file of a doc set.
+func (a docIndexArchiver) path(set string) string {
+	return filepath.Join(a.dir, unsafeFileChars.ReplaceAllString(set, "-")+".md")
+}
+
+func (a docIndexArchiver) Archive(tabs []Tab) error {
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return err
+	}
+
+	var sets []string
+	bySet := make(map[string][]Tab)
+	for _, tab := range tabs {
+		if _, ok := bySet[tab.DocSet]; !ok {
+			sets = append(sets, tab.DocSet)
+		}
+		bySet[tab.DocSet] = append(bySet[tab.DocSet], tab)
+	}
+
+	for _, set := range sets {
+		if err := a.add(set, bySet[set]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add appends the tabs not yet linked from a doc set's index to it,
+// creating the index with a heading if needed.
+func (a docIndexArchiver) add(set string, tabs []Tab) error {
+	path := a.path(set)
+	indexed, err := indexedURLs(path)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Fprintf(&b, "# %s\n", docSetLabel(set))
+	}
+	var added bool
+	for _, tab := range tabs {
+		if indexed[tab.URL] {
+			continue
+		}
+		if !added {
+			fmt.Fprintf(&b, "\n## %s\n\n", tr("Added %s", time.Now().Format("2006-01-02 15:04")))
+			added = true
+		}
+		indexed[tab.URL] = true
+		fmt.Fprintf(&b, "- %s\n", markdownLink(tab))
+	}
+	if !added {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(b.String())
+	return err
+}
+
+// indexedURLs returns the URLs an index file already links to.
+func indexedURLs(path string) (map[string]bool, error) {
+	urls := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return urls, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		for _, m := range indexLinkRegexp.FindAllStringSubmatch(scanner.Text(), -1) {
+			urls[m[1]] = true
+		}
+	}
+	return urls, scanner.Err()
+}
+
+// exportDocs adds the documentation tabs among tabs to their doc sets'
+// indexes and closes them. Other tabs are left alone.
+func (m *model) exportDocs(tabs []Tab) tea.Cmd {
+	dir := docIndexDir
+	if dir == "" {
+		dir = defaultDocIndexDir()
+	}
+	return m.archiveOnly(docIndexArchiver{dir: dir}, tabs, func(t Tab) bool { return t.DocSet != "" }, tr("No documentation tabs selected."))
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The doctor command checks everything the app depends on, one thing at a
+// time, and says how to fix what fails: the first thing to run when the
+// list comes up empty.
+
+// Doctor check results.
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN" // Works, with features missing
+	checkFail = "FAIL"
+	checkSkip = "SKIP" // Can't be checked until an earlier check passes
+)
+
+// A checkResult is the outcome of one check.
+type checkResult struct {
+	name   string
+	status string
+	detail string
+	fix    string // How to fix a warning or failure
+}
+
+// runDoctor is the doctor subcommand.
+func runDoctor(args []string) {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	failed := false
+	for _, result := range doctorChecks(*profile) {
+		fmt.Printf("[%s] %s: %s\n", result.status, result.name, result.detail)
+		if result.fix != "" {
+			fmt.Printf("       %s\n", tr("Fix: %s", result.fix))
+		}
+		failed = failed || result.status == checkFail
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// doctorChecks runs the checks in order, skipping those that depend on one
+// that failed.
+func doctorChecks(profile string) []checkResult {
+	var results []checkResult
+	add := func(name, status, detail, fix string) {
+		results = append(results, checkResult{name: name, status: status, detail: detail, fix: fix})
+	}
+
+	// Scripting Safari
+	osascript := "osascript"
+	if path, err := exec.LookPath("osascript"); err != nil {
+		add(osascript, checkFail, err.Error(), tr("The app needs macOS, where osascript is in /usr/bin."))
+	} else {
+		add(osascript, checkPass, path, "")
+	}
+
+	app := tr("%s installed", safariApp)
+	version, err := safariVersion()
+	switch {
+	case err != nil:
+		add(app, checkFail, err.Error(), tr("Install %s in /Applications.", safariApp))
+	case featureTabs.require() != nil:
+		add(app, checkFail, featureTabs.require().Error(), tr("Update macOS to get a newer %s.", safariApp))
+	default:
+		add(app, checkPass, tr("version %s", version), "")
+	}
+
+	running := tr("%s running", safariApp)
+	automation := tr("Automation permission")
+	tabs := tr("Tabs")
+	javascript := tr("JavaScript from Apple Events")
+	isRunning, err := safariRunning()
+	switch {
+	case err != nil:
+		add(running, checkFail, err.Error(), "")
+	case !isRunning:
+		add(running, checkFail, tr("not running"), tr("Open %s; tabs are read from the running app.", safariApp))
+	default:
+		add(running, checkPass, tr("running"), "")
+	}
+
+	if err != nil || !isRunning {
+		add(automation, checkSkip, tr("needs %s running", safariApp), "")
+		add(tabs, checkSkip, tr("needs %s running", safariApp), "")
+		add(javascript, checkSkip, tr("needs %s running", safariApp), "")
+	} else if windows, tabCount, err := countSafariTabs(); err != nil {
+		fix := ""
+		if strings.Contains(err.Error(), "-1743") {
+			fix = tr("Allow your terminal app to control %s in System Settings → Privacy & Security → Automation.", safariApp)
+		}
+		add(automation, checkFail, err.Error(), fix)
+		add(tabs, checkSkip, tr("needs the Automation permission"), "")
+		add(javascript, checkSkip, tr("needs the Automation permission"), "")
+	} else {
+		add(automation, checkPass, tr("granted"), "")
+		switch {
+		case tabCount == 0 && featureTabGroups.supported():
+			add(tabs, checkFail, tr("%d windows, no tabs", windows), tr("Open a window. Only the tabs of each window's current Tab Group can be read, and -window or -pick-window limit the scan to one window."))
+		case tabCount == 0:
+			add(tabs, checkFail, tr("%d windows, no tabs", windows), tr("Open a window. -window or -pick-window limit the scan to one window."))
+		case featureTabGroups.supported():
+			add(tabs, checkPass, tr("%d tabs in %d windows; only the current Tab Group of each window can be read", tabCount, windows), "")
+		default:
+			add(tabs, checkPass, tr("%d tabs in %d windows", tabCount, windows), "")
+		}
+
+		if tabCount == 0 {
+			add(javascript, checkSkip, tr("needs a tab"), "")
+		} else if err := checkAppleEventsJavaScript(); err != nil {
+			add(javascript, checkWarn, tr("off: loading, audio and video state is unknown, so playing tabs aren't spared"), javascriptSettingFix())
+		} else {
+			add(javascript, checkPass, tr("allowed"), "")
+		}
+	}
+
+	// Files that need Full Disk Access
+	fix := tr("Grant Full Disk Access to your terminal app in System Settings → Privacy & Security → Full Disk Access, then start it again.")
+	for _, c := range capabilities {
+		switch state := c.state(); state {
+		case capAvailable:
+			add(tr(c.features), checkPass, tr(state), "")
+		case capNeedsAccess:
+			add(tr(c.features), checkWarn, tr(state), fix)
+		default:
+			add(tr(c.features), checkWarn, tr(state), "")
+		}
+	}
+	history := "History.db"
+	if !capHistory.available() {
+		add(history, checkSkip, tr("can't be opened"), "")
+	} else if count, err := countHistoryItems(); err != nil {
+		add(history, checkFail, err.Error(), tr("Quit %s and try again; if it keeps failing, the history may be damaged.", safariApp))
+	} else {
+		add(history, checkPass, tr("%d pages in history", count), "")
+	}
+
+	// Settings
+	configName := "config.json"
+	if cfg, err := setupConfig(profile); err != nil {
+		add(configName, checkFail, err.Error(), configFix())
+	} else {
+		var problems []string
+		for _, view := range cfg.Views {
+			if err := view.validate(); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		if len(problems) > 0 {
+			add(configName, checkFail, strings.Join(problems, "; "), configFix())
+		} else {
+			add(configName, checkPass, tr("valid"), "")
+		}
+	}
+	return results
+}
+
+// configFix says where to fix the config.
+func configFix() string {
+	dir, err := configDir()
+	if err != nil {
+		return ""
+	}
+	return tr("Edit %s.", filepath.Join(dir, "config.json"))
+}
+
+// countSafariTabs counts Safari's windows and their tabs.
+func countSafariTabs() (windows, tabs int, err error) {
+	script := fmt.Sprintf(`
+	tell application %s
+		set tabCount to 0
+		repeat with w in windows
+			set tabCount to tabCount + (count of tabs of w)
+		end repeat
+		return ((count of windows) as text) & " " & tabCount
+	end tell
+	`, appleScriptString(safariApp))
+	output, err := exec.Command("osascript", "-e", script).CombinedOutput()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected answer %q", strings.TrimSpace(string(output)))
+	}
+	windows, _ = strconv.Atoi(fields[0])
+	tabs, _ = strconv.Atoi(fields[1])
+	return windows, tabs, nil
+}
+
+// checkAppleEventsJavaScript runs a script in the front tab, which Safari
+// refuses unless "Allow JavaScript from Apple Events" is on.
+func checkAppleEventsJavaScript() error {
+	script := fmt.Sprintf(`tell application %s to do JavaScript "1" in current tab of front window`, appleScriptString(safariApp))
+	if output, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// countHistoryItems counts the pages in History.db, which fails if it
+// can't be queried.
+func countHistoryItems() (int, error) {
+	path, err := safariHistoryPath()
+	if err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM history_items`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("could not query History.db: %w", err)
+	}
+	return count, nil
+}
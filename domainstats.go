@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The stats screen shows the heatmap of tab ages by window, and lists the
+// domains with the most tabs: how many, how many are duplicates, how long
+// the oldest was left alone and how long they all take to read. Picking a
+// window shows its tabs in the list. Picking a domain lists its tabs, where
+// they can be selected and closed or archived without leaving the screen, a
+// cleanup of just that domain. The selection is the list's, so it carries
+// over both ways.
+
+// domainStat is a domain's line on the stats screen.
+type domainStat struct {
+	domain         string
+	tabs           []int // Tab indexes, in window order
+	duplicates     int
+	oldest         time.Time // Zero if no tab has a known last use
+	readingMinutes int
+}
+
+// domainStatsScreen is the state of the stats screen.
+type domainStatsScreen struct {
+	windows   []windowAges
+	window    int  // Cursor among the heatmap's windows
+	onHeatmap bool // The heatmap has the focus rather than the domains
+	domains   []domainStat
+	cursor    int
+	drill     bool // Showing the tabs of domains[cursor]
+	tab       int  // Cursor among those tabs
+}
+
+// domainStats gathers the stats of every domain of the unpinned tabs, the
+// most tabs first.
+func domainStats(tabs []Tab) []domainStat {
+	var stats []domainStat
+	index := make(map[string]int)
+	for i, tab := range tabs {
+		if tab.Pinned {
+			continue
+		}
+		domain := siteOf(tab.URL)
+		n, ok := index[domain]
+		if !ok {
+			n = len(stats)
+			index[domain] = n
+			stats = append(stats, domainStat{domain: domain})
+		}
+		s := &stats[n]
+		s.tabs = append(s.tabs, i)
+		if tab.DuplicateOf != 0 {
+			s.duplicates++
+		}
+		if last := staleness(tab); !last.IsZero() && (s.oldest.IsZero() || last.Before(s.oldest)) {
+			s.oldest = last
+		}
+		s.readingMinutes += tab.ReadingMinutes
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return len(stats[i].tabs) > len(stats[j].tabs)
+	})
+	return stats
+}
+
+// startDomainStats opens the stats screen.
+func (m *model) startDomainStats() tea.Cmd {
+	domains := domainStats(m.tabs)
+	if len(domains) == 0 {
+		return m.showToast(tr("No tabs to show stats for."))
+	}
+	m.domainStats = &domainStatsScreen{windows: windowHeatmap(m.tabs, time.Now()), domains: domains}
+	return nil
+}
+
+// updateDomainStats handles a key on the stats screen.
+func (m *model) updateDomainStats(msg tea.KeyMsg) tea.Cmd {
+	s := m.domainStats
+	if key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))) {
+		return m.requestQuit()
+	}
+	if s.onHeatmap {
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "t"))):
+			m.domainStats = nil
+		case key.Matches(msg, key.NewBinding(key.WithKeys("tab"))):
+			s.onHeatmap = false
+		case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+			s.window = max(0, s.window-1)
+		case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+			s.window = min(len(s.windows)-1, s.window+1)
+		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+			// Back to the list, showing just this window
+			window := s.windows[s.window].window
+			m.domainStats = nil
+			m.filter.window = window
+			m.refreshItems()
+			return m.showToast(tr("Showing the tabs of window %d; press 's' to select them, or 'v' and '0' for all tabs.", window))
+		}
+		return nil
+	}
+	if !s.drill {
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "t"))):
+			m.domainStats = nil
+		case key.Matches(msg, key.NewBinding(key.WithKeys("tab"))):
+			s.onHeatmap = true
+		case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+			s.cursor = max(0, s.cursor-1)
+		case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+			s.cursor = min(len(s.domains)-1, s.cursor+1)
+		case key.Matches(msg, key.NewBinding(key.WithKeys("enter", "right", "l"))):
+			s.drill, s.tab = true, 0
+		}
+		return nil
+	}
+
+	domain := s.domains[s.cursor]
+	selectWhere := func(format string, match func(Tab) bool) tea.Cmd {
+		n := 0
+		for _, i := range domain.tabs {
+			if match(m.tabs[i]) && !m.tabs[i].locked() {
+				m.selected.set(i, true)
+				n++
+			}
+		}
+		return m.showToast(tr(format, n, domainLabel(domain.domain)))
+	}
+
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "left", "h"))):
+		s.drill = false
+	case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+		s.tab = max(0, s.tab-1)
+	case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+		s.tab = min(len(domain.tabs)-1, s.tab+1)
+	case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
+		i := domain.tabs[s.tab]
+		if reason := m.tabs[i].lockReason(); reason != "" {
+			return m.showToast(tr("That tab is %s and can't be closed.", reason))
+		}
+		m.selected.set(i, !m.selected.has(i))
+	case key.Matches(msg, key.NewBinding(key.WithKeys("s"))):
+		return selectWhere("Selected %d tabs on %s", func(Tab) bool { return true })
+	case key.Matches(msg, key.NewBinding(key.WithKeys("a"))):
+		return selectWhere("Selected %d duplicates on %s", func(t Tab) bool { return t.DuplicateOf != 0 })
+	case key.Matches(msg, key.NewBinding(key.WithKeys("o"))):
+		return selectWhere("Selected %d old tabs on %s", func(t Tab) bool { return t.IsOld })
+	case key.Matches(msg, key.NewBinding(key.WithKeys("n"))):
+		for _, i := range domain.tabs {
+			m.selected.set(i, false)
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("f"))):
+		// Back to the list, showing just this domain
+		m.domainStats = nil
+		m.filter.domain = domain.domain
+		m.refreshItems()
+		return m.showToast(tr("Showing tabs on %s; press 's' to select them.", domainLabel(domain.domain)))
+	case key.Matches(msg, key.NewBinding(key.WithKeys("c", "A"))):
+		tabs := m.domainSelection(domain)
+		if len(tabs) == 0 {
+			return m.showToast(tr("No tabs on %s selected.", domainLabel(domain.domain)))
+		}
+		m.domainStats = nil
+		if msg.String() == "A" {
+			return m.archiveTabs(m.archiver, tabs)
+		}
+		m.startClosing(len(tabs))
+		return closeTabsAsync(tabs, m.emptyPinnedOnlyWindows)
+	}
+	return nil
+}
+
+// domainSelection returns the selected tabs of a domain.
+func (m model) domainSelection(domain domainStat) []Tab {
+	var tabs []Tab
+	for _, i := range domain.tabs {
+		if m.selected.has(i) {
+			tabs = append(tabs, m.tabs[i])
+		}
+	}
+	return tabs
+}
+
+// describeDomain sums up a domain's stats on one line.
+func describeDomain(s domainStat, now time.Time) string {
+	parts := []string{tr("%d tabs", len(s.tabs))}
+	if s.duplicates > 0 {
+		parts = append(parts, tr("%d duplicates", s.duplicates))
+	}
+	if !s.oldest.IsZero() {
+		parts = append(parts, tr("oldest %s", humanizeTime(s.oldest, now)))
+	}
+	if s.readingMinutes > 0 {
+		parts = append(parts, tr("~%d min read", s.readingMinutes))
+	}
+	return strings.Join(parts, sym.separator)
+}
+
+// domainStatsView shows the heatmap and the domains, or the tabs of the
+// domain drilled into.
+func (m model) domainStatsView() string {
+	s := m.domainStats
+	now := time.Now()
+	width := max(minTextWidth, m.list.Width()-4)
+	rows := max(3, m.list.Height()-2)
+	var lines []string
+	var hints []string
+
+	// scrolled renders the rows around the cursor that fit in rows,
+	// marking the cursor if the rows have the focus
+	scrolled := func(n, cursor, rows int, focused bool, row func(i int) string) {
+		start := max(0, min(cursor-rows/2, n-rows))
+		for i := start; i < min(n, start+rows); i++ {
+			prefix := strings.Repeat(" ", textWidth(sym.cursor))
+			if focused && i == cursor {
+				prefix = sym.cursor
+			}
+			lines = append(lines, "  "+prefix+row(i))
+		}
+	}
+
+	if !s.drill {
+		lines = append(lines, titleStyle.Render(tr("Tab ages by window")), "")
+		heading, heatRow := heatmap(s.windows)
+		lines = append(lines, "  "+strings.Repeat(" ", textWidth(sym.cursor))+helpStyle.Render(heading))
+		heatRows := min(len(s.windows), max(2, rows/3))
+		scrolled(len(s.windows), s.window, heatRows, s.onHeatmap, func(i int) string {
+			return heatRow(i, s.onHeatmap && i == s.window)
+		})
+		rows = max(3, rows-heatRows-4)
+
+		lines = append(lines, "", titleStyle.Render(tr("Domains - %d tabs on %d domains", budgetTabs(m.tabs), len(s.domains))), "")
+		nameWidth := 0
+		for _, d := range s.domains {
+			nameWidth = max(nameWidth, textWidth(domainLabel(d.domain)))
+		}
+		nameWidth = min(nameWidth, width/2)
+		scrolled(len(s.domains), s.cursor, rows, !s.onHeatmap, func(i int) string {
+			d := s.domains[i]
+			name := padRight(truncateEnd(domainLabel(d.domain), nameWidth), nameWidth)
+			style := normalStyle
+			if !s.onHeatmap && i == s.cursor {
+				style = style.Bold(true)
+			}
+			return style.Render(name) + "  " + helpStyle.Render(truncateEnd(describeDomain(d, now), max(minTextWidth, width-nameWidth-2)))
+		})
+		hints = []string{sym.up + "/" + sym.down + ": " + tr("move"), tr("enter: show tabs"), tr("tab: heatmap"), tr("esc: back to the list")}
+		if s.onHeatmap {
+			hints = []string{sym.up + "/" + sym.down + ": " + tr("move"), tr("enter: show the window's tabs"), tr("tab: domains"), tr("esc: back to the list")}
+		}
+	} else {
+		d := s.domains[s.cursor]
+		selected := len(m.domainSelection(d))
+		lines = append(lines, titleStyle.Render(domainLabel(d.domain)), titleStyle.Render(helpStyle.Render(describeDomain(d, now)+sym.separator+tr("%d selected", selected))), "")
+		scrolled(len(d.tabs), s.tab, rows, true, func(i int) string {
+			tab := &m.tabs[d.tabs[i]]
+			check := sym.unchecked
+			if m.selected.has(d.tabs[i]) {
+				check = sym.checked
+			}
+			var details []string
+			if tab.DuplicateOf != 0 {
+				details = append(details, tr("duplicate"))
+			}
+			if last := tab.lastActive(); !last.IsZero() {
+				details = append(details, humanizeTime(last, now))
+			}
+			if tab.ReadingMinutes > 0 {
+				details = append(details, tr("~%d min read", tab.ReadingMinutes))
+			}
+			if reason := tab.lockReason(); reason != "" {
+				details = append(details, reason)
+			}
+			suffix := ""
+			if len(details) > 0 {
+				suffix = "  " + strings.Join(details, sym.separator)
+			}
+			style := normalStyle
+			if tab.DuplicateOf != 0 {
+				style = duplicateStyle
+			}
+			title := truncateEnd(tabName(*tab), max(minTextWidth, width-textWidth(check+" "+suffix)))
+			return fmt.Sprintf("%s %s%s", check, style.Render(title), helpStyle.Render(suffix))
+		})
+		hints = []string{
+			tr("space: toggle"),
+			tr("s: select all"),
+			tr("a: select duplicates"),
+			tr("o: select old"),
+			tr("n: deselect"),
+			tr("c: close selected"),
+			tr("A: archive selected"),
+			tr("f: show in the list"),
+			tr("esc: back to domains"),
+		}
+	}
+
+	lines = append(lines, "", helpStyle.Render(" "+strings.Join(hints, sym.separator)))
+	if m.toast != "" {
+		lines = append(lines, messageStyle.Render(" "+m.toast))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The downloads subcommand cleans up Safari's Downloads list the way the TUI
+// cleans up tabs: it lists the downloads with their age and whether the file
+// is still there, preselects the ones whose file is gone, and clears the
+// selected entries from Downloads.plist. The files themselves are never
+// touched.
+
+// download is one entry of Safari's Downloads list.
+type download struct {
+	URL      string
+	Path     string
+	Added    time.Time
+	Size     int64
+	Missing  bool // The downloaded file no longer exists
+	Selected bool
+}
+
+// plistNode is an element of an XML property list, kept whole so that
+// entries can be removed without losing the keys this tool doesn't know.
+type plistNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr  `xml:",any,attr"`
+	Text    string      `xml:",chardata"`
+	Nodes   []plistNode `xml:",any"`
+}
+
+// value returns the value of a key in a dict node, or nil.
+func (n *plistNode) value(key string) *plistNode {
+	for i := 0; i+1 < len(n.Nodes); i += 2 {
+		if n.Nodes[i].XMLName.Local == "key" && n.Nodes[i].Text == key {
+			return &n.Nodes[i+1]
+		}
+	}
+	return nil
+}
+
+func downloadsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	library := "Safari"
+	if safariApp == "Safari Technology Preview" {
+		library = "SafariTechnologyPreview"
+	}
+	return filepath.Join(homeDir, "Library", library, "Downloads.plist"), nil
+}
+
+// loadDownloads reads Downloads.plist, which is binary, through plutil. It
+// returns the parsed list along with the downloads in it.
+func loadDownloads(path string) (*plistNode, []download, error) {
+	output, err := exec.Command("plutil", "-convert", "xml1", "-o", "-", path).Output()
+	if err != nil {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			return nil, nil, fmt.Errorf("%s has no downloads list", safariApp)
+		}
+		return nil, nil, fmt.Errorf("could not read the downloads list: %w", err)
+	}
+	var root plistNode
+	if err := xml.Unmarshal(output, &root); err != nil {
+		return nil, nil, fmt.Errorf("could not read the downloads list: %w", err)
+	}
+	if len(root.Nodes) == 0 {
+		return &root, nil, nil
+	}
+	history := root.Nodes[0].value("DownloadHistory")
+	if history == nil {
+		return &root, nil, nil
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	downloads := make([]download, len(history.Nodes))
+	for i := range history.Nodes {
+		entry := &history.Nodes[i]
+		var d download
+		if v := entry.value("DownloadEntryURL"); v != nil {
+			d.URL = v.Text
+		}
+		if v := entry.value("DownloadEntryPath"); v != nil {
+			d.Path = v.Text
+			if rest, ok := strings.CutPrefix(d.Path, "~/"); ok && homeDir != "" {
+				d.Path = filepath.Join(homeDir, rest)
+			}
+		}
+		if v := entry.value("DownloadEntryDateAddedKey"); v != nil {
+			d.Added, _ = time.Parse(time.RFC3339, v.Text)
+		}
+		if v := entry.value("DownloadEntryProgressTotalToLoad"); v != nil {
+			d.Size, _ = strconv.ParseInt(v.Text, 10, 64)
+		}
+		if d.Path == "" {
+			d.Missing = true
+		} else if _, err := os.Stat(d.Path); os.IsNotExist(err) {
+			d.Missing = true
+		}
+		d.Selected = d.Missing
+		downloads[i] = d
+	}
+	return &root, downloads, nil
+}
+
+// clearDownloads removes the selected downloads from Downloads.plist, after
+// backing it up. Safari must be closed, since it writes the list back when
+// it quits.
+func clearDownloads(path string, root *plistNode, downloads []download) (cleared int, backup string, err error) {
+	if running, err := safariRunning(); err != nil {
+		return 0, "", err
+	} else if running {
+		return 0, "", fmt.Errorf("quit %s first, it would write its downloads list back", safariApp)
+	}
+
+	history := root.Nodes[0].value("DownloadHistory")
+	var kept []plistNode
+	for i, d := range downloads {
+		if d.Selected {
+			cleared++
+		} else {
+			kept = append(kept, history.Nodes[i])
+		}
+	}
+	if cleared == 0 {
+		return 0, "", nil
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		return 0, "", err
+	}
+	backup = filepath.Join(dir, "downloads-backups", time.Now().Format("2006-01-02 15.04.05"))
+	if err := os.MkdirAll(backup, 0o755); err != nil {
+		return 0, "", err
+	}
+	if err := copyFile(path, filepath.Join(backup, filepath.Base(path))); err != nil {
+		return 0, "", fmt.Errorf("could not back up the downloads list: %w", err)
+	}
+
+	original := history.Nodes
+	history.Nodes = kept
+	err = writePlist(path, root)
+	history.Nodes = original
+	if err != nil {
+		return 0, backup, fmt.Errorf("could not write the downloads list: %w", err)
+	}
+	return cleared, backup, nil
+}
+
+// writePlist writes root as XML, then lets plutil turn it back into a
+// binary plist in place of the one at path.
+func writePlist(path string, root *plistNode) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(trimPlist(*root)); err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	tmp, err := os.CreateTemp(filepath.Dir(path), name+"-*.plist")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if output, err := exec.Command("plutil", "-convert", "binary1", "-o", path, tmp.Name()).CombinedOutput(); err != nil {
+		return errors.New(string(bytes.TrimSpace(output)))
+	}
+	return nil
+}
+
+// trimPlist drops the indentation between elements, which xml.Unmarshal
+// keeps as text, so it isn't written back doubled.
+func trimPlist(n plistNode) plistNode {
+	if len(n.Nodes) > 0 {
+		n.Text = ""
+		nodes := make([]plistNode, len(n.Nodes))
+		for i := range n.Nodes {
+			nodes[i] = trimPlist(n.Nodes[i])
+		}
+		n.Nodes = nodes
+	}
+	return n
+}
+
+// runDownloads is the downloads subcommand, a numbered list with prompts in
+// the style of --plain.
+func runDownloads(args []string) {
+	flags := flag.NewFlagSet("downloads", flag.ExitOnError)
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for old downloads"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args)
+
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	path, err := downloadsPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if capDownloads.state() == capNeedsAccess {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", tr("reading the downloads list needs Full Disk Access, see \"safari-tab-manager permissions\"")))
+		os.Exit(1)
+	}
+	root, downloads, err := loadDownloads(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if len(downloads) == 0 {
+		fmt.Println(tr("The downloads list is empty."))
+		return
+	}
+	cleanDownloads(os.Stdin, os.Stdout, path, root, downloads, *ageDays)
+}
+
+// cleanDownloads lets the user select downloads and clear them.
+func cleanDownloads(in io.Reader, out io.Writer, path string, root *plistNode, downloads []download, ageDays int) {
+	reader := bufio.NewReader(in)
+	printDownloads(out, downloads)
+
+	for {
+		selected := 0
+		for _, d := range downloads {
+			if d.Selected {
+				selected++
+			}
+		}
+		fmt.Fprintf(out, "\n%s\n> ", tr("%d selected. Enter download numbers to toggle (e.g. \"3 5-8\"), m: select missing files, o: select old, n: deselect all, l: list, c: clear selected, q: quit", selected))
+
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(out, "\n"+tr("Cancelled. No downloads were cleared."))
+			return
+		}
+		line = strings.TrimSpace(line)
+
+		switch line {
+		case "":
+			continue
+
+		case "q":
+			fmt.Fprintln(out, tr("Cancelled. No downloads were cleared."))
+			return
+
+		case "l":
+			printDownloads(out, downloads)
+
+		case "m":
+			for i := range downloads {
+				if downloads[i].Missing {
+					downloads[i].Selected = true
+				}
+			}
+
+		case "o":
+			for i := range downloads {
+				if !downloads[i].Added.IsZero() && time.Since(downloads[i].Added) > time.Duration(ageDays)*24*time.Hour {
+					downloads[i].Selected = true
+				}
+			}
+
+		case "n":
+			for i := range downloads {
+				downloads[i].Selected = false
+			}
+
+		case "c":
+			if selected == 0 {
+				fmt.Fprintln(out, tr("No downloads selected."))
+				continue
+			}
+			fmt.Fprint(out, tr("Clear %d downloads from the list? The files stay where they are. Type y to confirm:", selected)+" ")
+			answer, _ := reader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(answer)) != tr("y") {
+				fmt.Fprintln(out, tr("Nothing cleared."))
+				continue
+			}
+			cleared, backup, err := clearDownloads(path, root, downloads)
+			if err != nil {
+				fmt.Fprintln(out, tr("Error: %v", err))
+				continue
+			}
+			fmt.Fprintln(out, tr("Cleared %d downloads. The old list is backed up in %s.", cleared, backup))
+			return
+
+		default:
+			numbers, err := parseTabNumbers(line, len(downloads))
+			if err != nil {
+				fmt.Fprintf(out, "%v\n", err)
+				continue
+			}
+			for _, n := range numbers {
+				d := &downloads[n-1]
+				d.Selected = !d.Selected
+				if d.Selected {
+					fmt.Fprintln(out, tr("%d selected: %s", n, filepath.Base(d.Path)))
+				} else {
+					fmt.Fprintln(out, tr("%d deselected: %s", n, filepath.Base(d.Path)))
+				}
+			}
+		}
+	}
+}
+
+func printDownloads(out io.Writer, downloads []download) {
+	missing := 0
+	for _, d := range downloads {
+		if d.Missing {
+			missing++
+		}
+	}
+	fmt.Fprintf(out, "%s\n\n", tr("%d downloads, %d of them no longer on disk.", len(downloads), missing))
+	for i, d := range downloads {
+		var notes []string
+		if d.Selected {
+			notes = append(notes, tr("selected"))
+		}
+		if !d.Added.IsZero() {
+			notes = append(notes, tr("%d days old", int(time.Since(d.Added).Hours()/24)))
+		}
+		if d.Size > 0 {
+			notes = append(notes, fmt.Sprintf("%.1f MB", float64(d.Size)/(1<<20)))
+		}
+		if d.Missing {
+			notes = append(notes, tr("file missing"))
+		}
+
+		name := filepath.Base(d.Path)
+		if d.Path == "" {
+			name = d.URL
+		}
+		line := fmt.Sprintf("%d. %s", i+1, name)
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Fprintf(out, "%s\n   %s\n", line, d.URL)
+	}
+}
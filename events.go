@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	eventBuffer       = 16 // Scans queued per client before it is dropped
+	eventWriteTimeout = 10 * time.Second
+)
+
+// A tabEvent describes how the tabs changed between two scans. Clients are
+// sent a snapshot when they connect and events after every scan.
+type tabEvent struct {
+	Type  string      `json:"type"` // "snapshot", "added", "removed" or "changed"
+	Tab   *tabRecord  `json:"tab,omitempty"`
+	Tabs  []tabRecord `json:"tabs,omitempty"` // Only for snapshots
+	Count int         `json:"count"`          // Tabs open after the change
+	Time  time.Time   `json:"time"`
+}
+
+// eventHub broadcasts tab events to every connected WebSocket client.
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[chan []tabEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[chan []tabEvent]struct{})}
+}
+
+func (h *eventHub) subscribe() chan []tabEvent {
+	ch := make(chan []tabEvent, eventBuffer)
+	h.mu.Lock()
+	h.clients[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan []tabEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[ch]; ok {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// broadcast queues one scan's events for every client. A client too slow
+// to keep up is disconnected rather than holding up the scanner.
+func (h *eventHub) broadcast(events []tabEvent) {
+	if len(events) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- events:
+		default:
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// keyedTabs identifies tabs across scans by their URL and how many earlier
+// tabs share it, since window and tab indexes shift whenever a tab closes.
+func keyedTabs(tabs []Tab) ([]string, map[string]Tab) {
+	keys := make([]string, len(tabs))
+	byKey := make(map[string]Tab, len(tabs))
+	seen := make(map[string]int)
+	for i, tab := range tabs {
+		keys[i] = fmt.Sprintf("%s#%d", tab.URL, seen[tab.URL])
+		byKey[keys[i]] = tab
+		seen[tab.URL]++
+	}
+	return keys, byKey
+}
+
+// tabChanged reports whether anything a client sees about a tab changed.
+func tabChanged(a, b Tab) bool {
+	return a.Title != b.Title ||
+		a.WindowIndex != b.WindowIndex ||
+		a.TabIndex != b.TabIndex ||
+		a.IsOld != b.IsOld ||
+		a.ReadElsewhere != b.ReadElsewhere ||
+		a.SavedIn != b.SavedIn ||
+		a.Blocked != b.Blocked ||
+		a.Expired != b.Expired ||
+		a.Resolved != b.Resolved ||
+		a.VideoSeconds != b.VideoSeconds ||
+		a.VideoPosition != b.VideoPosition ||
+		a.Price != b.Price ||
+		(a.DuplicateOf != 0) != (b.DuplicateOf != 0) ||
+		a.Category != b.Category
+}
+
+// diffTabs returns the events that turn before into after.
+func diffTabs(before, after []Tab) []tabEvent {
+	now := time.Now()
+	event := func(kind string, tab Tab) tabEvent {
+		record := newTabRecord(tab)
+		return tabEvent{Type: kind, Tab: &record, Count: len(after), Time: now}
+	}
+
+	beforeKeys, previous := keyedTabs(before)
+	afterKeys, current := keyedTabs(after)
+
+	var events []tabEvent
+	for _, key := range beforeKeys {
+		if _, ok := current[key]; !ok {
+			events = append(events, event("removed", previous[key]))
+		}
+	}
+	for _, key := range afterKeys {
+		old, ok := previous[key]
+		if !ok {
+			events = append(events, event("added", current[key]))
+		} else if tabChanged(old, current[key]) {
+			events = append(events, event("changed", current[key]))
+		}
+	}
+	return events
+}
+
+var upgrader = websocket.Upgrader{} // Rejects cross-origin requests, so web pages can't read your tabs
+
+// handleEvents streams tab events to a WebSocket client, starting with a
+// snapshot of the current tabs.
+func (s *scanner) handleEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade has already replied with an error
+	}
+	defer conn.Close()
+
+	tabs, ch := s.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	records := make([]tabRecord, len(tabs))
+	for i, tab := range tabs {
+		records[i] = newTabRecord(tab)
+	}
+	if err := writeEvent(conn, tabEvent{Type: "snapshot", Tabs: records, Count: len(tabs), Time: time.Now()}); err != nil {
+		return
+	}
+
+	// Reading is only needed to notice the client going away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case events, ok := <-ch:
+			if !ok {
+				return
+			}
+			for _, event := range events {
+				if err := writeEvent(conn, event); err != nil {
+					log.Printf("Warning: dropping event client: %v", err)
+					return
+				}
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func writeEvent(conn *websocket.Conn, event tabEvent) error {
+	conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+	return conn.WriteJSON(event)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Some tabs go stale on a date rather than with disuse: a meeting link is
+// done once the meeting is, and a page with a date in its URL, like an
+// event or a daily agenda, once that day has passed. Such tabs are marked
+// expired however recently they were visited.
+
+// meetingLength is how long after its last visit a meeting tab counts as
+// over.
+const meetingLength = 3 * time.Hour
+
+// urlDate matches a date in a URL, like /2024/05/03/ or 2024-05-03.
+var urlDate = regexp.MustCompile(`(?:^|[^0-9])(20[0-9]{2})[/_-](0[1-9]|1[0-2])[/_-](0[1-9]|[12][0-9]|3[01])(?:[^0-9]|$)`)
+
+// isMeeting reports whether a URL joins a Zoom, Google Meet, Webex or Teams
+// meeting.
+func isMeeting(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.ToLower(u.Path)
+	switch {
+	case host == "zoom.us" || strings.HasSuffix(host, ".zoom.us"):
+		for _, prefix := range []string{"/j/", "/w/", "/wc/", "/s/", "/my/"} {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+	case host == "meet.google.com":
+		return len(path) > 1 && !strings.HasPrefix(path, "/_")
+	case strings.HasSuffix(host, ".webex.com"):
+		return strings.Contains(path, "/meet/") || strings.Contains(path, "/j.php") || strings.Contains(path, "/wbxmjs/")
+	case host == "teams.microsoft.com":
+		return strings.HasPrefix(path, "/l/meetup-join/")
+	case host == "teams.live.com":
+		return strings.HasPrefix(path, "/meet/")
+	}
+	return false
+}
+
+// pastURLDate returns the date in a URL if that day is over, or the zero
+// time.
+func pastURLDate(rawURL string, now time.Time) time.Time {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}
+	}
+	match := urlDate.FindStringSubmatch(u.Path + "?" + u.RawQuery)
+	if match == nil {
+		return time.Time{}
+	}
+	date, err := time.ParseInLocation("2006-01-02", match[1]+"-"+match[2]+"-"+match[3], now.Location())
+	if err != nil {
+		return time.Time{}
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !date.Before(today) {
+		return time.Time{}
+	}
+	return date
+}
+
+// markExpired sets Expired on meeting tabs not visited for the length of a
+// meeting, and on tabs whose URL carries a date that has passed.
+func markExpired(tabs []Tab) {
+	now := time.Now()
+	for i := range tabs {
+		tabs[i].Expired = ""
+		if isMeeting(tabs[i].URL) {
+			if tabs[i].LastVisit.IsZero() || now.Sub(tabs[i].LastVisit) > meetingLength {
+				tabs[i].Expired = "meeting"
+			}
+		} else if date := pastURLDate(tabs[i].URL, now); !date.IsZero() {
+			tabs[i].Expired = date.Format("2006-01-02")
+		}
+	}
+}
+
+// expiredReason says why a tab is expired, for display.
+func expiredReason(tab *Tab) string {
+	if tab.Expired == "meeting" {
+		return tr("meeting is over")
+	}
+	return tr("dated %s, expired", tab.Expired)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var showFavicons bool // Set by the -favicons flag
+
+// Kitty's Unicode placeholder character and the diacritics that encode the
+// row and column of each cell (see the kitty graphics protocol docs).
+const (
+	kittyPlaceholder = '\U0010EEEE'
+	kittyDiacritic0  = '\u0305'
+	kittyDiacritic1  = '\u030d'
+	kittyMaxImageID  = 255 // IDs are encoded as a 256-color foreground
+	kittyChunkSize   = 4096
+	faviconMaxBytes  = 256 * 1024
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// supportsKittyGraphics reports whether the terminal understands the kitty
+// graphics protocol with Unicode placeholders. Placeholders are required
+// because they are ordinary text and survive Bubble Tea's line truncation;
+// iTerm2's inline images are raw escape payloads that do not, so iTerm2
+// gets the Unicode fallback.
+func supportsKittyGraphics() bool {
+	return os.Getenv("KITTY_WINDOW_ID") != "" ||
+		os.Getenv("TERM") == "xterm-kitty" ||
+		os.Getenv("TERM_PROGRAM") == "ghostty"
+}
+
+// faviconFallback renders the first letter of a domain as a circled letter,
+// padded to the same two cells an inline icon takes.
+func faviconFallback(domain string) string {
+	for _, r := range domain {
+		r = unicode.ToLower(r)
+		if r >= 'a' && r <= 'z' {
+			return string('ⓐ'+(r-'a')) + " "
+		}
+	}
+	return "◌ "
+}
+
+// loadFavicons returns the rendered icon for each domain in tabs. On kitty
+// compatible terminals the images are transmitted to the terminal up front
+// and rendered later as placeholder text; everything else gets the fallback.
+func loadFavicons(tabs []Tab, out io.Writer) map[string]string {
+	hosts := make(map[string]string) // domain -> host to fetch from
+	for _, tab := range tabs {
+		domain := extractDomain(tab.URL)
+		if domain == "" {
+			continue
+		}
+		if _, ok := hosts[domain]; ok {
+			continue
+		}
+		if u, err := url.Parse(tab.URL); err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https") {
+			hosts[domain] = u.Host
+		} else {
+			hosts[domain] = ""
+		}
+	}
+
+	icons := make(map[string]string, len(hosts))
+	for domain := range hosts {
+		icons[domain] = faviconFallback(domain)
+	}
+
+	if !supportsKittyGraphics() {
+		return icons
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+		return icons
+	}
+	dir = filepath.Join(dir, "favicons")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("Warning: could not create favicon cache: %v", err)
+		return icons
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+	images := make(map[string][]byte)
+
+	for domain, host := range hosts {
+		if host == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(domain, host string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if data := cachedFavicon(dir, domain, host); len(data) > 0 {
+				mu.Lock()
+				images[domain] = data
+				mu.Unlock()
+			}
+		}(domain, host)
+	}
+	wg.Wait()
+
+	id := 0
+	for domain, data := range images {
+		if id == kittyMaxImageID {
+			break
+		}
+		id++
+		transmitKittyImage(out, id, data)
+		icons[domain] = kittyPlaceholderCells(id)
+	}
+
+	return icons
+}
+
+// cachedFavicon returns the PNG favicon for domain, fetching it when the
+// cache is missing or stale. An empty cache file records a failed fetch.
+func cachedFavicon(dir, domain, host string) []byte {
+	path := filepath.Join(dir, domain+".png")
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < fetchCacheTTL {
+		data, _ := os.ReadFile(path)
+		return data
+	}
+
+	data, err := fetchPage("https://"+host+"/favicon.ico", faviconMaxBytes)
+	if err == nil {
+		data, err = faviconToPNG(data)
+	}
+	if err != nil {
+		data = nil
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Printf("Warning: could not cache favicon for %s: %v", domain, err)
+	}
+	return data
+}
+
+// faviconToPNG converts a favicon to PNG, which is the only format kitty
+// accepts. ICO files are unpacked to their largest image.
+func faviconToPNG(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, pngSignature) {
+		return data, nil
+	}
+
+	var img image.Image
+	var err error
+	if isICO(data) {
+		var entry []byte
+		entry, err = largestICOEntry(data)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(entry, pngSignature) {
+			return entry, nil
+		}
+		img, err = decodeICOBitmap(entry)
+	} else {
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isICO(data []byte) bool {
+	return len(data) >= 6 && binary.LittleEndian.Uint16(data[0:]) == 0 && binary.LittleEndian.Uint16(data[2:]) == 1
+}
+
+func largestICOEntry(data []byte) ([]byte, error) {
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	var best []byte
+	bestSize := -1
+	for i := 0; i < count; i++ {
+		off := 6 + i*16
+		if off+16 > len(data) {
+			break
+		}
+		width := int(data[off])
+		if width == 0 {
+			width = 256
+		}
+		size := binary.LittleEndian.Uint32(data[off+8:])
+		start := binary.LittleEndian.Uint32(data[off+12:])
+		if uint64(start)+uint64(size) > uint64(len(data)) {
+			continue
+		}
+		if width > bestSize {
+			bestSize = width
+			best = data[start : start+size]
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no usable icon in ICO file")
+	}
+	return best, nil
+}
+
+// decodeICOBitmap decodes the 32-bit BGRA bitmaps that modern ICO files use.
+// The stored height covers the image plus its AND mask, so it is halved.
+func decodeICOBitmap(entry []byte) (image.Image, error) {
+	if len(entry) < 40 {
+		return nil, fmt.Errorf("truncated ICO bitmap")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(entry[0:]))
+	width := int(int32(binary.LittleEndian.Uint32(entry[4:])))
+	height := int(int32(binary.LittleEndian.Uint32(entry[8:]))) / 2
+	bpp := binary.LittleEndian.Uint16(entry[14:])
+	if bpp != 32 {
+		return nil, fmt.Errorf("unsupported ICO bit depth %d", bpp)
+	}
+	if width <= 0 || height <= 0 || headerSize+width*height*4 > len(entry) {
+		return nil, fmt.Errorf("invalid ICO bitmap size")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	pixels := entry[headerSize:]
+	for y := 0; y < height; y++ {
+		row := pixels[(height-1-y)*width*4:] // Rows are stored bottom-up
+		for x := 0; x < width; x++ {
+			p := row[x*4:]
+			img.SetNRGBA(x, y, color.NRGBA{R: p[2], G: p[1], B: p[0], A: p[3]})
+		}
+	}
+	return img, nil
+}
+
+// transmitKittyImage uploads a PNG with a virtual placement of 2x1 cells so it
+// can later be displayed with placeholder characters.
+func transmitKittyImage(out io.Writer, id int, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for first := true; encoded != "" || first; first = false {
+		chunk := encoded
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		encoded = encoded[len(chunk):]
+
+		more := 0
+		if encoded != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(out, "\x1b_Ga=T,U=1,f=100,t=d,i=%d,c=2,r=1,q=2,m=%d;%s\x1b\\", id, more, chunk)
+		} else {
+			fmt.Fprintf(out, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+}
+
+// kittyPlaceholderCells renders the two placeholder cells for image id.
+func kittyPlaceholderCells(id int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1b[38;5;%dm", id)
+	b.WriteRune(kittyPlaceholder)
+	b.WriteRune(kittyDiacritic0) // row 0
+	b.WriteRune(kittyDiacritic0) // column 0
+	b.WriteRune(kittyPlaceholder)
+	b.WriteRune(kittyDiacritic0) // row 0
+	b.WriteRune(kittyDiacritic1) // column 1
+	b.WriteString("\x1b[39m")
+	return b.String()
+}
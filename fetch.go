@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fetchTimeout     = 5 * time.Second
+	fetchTitleBytes  = 512 * 1024 // Only the head of a page is needed for its title
+	fetchConcurrency = 8
+	fetchCacheTTL    = 7 * 24 * time.Hour
+)
+
+var fetchTitles bool // Set by the -fetch-titles flag
+
+var httpClient = &http.Client{Timeout: fetchTimeout}
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+type cachedTitle struct {
+	Title     string    `json:"title"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// cacheDir returns the directory used for cached page data, creating it if needed.
+func cacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "safari-tab-manager")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// loadJSON decodes path into v. A missing file leaves v untouched.
+func loadJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func saveJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// needsTitle reports whether a tab shows no useful title, which usually
+// means the page never finished loading.
+func needsTitle(tab Tab) bool {
+	if !strings.HasPrefix(tab.URL, "http://") && !strings.HasPrefix(tab.URL, "https://") {
+		return false
+	}
+	title := strings.TrimSpace(tab.Title)
+	return title == "" || title == "Untitled" || title == tab.URL
+}
+
+// fetchPage downloads at most limit bytes of the page at url.
+func fetchPage(url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "safari-tab-manager/"+Version)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+func extractTitle(page []byte) string {
+	match := titleRegexp.FindSubmatch(page)
+	if match == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+}
+
+// fetchMissingTitles fills in titles for blank or "Untitled" tabs by fetching
+// the page over HTTP. Results are cached on disk so repeated runs stay fast.
+func fetchMissingTitles(tabs []Tab) []Tab {
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+		return tabs
+	}
+
+	cachePath := filepath.Join(dir, "titles.json")
+	cache := make(map[string]cachedTitle)
+	if err := loadJSON(cachePath, &cache); err != nil {
+		log.Printf("Warning: could not read title cache: %v", err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+
+	for i := range tabs {
+		if !needsTitle(tabs[i]) {
+			continue
+		}
+
+		if cached, ok := cache[tabs[i].URL]; ok && time.Since(cached.FetchedAt) < fetchCacheTTL {
+			if cached.Title != "" {
+				tabs[i].Title = cached.Title
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			page, err := fetchPage(tabs[i].URL, fetchTitleBytes)
+			if err != nil {
+				log.Printf("Warning: could not fetch title for %s: %v", tabs[i].URL, err)
+				return
+			}
+
+			title := extractTitle(page)
+			mu.Lock()
+			cache[tabs[i].URL] = cachedTitle{Title: title, FetchedAt: time.Now()}
+			mu.Unlock()
+
+			if title != "" {
+				tabs[i].Title = title
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := saveJSON(cachePath, cache); err != nil {
+		log.Printf("Warning: could not write title cache: %v", err)
+	}
+
+	return tabs
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quickFilterCount is how many top domains the header offers as quick
+// filters on the number keys.
+const quickFilterCount = 5
+
+// A tabFilter limits which tabs the list shows. The zero value shows all
+// tabs; set fields must all match.
+type tabFilter struct {
+	category   string
+	language   string
+	domain     string // Matches subdomains too
+	window     int    // Safari's window number, 0 for every window
+	display    string // Part of the display's name, or "gone" for displays no longer connected
+	old        bool
+	duplicates bool
+	elsewhere  bool // Read on another device
+	saved      bool // In the Reading List or bookmarks
+	heavy      bool // Using a lot of memory or CPU
+	minAgeDays int  // Days since the last visit; tabs never visited count as old enough
+}
+
+func (f tabFilter) active() bool {
+	return f != tabFilter{}
+}
+
+func (f tabFilter) matches(tab Tab) bool {
+	if f.category != "" && tab.Category != f.category {
+		return false
+	}
+	if f.language != "" && tab.Language != f.language {
+		return false
+	}
+	if f.domain != "" && !onDomain(tab.URL, []string{f.domain}) {
+		return false
+	}
+	if f.window > 0 && tab.WindowIndex != f.window {
+		return false
+	}
+	if f.display == "gone" && !tab.DisplayGone {
+		return false
+	}
+	if f.display != "" && f.display != "gone" && !strings.Contains(strings.ToLower(tab.Display), f.display) {
+		return false
+	}
+	if f.old && !tab.IsOld {
+		return false
+	}
+	if f.duplicates && tab.DuplicateOf == 0 {
+		return false
+	}
+	if f.saved && tab.SavedIn == "" {
+		return false
+	}
+	if f.elsewhere && !tab.ReadElsewhere {
+		return false
+	}
+	if f.heavy && !tab.heavy() {
+		return false
+	}
+	if f.minAgeDays > 0 && !tab.lastActive().IsZero() && time.Since(tab.lastActive()) < time.Duration(f.minAgeDays)*24*time.Hour {
+		return false
+	}
+	return true
+}
+
+func (f tabFilter) String() string {
+	var parts []string
+	if f.old {
+		parts = append(parts, "old")
+	}
+	if f.duplicates {
+		parts = append(parts, "duplicates")
+	}
+	if f.elsewhere {
+		parts = append(parts, "read-elsewhere")
+	}
+	if f.saved {
+		parts = append(parts, "saved-elsewhere")
+	}
+	if f.heavy {
+		parts = append(parts, "heavy")
+	}
+	if f.minAgeDays > 0 {
+		parts = append(parts, "age:"+strconv.Itoa(f.minAgeDays))
+	}
+	if f.domain != "" {
+		parts = append(parts, "domain:"+f.domain)
+	}
+	if f.window > 0 {
+		parts = append(parts, "window:"+strconv.Itoa(f.window))
+	}
+	if f.display != "" {
+		parts = append(parts, "display:"+f.display)
+	}
+	if f.category != "" {
+		parts = append(parts, "category:"+f.category)
+	}
+	if f.language != "" {
+		parts = append(parts, "lang:"+f.language)
+	}
+	return strings.Join(parts, " ")
+}
+
+// add narrows the filter by one --only term: "old", "duplicates",
+// "read-elsewhere", "heavy", "age:N", "domain:example.com", "window:N",
+// "display:NAME", "category:news" or "lang:de".
+func (f *tabFilter) add(term string) error {
+	name, value, _ := strings.Cut(term, ":")
+	switch {
+	case term == "old":
+		f.old = true
+	case term == "duplicates":
+		f.duplicates = true
+	case term == "read-elsewhere":
+		f.elsewhere = true
+	case term == "saved-elsewhere":
+		f.saved = true
+	case term == "heavy":
+		f.heavy = true
+	case name == "age" && value != "":
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 {
+			return errors.New(tr("age:N needs a number of days, got %q", value))
+		}
+		f.minAgeDays = days
+	case name == "domain" && value != "":
+		f.domain = strings.ToLower(strings.TrimPrefix(value, "www."))
+	case name == "window" && value != "":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return errors.New(tr("window:N needs a window number, got %q", value))
+		}
+		f.window = n
+	case name == "display" && value != "":
+		f.display = strings.ToLower(value)
+	case name == "category" && value != "":
+		f.category = value
+	case name == "lang" && value != "":
+		f.language = strings.ToLower(value)
+	default:
+		return errors.New(tr("unknown filter %q, want old, duplicates, read-elsewhere, saved-elsewhere, heavy, age:N, domain:NAME, window:N, display:NAME, category:NAME or lang:CODE", term))
+	}
+	return nil
+}
+
+// domainCounts counts the unpinned tabs on each domain.
+func domainCounts(tabs []Tab) map[string]int {
+	counts := make(map[string]int)
+	for _, tab := range tabs {
+		if !tab.Pinned {
+			counts[siteOf(tab.URL)]++
+		}
+	}
+	return counts
+}
+
+// quickFilter filters by the nth top domain shown in the header, or stops
+// filtering by it if it is already the filter.
+func (m *model) quickFilter(n int) tea.Cmd {
+	counts := domainCounts(m.tabs)
+	top := topDomains(counts, quickFilterCount)
+	if n >= len(top) {
+		return nil
+	}
+	domain := top[n]
+	if m.filter.domain == domain {
+		m.filter.domain = ""
+		m.refreshItems()
+		return m.showToast(tr("Stopped filtering by %s.", domain))
+	}
+	m.filter.domain = domain
+	m.refreshItems()
+	return m.showToast(tr("Showing tabs on %s; press 's' to select them.", domain))
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Focus mode closes the tabs on distracting sites for a while. They are
+// saved as a session first, so ending focus mode can open them again.
+
+// focusConfig lists the sites focus mode closes.
+type focusConfig struct {
+	Distractions []string `json:"distractions"` // Domains, or domains with a path prefix, as in the blocklist
+}
+
+// focusState is the running focus mode, kept in focus.json in the config
+// directory.
+type focusState struct {
+	Session    string    `json:"session"` // Session the closed tabs were saved as
+	StartedAt  time.Time `json:"started_at"`
+	Restore    bool      `json:"restore"`    // Open the tabs again when focus mode ends
+	Background bool      `json:"background"` // Open them without bringing Safari to the front
+}
+
+func focusPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "focus.json"), nil
+}
+
+// loadFocus reads the running focus mode. A zero StartedAt means there is
+// none.
+func loadFocus() (focusState, error) {
+	var state focusState
+	path, err := focusPath()
+	if err != nil {
+		return state, err
+	}
+	return state, loadJSON(path, &state)
+}
+
+// distracting reports whether a URL is on one of the distraction sites.
+func distracting(rawURL string, distractions []string) bool {
+	for _, site := range distractions {
+		if blockedBy(rawURL, site) {
+			return true
+		}
+	}
+	return false
+}
+
+// runFocus is the focus subcommand: "focus start" closes the distracting
+// tabs, "focus end" opens them again and "focus" alone says whether focus
+// mode is on.
+func runFocus(args []string) {
+	flags := flag.NewFlagSet("focus", flag.ExitOnError)
+	duration := flags.Duration("for", 0, tr("End focus mode after this long, e.g. 50m; keeps running until then"))
+	noRestore := flags.Bool("no-restore", false, tr("Don't open the closed tabs again when focus mode ends"))
+	background := flags.Bool("background", false, tr("Open the closed tabs again without bringing Safari to the front, in a window behind the others"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+
+	command := "status"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flags.Parse(args)
+
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	switch command {
+	case "start":
+		err = startFocus(cfg.Focus.Distractions, !*noRestore, *background)
+		if err == nil && *duration > 0 {
+			waitForFocus(*duration)
+			err = endFocus(*background)
+		}
+	case "end":
+		err = endFocus(*background)
+	case "status":
+		err = printFocus()
+	default:
+		err = fmt.Errorf("unknown focus command %q, want start, end or nothing", command)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+}
+
+// startFocus saves the tabs on distraction sites as a session and closes
+// them.
+func startFocus(distractions []string, restore, background bool) error {
+	if len(distractions) == 0 {
+		return fmt.Errorf("no distractions in config.json; add them as focus.distractions")
+	}
+	if state, err := loadFocus(); err != nil {
+		return err
+	} else if !state.StartedAt.IsZero() {
+		return fmt.Errorf("focus mode is already on since %s; end it with focus end", state.StartedAt.Local().Format("15:04"))
+	}
+
+	tabs, err := getSafariTabsRaw()
+	if err != nil {
+		return err
+	}
+	tabs, _ = markPinnedTabs(tabs)
+	var closing []Tab
+	for _, tab := range applyRules(withoutPinned(tabs)) {
+		if distracting(tab.URL, distractions) && !tab.locked() {
+			closing = append(closing, tab)
+		}
+	}
+
+	now := time.Now()
+	state := focusState{StartedAt: now, Restore: restore, Background: background}
+	if len(closing) > 0 {
+		saved, err := saveSession("focus "+now.Format("2006-01-02 15.04.05"), closing)
+		if err != nil {
+			return err
+		}
+		state.Session = saved.Name
+
+		switch msg := closeTabsAsync(closing, nil)().(type) {
+		case closeAbortedMsg:
+			return msg.err
+		case closingCompleteMsg:
+			fmt.Println(tr("Closed %d distracting tabs, saved as session %q.", msg.count, saved.Name))
+		}
+	} else {
+		fmt.Println(tr("No distracting tabs open."))
+	}
+
+	path, err := focusPath()
+	if err != nil {
+		return err
+	}
+	if err := saveJSON(path, state); err != nil {
+		return err
+	}
+	fmt.Println(tr("Focus mode is on."))
+	return nil
+}
+
+// waitForFocus waits out focus mode, ending it early on Ctrl+C.
+func waitForFocus(duration time.Duration) {
+	fmt.Println(tr("Focusing until %s; press Ctrl+C to end early.", time.Now().Add(duration).Format("15:04")))
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case <-time.After(duration):
+	case <-signals:
+	}
+}
+
+// endFocus ends focus mode, opening the tabs it closed again unless asked
+// not to, in the background if asked now or when it started.
+func endFocus(background bool) error {
+	state, err := loadFocus()
+	if err != nil {
+		return err
+	}
+	if state.StartedAt.IsZero() {
+		return fmt.Errorf("focus mode is not on")
+	}
+
+	if state.Restore && state.Session != "" {
+		s, err := loadSession(state.Session)
+		if err != nil {
+			return err
+		}
+		urls := make([]string, len(s.Tabs))
+		for i, tab := range s.Tabs {
+			urls[i] = tab.URL
+		}
+		opened, err := openURLs(urls, background || state.Background)
+		if err != nil {
+			return err
+		}
+		fmt.Println(tr("Opened %d tabs closed for focus mode.", opened))
+	}
+
+	path, err := focusPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	fmt.Println(tr("Focus mode is off after %s.", time.Since(state.StartedAt).Round(time.Minute)))
+	return nil
+}
+
+// printFocus says whether focus mode is on.
+func printFocus() error {
+	state, err := loadFocus()
+	if err != nil {
+		return err
+	}
+	if state.StartedAt.IsZero() {
+		fmt.Println(tr("Focus mode is off."))
+		return nil
+	}
+	fmt.Println(tr("Focus mode is on since %s.", state.StartedAt.Local().Format("15:04")))
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tabs of GitHub and GitLab pull requests, merge requests and issues, and
+// of issue tracker tickets (see tracker.go), stay open long after the work
+// is done. With -check-resolved their state is looked up through the APIs,
+// and tabs of merged, closed or done ones are marked resolved, safe to
+// close.
+
+var checkResolved bool // Set by the -check-resolved flag
+
+// forgeConfig holds the API tokens. Without them only public repositories
+// can be checked, within much lower rate limits.
+type forgeConfig struct {
+	GitHubToken string   `json:"github_token"`
+	GitLabToken string   `json:"gitlab_token"`
+	GitLabHosts []string `json:"gitlab_hosts"` // Self-hosted GitLab instances, besides gitlab.com
+}
+
+var forges forgeConfig // Set from config.json
+
+// openStateTTL is how long an open state is cached. Merged and closed are
+// final, so they are kept as long as other fetched data.
+const openStateTTL = time.Hour
+
+var (
+	githubItemRegexp = regexp.MustCompile(`^/([^/]+)/([^/]+)/(pull|issues)/([0-9]+)`)
+	gitlabItemRegexp = regexp.MustCompile(`^/(.+?)/-/(merge_requests|issues)/([0-9]+)`)
+)
+
+type cachedState struct {
+	State     string    `json:"state"` // "merged", "closed", "done" or "open"
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// A stateLookup asks a service for the state of the pull request, issue or
+// ticket a tab shows.
+type stateLookup struct {
+	key   string // Cache key, the same for every URL of the item
+	req   *http.Request
+	parse func(body io.Reader) (string, error) // Returns "merged", "closed", "done" or "open"
+}
+
+// lookupState returns the lookup for a tab's URL, or nil if it shows
+// nothing that can be resolved.
+func lookupState(rawURL string) *stateLookup {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	if lookup := forgeLookup(u); lookup != nil {
+		return lookup
+	}
+	return trackerLookup(u)
+}
+
+// forgeLookup returns the lookup for a GitHub or GitLab pull request,
+// merge request or issue URL.
+func forgeLookup(u *url.URL) *stateLookup {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	var apiURL, token, header string
+	if host == "github.com" {
+		m := githubItemRegexp.FindStringSubmatch(u.Path)
+		if m == nil {
+			return nil
+		}
+		// The issues endpoint covers pull requests too
+		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%s", m[1], m[2], m[4])
+		token, header = forges.GitHubToken, "Authorization"
+		if token != "" {
+			token = "Bearer " + token
+		}
+	} else if host == "gitlab.com" || slices.ContainsFunc(forges.GitLabHosts, func(h string) bool { return strings.EqualFold(h, host) }) {
+		m := gitlabItemRegexp.FindStringSubmatch(u.Path)
+		if m == nil {
+			return nil
+		}
+		apiURL = fmt.Sprintf("https://%s/api/v4/projects/%s/%s/%s", u.Host, url.PathEscape(m[1]), m[2], m[3])
+		token, header = forges.GitLabToken, "PRIVATE-TOKEN"
+	} else {
+		return nil
+	}
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", "safari-tab-manager/"+Version)
+	if token != "" {
+		req.Header.Set(header, token)
+	}
+	return &stateLookup{key: apiURL, req: req, parse: parseForgeState}
+}
+
+// parseForgeState reads the state of a GitHub or GitLab item.
+func parseForgeState(body io.Reader) (string, error) {
+	var item struct {
+		State       string `json:"state"` // GitHub: open, closed; GitLab: opened, closed, merged, locked
+		PullRequest *struct {
+			MergedAt *time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+	}
+	if err := json.NewDecoder(body).Decode(&item); err != nil {
+		return "", err
+	}
+	switch {
+	case item.State == "merged" || (item.PullRequest != nil && item.PullRequest.MergedAt != nil):
+		return "merged", nil
+	case item.State == "closed" || item.State == "locked":
+		return "closed", nil
+	}
+	return "open", nil
+}
+
+// fetchState runs a lookup.
+func fetchState(lookup *stateLookup) (string, error) {
+	resp, err := httpClient.Do(lookup.req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	return lookup.parse(resp.Body)
+}
+
+// addResolvedStates marks tabs of merged or closed pull requests, merge
+// requests and issues, and of done tickets, as resolved. States are cached
+// on disk.
+func addResolvedStates(tabs []Tab) []Tab {
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+		return tabs
+	}
+
+	cachePath := filepath.Join(dir, "forge-states.json")
+	cache := make(map[string]cachedState)
+	if err := loadJSON(cachePath, &cache); err != nil {
+		log.Printf("Warning: could not read forge state cache: %v", err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+
+	for i := range tabs {
+		lookup := lookupState(tabs[i].URL)
+		if lookup == nil {
+			continue
+		}
+
+		if cached, ok := cache[lookup.key]; ok {
+			ttl := fetchCacheTTL
+			if cached.State == "open" {
+				ttl = openStateTTL
+			}
+			if time.Since(cached.FetchedAt) < ttl {
+				tabs[i].Resolved = resolvedState(cached.State)
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			state, err := fetchState(lookup)
+			if err != nil {
+				log.Printf("Warning: could not check %s: %v", tabs[i].URL, err)
+				return
+			}
+			mu.Lock()
+			cache[lookup.key] = cachedState{State: state, FetchedAt: time.Now()}
+			mu.Unlock()
+
+			tabs[i].Resolved = resolvedState(state)
+		}(i)
+	}
+	wg.Wait()
+
+	if err := saveJSON(cachePath, cache); err != nil {
+		log.Printf("Warning: could not write forge state cache: %v", err)
+	}
+
+	return tabs
+}
+
+// resolvedReason says how a tab's pull request or issue was resolved, for
+// display.
+func resolvedReason(tab *Tab) string {
+	switch tab.Resolved {
+	case "merged":
+		return tr("merged, safe to close")
+	case "done":
+		return tr("done, safe to close")
+	}
+	return tr("closed, safe to close")
+}
+
+// resolvedState is the Resolved value for an item state: empty while it's
+// open.
+func resolvedState(state string) string {
+	if state == "open" {
+		return ""
+	}
+	return state
+}
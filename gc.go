@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// What the tool keeps grows with use: autosaves, the action log and the
+// run history. The log and history are capped at 10,000 entries, which
+// can still be years of them; gc drops what "retention" in the config no
+// longer wants kept, thins the autosaves the way serve does, and compacts
+// the databases, which SQLite doesn't shrink on its own.
+
+// retentionConfig is how long the action log and run history are kept,
+// as ages like "1y" or "6m" that parseAge reads.
+type retentionConfig struct {
+	Actions string `json:"actions"` // Closes and archives logged before this are dropped; empty keeps the last 10,000
+	Runs    string `json:"runs"`    // Runs logged before this are dropped; empty keeps the last 10,000
+}
+
+var retention retentionConfig // Set from config.json
+
+// validate checks the ages.
+func (c retentionConfig) validate() error {
+	for _, age := range []string{c.Actions, c.Runs} {
+		if age == "" {
+			continue
+		}
+		if _, err := parseAge(age); err != nil {
+			return fmt.Errorf("retention: %w", err)
+		}
+	}
+	return nil
+}
+
+// cutoff returns the time before which entries kept for age are dropped,
+// or the zero time to keep them all.
+func cutoff(age string, now time.Time) time.Time {
+	d, err := parseAge(age)
+	if age == "" || err != nil {
+		return time.Time{}
+	}
+	return now.Add(-d)
+}
+
+// gcResult counts what gc pruned, or would prune.
+type gcResult struct {
+	autosaves int
+	actions   int
+	runs      int
+}
+
+// runGC is the gc subcommand: it prunes the state past its retention and
+// compacts what's left, reporting the room it gave back.
+func runGC(args []string) {
+	flags := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, tr("Only count what would be pruned"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args)
+
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	paths := stateStore.Paths()
+	if path, err := autosavesPath(); err == nil {
+		paths = append(paths, path)
+	}
+	sizeBefore := totalSize(paths)
+
+	result, err := collectGarbage(time.Now(), *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Println(tr("Would prune %d autosaves, %d logged actions and %d runs.", result.autosaves, result.actions, result.runs))
+		return
+	}
+	fmt.Println(tr("Pruned %d autosaves, %d logged actions and %d runs.", result.autosaves, result.actions, result.runs))
+	reclaimed := max(0, int(sizeBefore-totalSize(paths)))
+	fmt.Println(tr("Reclaimed %.1f MB.", float64(reclaimed)/(1<<20)))
+}
+
+// collectGarbage prunes the autosaves, action log and run history as of
+// now, and compacts the store and autosave database. With dryRun it only
+// counts.
+func collectGarbage(now time.Time, dryRun bool) (gcResult, error) {
+	var result gcResult
+	actionsBefore, runsBefore := cutoff(retention.Actions, now), cutoff(retention.Runs, now)
+
+	if dryRun {
+		actions, err := stateStore.LoadActions()
+		if err != nil {
+			return result, err
+		}
+		for _, action := range actions {
+			if action.Time.Before(actionsBefore) {
+				result.actions++
+			}
+		}
+		runs, err := stateStore.LoadRuns()
+		if err != nil {
+			return result, err
+		}
+		for _, run := range runs {
+			if run.Time.Before(runsBefore) {
+				result.runs++
+			}
+		}
+	} else {
+		var err error
+		if !actionsBefore.IsZero() {
+			if result.actions, err = stateStore.PruneActions(actionsBefore); err != nil {
+				return result, fmt.Errorf("could not prune the action log: %w", err)
+			}
+		}
+		if !runsBefore.IsZero() {
+			if result.runs, err = stateStore.PruneRuns(runsBefore); err != nil {
+				return result, fmt.Errorf("could not prune the run history: %w", err)
+			}
+		}
+		if err := stateStore.Compact(); err != nil {
+			return result, fmt.Errorf("could not compact %s: %w", stateStore.Location(), err)
+		}
+	}
+
+	// Without autosaves there's no database to open, and opening would
+	// create one
+	path, err := autosavesPath()
+	if err != nil {
+		return result, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return result, nil
+	}
+	db, err := openAutosaves()
+	if err != nil {
+		return result, err
+	}
+	defer db.Close()
+	if dryRun {
+		entries, err := loadAutosaves(db)
+		if err != nil {
+			return result, err
+		}
+		times := make([]time.Time, len(entries))
+		for i, entry := range entries {
+			times[i] = entry.savedAt
+		}
+		for _, kept := range keptAutosaves(times, now, autosave) {
+			if !kept {
+				result.autosaves++
+			}
+		}
+		return result, nil
+	}
+	if result.autosaves, err = pruneAutosaves(db, now); err != nil {
+		return result, fmt.Errorf("could not prune autosaves: %w", err)
+	}
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		return result, fmt.Errorf("could not compact %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// totalSize adds up the sizes of the files there are of paths.
+func totalSize(paths []string) int64 {
+	var size int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
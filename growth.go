@@ -0,0 +1,129 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Growth alerts nudge a cleanup while it's still small: when the run
+// history shows the open tabs grew by more than a threshold over the last
+// days, the list shows a banner and serve mode a notification. Tabs opened
+// are worked out from the growth and the tabs closed and archived here, so
+// tabs closed in Safari itself count as never opened.
+
+// growthConfig is when to warn about tabs piling up.
+type growthConfig struct {
+	Tabs int `json:"tabs"` // Warn when the open tabs grew by more than this; 0 turns alerts off
+	Days int `json:"days"` // Over this many days, 7 by default
+}
+
+var growthAlert growthConfig // Set from config.json
+
+const defaultGrowthDays = 7
+
+// serveRunInterval is how often serve mode adds a run to the run history.
+const serveRunInterval = time.Hour
+
+// tabGrowth is how the open tabs changed over some days.
+type tabGrowth struct {
+	days   int
+	opened int // At least; tabs closed outside this tool aren't known
+	closed int // Closed or archived here
+	growth int
+}
+
+// recentGrowth compares the latest run with the earliest within days
+// before it. It reports false without a day of history to compare.
+func recentGrowth(runs []loggedRun, days int) (tabGrowth, bool) {
+	if len(runs) < 2 {
+		return tabGrowth{}, false
+	}
+	latest := runs[len(runs)-1]
+	cutoff := latest.Time.AddDate(0, 0, -days)
+	then := latest
+	for _, run := range runs {
+		if !run.Time.Before(cutoff) {
+			then = run
+			break
+		}
+	}
+	span := latest.Time.Sub(then.Time)
+	if span < 24*time.Hour {
+		return tabGrowth{}, false
+	}
+
+	closed := int(latest.Closed + latest.Archived - then.Closed - then.Archived)
+	growth := latest.Tabs - then.Tabs
+	return tabGrowth{
+		days:   int(span.Hours()/24 + 0.5),
+		opened: max(0, growth+closed),
+		closed: closed,
+		growth: growth,
+	}, true
+}
+
+// growthWarning returns the growth alert for the run history, or "" if
+// alerts are off or the tabs didn't grow past the threshold.
+func growthWarning(runs []loggedRun) string {
+	if growthAlert.Tabs <= 0 {
+		return ""
+	}
+	growth, ok := recentGrowth(runs, growthAlert.Days)
+	if !ok || growth.growth <= growthAlert.Tabs {
+		return ""
+	}
+	return tr("You've added %d tabs in the last %d days and closed %d. Time for a cleanup?", growth.opened, growth.days, growth.closed)
+}
+
+// loadGrowthWarning reads the run history for growthWarning. Without a
+// history there's nothing to warn about, so errors are only logged.
+func loadGrowthWarning() string {
+	if growthAlert.Tabs <= 0 {
+		return ""
+	}
+	runs, err := loadRunLog()
+	if err != nil {
+		log.Printf("Warning: could not read run history: %v", err)
+		return ""
+	}
+	return growthWarning(runs)
+}
+
+// growthWarningBefore is loadGrowthWarning with tabs as the latest run,
+// for the list, which only logs its run once the stages are done. The
+// alert only goes by the tab count, which the scan already has.
+func growthWarningBefore(tabs []Tab) string {
+	if growthAlert.Tabs <= 0 {
+		return ""
+	}
+	runs, err := loadRunLog()
+	if err != nil {
+		log.Printf("Warning: could not read run history: %v", err)
+		return ""
+	}
+	return growthWarning(append(runs, newRun(tabs)))
+}
+
+// checkGrowth adds the latest scan to the run history every
+// serveRunInterval, and shows the growth alert as a notification at most
+// once a day.
+func (s *scanner) checkGrowth() {
+	runs, err := loadRunLog()
+	if err != nil {
+		log.Printf("Warning: could not read run history: %v", err)
+		return
+	}
+	if len(runs) > 0 && time.Since(runs[len(runs)-1].Time) < serveRunInterval {
+		return
+	}
+	tabs, _, _ := s.snapshot()
+	logRun(tabs)
+
+	if time.Since(s.growthNotified) < 24*time.Hour {
+		return
+	}
+	if warning := loadGrowthWarning(); warning != "" {
+		notify(warning)
+		s.growthNotified = time.Now()
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"safari-tab-manager/tabspb"
+)
+
+// grpcServer serves the TabService from tabspb/tabs.proto using the same
+// scanner as the REST API.
+type grpcServer struct {
+	tabspb.UnimplementedTabServiceServer
+	scanner *scanner
+}
+
+// serveGRPC listens on addr until the listener fails.
+func serveGRPC(addr string, s *scanner) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	tabspb.RegisterTabServiceServer(server, &grpcServer{scanner: s})
+	return server.Serve(listener)
+}
+
+func protoTab(tab Tab) *tabspb.Tab {
+	pb := &tabspb.Tab{
+		Title:          tab.Title,
+		Url:            tab.URL,
+		Window:         int32(tab.WindowIndex),
+		Tab:            int32(tab.TabIndex),
+		Duplicate:      tab.DuplicateOf != 0,
+		Old:            tab.IsOld,
+		Category:       tab.Category,
+		ReadingMinutes: int32(tab.ReadingMinutes),
+		Protected:      tab.Protected,
+		Tags:           tab.Tags,
+		Suggested:      tab.Selected,
+	}
+	if !tab.LastVisit.IsZero() {
+		pb.LastVisit = timestamppb.New(tab.LastVisit)
+	}
+	return pb
+}
+
+func protoTabs(tabs []Tab) []*tabspb.Tab {
+	result := make([]*tabspb.Tab, len(tabs))
+	for i, tab := range tabs {
+		result[i] = protoTab(tab)
+	}
+	return result
+}
+
+// protoRecord converts a tab as sent to event clients.
+func protoRecord(record tabRecord) *tabspb.Tab {
+	pb := &tabspb.Tab{
+		Title:          record.Title,
+		Url:            record.URL,
+		Window:         int32(record.Window),
+		Tab:            int32(record.Tab),
+		Duplicate:      record.Duplicate,
+		Old:            record.Old,
+		Category:       record.Category,
+		ReadingMinutes: int32(record.ReadingMinutes),
+		Protected:      record.Protected,
+		Tags:           record.Tags,
+	}
+	if record.LastVisit != nil {
+		pb.LastVisit = timestamppb.New(*record.LastVisit)
+	}
+	return pb
+}
+
+var protoEventTypes = map[string]tabspb.TabEvent_Type{
+	"added":   tabspb.TabEvent_TYPE_ADDED,
+	"removed": tabspb.TabEvent_TYPE_REMOVED,
+	"changed": tabspb.TabEvent_TYPE_CHANGED,
+}
+
+func (g *grpcServer) ListTabs(ctx context.Context, req *tabspb.ListTabsRequest) (*tabspb.ListTabsResponse, error) {
+	tabs, scannedAt, _ := g.scanner.snapshot()
+	response := &tabspb.ListTabsResponse{Tabs: protoTabs(tabs), AgeDays: int32(g.scanner.ageDays)}
+	if !scannedAt.IsZero() {
+		response.ScannedAt = timestamppb.New(scannedAt)
+	}
+	return response, nil
+}
+
+func (g *grpcServer) StreamTabs(req *tabspb.StreamTabsRequest, stream grpc.ServerStreamingServer[tabspb.TabEvent]) error {
+	tabs, ch := g.scanner.subscribe()
+	defer g.scanner.events.unsubscribe(ch)
+
+	snapshot := &tabspb.TabEvent{
+		Type:  tabspb.TabEvent_TYPE_SNAPSHOT,
+		Tabs:  protoTabs(tabs),
+		Count: int32(len(tabs)),
+		Time:  timestamppb.Now(),
+	}
+	if err := stream.Send(snapshot); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case events, ok := <-ch:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "client fell behind")
+			}
+			for _, event := range events {
+				err := stream.Send(&tabspb.TabEvent{
+					Type:  protoEventTypes[event.Type],
+					Tab:   protoRecord(*event.Tab),
+					Count: int32(event.Count),
+					Time:  timestamppb.New(event.Time),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (g *grpcServer) CloseTabs(ctx context.Context, req *tabspb.CloseTabsRequest) (*tabspb.CloseTabsResponse, error) {
+	closed, err := g.scanner.closeURLs(req.Urls, req.Archive)
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	return &tabspb.CloseTabsResponse{Closed: int32(closed)}, nil
+}
+
+func (g *grpcServer) SaveSession(ctx context.Context, req *tabspb.SaveSessionRequest) (*tabspb.SaveSessionResponse, error) {
+	saved, err := g.scanner.saveSession(req.Name)
+	if errors.Is(err, errNoScan) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &tabspb.SaveSessionResponse{Name: saved.Name, Tabs: int32(len(saved.Tabs))}, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// The stats screen opens with a heatmap of tab ages by window: a row per
+// window and a column per age bucket, each cell the number of tabs, shaded
+// by how many, so the window where tabs go to die stands out.
+
+// ageBuckets are the heatmap's columns, by how long ago a tab was last
+// used, up to and excluding upTo. The last bucket is everything older; tabs
+// never used go to a column of their own.
+var ageBuckets = []struct {
+	label string
+	upTo  time.Duration
+}{
+	{"day", 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"3 months", 91 * 24 * time.Hour},
+	{"older", 0},
+}
+
+// heatShades color cells from few tabs to the most in any cell.
+var heatShades = []lipgloss.Color{"22", "58", "94", "130", "160"}
+
+// windowAges is a heatmap row: a window's tabs by age bucket, with the
+// unknown ones last.
+type windowAges struct {
+	window int
+	counts []int
+}
+
+// windowHeatmap counts the unpinned tabs of every window by age bucket,
+// leaving out saved items, which have no window.
+func windowHeatmap(tabs []Tab, now time.Time) []windowAges {
+	var rows []windowAges
+	index := make(map[int]int)
+	for _, tab := range tabs {
+		if tab.Pinned || tab.Source != "" {
+			continue
+		}
+		n, ok := index[tab.WindowIndex]
+		if !ok {
+			n = len(rows)
+			index[tab.WindowIndex] = n
+			rows = append(rows, windowAges{window: tab.WindowIndex, counts: make([]int, len(ageBuckets)+1)})
+		}
+		rows[n].counts[ageBucket(staleness(tab), now)]++
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].window < rows[j].window })
+	return rows
+}
+
+// ageBucket returns the column for a tab last used at last.
+func ageBucket(last time.Time, now time.Time) int {
+	if last.IsZero() {
+		return len(ageBuckets)
+	}
+	age := now.Sub(last)
+	for i, bucket := range ageBuckets {
+		if bucket.upTo == 0 || age < bucket.upTo {
+			return i
+		}
+	}
+	return len(ageBuckets) - 1
+}
+
+// heatCell renders a count, shaded against the most in any cell.
+func heatCell(n, most, width int) string {
+	if n == 0 {
+		return helpStyle.Render(fmt.Sprintf("%*s", width, "·"))
+	}
+	shade := heatShades[min(len(heatShades)-1, (n-1)*len(heatShades)/max(1, most))]
+	return lipgloss.NewStyle().Background(shade).Foreground(lipgloss.Color("15")).Render(fmt.Sprintf("%*d", width, n))
+}
+
+// heatmap renders the heading of the heatmap and returns it with a
+// function rendering row i, bold if it is focused.
+func heatmap(rows []windowAges) (string, func(i int, focused bool) string) {
+	labels := make([]string, 0, len(ageBuckets)+1)
+	for _, bucket := range ageBuckets {
+		labels = append(labels, tr(bucket.label))
+	}
+	labels = append(labels, tr("never"))
+	cellWidth := 4
+	for _, label := range labels {
+		cellWidth = max(cellWidth, runewidth.StringWidth(label))
+	}
+
+	names := make([]string, len(rows))
+	nameWidth := 0
+	most := 0
+	for i, row := range rows {
+		names[i] = tr("Window %d", row.window)
+		nameWidth = max(nameWidth, runewidth.StringWidth(names[i]))
+		for _, n := range row.counts {
+			most = max(most, n)
+		}
+	}
+
+	heading := strings.Repeat(" ", nameWidth)
+	for _, label := range labels {
+		heading += " " + runewidth.FillLeft(label, cellWidth)
+	}
+
+	return heading, func(i int, focused bool) string {
+		style := normalStyle
+		if focused {
+			style = style.Bold(true)
+		}
+		line := style.Render(runewidth.FillRight(names[i], nameWidth))
+		for _, n := range rows[i].counts {
+			line += " " + heatCell(n, most, cellWidth)
+		}
+		return line
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runHistory is the history subcommand. prune deletes a site's visits from
+// Safari's History.db, the history side of a tab cleanup, and variants
+// lists the tabs whose page is in history under several URLs.
+func runHistory(args []string) {
+	if len(args) > 0 && args[0] == "variants" {
+		runHistoryVariants(args[1:])
+		return
+	}
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Fprintln(os.Stderr, tr("Usage: safari-tab-manager history prune -domain DOMAIN [-older-than AGE] [-dry-run]"))
+		fmt.Fprintln(os.Stderr, tr("       safari-tab-manager history variants [-policy POLICY]"))
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("history prune", flag.ExitOnError)
+	domain := flags.String("domain", "", tr("Delete the history of this domain and its subdomains"))
+	olderThan := flags.String("older-than", "", tr("Only delete visits older than this, e.g. 30d, 6w, 3m or 1y; all visits by default"))
+	dryRun := flags.Bool("dry-run", false, tr("Only count what would be deleted"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args[1:])
+
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	if *domain == "" {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", errors.New("-domain is required")))
+		os.Exit(2)
+	}
+	before := time.Now()
+	if *olderThan != "" {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(2)
+		}
+		before = before.Add(-age)
+	}
+
+	visits, items, backup, err := pruneHistory(*domain, before, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Println(tr("Would delete %d visits and %d pages of %s.", visits, items, *domain))
+		return
+	}
+	fmt.Println(tr("Deleted %d visits and %d pages of %s. The old history is backed up in %s.", visits, items, *domain, backup))
+}
+
+// parseAge reads an age like 30d, 6w, 3m (months) or 1y.
+func parseAge(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour, 'm': 30 * 24 * time.Hour, 'y': 365 * 24 * time.Hour}
+	if s == "" {
+		return 0, fmt.Errorf("empty age")
+	}
+	unit, ok := units[s[len(s)-1]]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if !ok || err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid age %q, want a number followed by d, w, m or y", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// safariRunning reports whether Safari is running, without launching it.
+func safariRunning() (bool, error) {
+	script := fmt.Sprintf(`application %s is running`, appleScriptString(safariApp))
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return false, fmt.Errorf("could not tell whether %s is running: %w", safariApp, err)
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// pruneHistory deletes the visits to a domain from before a time, and the
+// pages left without visits. Safari must be closed, since it keeps the
+// database open, and the database is backed up first.
+func pruneHistory(domain string, before time.Time, dryRun bool) (visits, items int64, backup string, err error) {
+	if running, err := safariRunning(); err != nil {
+		return 0, 0, "", err
+	} else if running && !dryRun {
+		return 0, 0, "", fmt.Errorf("quit %s first, it keeps its history open", safariApp)
+	}
+
+	historyPath, err := safariHistoryPath()
+	if err != nil {
+		return 0, 0, "", err
+	}
+	db, err := sql.Open("sqlite", historyPath)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("could not open Safari history: %w", err)
+	}
+	defer db.Close()
+
+	// Visit times are seconds since 2001-01-01, Core Data's epoch
+	cfAbsoluteTimeOffset := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	cutoff := float64(before.Unix() - cfAbsoluteTimeOffset)
+
+	rows, err := db.Query(`SELECT id, url FROM history_items`)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("could not read Safari history: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var url string
+		if err := rows.Scan(&id, &url); err != nil {
+			rows.Close()
+			return 0, 0, "", err
+		}
+		if onDomain(url, []string{domain}) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, "", err
+	}
+
+	if dryRun {
+		for _, id := range ids {
+			var n, left int64
+			err := db.QueryRow(`SELECT COUNT(*) FROM history_visits WHERE history_item = ? AND visit_time < ?`, id, cutoff).Scan(&n)
+			if err == nil {
+				err = db.QueryRow(`SELECT COUNT(*) FROM history_visits WHERE history_item = ? AND visit_time >= ?`, id, cutoff).Scan(&left)
+			}
+			if err != nil {
+				return 0, 0, "", fmt.Errorf("could not count visits: %w", err)
+			}
+			visits += n
+			if left == 0 {
+				items++
+			}
+		}
+		return visits, items, "", nil
+	}
+	if len(ids) == 0 {
+		return 0, 0, "", nil
+	}
+
+	if backup, err = backupHistory(historyPath); err != nil {
+		return 0, 0, "", err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, backup, err
+	}
+	defer tx.Rollback()
+	pageTables, err := pageTables(tx)
+	if err != nil {
+		return 0, 0, backup, fmt.Errorf("could not read Safari history: %w", err)
+	}
+	for _, id := range ids {
+		if err := unlinkVisits(tx, id, cutoff); err != nil {
+			return 0, 0, backup, fmt.Errorf("could not delete visits: %w", err)
+		}
+		result, err := tx.Exec(`DELETE FROM history_visits WHERE history_item = ? AND visit_time < ?`, id, cutoff)
+		if err != nil {
+			return 0, 0, backup, fmt.Errorf("could not delete visits: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		visits += n
+
+		result, err = tx.Exec(`DELETE FROM history_items WHERE id = ? AND NOT EXISTS (SELECT 1 FROM history_visits WHERE history_item = ?)`, id, id)
+		if err != nil {
+			return 0, 0, backup, fmt.Errorf("could not delete pages: %w", err)
+		}
+		n, _ = result.RowsAffected()
+		items += n
+		if n > 0 {
+			for _, table := range pageTables {
+				if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %q WHERE %q = ?`, table.name, table.column), id); err != nil {
+					return 0, 0, backup, fmt.Errorf("could not delete pages: %w", err)
+				}
+			}
+		}
+	}
+	return visits, items, backup, tx.Commit()
+}
+
+// unlinkVisits prepares the visits to a page from before cutoff for
+// deleting. Safari's schema would cascade the delete along redirects, so
+// pruning a link shortener would take the visits it sent you to on other
+// sites with it; instead the redirects to and from the visits are cleared
+// and the foreign keys are left off. A tombstone tells iCloud the visits
+// are gone, as Safari does when it deletes history, or syncing would bring
+// them back.
+func unlinkVisits(tx *sql.Tx, id int64, cutoff float64) error {
+	const doomed = `SELECT id FROM history_visits WHERE history_item = ? AND visit_time < ?`
+	if _, err := tx.Exec(`UPDATE history_visits SET redirect_source = NULL WHERE redirect_source IN (`+doomed+`)`, id, cutoff); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE history_visits SET redirect_destination = NULL WHERE redirect_destination IN (`+doomed+`)`, id, cutoff); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT INTO history_tombstones (start_time, end_time, url, generation)
+		SELECT MIN(visit_time), MAX(visit_time), (SELECT url FROM history_items WHERE id = ?),
+			(SELECT IFNULL(MAX(generation), 0) FROM history_visits)
+		FROM history_visits WHERE history_item = ? AND visit_time < ?
+		HAVING COUNT(*) > 0`, id, id, cutoff)
+	return err
+}
+
+// pageTable is a column that refers to history_items, other than the
+// visits', whose rows go with the page.
+type pageTable struct {
+	name, column string
+}
+
+// pageTables finds the tables that refer to history_items, like the tags
+// of newer versions of Safari, whose rows the foreign keys would have
+// deleted with a page.
+func pageTables(tx *sql.Tx) ([]pageTable, error) {
+	rows, err := tx.Query(`
+		SELECT m.name, f."from" FROM sqlite_master m, pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND f."table" = 'history_items' AND m.name != 'history_visits'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []pageTable
+	for rows.Next() {
+		var table pageTable
+		if err := rows.Scan(&table.name, &table.column); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// backupHistory copies History.db, with its write-ahead log if it has one,
+// to a dated folder in the cache directory.
+func backupHistory(historyPath string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "history-backups", time.Now().Format("2006-01-02 15.04.05"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err := copyFile(historyPath+suffix, filepath.Join(dir, filepath.Base(historyPath)+suffix))
+		if err != nil && !(suffix != "" && os.IsNotExist(err)) {
+			return "", fmt.Errorf("could not back up Safari history: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Safari's history keeps a row per URL, so a tab's page can be in it under
+// several variants: http and https, with and without "www." or a trailing
+// slash, and with an international domain in Unicode or punycode. A tab is
+// matched with all of them, and history_match in the config decides which
+// one its visit times come from, rather than only ever the exact URL.
+// "history variants" lists the tabs with more than one.
+
+// History match policies.
+const (
+	historyMatchExactFirst = "exact-first" // The tab's own URL if history has it, else the variant visited last
+	historyMatchMostRecent = "most-recent" // The variant visited last
+)
+
+var historyMatch = historyMatchExactFirst // Set from config.json
+
+// historyKey reduces a URL to what its variants share: no scheme, no
+// "www.", no default port or trailing slash, a lowercase punycode host and
+// normalized percent-encoding. Other URLs are their own key.
+func historyKey(raw string) string {
+	u, ok := parseURL(raw)
+	if !ok || (u.Scheme != "http" && u.Scheme != "https") {
+		return raw
+	}
+	host := strings.TrimPrefix(asciiHost(u.Host), "www.")
+	key := host + strings.TrimSuffix(normalizeEscapes(u.EscapedPath()), "/")
+	if u.RawQuery != "" {
+		key += "?" + normalizeEscapes(u.RawQuery)
+	}
+	if u.Fragment != "" {
+		key += "#" + normalizeEscapes(u.EscapedFragment())
+	}
+	return key
+}
+
+// pickHistoryURL picks the variant a tab's visit times come from, by
+// policy, or "" if history has none.
+func pickHistoryURL(tabURL string, candidates []string, lastVisits map[string]time.Time, policy string) string {
+	if policy != historyMatchMostRecent {
+		if _, ok := lastVisits[tabURL]; ok {
+			return tabURL
+		}
+	}
+	var picked string
+	for _, candidate := range candidates {
+		if picked == "" || lastVisits[candidate].After(lastVisits[picked]) {
+			picked = candidate
+		}
+	}
+	return picked
+}
+
+// validHistoryMatch reports whether policy is a known history_match policy.
+func validHistoryMatch(policy string) bool {
+	return policy == historyMatchExactFirst || policy == historyMatchMostRecent
+}
+
+// historyVariants reads the last visit to every URL in history, and groups
+// the URLs by historyKey.
+func historyVariants() (map[string]time.Time, map[string][]string, error) {
+	historyPath, err := safariHistoryPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("sqlite", historyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open Safari history: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT hi.url, MAX(hv.visit_time)
+		FROM history_items hi
+		JOIN history_visits hv ON hi.id = hv.history_item
+		GROUP BY hi.url
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read Safari history: %w", err)
+	}
+	defer rows.Close()
+
+	// Visit times are seconds since 2001-01-01, Core Data's epoch
+	cfAbsoluteTimeOffset := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	lastVisits := make(map[string]time.Time)
+	variants := make(map[string][]string)
+	for rows.Next() {
+		var u string
+		var visitTime float64
+		if err := rows.Scan(&u, &visitTime); err != nil {
+			return nil, nil, err
+		}
+		lastVisits[u] = time.Unix(int64(visitTime)+cfAbsoluteTimeOffset, 0)
+		key := historyKey(u)
+		variants[key] = append(variants[key], u)
+	}
+	return lastVisits, variants, rows.Err()
+}
+
+// runHistoryVariants lists the open tabs whose page is in history under
+// more than one URL, with the last visit to each, marking the variant the
+// policy picks.
+func runHistoryVariants(args []string) {
+	flags := flag.NewFlagSet("history variants", flag.ExitOnError)
+	policy := flags.String("policy", "", tr("Show what this history_match policy picks instead of the configured one: exact-first or most-recent"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args)
+
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *policy != "" {
+		if !validHistoryMatch(*policy) {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", fmt.Errorf("unknown history_match policy %q, want exact-first or most-recent", *policy)))
+			os.Exit(2)
+		}
+		historyMatch = *policy
+	}
+
+	tabs, err := getSafariTabsRaw()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	lastVisits, variants, err := historyVariants()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	shown := 0
+	seen := make(map[string]bool)
+	for _, tab := range tabs {
+		candidates := variants[historyKey(tab.URL)]
+		if len(candidates) < 2 || seen[tab.URL] {
+			continue
+		}
+		seen[tab.URL] = true
+		shown++
+
+		sort.Slice(candidates, func(i, j int) bool {
+			return lastVisits[candidates[i]].After(lastVisits[candidates[j]])
+		})
+		picked := pickHistoryURL(tab.URL, candidates, lastVisits, historyMatch)
+		fmt.Printf("%s (%s)\n", tabName(tab), tab.container())
+		for _, candidate := range candidates {
+			marker := "   "
+			if candidate == picked {
+				marker = " " + sym.cursor
+			}
+			note := shortDateTime(lastVisits[candidate])
+			if candidate == tab.URL {
+				note += sym.separator + tr("the tab's URL")
+			}
+			fmt.Printf("%s%s  %s\n", marker, candidate, helpStyle.Render(note))
+		}
+		fmt.Println()
+	}
+	if shown == 0 {
+		fmt.Println(tr("No open tab's page is in history under more than one URL."))
+		return
+	}
+	fmt.Println(tr("%d tabs have several history entries; %s marks the one their visit times come from (history_match: %s).", shown, strings.TrimSpace(sym.cursor), historyMatch))
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const hookTimeout = 30 * time.Second
+
+// hooksConfig names the user scripts run around closing and archiving. Each
+// is a shell command that receives the affected tabs as JSON on stdin.
+type hooksConfig struct {
+	PreClose  string `json:"pre_close"`  // A non-zero exit cancels the close
+	PostClose string `json:"post_close"` // Receives the tabs that were closed
+	OnArchive string `json:"on_archive"` // Receives the tabs that were archived
+	OnOpen    string `json:"on_open"`    // Serve mode: receives the tabs opened since the last scan
+}
+
+var hooks hooksConfig // Set from config.json
+
+// tabRecord is the JSON form of a tab handed to scripts.
+type tabRecord struct {
+	Title      string     `json:"title"`
+	URL        string     `json:"url"`
+	Window     int        `json:"window"`
+	Tab        int        `json:"tab"`
+	Duplicate  bool       `json:"duplicate"`
+	Old        bool       `json:"old"`
+	Category   string     `json:"category,omitempty"`
+	Language   string     `json:"language,omitempty"`
+	Search     string     `json:"search_query,omitempty"`
+	DocSet     string     `json:"doc_set,omitempty"`
+	Product    bool       `json:"product,omitempty"`
+	Name       string     `json:"product_name,omitempty"`
+	Price      string     `json:"price,omitempty"`
+	LastVisit  *time.Time `json:"last_visit,omitempty"`
+	LastActive *time.Time `json:"last_active,omitempty"`
+	FirstSeen  *time.Time `json:"first_seen,omitempty"`
+
+	ReadingMinutes int      `json:"reading_minutes,omitempty"`
+	Protected      bool     `json:"protected,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	ReadElsewhere  bool     `json:"read_elsewhere,omitempty"`
+	Blocked        bool     `json:"blocked,omitempty"`
+	Expired        string   `json:"expired,omitempty"`
+	Resolved       string   `json:"resolved,omitempty"`
+
+	Video         bool `json:"video,omitempty"`
+	VideoSeconds  int  `json:"video_seconds,omitempty"`
+	VideoPosition int  `json:"video_position,omitempty"`
+}
+
+func newTabRecord(tab Tab) tabRecord {
+	record := tabRecord{
+		Title:     tab.Title,
+		URL:       tab.URL,
+		Window:    tab.WindowIndex,
+		Tab:       tab.TabIndex,
+		Duplicate: tab.DuplicateOf != 0,
+		Old:       tab.IsOld,
+		Category:  tab.Category,
+		Language:  tab.Language,
+		Search:    tab.SearchQuery,
+		DocSet:    tab.DocSet,
+		Product:   tab.Product,
+		Name:      tab.ProductName,
+		Price:     tab.Price,
+
+		ReadingMinutes: tab.ReadingMinutes,
+		Protected:      tab.Protected,
+		Tags:           tab.Tags,
+		ReadElsewhere:  tab.ReadElsewhere,
+		Blocked:        tab.Blocked,
+		Expired:        tab.Expired,
+		Resolved:       tab.Resolved,
+
+		Video:         tab.Video,
+		VideoSeconds:  tab.VideoSeconds,
+		VideoPosition: tab.VideoPosition,
+	}
+	if !tab.LastVisit.IsZero() {
+		lastVisit := tab.LastVisit
+		record.LastVisit = &lastVisit
+	}
+	if !tab.LastActivated.IsZero() {
+		lastActive := tab.LastActivated
+		record.LastActive = &lastActive
+	}
+	if !tab.FirstSeen.IsZero() {
+		firstSeen := tab.FirstSeen
+		record.FirstSeen = &firstSeen
+	}
+	return record
+}
+
+// tab turns a record back into a tab, with what archivers use, for tabs
+// kept on disk to archive later.
+func (r tabRecord) tab() Tab {
+	return Tab{
+		Title:         r.Title,
+		URL:           r.URL,
+		Category:      r.Category,
+		Language:      r.Language,
+		SearchQuery:   r.Search,
+		DocSet:        r.DocSet,
+		Product:       r.Product,
+		ProductName:   r.Name,
+		Price:         r.Price,
+		Tags:          r.Tags,
+		Video:         r.Video,
+		VideoSeconds:  r.VideoSeconds,
+		VideoPosition: r.VideoPosition,
+	}
+}
+
+// runHook runs a hook command with the tabs as a JSON array on stdin. The
+// hook's name is passed in SAFARI_TAB_MANAGER_HOOK so one script can serve
+// several hooks. An empty command is a no-op.
+func runHook(name, command string, tabs []Tab) error {
+	if command == "" {
+		return nil
+	}
+
+	records := make([]tabRecord, len(tabs))
+	for i, tab := range tabs {
+		records[i] = newTabRecord(tab)
+	}
+	input, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "SAFARI_TAB_MANAGER_HOOK="+name)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return fmt.Errorf("%s hook failed: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+// runHookAndLog runs a hook whose failure must not stop the operation.
+func runHookAndLog(name, command string, tabs []Tab) {
+	if err := runHook(name, command, tabs); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// User-facing strings are looked up in a message catalog keyed by their
+// English text, gettext style: English needs no catalog, and a string
+// missing from a translation falls back to English.
+
+// catalogs holds the translations for each supported language.
+var catalogs = map[string]map[string]string{
+	"de": catalogDE,
+}
+
+// language is the two-letter language code picked from the environment.
+var language = detectLanguage()
+
+// detectLanguage reads the language from LC_ALL, LC_MESSAGES or LANG, in
+// the order POSIX gives them precedence, e.g. "de_DE.UTF-8" -> "de".
+func detectLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		lang, _, _ := strings.Cut(value, "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		return strings.ToLower(lang)
+	}
+	return "en"
+}
+
+// tr translates a message and formats it with args like fmt.Sprintf.
+func tr(message string, args ...any) string {
+	if translated, ok := catalogs[language][message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
@@ -0,0 +1,666 @@
+package main
+
+// catalogDE is the German translation.
+var catalogDE = map[string]string{
+	// List
+	"Safari Tabs":           "Safari-Tabs",
+	"Duplicate of: %s (%s)": "Duplikat von: %s (%s)",
+	"Window %d, Tab %d":     "Fenster %d, Tab %d",
+	"Last visited %s":       "Zuletzt besucht %s",
+	"~%d min read":          "~%d Min. Lesezeit",
+	"URL:":                  "URL:",
+	"[DUP]":                 "[DUP]",
+	"[OLD]":                 "[ALT]",
+	"uncategorized %d":      "ohne Kategorie %d",
+	"Safari Tab Manager %s - %d unique, %d duplicates, %d old (>%d days), %d selected to close": "Safari Tab Manager %s - %d einzigartig, %d Duplikate, %d alt (>%d Tage), %d zum Schließen ausgewählt",
+
+	// Closing and archiving
+	"Cancelled. No tabs were closed.":            "Abgebrochen. Es wurden keine Tabs geschlossen.",
+	"Closing tabs... %d/%d":                      "Tabs werden geschlossen... %d/%d",
+	"Successfully closed %d tabs. Refreshing...": "%d Tabs geschlossen. Wird aktualisiert...",
+	"Successfully closed %d tabs.":               "%d Tabs geschlossen.",
+	"Tabs refreshed.":                            "Tabs aktualisiert.",
+	"No tabs selected for closing.":              "Keine Tabs zum Schließen ausgewählt.",
+	"No tabs selected for archiving.":            "Keine Tabs zum Archivieren ausgewählt.",
+	"Archiving failed, no tabs were closed: %v":  "Archivieren fehlgeschlagen, es wurden keine Tabs geschlossen: %v",
+	"Closing cancelled, no tabs were closed: %v": "Schließen abgebrochen, es wurden keine Tabs geschlossen: %v",
+	"Archived %s":                                "Archiviert am %s",
+
+	// Selection toasts
+	"Selected %d duplicates":            "%d Duplikate ausgewählt",
+	"Selected %d old tabs":              "%d alte Tabs ausgewählt",
+	"Selected %d long reads":            "%d lange Artikel ausgewählt",
+	"Selected %d shown tabs":            "%d angezeigte Tabs ausgewählt",
+	"Deselected %d tabs":                "Auswahl von %d Tabs aufgehoben",
+	"Press 'f' or 1-5 to filter first.": "Zuerst mit 'f' oder 1-5 filtern.",
+
+	// Status bar
+	"all":           "alle",
+	"truncate":      "kürzen",
+	"wrap":          "umbrechen",
+	"filter: %s":    "Filter: %s",
+	"view: %s":      "Ansicht: %s",
+	"%d/%d shown":   "%d/%d angezeigt",
+	"%d selected":   "%d ausgewählt",
+	"%d duplicates": "%d Duplikate",
+	"%d old":        "%d alt",
+
+	// Key hints
+	"k/%s j/%s: navigate":      "k/%s j/%s: bewegen",
+	"space: toggle":            "Leertaste: auswählen",
+	"a: select all duplicates": "a: alle Duplikate auswählen",
+	"o: select all old":        "o: alle alten auswählen",
+	"r: select long reads":     "r: lange Artikel auswählen",
+	"f: filter category":       "f: nach Kategorie filtern",
+	"f: next category":         "f: nächste Kategorie",
+	"s: select shown":          "s: angezeigte auswählen",
+	"w: wrap/truncate":         "w: umbrechen/kürzen",
+	"n: deselect all":          "n: Auswahl aufheben",
+	"c: close selected":        "c: ausgewählte schließen",
+	"c: close %d":              "c: %d schließen",
+	"A: archive selected":      "A: ausgewählte archivieren",
+	"A: archive %d":            "A: %d archivieren",
+	"?: all keys":              "?: alle Tasten",
+	"?: fewer keys":            "?: weniger Tasten",
+	"q: quit":                  "q: beenden",
+
+	// Plain mode
+	"%d selected. Enter tab numbers to toggle (e.g. \"3 5-8\"), a: select duplicates, o: select old, n: deselect all, l: list, c: close selected, A: archive selected, q: quit": "%d ausgewählt. Tab-Nummern zum Auswählen eingeben (z. B. \"3 5-8\"), a: Duplikate auswählen, o: alte auswählen, n: Auswahl aufheben, l: Liste, c: ausgewählte schließen, A: ausgewählte archivieren, q: beenden",
+	"Safari Tab Manager %s. %d tabs. Tabs marked old were last visited more than %d days ago.":                                                                                  "Safari Tab Manager %s. %d Tabs. Als alt markierte Tabs wurden vor mehr als %d Tagen zuletzt besucht.",
+	"No tabs selected.":                             "Keine Tabs ausgewählt.",
+	"Close %d tabs? Type y to confirm:":             "%d Tabs schließen? Zum Bestätigen j eingeben:",
+	"Archive and close %d tabs? Type y to confirm:": "%d Tabs archivieren und schließen? Zum Bestätigen j eingeben:",
+	"y":                    "j",
+	"Nothing closed.":      "Nichts geschlossen.",
+	"%d selected: %s":      "%d ausgewählt: %s",
+	"%d deselected: %s":    "%d abgewählt: %s",
+	"selected":             "ausgewählt",
+	"duplicate of %s (%s)": "Duplikat von %s (%s)",
+	"old":                  "alt",
+	"playing audio":        "spielt Audio ab",
+	"loading":              "lädt",
+	"not a tab number: %q": "keine Tab-Nummer: %q",
+	"not a tab range: %q":  "kein Tab-Bereich: %q",
+	"tab numbers must be between 1 and %d: %q": "Tab-Nummern müssen zwischen 1 und %d liegen: %q",
+
+	// Command line
+	"Safari Tab Manager %s":                                      "Safari Tab Manager %s",
+	"Error: %v":                                                  "Fehler: %v",
+	"Error: age must be at least 1 day":                          "Fehler: Das Alter muss mindestens 1 Tag betragen",
+	"Error running program: %v":                                  "Fehler beim Ausführen: %v",
+	"No Safari tabs found. Is Safari running?":                   "Keine Safari-Tabs gefunden. Läuft Safari?",
+	"Age threshold in days for highlighting old tabs":            "Alter in Tagen, ab dem Tabs als alt hervorgehoben werden",
+	"Print version and exit":                                     "Version ausgeben und beenden",
+	"Use Safari Technology Preview instead of Safari":            "Safari Technology Preview statt Safari verwenden",
+	"Fetch page titles over HTTP for blank or \"Untitled\" tabs": "Seitentitel für leere oder \"Ohne Titel\"-Tabs per HTTP laden",
+	"Show favicons next to tab titles":                           "Favicons neben den Tab-Titeln anzeigen",
+	"Fetch pages over HTTP to estimate each tab's reading time":  "Seiten per HTTP laden, um die Lesezeit jedes Tabs zu schätzen",
+	"Markdown file that archived tabs are appended to, or a Slack or Discord webhook URL to post them to": "Markdown-Datei, an die archivierte Tabs angehängt werden, oder Slack- oder Discord-Webhook-URL, an die sie gesendet werden",
+	"Use plain ASCII instead of emoji and symbols, and disable styling":                                   "Reines ASCII statt Emoji und Symbolen verwenden und Formatierung abschalten",
+	"Use numbered prompts instead of the full-screen list (screen reader friendly)":                       "Nummerierte Eingabeaufforderungen statt der Vollbildliste verwenden (für Screenreader)",
+	"protected":                                              "geschützt",
+	"This tab is protected by a rule.":                       "Dieser Tab ist durch eine Regel geschützt.",
+	"Address to listen on":                                   "Adresse, auf der gelauscht wird",
+	"How often to rescan Safari":                             "Wie oft Safari neu eingelesen wird",
+	"Number of domains to report tab counts for":             "Anzahl der Domains, für die Tab-Zahlen gemeldet werden",
+	"Error: interval must be at least 1s":                    "Fehler: Das Intervall muss mindestens 1s betragen",
+	"Serving on http://%s, rescanning every %s":              "Läuft auf http://%s, liest alle %s neu ein",
+	"Close duplicate tabs and exit (used by the menu)":       "Doppelte Tabs schließen und beenden (vom Menü verwendet)",
+	"%d tabs open, alert at %d":                              "%d Tabs offen, Warnung ab %d",
+	"%d duplicates, alert at %d":                             "%d Duplikate, Warnung ab %d",
+	"%d old tabs, alert at %d":                               "%d alte Tabs, Warnung ab %d",
+	"%d duplicates, %d old (>%d days)":                       "%d Duplikate, %d alt (>%d Tage)",
+	"Close %d duplicates":                                    "%d Duplikate schließen",
+	"No duplicates to close":                                 "Keine Duplikate zu schließen",
+	"Open manager":                                           "Manager öffnen",
+	"Refresh":                                                "Aktualisieren",
+	"Address to serve the gRPC API on, empty to disable it":  "Adresse für die gRPC-API, leer zum Abschalten",
+	"session names can't contain / \\ : or start with a dot": "Sitzungsnamen dürfen kein / \\ : enthalten oder mit einem Punkt beginnen",
+	"Use the named profile from config.json":                 "Das benannte Profil aus config.json verwenden",
+	"Only show tabs matching old, duplicates, read-elsewhere, saved-elsewhere, heavy, age:N, domain:NAME, window:N, display:NAME, category:NAME or lang:CODE (repeatable)": "Nur Tabs anzeigen, die old, duplicates, read-elsewhere, saved-elsewhere, heavy, age:N, domain:NAME, window:N, display:NAME, category:NAME oder lang:CODE entsprechen (wiederholbar)",
+	"age:N needs a number of days, got %q": "age:N braucht eine Anzahl von Tagen, nicht %q",
+	"unknown filter %q, want old, duplicates, read-elsewhere, saved-elsewhere, heavy, age:N, domain:NAME, window:N, display:NAME, category:NAME or lang:CODE": "Unbekannter Filter %q, erwartet old, duplicates, read-elsewhere, saved-elsewhere, heavy, age:N, domain:NAME, window:N, display:NAME, category:NAME oder lang:CODE",
+	"pinned":                        "angeheftet",
+	"Pinned tabs are never closed.": "Angeheftete Tabs werden nie geschlossen.",
+	"%d can't be selected (%s): %s": "%d kann nicht ausgewählt werden (%s): %s",
+	"Treat every tab as unpinned, turning off the pinned tab heuristic": "Alle Tabs als nicht angeheftet behandeln und die Erkennung angehefteter Tabs abschalten",
+	"filtered out by %s":          "ausgefiltert durch %s",
+	"%d excluded":                 "%d ausgeblendet",
+	"h: show/hide excluded":       "h: Ausgeblendete zeigen/verbergen",
+	"h: show excluded":            "h: Ausgeblendete zeigen",
+	"h: hide excluded":            "h: Ausgeblendete verbergen",
+	"This tab has no duplicates.": "Dieser Tab hat keine Duplikate.",
+	"The other tab is excluded from the list; press 'h' to show it.": "Der andere Tab ist ausgeblendet; mit 'h' anzeigen.",
+	"d: go to original":                                       "d: zum Original",
+	"d: go to duplicate":                                      "d: zum Duplikat",
+	"d: jump between duplicate and original":                  "d: zwischen Duplikat und Original springen",
+	"No duplicates to review.":                                "Keine Duplikate zu prüfen.",
+	"Reviewed %d of %d duplicate pairs.":                      "%d von %d Duplikat-Paaren geprüft.",
+	"That tab is %s and can't be closed.":                     "Dieser Tab ist %s und kann nicht geschlossen werden.",
+	"Duplicate review - group %d of %d, duplicate %d of %d":   "Duplikat-Prüfung - Gruppe %d von %d, Duplikat %d von %d",
+	"left/1: keep left":                                       "links/1: linken behalten",
+	"right/2: keep right":                                     "rechts/2: rechten behalten",
+	"b: keep both":                                            "b: beide behalten",
+	"s: skip":                                                 "s: überspringen",
+	"esc: back to the list":                                   "Esc: zurück zur Liste",
+	"keep":                                                    "behalten",
+	"close":                                                   "schließen",
+	"No recorded visits":                                      "Keine Besuche erfasst",
+	"D: review duplicates side by side":                       "D: Duplikate nebeneinander prüfen",
+	"D: review duplicates":                                    "D: Duplikate prüfen",
+	"Similar to: %s (%s), differences highlighted":            "Ähnlich wie: %s (%s), Unterschiede hervorgehoben",
+	"read on another device":                                  "auf einem anderen Gerät gelesen",
+	"Macro discarded.":                                        "Makro verworfen.",
+	"Could not save macros: %v":                               "Makros konnten nicht gespeichert werden: %v",
+	"Saved macro %s with %d keys; press @%s to play it.":      "Makro %s mit %d Tasten gespeichert; mit @%s abspielen.",
+	"No macro saved under %s.":                                "Unter %s ist kein Makro gespeichert.",
+	"Recording macro, press m to stop.":                       "Makro wird aufgezeichnet, mit m beenden.",
+	"Press a key to save the macro under, esc to discard it.": "Taste drücken, unter der das Makro gespeichert wird, Esc zum Verwerfen.",
+	"Macros can't play other macros.":                         "Makros können keine anderen Makros abspielen.",
+	"No macros saved yet; press m to record one.":             "Noch keine Makros gespeichert; mit m eines aufzeichnen.",
+	"Press the key of the macro to play.":                     "Taste des abzuspielenden Makros drücken.",
+	"%s can't be recorded.":                                   "%s kann nicht aufgezeichnet werden.",
+	"recording macro (%d keys)":                               "Makro-Aufnahme (%d Tasten)",
+	"m: record macro":                                         "m: Makro aufzeichnen",
+	"@: play macro":                                           "@: Makro abspielen",
+	"m: stop recording":                                       "m: Aufnahme beenden",
+	"sort: %s":                                                "Sortierung: %s",
+	"group: %s":                                               "Gruppierung: %s",
+	"none":                                                    "keine",
+	"Window %d":                                               "Fenster %d",
+	"S: cycle sort order":                                     "S: Sortierung wechseln",
+	"g: cycle grouping":                                       "g: Gruppierung wechseln",
+	"v: saved views":                                          "v: gespeicherte Ansichten",
+	"v: views":                                                "v: Ansichten",
+	"Could not save view: %v":                                 "Ansicht konnte nicht gespeichert werden: %v",
+	"Saved view %q.":                                          "Ansicht %q gespeichert.",
+	"Showing all tabs.":                                       "Alle Tabs werden angezeigt.",
+	"Showing view %q.":                                        "Ansicht %q wird angezeigt.",
+	"Views":                                                   "Ansichten",
+	"All tabs":                                                "Alle Tabs",
+	"No saved views yet. Set a filter, sort order and grouping, then press + to save them.": "Noch keine gespeicherten Ansichten. Filter, Sortierung und Gruppierung einstellen, dann mit + speichern.",
+	"Name: %s":             "Name: %s",
+	"enter: save":          "Enter: speichern",
+	"esc: cancel":          "Esc: abbrechen",
+	"1-9: show view":       "1-9: Ansicht zeigen",
+	"0: all tabs":          "0: alle Tabs",
+	"+: save current view": "+: aktuelle Ansicht speichern",
+	"Start with the named view from config.json": "Mit der benannten Ansicht aus config.json starten",
+	"uncategorized":                            "ohne Kategorie",
+	"New file...":                              "Neue Datei...",
+	"Window %d (%d tabs)":                      "Fenster %d (%d Tabs)",
+	"New window":                               "Neues Fenster",
+	"Close":                                    "Schließen",
+	"Archive to...":                            "Archivieren in...",
+	"Move to window...":                        "In Fenster verschieben...",
+	"Tag...":                                   "Taggen...",
+	"Export as session":                        "Als Sitzung exportieren",
+	"Could not save session: %v":               "Sitzung konnte nicht gespeichert werden: %v",
+	"Saved %d tabs as session %q.":             "%d Tabs als Sitzung %q gespeichert.",
+	"Tagged %d tabs #%s.":                      "%d Tabs mit #%s getaggt.",
+	"Could not copy to the clipboard: %v":      "Kopieren in die Zwischenablage fehlgeschlagen: %v",
+	"Copied %d tabs to the clipboard.":         "%d Tabs in die Zwischenablage kopiert.",
+	"%d selected tabs":                         "%d ausgewählte Tabs",
+	"Archive %d tabs to":                       "%d Tabs archivieren in",
+	"Move %d tabs to":                          "%d Tabs verschieben nach",
+	"Tag %d tabs":                              "%d Tabs taggen",
+	"Tag: %s":                                  "Tag: %s",
+	"enter: tag":                               "Enter: taggen",
+	"esc: back":                                "Esc: zurück",
+	"File: %s":                                 "Datei: %s",
+	"enter: archive":                           "Enter: archivieren",
+	"1-9: pick":                                "1-9: auswählen",
+	"Moving failed: %v":                        "Verschieben fehlgeschlagen: %v",
+	"Moved %d tabs.":                           "%d Tabs verschoben.",
+	"enter: actions on the selection":          "Enter: Aktionen für die Auswahl",
+	"enter: actions":                           "Enter: Aktionen",
+	"Tabs are still being closed.":             "Tabs werden noch geschlossen.",
+	"%d tabs are selected.":                    "%d Tabs sind ausgewählt.",
+	"Tabs are still being moved.":              "Tabs werden noch verschoben.",
+	"A macro is still playing.":                "Ein Makro läuft noch.",
+	"The macro being recorded isn't saved.":    "Das aufgezeichnete Makro ist nicht gespeichert.",
+	"Quit?":                                    "Beenden?",
+	"y: quit anyway":                           "y: trotzdem beenden",
+	"c: close the %d selected tabs, then quit": "c: die %d ausgewählten Tabs schließen, dann beenden",
+	"s: save the %d selected tabs as a session, then quit": "s: die %d ausgewählten Tabs als Sitzung speichern, dann beenden",
+	"esc: keep going": "Esc: weitermachen",
+	"%d tabs are selected. Type y to quit anyway, or s to save them as a session and quit:": "%d Tabs sind ausgewählt. y zum Beenden eingeben, oder s, um sie als Sitzung zu speichern und zu beenden:",
+	"w: finish closing, then quit": "w: Schließen abschließen, dann beenden",
+	"Closing stopped with %d tabs still open; they are listed in %s and will be offered for closing the next time you start.": "Schließen wurde mit %d noch offenen Tabs angehalten; sie sind in %s aufgeführt und werden beim nächsten Start zum Schließen angeboten.",
+	"A cleanup started %s didn't finish; %d tabs were left to close:":                                                         "Eine am %s begonnene Bereinigung wurde nicht abgeschlossen; %d Tabs blieben offen:",
+	"and %d more": "und %d weitere",
+	"Type r to close them now, d to discard the journal, or press Enter to decide later:": "r eingeben, um sie jetzt zu schließen, d, um das Protokoll zu verwerfen, oder Enter, um später zu entscheiden:",
+	"Discarded the close journal.":            "Schließprotokoll verworfen.",
+	"Number of times to run each phase":       "Wie oft jede Phase ausgeführt wird",
+	"Write a CPU profile to this file":        "Ein CPU-Profil in diese Datei schreiben",
+	"Write a memory profile to this file":     "Ein Speicherprofil in diese Datei schreiben",
+	"Error: runs must be at least 1":          "Fehler: runs muss mindestens 1 sein",
+	"Safari Tab Manager %s, %d tabs, %d runs": "Safari Tab Manager %s, %d Tabs, %d Durchläufe",
+	"phase":   "Phase",
+	"best":    "bester",
+	"mean":    "Mittel",
+	"per tab": "pro Tab",
+	"total":   "gesamt",
+	"Heap in use: %.1f MB, allocated in total: %.1f MB": "Heap belegt: %.1f MB, insgesamt zugewiesen: %.1f MB",
+	"enumeration":                        "Aufzählung",
+	"pinned detection":                   "Erkennung angehefteter Tabs",
+	"history enrichment":                 "Verlaufsdaten",
+	"categories":                         "Kategorien",
+	"dedupe":                             "Duplikate",
+	"rules":                              "Regeln",
+	"list build":                         "Listenaufbau",
+	"render":                             "Darstellung",
+	"[BLOCKED]":                          "[GESPERRT]",
+	"Block these sites":                  "Diese Seiten sperren",
+	"Could not update the blocklist: %v": "Sperrliste konnte nicht aktualisiert werden: %v",
+	"Blocked %s; tabs on the blocklist are selected.":                                     "%s gesperrt; Tabs auf der Sperrliste sind ausgewählt.",
+	"Remove the sites from the blocklist instead of adding them":                          "Die Seiten von der Sperrliste entfernen, statt sie hinzuzufügen",
+	"The blocklist is empty.":                                                             "Die Sperrliste ist leer.",
+	"Closed %d blocked tabs.":                                                             "%d gesperrte Tabs geschlossen.",
+	"End focus mode after this long, e.g. 50m; keeps running until then":                  "Fokusmodus nach dieser Dauer beenden, z. B. 50m; läuft bis dahin weiter",
+	"Don't open the closed tabs again when focus mode ends":                               "Die geschlossenen Tabs am Ende des Fokusmodus nicht wieder öffnen",
+	"Closed %d distracting tabs, saved as session %q.":                                    "%d ablenkende Tabs geschlossen, als Sitzung %q gespeichert.",
+	"No distracting tabs open.":                                                           "Keine ablenkenden Tabs offen.",
+	"Focus mode is on.":                                                                   "Fokusmodus ist an.",
+	"Focusing until %s; press Ctrl+C to end early.":                                       "Fokus bis %s; Strg+C beendet ihn früher.",
+	"Opened %d tabs closed for focus mode.":                                               "%d für den Fokusmodus geschlossene Tabs wieder geöffnet.",
+	"Focus mode is off after %s.":                                                         "Fokusmodus ist nach %s aus.",
+	"Focus mode is off.":                                                                  "Fokusmodus ist aus.",
+	"Focus mode is on since %s.":                                                          "Fokusmodus ist seit %s an.",
+	"Usage: safari-tab-manager history prune -domain DOMAIN [-older-than AGE] [-dry-run]": "Verwendung: safari-tab-manager history prune -domain DOMAIN [-older-than ALTER] [-dry-run]",
+	"Delete the history of this domain and its subdomains":                                "Den Verlauf dieser Domain und ihrer Subdomains löschen",
+	"Only delete visits older than this, e.g. 30d, 6w, 3m or 1y; all visits by default":   "Nur Besuche löschen, die älter sind, z. B. 30d, 6w, 3m oder 1y; standardmäßig alle Besuche",
+	"Only count what would be deleted":                                                    "Nur zählen, was gelöscht würde",
+	"Would delete %d visits and %d pages of %s.":                                          "Würde %d Besuche und %d Seiten von %s löschen.",
+	"Deleted %d visits and %d pages of %s. The old history is backed up in %s.":           "%d Besuche und %d Seiten von %s gelöscht. Der alte Verlauf ist in %s gesichert.",
+
+	// Downloads
+	"Age threshold in days for old downloads": "Altersgrenze in Tagen für alte Downloads",
+	"The downloads list is empty.":            "Die Downloadliste ist leer.",
+	"%d selected. Enter download numbers to toggle (e.g. \"3 5-8\"), m: select missing files, o: select old, n: deselect all, l: list, c: clear selected, q: quit": "%d ausgewählt. Download-Nummern zum Umschalten eingeben (z. B. \"3 5-8\"), m: fehlende Dateien auswählen, o: alte auswählen, n: keine auswählen, l: Liste, c: Ausgewählte entfernen, q: Beenden",
+	"Cancelled. No downloads were cleared.": "Abgebrochen. Es wurden keine Downloads entfernt.",
+	"No downloads selected.":                "Keine Downloads ausgewählt.",
+	"Clear %d downloads from the list? The files stay where they are. Type y to confirm:": "%d Downloads aus der Liste entfernen? Die Dateien bleiben, wo sie sind. Zum Bestätigen j eingeben:",
+	"Nothing cleared.": "Nichts entfernt.",
+	"Cleared %d downloads. The old list is backed up in %s.": "%d Downloads entfernt. Die alte Liste ist in %s gesichert.",
+	"%d downloads, %d of them no longer on disk.":            "%d Downloads, %d davon nicht mehr auf der Festplatte.",
+	"%d days old":  "%d Tage alt",
+	"file missing": "Datei fehlt",
+
+	// Top-domain quick filters
+	"1-5: filter by top domain":                     "1-5: nach häufigster Domain filtern",
+	"Stopped filtering by %s.":                      "Filter für %s aufgehoben.",
+	"Showing tabs on %s; press 's' to select them.": "Zeige Tabs auf %s; 's' wählt sie aus.",
+
+	// Bursts
+	"no burst":      "kein Schub",
+	"burst from %s": "Schub von %s",
+	"This tab wasn't opened in a burst of tabs.": "Dieser Tab wurde nicht in einem Schub von Tabs geöffnet.",
+	"Selected %d tabs of this burst":             "%d Tabs dieses Schubs ausgewählt",
+	"b: select the tab's burst":                  "b: Schub des Tabs auswählen",
+	"b: select burst":                            "b: Schub auswählen",
+
+	// Projects
+	"projects":                          "Projekte",
+	"no project":                        "kein Projekt",
+	"project %s":                        "Projekt %s",
+	"This tab isn't part of a project.": "Dieser Tab gehört zu keinem Projekt.",
+	"Selected %d tabs of this project":  "%d Tabs dieses Projekts ausgewählt",
+	"p: select the tab's project":       "p: Projekt des Tabs auswählen",
+	"p: select project":                 "p: Projekt auswählen",
+
+	// Sharing
+	"Share as link list":        "Als Linkliste teilen",
+	"%d tabs, %s":               "%d Tabs, %s",
+	"Tabs shared from Safari":   "Aus Safari geteilte Tabs",
+	"Sharing %d tabs...":        "Teile %d Tabs...",
+	"Sharing failed: %v":        "Teilen fehlgeschlagen: %v",
+	"Shared %d tabs at %s":      "%d Tabs geteilt unter %s",
+	"Shared %d tabs; copied %s": "%d Tabs geteilt; %s kopiert",
+
+	// Webhooks
+	"Archived %d tabs, %s": "%d Tabs archiviert, %s",
+	"Closed %d tabs, %s":   "%d Tabs geschlossen, %s",
+
+	// Expired tabs
+	"meeting is over":          "Meeting ist vorbei",
+	"dated %s, expired":        "vom %s, abgelaufen",
+	"Selected %d expired tabs": "%d abgelaufene Tabs ausgewählt",
+	"e: select all expired":    "e: alle abgelaufenen auswählen",
+
+	// Resolved pull requests and issues
+	"Ask GitHub, GitLab, Jira and Linear which pull requests, issues and tickets in tabs are finished": "Bei GitHub, GitLab, Jira und Linear nachfragen, welche Pull Requests, Issues und Tickets in Tabs erledigt sind",
+	"merged, safe to close":     "gemergt, kann geschlossen werden",
+	"closed, safe to close":     "geschlossen, kann geschlossen werden",
+	"Selected %d resolved tabs": "%d erledigte Tabs ausgewählt",
+	"R: select all resolved":    "R: alle erledigten auswählen",
+	"done, safe to close":       "erledigt, kann geschlossen werden",
+
+	// Videos
+	"video":                     "Video",
+	"video, %s":                 "Video, %s",
+	"watched":                   "angesehen",
+	"%s of %s watched":          "%s von %s angesehen",
+	"watched to %s":             "bis %s angesehen",
+	"playing":                   "läuft",
+	"Selected %d videos":        "%d Videos ausgewählt",
+	"V: select videos":          "V: Videos auswählen",
+	"Watch later (videos only)": "Später ansehen (nur Videos)",
+	"No videos selected.":       "Keine Videos ausgewählt.",
+	"Added %s":                  "Hinzugefügt am %s",
+
+	// Searches
+	"not a search":               "keine Suche",
+	"search %q":                  "Suche %q",
+	"Selected %d stale searches": "%d veraltete Suchen ausgewählt",
+	"x: select stale searches":   "x: veraltete Suchen auswählen",
+
+	// Documentation
+	"not documentation":               "keine Dokumentation",
+	"docs %s":                         "Doku %s",
+	"This tab isn't documentation.":   "Dieser Tab ist keine Dokumentation.",
+	"Selected %d tabs of these docs":  "%d Tabs dieser Doku ausgewählt",
+	"No documentation tabs selected.": "Keine Doku-Tabs ausgewählt.",
+	"Doc index (documentation only)":  "Doku-Index (nur Dokumentation)",
+	"i: select the tab's docs":        "i: Doku des Tabs auswählen",
+	"i: select docs":                  "i: Doku auswählen",
+
+	// Shopping
+	"Fetch product pages over HTTP for their name and price": "Produktseiten über HTTP abrufen, um Name und Preis zu lesen",
+	"product":                       "Produkt",
+	"product, %s":                   "Produkt, %s",
+	"No product pages selected.":    "Keine Produktseiten ausgewählt.",
+	"Wishlist (product pages only)": "Wunschliste (nur Produktseiten)",
+	"Selected %d product pages":     "%d Produktseiten ausgewählt",
+	"$: select product pages":       "$: Produktseiten auswählen",
+
+	// Languages
+	"unknown language":                              "unbekannte Sprache",
+	"language %s":                                   "Sprache %s",
+	"Stopped filtering by language.":                "Sprachfilter aufgehoben.",
+	"Showing tabs in %s; press 's' to select them.": "Tabs auf %s werden angezeigt; 's' wählt sie aus.",
+	"L: filter language":                            "L: nach Sprache filtern",
+	"L: next language":                              "L: nächste Sprache",
+
+	// Tab activity
+	"Last active %s":            "Zuletzt aktiv %s",
+	"never":                     "nie",
+	"just now":                  "gerade eben",
+	"%d min ago":                "vor %d Min.",
+	"today %s":                  "heute %s",
+	"yesterday %s":              "gestern %s",
+	"%d days ago":               "vor %d Tagen",
+	"%d wk ago":                 "vor %d Wo.",
+	"%d mo ago":                 "vor %d Mon.",
+	"%d yr ago":                 "vor %d J.",
+	"Jan 2 15:04":               "2.1. 15:04",
+	"Mon Jan 2 2006, 15:04 MST": "02.01.2006, 15:04 MST",
+	"File to write the report to, - for standard output; {date} is replaced by today's date": "Datei für den Bericht, - für die Standardausgabe; {date} wird durch das heutige Datum ersetzt",
+	"Report format: markdown or json": "Berichtsformat: markdown oder json",
+	"Safari tabs, %s":                 "Safari-Tabs, %s",
+	"%d tabs in %d windows: %d duplicates, %d old (not active for %d days).": "%d Tabs in %d Fenstern: %d Duplikate, %d alt (seit %d Tagen nicht aktiv).",
+	"%d pinned tabs are left out.":                                           "%d angeheftete Tabs sind ausgelassen.",
+	"Top domains":                                                            "Häufigste Domains",
+	"Duplicates":                                                             "Duplikate",
+	"Window %d, Tab %d, duplicate of Window %d, Tab %d":                      "Fenster %d, Tab %d, Duplikat von Fenster %d, Tab %d",
+	"Old tabs":       "Alte Tabs",
+	"never visited":  "nie besucht",
+	"last active %s": "zuletzt aktiv %s",
+	"Only scan and show the tabs of window N, numbered frontmost first": "Nur die Tabs von Fenster N lesen und zeigen, gezählt vom vordersten an",
+	"Choose the window to scan from a list at startup":                  "Beim Start das zu lesende Fenster aus einer Liste wählen",
+	"Choose a window": "Fenster wählen",
+	"Choose a window by number, a for all windows, or q to quit:": "Fenster per Nummer wählen, a für alle Fenster oder q zum Beenden:",
+	"move":                                   "bewegen",
+	"enter or 1-9: choose":                   "Enter oder 1-9: wählen",
+	"a: all windows":                         "a: alle Fenster",
+	"Untitled":                               "Ohne Titel",
+	"%s (%d tabs)":                           "%s (%d Tabs)",
+	"Safari: %d tabs, %d duplicates, %d old": "Safari: %d Tabs, %d Duplikate, %d alt",
+	"Output format: plain, tmux or json":     "Ausgabeformat: plain, tmux oder json",
+	"Reuse counts from a scan at most this old; 0 always scans": "Zahlen eines höchstens so alten Durchlaufs wiederverwenden; 0 liest immer neu",
+	"%d tabs":                        "%d Tabs",
+	"%d dup":                         "%d Dup.",
+	"%d tabs, %d duplicates, %d old": "%d Tabs, %d Duplikate, %d alt",
+	"Safari Tab Manager":             "Safari Tab Manager",
+	"Closed %d new tabs.":            "%d neue Tabs geschlossen.",
+	"%s is on the blocklist.":        "%s steht auf der Sperrliste.",
+	"%s is already open.":            "%s ist bereits geöffnet.",
+	"Tab budget, the most tabs to keep open, 0 for none":                                   "Tab-Budget: höchstens so viele Tabs offen halten, 0 für keins",
+	"Archive the tabs left alone longest whenever more tabs than the budget are open":      "Die am längsten unbenutzten Tabs archivieren, sobald mehr Tabs als das Budget offen sind",
+	"Error: -enforce needs a tab budget":                                                   "Fehler: -enforce braucht ein Tab-Budget",
+	"%d tabs open, %d over the budget of %d.":                                              "%d Tabs offen, %d über dem Budget von %d.",
+	"Archived %d tabs to stay within the budget of %d.":                                    "%d Tabs archiviert, um im Budget von %d zu bleiben.",
+	"%d/%d tabs, %d over budget":                                                           "%d/%d Tabs, %d über dem Budget",
+	"%d/%d tabs of the budget":                                                             "%d/%d Tabs des Budgets",
+	"SQLite database to add the open tabs and logged actions to":                           "SQLite-Datenbank, in die die offenen Tabs und protokollierten Aktionen geschrieben werden",
+	"%d tabs closed, %d archived.":                                                         "%d Tabs geschlossen, %d archiviert.",
+	"Added a run of %d tabs and %d new actions to %s.":                                     "Lauf mit %d Tabs und %d neuen Aktionen zu %s hinzugefügt.",
+	"Print the run history as time series: json":                                           "Den Verlauf der Läufe als Zeitreihen ausgeben: json",
+	"Refreshing failed, the list may be out of date: %v":                                   "Aktualisieren fehlgeschlagen, die Liste ist eventuell veraltet: %v",
+	"%s: %d tabs queued to retry (%v)":                                                     "%s: %d Tabs für einen neuen Versuch vorgemerkt (%v)",
+	"%s: %d tabs archived":                                                                 "%s: %d Tabs archiviert",
+	"%s: archiving %d tabs...":                                                             "%s: %d Tabs werden archiviert...",
+	"%s: %d tabs waiting":                                                                  "%s: %d Tabs warten",
+	"%d tabs couldn't be archived and are queued; run archive -retry-failed to try again.": "%d Tabs konnten nicht archiviert werden und sind vorgemerkt; mit archive -retry-failed erneut versuchen.",
+	"Archive the tabs queued after failing, keeping those that fail again":                 "Nach einem Fehler vorgemerkte Tabs archivieren und erneut fehlschlagende behalten",
+	"No tabs are waiting to be archived.":                                                  "Keine Tabs warten auf das Archivieren.",
+	"%s: %d tabs, queued %s (%s)":                                                          "%s: %d Tabs, vorgemerkt %s (%s)",
+	"Run archive -retry-failed to archive them.":                                           "Mit archive -retry-failed archivieren.",
+	"%s: %d tabs still queued (%v)":                                                        "%s: %d Tabs weiterhin vorgemerkt (%v)",
+	"The archive queue is empty.":                                                          "Die Warteschlange zum Archivieren ist leer.",
+	"Domains - %d tabs on %d domains":                                                      "Domains - %d Tabs auf %d Domains",
+	"No tabs on %s selected.":                                                              "Keine Tabs auf %s ausgewählt.",
+	"No tabs to show stats for.":                                                           "Keine Tabs für eine Statistik.",
+	"Selected %d duplicates on %s":                                                         "%d Duplikate auf %s ausgewählt",
+	"Selected %d old tabs on %s":                                                           "%d alte Tabs auf %s ausgewählt",
+	"Selected %d tabs on %s":                                                               "%d Tabs auf %s ausgewählt",
+	"a: select duplicates":                                                                 "a: Duplikate auswählen",
+	"duplicate":                                                                            "Duplikat",
+	"enter: show tabs":                                                                     "Enter: Tabs zeigen",
+	"esc: back to domains":                                                                 "Esc: zurück zu den Domains",
+	"f: show in the list":                                                                  "f: in der Liste zeigen",
+	"n: deselect":                                                                          "n: Auswahl aufheben",
+	"o: select old":                                                                        "o: alte auswählen",
+	"oldest %s":                                                                            "ältester %s",
+	"s: select all":                                                                        "s: alle auswählen",
+	"3 months":                                                                             "3 Monate",
+	"Showing the tabs of window %d; press 's' to select them, or 'v' and '0' for all tabs.": "Tabs von Fenster %d werden angezeigt; 's' wählt sie aus, 'v' und '0' zeigt alle Tabs.",
+	"Tab ages by window":                     "Tab-Alter nach Fenster",
+	"day":                                    "Tag",
+	"enter: show the window's tabs":          "Enter: Tabs des Fensters zeigen",
+	"month":                                  "Monat",
+	"older":                                  "älter",
+	"t: stats by window and domain":          "t: Statistik nach Fenster und Domain",
+	"tab: domains":                           "Tab: Domains",
+	"tab: heatmap":                           "Tab: Heatmap",
+	"week":                                   "Woche",
+	"window:N needs a window number, got %q": "window:N braucht eine Fensternummer, erhalten: %q",
+	"You've added %d tabs in the last %d days and closed %d. Time for a cleanup?":                   "Du hast in den letzten %[2]d Tagen %[1]d Tabs geöffnet und %[3]d geschlossen. Zeit zum Aufräumen?",
+	"%d saved items will be removed once %s is quit, the next time this runs or with saved -apply.": "%d gespeicherte Einträge werden entfernt, sobald %s beendet ist, beim nächsten Start oder mit saved -apply.",
+	"Add the Reading List to the list, to triage it along with the tabs":                            "Die Leseliste zur Liste hinzufügen, um sie zusammen mit den Tabs auszusortieren",
+	"Add the bookmarks in this folder, like \"Favorites/Read later\", to the list":                  "Die Lesezeichen in diesem Ordner, etwa \"Favoriten/Später lesen\", zur Liste hinzufügen",
+	"Bookmarks": "Lesezeichen",
+	"No saved items are waiting to be removed.":    "Keine gespeicherten Einträge warten darauf, entfernt zu werden.",
+	"Quit %s and run saved -apply to remove them.": "Beende %s und führe saved -apply aus, um sie zu entfernen.",
+	"Reading List": "Leseliste",
+	"Remove the closed Reading List items and bookmarks from Safari, which must be quit": "Die geschlossenen Einträge der Leseliste und Lesezeichen aus Safari entfernen, das dazu beendet sein muss",
+	"Removed %d saved items from %s's bookmarks. The old bookmarks are backed up in %s.": "%d gespeicherte Einträge aus den Lesezeichen von %s entfernt. Die alten Lesezeichen sind in %s gesichert.",
+	"Saved items couldn't be removed: %v":                                                "Gespeicherte Einträge konnten nicht entfernt werden: %v",
+	"Warning: could not remove saved items: %v":                                          "Warnung: Gespeicherte Einträge konnten nicht entfernt werden: %v",
+	"[BOOKMARK]":                                       "[LESEZEICHEN]",
+	"[READING LIST]":                                   "[LESELISTE]",
+	"M: move tabs between windows":                     "M: Tabs zwischen Fenstern verschieben",
+	"Move tabs between windows":                        "Tabs zwischen Fenstern verschieben",
+	"Moving tabs...":                                   "Tabs werden verschoben...",
+	"No tabs to move.":                                 "Keine Tabs zum Verschieben.",
+	"Still moving tabs...":                             "Tabs werden noch verschoben...",
+	"Tabs sent here open in a new window.":             "Hierher geschickte Tabs öffnen sich in einem neuen Fenster.",
+	"Window %d - %d tabs":                              "Fenster %d - %d Tabs",
+	"enter: send selected, or the focused tab, across": "Enter: Ausgewählte oder den aktuellen Tab hinüberschicken",
+	"left/right: change window":                        "links/rechts: Fenster wechseln",
+	"tab: other side":                                  "Tab: andere Seite",
+	"Labeled %d windows.":                              "%d Fenster beschriftet.",
+	"Labeling windows failed: %v":                      "Beschriften der Fenster fehlgeschlagen: %v",
+	"Labeling windows...":                              "Fenster werden beschriftet...",
+	"No window has a project to label it with.":        "Kein Fenster hat ein Projekt, mit dem es sich beschriften ließe.",
+	"W: label windows by project":                      "W: Fenster nach Projekt beschriften",
+	"Window %d, labeled %s.":                           "Fenster %d, beschriftet %s.",
+	"%s on %s":                                         "%s auf %s",
+	"%s, disconnected":                                 "%s, getrennt",
+	"off screen":                                       "außerhalb des Bildschirms",
+	"heavy: ~%d MB, %.0f%% CPU":                        "schwer: ~%d MB, %.0f%% CPU",
+	"heavy: ~%d MB":                                    "schwer: ~%d MB",
+	"No tabs with a known memory use left to select.":  "Keine Tabs mit bekanntem Speicherverbrauch mehr auszuwählen.",
+	"Selected the %d heaviest tabs, about %d MB.":      "Die %d schwersten Tabs ausgewählt, etwa %d MB.",
+	"H: select the heaviest tabs":                      "H: schwerste Tabs auswählen",
+	"Suspended to free memory. Follow the link to load the page again.": "Ausgesetzt, um Speicher freizugeben. Dem Link folgen, um die Seite wieder zu laden.",
+	"No open tabs to suspend.":      "Keine offenen Tabs zum Aussetzen.",
+	"Suspending %d tabs...":         "%d Tabs werden ausgesetzt...",
+	"suspended, %s":                 "ausgesetzt, %s",
+	"Suspending failed: %v":         "Aussetzen fehlgeschlagen: %v",
+	"Suspended %d tabs.":            "%d Tabs ausgesetzt.",
+	"Copy...":                       "Kopieren...",
+	"Suspend":                       "Aussetzen",
+	"URLs":                          "URLs",
+	"Markdown links":                "Markdown-Links",
+	"Copy %d tabs as":               "%d Tabs kopieren als",
+	"No suspended tabs to restore.": "Keine ausgesetzten Tabs wiederherzustellen.",
+	"Restoring %d tabs...":          "%d Tabs werden wiederhergestellt...",
+	"Restoring failed: %v":          "Wiederherstellen fehlgeschlagen: %v",
+	"Restored %d tabs.":             "%d Tabs wiederhergestellt.",
+	"U: restore suspended tabs":     "U: ausgesetzte Tabs wiederherstellen",
+	"       safari-tab-manager history variants [-policy POLICY]":                                         "            safari-tab-manager history variants [-policy RICHTLINIE]",
+	"Show what this history_match policy picks instead of the configured one: exact-first or most-recent": "Zeigen, was diese history_match-Richtlinie statt der eingestellten wählt: exact-first oder most-recent",
+	"the tab's URL": "die URL des Tabs",
+	"No open tab's page is in history under more than one URL.":                                               "Die Seite keines offenen Tabs steht unter mehr als einer URL im Verlauf.",
+	"%d tabs have several history entries; %s marks the one their visit times come from (history_match: %s).": "%d Tabs haben mehrere Verlaufseinträge; %s markiert den, aus dem ihre Besuchszeiten stammen (history_match: %s).",
+	"available":              "verfügbar",
+	"needs Full Disk Access": "braucht Festplattenvollzugriff",
+	"not found":              "nicht gefunden",
+	"visit history: old tabs, read elsewhere, bursts": "Verlauf: alte Tabs, anderswo gelesen, Schübe",
+	"last active times": "zuletzt aktiv",
+	"Reading List and bookmarks, tabs saved elsewhere": "Leseliste und Lesezeichen, anderswo gesicherte Tabs",
+	"downloads cleanup":                       "Aufräumen der Downloads",
+	"Unavailable, needs Full Disk Access: %s": "Nicht verfügbar, braucht Festplattenvollzugriff: %s",
+	"Grant Full Disk Access to your terminal app in System Settings → Privacy & Security → Full Disk Access, then start it again.": "Dem Terminal in Systemeinstellungen → Datenschutz & Sicherheit → Festplattenvollzugriff den Vollzugriff erteilen und es neu starten.",
+	"reading the downloads list needs Full Disk Access, see \"safari-tab-manager permissions\"":                                    "die Downloadliste zu lesen braucht Festplattenvollzugriff, siehe \"safari-tab-manager permissions\"",
+	"%d pages in history": "%d Seiten im Verlauf",
+	"%d tabs in %d windows; only the current Tab Group of each window can be read": "%d Tabs in %d Fenstern; nur die aktuelle Tabgruppe jedes Fensters ist lesbar",
+	"%d windows, no tabs": "%d Fenster, keine Tabs",
+	"%s installed":        "%s installiert",
+	"%s running":          "%s läuft",
+	"Allow your terminal app to control %s in System Settings → Privacy & Security → Automation.": "Dem Terminal in Systemeinstellungen → Datenschutz & Sicherheit → Automation erlauben, %s zu steuern.",
+	"Automation permission": "Automation-Berechtigung",
+	"Edit %s.":              "%s bearbeiten.",
+	"Fix: %s":               "Abhilfe: %s",
+	"In %s, turn on Settings → Advanced → Show features for web developers, then Develop → Allow JavaScript from Apple Events.": "In %s Einstellungen → Erweitert → Funktionen für Webentwickler einblenden einschalten, dann Entwickler → JavaScript von Apple Events erlauben.",
+	"Install %s in /Applications.":                 "%s in /Applications installieren.",
+	"JavaScript from Apple Events":                 "JavaScript von Apple Events",
+	"Open %s; tabs are read from the running app.": "%s öffnen; die Tabs werden aus der laufenden App gelesen.",
+	"Open a window. Only the tabs of each window's current Tab Group can be read, and -window or -pick-window limit the scan to one window.": "Ein Fenster öffnen. Nur die Tabs der aktuellen Tabgruppe jedes Fensters sind lesbar, und -window oder -pick-window beschränken die Suche auf ein Fenster.",
+	"Quit %s and try again; if it keeps failing, the history may be damaged.":                                                                "%s beenden und erneut versuchen; schlägt es weiter fehl, ist der Verlauf vielleicht beschädigt.",
+	"Tabs": "Tabs",
+	"The app needs macOS, where osascript is in /usr/bin.": "Die App braucht macOS, wo osascript in /usr/bin liegt.",
+	"allowed":                         "erlaubt",
+	"can't be opened":                 "lässt sich nicht öffnen",
+	"granted":                         "erteilt",
+	"needs %s running":                "braucht ein laufendes %s",
+	"needs a tab":                     "braucht einen Tab",
+	"needs the Automation permission": "braucht die Automation-Berechtigung",
+	"not running":                     "läuft nicht",
+	"off: loading, audio and video state is unknown, so playing tabs aren't spared": "aus: Lade-, Audio- und Videozustand sind unbekannt, spielende Tabs werden also nicht verschont",
+	"running":    "läuft",
+	"valid":      "gültig",
+	"version %s": "Version %s",
+
+	// Safari versions
+	"In %s, turn on Settings → Advanced → Show Develop menu in menu bar, then Develop → Allow JavaScript from Apple Events.": "In %s Einstellungen → Erweitert → Menü „Entwickler“ in der Menüleiste anzeigen einschalten, dann Entwickler → JavaScript von Apple Events erlauben.",
+	"Could not read the version of %s; the table assumes the latest.":                                                        "Die Version von %s ist nicht lesbar; die Tabelle geht von der neuesten aus.",
+	"yes":                             "ja",
+	"no, needs %d":                    "nein, braucht %d",
+	"Update macOS to get a newer %s.": "macOS aktualisieren, um ein neueres %s zu bekommen.",
+	"Open a window. -window or -pick-window limit the scan to one window.": "Ein Fenster öffnen. -window oder -pick-window beschränken die Suche auf ein Fenster.",
+	"%d tabs in %d windows":                    "%d Tabs in %d Fenstern",
+	"listing, closing and moving tabs":         "Tabs auflisten, schließen und verschieben",
+	"loading, audio and video state":           "Lade-, Audio- und Videozustand",
+	"needs Allow JavaScript from Apple Events": "braucht „JavaScript von Apple Events erlauben“",
+	"Tab Groups": "Tabgruppen",
+	"only the current Tab Group of each window is read":       "nur die aktuelle Tabgruppe jedes Fensters wird gelesen",
+	"Show features for web developers setting":                "Einstellung „Funktionen für Webentwickler einblenden“",
+	"replaces Show Develop menu in menu bar":                  "ersetzt „Menü ‚Entwickler‘ in der Menüleiste anzeigen“",
+	"Profiles":                                                "Profile",
+	"only the default profile's history and session are read": "nur Verlauf und Sitzung des Standardprofils werden gelesen",
+
+	// URLs
+	"(no domain)": "(keine Domain)",
+	"saved in %s": "gesichert in %s",
+
+	// Restoring sessions
+	"Open the tabs without bringing Safari to the front, in a window behind the others": "Die Tabs öffnen, ohne Safari in den Vordergrund zu holen, in einem Fenster hinter den anderen",
+	"No saved sessions in %s.":      "Keine gesicherten Sitzungen in %s.",
+	"Opened %d tabs of session %q.": "%d Tabs der Sitzung %q geöffnet.",
+	"Open the closed tabs again without bringing Safari to the front, in a window behind the others": "Die geschlossenen Tabs wieder öffnen, ohne Safari in den Vordergrund zu holen, in einem Fenster hinter den anderen",
+	"Before closing %d tabs":                          "Vor dem Schließen von %d Tabs",
+	"They were saved as session %q.":                  "Sie wurden als Sitzung %q gesichert.",
+	"Type %d to close the tabs, or press esc.":        "Gib %d ein, um die Tabs zu schließen, oder drücke Esc.",
+	"Could not save session, no tabs were closed: %v": "Sitzung konnte nicht gesichert werden, keine Tabs geschlossen: %v",
+	"Close %d tabs?":                                  "%d Tabs schließen?",
+	"That's more than %d tabs. They will be saved as a session first, then closed in chunks of %d.": "Das sind mehr als %d Tabs. Sie werden zuerst als Sitzung gesichert und dann in Stücken zu je %d geschlossen.",
+	"Type %d to confirm:":       "Gib zur Bestätigung %d ein:",
+	"enter: close  esc: cancel": "Enter: schließen  Esc: abbrechen",
+	"Close %d tabs? They will be saved as a session first. Type %d to confirm:": "%d Tabs schließen? Sie werden zuerst als Sitzung gesichert. Gib zur Bestätigung %d ein:",
+	"Closed tab: %s": "Tab geschlossen: %s",
+	"Describe every action in a line of its own above the list, for screen readers": "Jede Aktion in einer eigenen Zeile über der Liste beschreiben, für Screenreader",
+	"Closing %d tabs.":                    "%d Tabs werden geschlossen.",
+	"Tab %d of %d: %s":                    "Tab %d von %d: %s",
+	"Selected: %s":                        "Ausgewählt: %s",
+	"Deselected: %s":                      "Abgewählt: %s",
+	"space":                               "Leertaste",
+	"Nothing deleted.":                    "Nichts gelöscht.",
+	"Pick tabs with tab and space first.": "Wähle zuerst mit Tab und Leertaste Tabs aus.",
+	"Exporting failed: %v":                "Exportieren fehlgeschlagen: %v",
+	"Exported %d tabs to %s.":             "%d Tabs nach %s exportiert.",
+	"Still restoring...":                  "Wird noch wiederhergestellt...",
+	"Restoring %d tabs of session %q...":  "%d Tabs der Sitzung %q werden wiederhergestellt...",
+	"Restoring failed after %d tabs: %v":  "Wiederherstellen nach %d Tabs fehlgeschlagen: %v",
+	"Deleting failed: %v":                 "Löschen fehlgeschlagen: %v",
+	"Deleted session %q.":                 "Sitzung %q gelöscht.",
+	"Saved sessions - %d":                 "Gespeicherte Sitzungen - %d",
+	"tab: sessions/tabs":                  "Tab: Sitzungen/Tabs",
+	"space: pick":                         "Leertaste: auswählen",
+	"r: restore all":                      "r: alle wiederherstellen",
+	"s: restore picked":                   "s: ausgewählte wiederherstellen",
+	"e: export":                           "e: exportieren",
+	"d: delete":                           "d: löschen",
+	"Delete session %q with its %d tabs? Type y to confirm.": "Sitzung %q mit ihren %d Tabs löschen? Zum Bestätigen j eingeben.",
+	"Saved %s":                    "Gespeichert %s",
+	"Could not read sessions: %v": "Sitzungen konnten nicht gelesen werden: %v",
+	"O: browse saved sessions":    "O: gespeicherte Sitzungen durchsehen",
+	"no autosave at or before %s": "keine automatische Sicherung am oder vor dem %s",
+	"Show the tabs of the last autosave at or before this time: yesterday, tuesday, 2024-05-03 or \"2024-05-03 15:00\"": "Die Tabs der letzten automatischen Sicherung zu oder vor dieser Zeit zeigen: yesterday, tuesday, 2024-05-03 oder \"2024-05-03 15:00\"",
+	"Save the tabs of the autosave as a session with this name":                                                         "Die Tabs der automatischen Sicherung als Sitzung mit diesem Namen speichern",
+	"Open the tabs of the autosave in a new window":                                                                     "Die Tabs der automatischen Sicherung in einem neuen Fenster öffnen",
+	"No autosaves yet. Set \"autosave\" in the config or run serve with -autosave hourly.":                              "Noch keine automatischen Sicherungen. Setze \"autosave\" in der Konfiguration oder starte serve mit -autosave hourly.",
+	"unchanged": "unverändert",
+	"Saved %d tabs of the autosave of %s as session %q.":                                            "%d Tabs der automatischen Sicherung vom %s als Sitzung %q gespeichert.",
+	"Opened %d tabs of the autosave of %s.":                                                         "%d Tabs der automatischen Sicherung vom %s geöffnet.",
+	"Autosave of %s: %d tabs in %d windows":                                                         "Automatische Sicherung vom %s: %d Tabs in %d Fenstern",
+	"Save the open tabs on a schedule: hourly or daily":                                             "Die offenen Tabs regelmäßig sichern: hourly oder daily",
+	"Only count what would be pruned":                                                               "Nur zählen, was entfernt würde",
+	"Would prune %d autosaves, %d logged actions and %d runs.":                                      "Würde %d automatische Sicherungen, %d protokollierte Aktionen und %d Läufe entfernen.",
+	"Pruned %d autosaves, %d logged actions and %d runs.":                                           "%d automatische Sicherungen, %d protokollierte Aktionen und %d Läufe entfernt.",
+	"Reclaimed %.1f MB.":                                                                            "%.1f MB freigegeben.",
+	"Usage: safari-tab-manager state export [-o FILE]":                                              "Verwendung: safari-tab-manager state export [-o DATEI]",
+	"       safari-tab-manager state import [-overwrite] FILE":                                      "            safari-tab-manager state import [-overwrite] DATEI",
+	"Usage: safari-tab-manager state import [-overwrite] FILE":                                      "Verwendung: safari-tab-manager state import [-overwrite] DATEI",
+	"File to export to, safari-tab-manager-state with the date in the current directory by default": "Datei, in die exportiert wird, standardmäßig safari-tab-manager-state mit dem Datum im aktuellen Verzeichnis",
+	"Replace config files and sessions that differ from the imported ones":                          "Konfigurationsdateien und Sitzungen ersetzen, die sich von den importierten unterscheiden",
+	"Exported the state to %s.":                                                                     "Zustand nach %s exportiert.",
+	"Imported %d config files, %d sessions, %d tags, %d logged actions and %d runs.":                "%d Konfigurationsdateien, %d Sitzungen, %d Tags, %d protokollierte Aktionen und %d Läufe importiert.",
+	"Kept your %s, which differs from the imported one; -overwrite replaces it.":                    "%s behalten, weil es sich vom importierten unterscheidet; -overwrite ersetzt es.",
+	"session %q":  "Sitzung %q",
+	"history":     "Verlauf",
+	"page checks": "Seitenprüfungen",
+	"duplicates":  "Duplikate",
+	"loading %s":  "lädt %s",
+}
@@ -0,0 +1,114 @@
+package main
+
+// tabIDs hands out tab IDs that survive refreshes and partial closes, for
+// duplicate references, selections and messages. Safari has no tab ids, so
+// like keyedTabs a tab is known by its URL and how many earlier tabs share
+// it: the nth open tab with a URL keeps the ID the nth one had before. IDs
+// start at 1 and are never reused.
+type tabIDs struct {
+	last  int
+	byURL map[string][]int
+}
+
+// assign sets the ID of every tab, reusing the IDs of tabs seen before.
+// Duplicates keep referring to the same originals under their new IDs.
+func (r *tabIDs) assign(tabs []Tab) {
+	byURL := make(map[string][]int, len(tabs))
+	renamed := make(map[int]int, len(tabs))
+	for i := range tabs {
+		url := tabs[i].URL
+		n := len(byURL[url])
+		was := tabs[i].ID
+		if known := r.byURL[url]; n < len(known) {
+			tabs[i].ID = known[n]
+		} else {
+			r.last++
+			tabs[i].ID = r.last
+		}
+		renamed[was] = tabs[i].ID
+		byURL[url] = append(byURL[url], tabs[i].ID)
+	}
+	for i := range tabs {
+		if tabs[i].DuplicateOf != 0 {
+			tabs[i].DuplicateOf = renamed[tabs[i].DuplicateOf]
+		}
+	}
+	r.byURL = byURL
+}
+
+// numberTabs gives the tabs of a scan IDs by their place, from 1, for
+// duplicates to refer to their originals by. The list goes on to give them
+// IDs of its own with tabIDs.
+func numberTabs(tabs []Tab) {
+	for i := range tabs {
+		tabs[i].ID = i + 1
+	}
+}
+
+// indexByID maps the IDs of tabs to their indexes.
+func indexByID(tabs []Tab) map[int]int {
+	byID := make(map[int]int, len(tabs))
+	for i := range tabs {
+		if tabs[i].ID != 0 {
+			byID[tabs[i].ID] = i
+		}
+	}
+	return byID
+}
+
+// tabByID returns the tab with the given ID among tabs, reporting false if
+// there's none, as for the 0 of a tab that duplicates nothing.
+func tabByID(tabs []Tab, id int) (Tab, bool) {
+	if id == 0 {
+		return Tab{}, false
+	}
+	for _, tab := range tabs {
+		if tab.ID == id {
+			return tab, true
+		}
+	}
+	return Tab{}, false
+}
+
+// forget retires the IDs of closed tabs, so the copies of a page still open
+// keep their own IDs, and with them their selection, instead of taking over
+// those of the copies that were closed.
+func (r *tabIDs) forget(closed []Tab) {
+	for _, tab := range closed {
+		ids := r.byURL[tab.URL]
+		for i, id := range ids {
+			if id == tab.ID {
+				r.byURL[tab.URL] = append(ids[:i:i], ids[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// indexOf returns the index in m.tabs of the tab with the given ID, or -1
+// if it's no longer open.
+func (m model) indexOf(id int) int {
+	for i := range m.tabs {
+		if m.tabs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// keepSelection carries the selection over to refreshed tabs: tabs that
+// were already open stay selected or not as they were, and only new tabs
+// take their suggested selection.
+func (m model) keepSelection(tabs []Tab) bitset {
+	was := make(map[int]bool, len(m.tabs))
+	for i := range m.tabs {
+		was[m.tabs[i].ID] = m.selected.has(i)
+	}
+	selected := suggestedSelection(tabs)
+	for i := range tabs {
+		if on, ok := was[tabs[i].ID]; ok {
+			selected.set(i, on && !tabs[i].locked())
+		}
+	}
+	return selected
+}
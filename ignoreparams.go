@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Some query parameters change from visit to visit without changing the
+// page: YouTube's t= start time, Google's hl= language, session ids. Which
+// ones depends on the site, so "ignore_params" in the config names them per
+// domain, and dedupe compares URLs without them. The list still shows each
+// tab's URL as it is.
+
+var ignoredParams map[string][]string // Set from config.json, by domain
+
+// withoutIgnoredParams drops the query parameters ignored on a URL's
+// domain, or its subdomains, keeping the others as they are. A name ending
+// in "*" ignores every parameter starting with the rest, like "utm_*".
+func withoutIgnoredParams(raw string) string {
+	if len(ignoredParams) == 0 {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+	var ignored []string
+	for domain, params := range ignoredParams {
+		if onDomain(raw, []string{domain}) {
+			ignored = append(ignored, params...)
+		}
+	}
+	if len(ignored) == 0 {
+		return raw
+	}
+
+	var kept []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !ignoredParam(name, ignored) {
+			kept = append(kept, param)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+// ignoredParam reports whether a parameter name matches one of names.
+func ignoredParam(name string, names []string) bool {
+	for _, pattern := range names {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) || name == pattern {
+			return true
+		}
+	}
+	return false
+}
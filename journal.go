@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A close works through the tabs one at a time, so quitting or losing the
+// terminal halfway leaves some closed and some open. The close journal keeps
+// the tabs still to close in close-journal.json in the cache directory until
+// the close is done, so a close cut short can be finished on the next start.
+
+type closeJournal struct {
+	StartedAt time.Time   `json:"started_at"`
+	Remaining []tabRecord `json:"remaining"` // Tabs not closed yet, in closing order
+}
+
+var (
+	closesRunning sync.WaitGroup // Closes in flight, waited for before exiting
+	stopClosing   atomic.Bool    // Stops closes in flight after the current tab
+)
+
+func journalPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "close-journal.json"), nil
+}
+
+// writeJournal records the tabs a close still has to close. The close goes
+// on without it, so errors are logged.
+func writeJournal(startedAt time.Time, remaining []Tab) {
+	journal := closeJournal{StartedAt: startedAt, Remaining: make([]tabRecord, len(remaining))}
+	for i, tab := range remaining {
+		journal.Remaining[i] = newTabRecord(tab)
+	}
+	path, err := journalPath()
+	if err == nil {
+		err = saveJSON(path, journal)
+	}
+	if err != nil {
+		log.Printf("Warning: could not write close journal: %v", err)
+	}
+}
+
+// removeJournal drops the journal once a close is done.
+func removeJournal() {
+	path, err := journalPath()
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: could not remove close journal: %v", err)
+	}
+}
+
+// loadJournal reads the journal left by a close that didn't finish. An empty
+// journal means there is none.
+func loadJournal() (closeJournal, string, error) {
+	var journal closeJournal
+	path, err := journalPath()
+	if err != nil {
+		return journal, "", err
+	}
+	return journal, path, loadJSON(path, &journal)
+}
+
+// stopOnSignal quits p when the terminal goes away or the process is asked
+// to stop, letting a close in flight stop cleanly after the current tab.
+func stopOnSignal(p *tea.Program) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM)
+	go func() {
+		<-signals
+		stopClosing.Store(true)
+		p.Quit()
+	}()
+}
+
+// finishClosing waits for closes in flight to finish or stop, and says what
+// is left if one stopped early.
+func finishClosing() string {
+	closesRunning.Wait()
+	journal, path, err := loadJournal()
+	if err != nil || len(journal.Remaining) == 0 {
+		return ""
+	}
+	return tr("Closing stopped with %d tabs still open; they are listed in %s and will be offered for closing the next time you start.", len(journal.Remaining), path)
+}
+
+// offerResume asks what to do with the journal of a close that didn't
+// finish: close the tabs it left open, discard it, or leave it for later.
+// Resuming matches the tabs by URL, so tabs closed by hand since are skipped.
+func offerResume(in io.Reader, out io.Writer) {
+	journal, _, err := loadJournal()
+	if err != nil {
+		log.Printf("Warning: could not read close journal: %v", err)
+		return
+	}
+	if len(journal.Remaining) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, tr("A cleanup started %s didn't finish; %d tabs were left to close:", shortDateTime(journal.StartedAt), len(journal.Remaining)))
+	for i, record := range journal.Remaining {
+		if i == 5 {
+			fmt.Fprintln(out, "  "+tr("and %d more", len(journal.Remaining)-i))
+			break
+		}
+		fmt.Fprintln(out, "  "+truncateEnd(record.Title, 70))
+	}
+	fmt.Fprint(out, tr("Type r to close them now, d to discard the journal, or press Enter to decide later:")+" ")
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.TrimSpace(strings.ToLower(answer)) {
+	case "r":
+		tabs := make([]Tab, len(journal.Remaining))
+		for i, record := range journal.Remaining {
+			tabs[i] = Tab{Title: record.Title, URL: record.URL}
+		}
+		switch msg := closeTabsAsync(tabs, nil)().(type) {
+		case closeAbortedMsg:
+			fmt.Fprintln(out, tr("Closing cancelled, no tabs were closed: %v", msg.err))
+		case closingCompleteMsg:
+			fmt.Fprintln(out, tr("Successfully closed %d tabs.", msg.count))
+		}
+	case "d":
+		removeJournal()
+		fmt.Fprintln(out, tr("Discarded the close journal."))
+	}
+}
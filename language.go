@@ -0,0 +1,204 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The language of a tab is guessed offline from its title and URL: the
+// writing system decides for scripts used by one language, and otherwise
+// common words in the title, a locale in the URL, like /de/ or fr.example.com,
+// and the country domain vote. Tabs are left without a language when nothing
+// points either way.
+
+// scriptLanguages are the scripts that identify a language by themselves,
+// checked in order, since Japanese mixes kana with Han characters.
+var scriptLanguages = []struct {
+	tables   []*unicode.RangeTable
+	language string
+}{
+	{[]*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}, "ja"},
+	{[]*unicode.RangeTable{unicode.Hangul}, "ko"},
+	{[]*unicode.RangeTable{unicode.Han}, "zh"},
+	{[]*unicode.RangeTable{unicode.Cyrillic}, "ru"},
+	{[]*unicode.RangeTable{unicode.Greek}, "el"},
+	{[]*unicode.RangeTable{unicode.Arabic}, "ar"},
+	{[]*unicode.RangeTable{unicode.Hebrew}, "he"},
+	{[]*unicode.RangeTable{unicode.Thai}, "th"},
+	{[]*unicode.RangeTable{unicode.Devanagari}, "hi"},
+}
+
+// stopwords are frequent short words of languages written in Latin script.
+// Words shared by several languages are left out.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "for", "with", "how", "what", "your", "you", "on", "from", "why", "are"},
+	"de": {"der", "die", "das", "und", "ist", "mit", "für", "von", "wie", "ein", "eine", "nicht", "auf", "zu", "im", "bei"},
+	"fr": {"le", "la", "les", "et", "du", "pour", "avec", "est", "une", "dans", "sur", "au", "aux", "comment"},
+	"es": {"el", "los", "las", "y", "del", "es", "por", "cómo", "qué"},
+	"it": {"il", "lo", "gli", "di", "della", "per", "è", "che", "come", "nel"},
+	"pt": {"o", "os", "dos", "com", "uma", "não", "em"},
+	"nl": {"het", "van", "een", "voor", "met", "niet", "op", "hoe", "wat"},
+	"pl": {"i", "w", "z", "nie", "jak", "się", "dla", "co"},
+	"sv": {"och", "att", "det", "som", "för", "med", "är", "på", "av", "hur"},
+}
+
+// countryLanguages are the languages of country domains, a weak hint.
+var countryLanguages = map[string]string{
+	"de": "de", "at": "de", "fr": "fr", "es": "es", "mx": "es", "it": "it",
+	"pt": "pt", "br": "pt", "nl": "nl", "pl": "pl", "se": "sv",
+	"uk": "en", "us": "en", "au": "en",
+}
+
+// tabLanguage guesses the ISO 639-1 language of a tab, or returns an
+// empty string.
+func tabLanguage(title, rawURL string) string {
+	if language := scriptLanguage(title); language != "" {
+		return language
+	}
+
+	scores := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for language, words := range stopwords {
+			for _, w := range words {
+				if w == word {
+					scores[language]++
+				}
+			}
+		}
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		if language := urlLocale(u); language != "" {
+			scores[language] += 2
+		}
+		host := strings.ToLower(u.Hostname())
+		if language, ok := countryLanguages[host[strings.LastIndex(host, ".")+1:]]; ok {
+			scores[language]++
+		}
+	}
+
+	best, bestScore, tie := "", 0, false
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = language, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// scriptLanguage returns the language of the script most of the letters in
+// s are written in, if that script identifies one.
+func scriptLanguage(s string) string {
+	var letters int
+	counts := make([]int, len(scriptLanguages))
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for i, script := range scriptLanguages {
+			if unicode.In(r, script.tables...) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	for i, script := range scriptLanguages {
+		// Kana among Han characters makes Japanese, however few
+		if counts[i] > 0 && (script.language == "ja" || 3*counts[i] >= letters) {
+			return script.language
+		}
+	}
+	return ""
+}
+
+// urlLocale returns the language of a locale in a URL: a language
+// subdomain, a first path segment like de or de-DE, or a hl or lang
+// parameter.
+func urlLocale(u *url.URL) string {
+	known := func(code string) string {
+		code = strings.ToLower(code)
+		if len(code) > 2 && (code[2] == '-' || code[2] == '_') {
+			code = code[:2]
+		}
+		if _, ok := stopwords[code]; ok {
+			return code
+		}
+		return ""
+	}
+
+	for _, param := range []string{"hl", "lang"} {
+		if value := u.Query().Get(param); value != "" {
+			return known(value)
+		}
+	}
+	first, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if len(first) == 2 || len(first) == 5 {
+		if language := known(first); language != "" {
+			return language
+		}
+	}
+	if sub, _, ok := strings.Cut(u.Hostname(), "."); ok && len(sub) == 2 {
+		return known(sub)
+	}
+	return ""
+}
+
+// markLanguages sets Language on every tab.
+func markLanguages(tabs []Tab) {
+	for i := range tabs {
+		tabs[i].Language = tabLanguage(tabs[i].Title, tabs[i].URL)
+	}
+}
+
+// tabLanguages returns the languages present in tabs, sorted.
+func tabLanguages(tabs []Tab) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tab := range tabs {
+		if tab.Language != "" && !seen[tab.Language] {
+			seen[tab.Language] = true
+			result = append(result, tab.Language)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// languageLabel names the group of tabs in a language.
+func languageLabel(language string) string {
+	if language == "" {
+		return tr("unknown language")
+	}
+	return tr("language %s", language)
+}
+
+// nextLanguageFilter cycles the filter through the languages present in the
+// tabs, ending with no filter.
+func (m *model) nextLanguageFilter() tea.Cmd {
+	languages := tabLanguages(m.tabs)
+	next := ""
+	if m.filter.language == "" && len(languages) > 0 {
+		next = languages[0]
+	}
+	for i, language := range languages {
+		if language == m.filter.language && i+1 < len(languages) {
+			next = languages[i+1]
+		}
+	}
+	m.filter.language = next
+	m.refreshItems()
+	if next == "" {
+		return m.showToast(tr("Stopped filtering by language."))
+	}
+	return m.showToast(tr("Showing tabs in %s; press 's' to select them.", next))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Closing hundreds of tabs at once is easy to do by accident and slow to
+// undo. Past "large_close.threshold" in the config, a close has to be
+// confirmed by typing how many tabs it closes, the tabs are saved as a
+// session first, and they close in chunks: each chunk is matched against
+// Safari as it is then, so window and tab indices don't go stale over a
+// long close, and the close journal always holds every tab not closed yet.
+
+// largeCloseConfig guards closes of many tabs.
+type largeCloseConfig struct {
+	Threshold int `json:"threshold"`  // Closes of more tabs than this are guarded, default 200, -1 never
+	ChunkSize int `json:"chunk_size"` // Tabs matched against Safari at a time in a guarded close, default 50
+}
+
+var largeClose = largeCloseConfig{Threshold: 200, ChunkSize: 50} // Overridden from config.json
+
+// guards reports whether closing count tabs is guarded.
+func (c largeCloseConfig) guards(count int) bool {
+	return c.Threshold >= 0 && count > c.Threshold
+}
+
+// closeChunks splits the tabs of a guarded close into chunks, and leaves
+// other closes whole.
+func closeChunks(tabs []Tab) [][]Tab {
+	if !largeClose.guards(len(tabs)) {
+		return [][]Tab{tabs}
+	}
+	size := max(largeClose.ChunkSize, 1)
+	var chunks [][]Tab
+	for start := 0; start < len(tabs); start += size {
+		chunks = append(chunks, tabs[start:min(start+size, len(tabs))])
+	}
+	return chunks
+}
+
+// snapshotBeforeClose saves the tabs of a guarded close as a session, so
+// they can be restored.
+func snapshotBeforeClose(tabs []Tab) (session, error) {
+	return saveSession(tr("Before closing %d tabs", len(tabs))+" "+time.Now().Format("2006-01-02 15.04.05"), tabs)
+}
+
+// snapshotNote tells where the tabs of a guarded close were saved.
+func snapshotNote(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " " + tr("They were saved as session %q.", name)
+}
+
+// closeTabs closes tabs, or asks for their count to be typed first if there
+// are too many, see largeClose.
+func (m *model) closeTabs(tabs []Tab) tea.Cmd {
+	if largeClose.guards(len(tabs)) {
+		m.actionMenu = ""
+		m.confirmClose = tabs
+		m.confirmCount = ""
+		return nil
+	}
+	m.startClosing(len(tabs))
+	return closeTabsAsync(tabs, m.emptyPinnedOnlyWindows)
+}
+
+// updateConfirmClose handles a key while asking for the count of a large
+// close.
+func (m *model) updateConfirmClose(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.confirmClose = nil
+		return m.requestQuit()
+
+	case tea.KeyEsc:
+		m.confirmClose = nil
+		m.quitAfterClosing = false
+		m.macroQueue = nil
+
+	case tea.KeyEnter:
+		tabs := m.confirmClose
+		typed := strings.TrimSpace(m.confirmCount)
+		m.confirmCount = ""
+		if n, err := strconv.Atoi(typed); err != nil || n != len(tabs) {
+			return m.showToast(tr("Type %d to close the tabs, or press esc.", len(tabs)))
+		}
+		m.confirmClose = nil
+		saved, err := snapshotBeforeClose(tabs)
+		if err != nil {
+			m.quitAfterClosing = false
+			return m.showToast(tr("Could not save session, no tabs were closed: %v", err))
+		}
+		m.closeSnapshot = saved.Name
+		m.startClosing(len(tabs))
+		return closeTabsAsync(tabs, m.emptyPinnedOnlyWindows)
+
+	case tea.KeyBackspace:
+		m.confirmCount = editText(m.confirmCount, msg)
+
+	case tea.KeyRunes:
+		if _, err := strconv.Atoi(string(msg.Runes)); err == nil {
+			m.confirmCount += string(msg.Runes)
+		}
+	}
+	return nil
+}
+
+// confirmCloseView asks for the count of a large close.
+func (m model) confirmCloseView() string {
+	count := len(m.confirmClose)
+	lines := []string{
+		titleStyle.Render(tr("Close %d tabs?", count)),
+		"",
+		"  " + tr("That's more than %d tabs. They will be saved as a session first, then closed in chunks of %d.", largeClose.Threshold, max(largeClose.ChunkSize, 1)),
+		"",
+		"  " + tr("Type %d to confirm:", count) + " " + m.confirmCount + "█",
+		"",
+		"  " + tr("enter: close  esc: cancel"),
+	}
+	if m.toast != "" {
+		lines = append(lines, "", "  "+m.toast)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
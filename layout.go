@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Each tab in the list takes three lines by default: its title, its URL,
+// and where it is with what else is known about it. "layout" in the config
+// rearranges them into one to four lines, each a list of fields, from a
+// single line of titles and domains to see many tabs at once, to a line of
+// its own for every detail. The title always starts the first line, after
+// the cursor and checkbox.
+
+// Fields of the layout.
+const (
+	fieldTitle     = "title"     // Title, with the old, audio, video and loading indicators
+	fieldURL       = "url"       // URL, on a line of its own labelled and highlighted where it differs from a similar original
+	fieldDomain    = "domain"    // Domain, as grouped by
+	fieldDuplicate = "duplicate" // The original of a duplicate, empty for other tabs
+	fieldLocation  = "location"  // Window and tab, or the saved item's place
+	fieldDetails   = "details"   // Last visit, reading time, category, tags and the rest
+	fieldInfo      = "info"      // The original of a duplicate, or the location and details of other tabs
+)
+
+var layoutFields = []string{fieldTitle, fieldURL, fieldDomain, fieldDuplicate, fieldLocation, fieldDetails, fieldInfo}
+
+// maxLayoutLines is the most lines a layout can have.
+const maxLayoutLines = 4
+
+// itemLayout lists the fields on each line of a list item.
+type itemLayout [][]string
+
+var defaultLayout = itemLayout{{fieldTitle}, {fieldURL}, {fieldInfo}}
+
+// validate checks the layout, and fills in the default if there is none.
+func (l *itemLayout) validate() error {
+	if len(*l) == 0 {
+		*l = defaultLayout
+		return nil
+	}
+	if len(*l) > maxLayoutLines {
+		return fmt.Errorf("layout has %d lines, at most %d are shown", len(*l), maxLayoutLines)
+	}
+	for n, fields := range *l {
+		if len(fields) == 0 {
+			return fmt.Errorf("line %d of the layout is empty", n+1)
+		}
+		for f, field := range fields {
+			if !slices.Contains(layoutFields, field) {
+				return fmt.Errorf("unknown layout field %q, want one of %s", field, strings.Join(layoutFields, ", "))
+			}
+			if field == fieldTitle && (n > 0 || f > 0) {
+				return fmt.Errorf("the title starts the first line of the layout")
+			}
+		}
+	}
+	if (*l)[0][0] != fieldTitle {
+		return fmt.Errorf("the title starts the first line of the layout")
+	}
+	return nil
+}
+
+// urlLine reports whether a line of the layout shows the URL alone, with
+// its label, wrapping and highlighted differences.
+func urlLine(fields []string) bool {
+	return len(fields) == 1 && fields[0] == fieldURL
+}
+
+// layoutText renders fields for an item, unstyled, joined in at most width
+// cells. Empty fields are left out.
+func layoutText(fields []string, i item, width int) string {
+	var texts []string
+	for _, field := range fields {
+		if text := fieldText(field, i, width); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return truncateEnd(strings.Join(texts, sym.separator), width)
+}
+
+// fieldText renders one field other than the title, unstyled, in about
+// width cells.
+func fieldText(field string, i item, width int) string {
+	tab := i.tab()
+	switch field {
+	case fieldURL:
+		return truncateMiddle(displayURL(tab.URL), width)
+	case fieldDomain:
+		return domainLabel(siteOf(tab.URL))
+	case fieldDuplicate:
+		return duplicateText(i, width)
+	case fieldLocation:
+		return tab.location()
+	case fieldDetails:
+		return detailsText(i)
+	case fieldInfo:
+		if tab.DuplicateOf != 0 {
+			return duplicateText(i, width)
+		}
+		if details := detailsText(i); details != "" {
+			return tab.location() + sym.separator + details
+		}
+		return tab.location()
+	}
+	return ""
+}
+
+// duplicateText names the original of a duplicate by title, the way it
+// appears in the list, shortening the title rather than the window.
+func duplicateText(i item, width int) string {
+	original, ok := tabByID(i.tabs, i.tab().DuplicateOf)
+	if !ok {
+		return ""
+	}
+	name := singleLine(original.Title)
+	if strings.TrimSpace(name) == "" {
+		name = displayURL(original.URL)
+	}
+	format := "Duplicate of: %s (%s)"
+	if i.similar != "" {
+		format = "Similar to: %s (%s), differences highlighted"
+	}
+	prefix := sym.arrow + " "
+	nameWidth := max(minTextWidth, width-textWidth(prefix+tr(format, "", original.container())))
+	return prefix + tr(format, truncateEnd(name, nameWidth), original.container())
+}
+
+// detailsText lists what is known about a tab besides where it is.
+func detailsText(i item) string {
+	tab := i.tab()
+	var details string
+	if tab.IsOld && !tab.LastActivated.IsZero() {
+		details += sym.separator + tr("Last active %s", humanizeTime(tab.LastActivated, time.Now()))
+	} else if tab.IsOld && !tab.LastVisit.IsZero() {
+		details += sym.separator + tr("Last visited %s", humanizeTime(tab.LastVisit, time.Now()))
+	}
+	if tab.ReadElsewhere {
+		details += sym.separator + tr("read on another device")
+	}
+	if tab.SavedIn != "" {
+		details += sym.separator + tr("saved in %s", tab.SavedIn)
+	}
+	if tab.SuspendedURL != "" {
+		details += sym.separator + tr("suspended, %s", tab.SuspendedURL)
+	}
+	if tab.Expired != "" {
+		details += sym.separator + expiredReason(tab)
+	}
+	if tab.Resolved != "" {
+		details += sym.separator + resolvedReason(tab)
+	}
+	if tab.ReadingMinutes > 0 {
+		details += sym.separator + tr("~%d min read", tab.ReadingMinutes)
+	}
+	if tab.Video {
+		details += sym.separator + videoInfo(tab)
+	}
+	if tab.Product {
+		details += sym.separator + productInfo(tab)
+	}
+	if tab.heavy() {
+		details += sym.separator + memoryInfo(tab)
+	}
+	if tab.Category != "" {
+		details += sym.separator + tab.Category
+	}
+	for _, tag := range tab.Tags {
+		details += " #" + tag
+	}
+	if i.excluded != "" {
+		details += sym.separator + i.excluded
+	} else if tab.Protected {
+		details += sym.separator + tr("protected")
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(details, sym.separator), " ")
+}
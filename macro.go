@@ -0,0 +1,151 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Macros are recorded key sequences saved under a single key, like vim
+// registers. They are stored by key in macros.json in the config directory.
+
+// Macro prompts, waiting for the key to save a recording under or to play.
+const (
+	macroPromptSave = "save"
+	macroPromptPlay = "play"
+)
+
+// namedKeys turns the names keys are recorded under back into key types.
+// Everything else is recorded as the runes it types.
+var namedKeys = map[string]tea.KeyType{
+	"enter":     tea.KeyEnter,
+	"esc":       tea.KeyEsc,
+	" ":         tea.KeySpace,
+	"tab":       tea.KeyTab,
+	"backspace": tea.KeyBackspace,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	"home":      tea.KeyHome,
+	"end":       tea.KeyEnd,
+	"pgup":      tea.KeyPgUp,
+	"pgdown":    tea.KeyPgDown,
+}
+
+func keyMsg(name string) tea.KeyMsg {
+	if t, ok := namedKeys[name]; ok {
+		return tea.KeyMsg{Type: t}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+func macrosPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "macros.json"), nil
+}
+
+// loadMacros reads the saved macros. Without them the macros just start out
+// empty, so errors are logged.
+func loadMacros() map[string][]string {
+	macros := make(map[string][]string)
+	path, err := macrosPath()
+	if err == nil {
+		err = loadJSON(path, &macros)
+	}
+	if err != nil {
+		log.Printf("Warning: could not load macros: %v", err)
+	}
+	return macros
+}
+
+func saveMacros(macros map[string][]string) error {
+	path, err := macrosPath()
+	if err != nil {
+		return err
+	}
+	return saveJSON(path, macros)
+}
+
+// updateMacro handles the macro keys: m starts and stops recording, @ plays
+// a macro, and the key after either picks the macro. It reports false for
+// keys it leaves to the rest of Update, recording them if needed.
+func (m *model) updateMacro(msg tea.KeyMsg) (tea.Cmd, bool) {
+	name := msg.String()
+
+	switch {
+	case m.typing():
+		// Typing a view name or a tag, where m and @ are just letters
+
+	case m.macroPrompt == macroPromptSave:
+		m.macroPrompt = ""
+		if name == "esc" {
+			m.macroKeys = nil
+			return m.showToast(tr("Macro discarded.")), true
+		}
+		m.macros[name] = m.macroKeys
+		m.macroKeys = nil
+		if err := saveMacros(m.macros); err != nil {
+			return m.showToast(tr("Could not save macros: %v", err)), true
+		}
+		return m.showToast(tr("Saved macro %s with %d keys; press @%s to play it.", name, len(m.macros[name]), name)), true
+
+	case m.macroPrompt == macroPromptPlay:
+		m.macroPrompt = ""
+		keys, ok := m.macros[name]
+		if !ok {
+			return m.showToast(tr("No macro saved under %s.", name)), true
+		}
+		m.macroQueue = append([]string(nil), keys...)
+		return nil, true
+
+	case name == "m":
+		if !m.recording {
+			m.recording = true
+			m.macroKeys = nil
+			return m.showToast(tr("Recording macro, press m to stop.")), true
+		}
+		m.recording = false
+		if len(m.macroKeys) == 0 {
+			return m.showToast(tr("Macro discarded.")), true
+		}
+		m.macroPrompt = macroPromptSave
+		return m.showToast(tr("Press a key to save the macro under, esc to discard it.")), true
+
+	case name == "@":
+		if m.recording {
+			return m.showToast(tr("Macros can't play other macros.")), true
+		}
+		if len(m.macros) == 0 {
+			return m.showToast(tr("No macros saved yet; press m to record one.")), true
+		}
+		m.macroPrompt = macroPromptPlay
+		return m.showToast(tr("Press the key of the macro to play.")), true
+	}
+
+	if m.recording {
+		if msg.Alt || msg.Type == tea.KeyCtrlC {
+			return m.showToast(tr("%s can't be recorded.", name)), true
+		}
+		m.macroKeys = append(m.macroKeys, name)
+	}
+	return nil, false
+}
+
+// playMacro feeds queued macro keys to Update. Closing and archiving run in
+// the background, so playing pauses until the tabs are refreshed.
+func (m model) playMacro() (model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for len(m.macroQueue) > 0 && !m.closing && !m.quitting {
+		name := m.macroQueue[0]
+		m.macroQueue = m.macroQueue[1:]
+		next, cmd := m.Update(keyMsg(name))
+		m = next.(model)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
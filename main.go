@@ -8,8 +8,9 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	_ "modernc.org/sqlite"
 )
 
@@ -31,30 +33,184 @@ var (
 	normalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("246"))
 	oldTabStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange for old tabs
 	helpStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	messageStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
 )
 
+// symbols are the glyphs drawn in the list and help text
+type symbols struct {
+	cursor    string
+	checked   string
+	unchecked string
+	old       string
+	audio     string
+	video     string // A video playing, muted or not
+	loading   string
+	arrow     string
+	ellipsis  string
+	separator string
+	up        string
+	down      string
+	group     string // Marks the other members of the focused tab's duplicate group
+}
+
+var unicodeSymbols = symbols{
+	cursor:    "→ ",
+	checked:   "[✓]",
+	unchecked: "[ ]",
+	old:       " 🕐", // Clock emoji for old tabs
+	audio:     " 🔊",
+	video:     " ▶",
+	loading:   " ⏳",
+	arrow:     "→",
+	ellipsis:  "…",
+	separator: " • ",
+	up:        "↑",
+	down:      "↓",
+	group:     "┃ ",
+}
+
+// asciiSymbols replace every non-ASCII glyph for --ascii mode
+var asciiSymbols = symbols{
+	cursor:    "> ",
+	checked:   "[x]",
+	unchecked: "[ ]",
+	old:       " (old)",
+	audio:     " (audio)",
+	video:     " (playing)",
+	loading:   " (loading)",
+	arrow:     "->",
+	ellipsis:  "...",
+	separator: " | ",
+	up:        "up",
+	down:      "down",
+	group:     "| ",
+}
+
+var sym = unicodeSymbols
+
 type Tab struct {
-	WindowIndex int
-	TabIndex    int
-	Title       string
-	URL         string
-	DuplicateOf *int
-	Selected    bool
-	LastVisit   time.Time
-	IsOld       bool // True if last visited > 30 days ago
+	ID            int // Stable across refreshes in the TUI, see tabIDs; elsewhere the place in the scan, see numberTabs
+	WindowIndex   int
+	TabIndex      int
+	Title         string
+	URL           string
+	DuplicateOf   int  // ID of the tab this one duplicates, 0 if none
+	Selected      bool // Suggested for closing; the TUI keeps its selection in model.selected
+	LastVisit     time.Time
+	LastActivated time.Time // When the tab itself was last active, from Safari's session state; zero if unknown
+	FirstSeen     time.Time // When this tool first saw the tab's URL open
+	FirstVisit    time.Time // First visit on this device, roughly when the tab was opened
+	Burst         time.Time // Start of the burst of tabs this one was opened in, zero if none
+	Project       string    // Inferred from URLs shared with other tabs, empty if none
+	SearchQuery   string    // Normalized query of a search results tab, see searchQuery
+	DocSet        string    // Project a documentation tab documents, see docSet
+	Product       bool      // True for a shop's product page, see isProduct
+	ProductName   string    // Read from the page with -prices, empty if unknown
+	Price         string    // Read from the page with -prices, like "19.99 USD"; empty if unknown
+	Language      string    // Probable ISO 639-1 language, see tabLanguage; empty if unknown
+	IsOld         bool      // True if not visited or active for -age days, or as the "old" setting decides
+	Loading       bool      // True if the page has not finished loading
+	PlaysAudio    bool      // True if the page has unmuted media playing
+	PlaysVideo    bool      // True if the page has a video playing, muted or not
+	MemoryMB      int       // Estimated share of its site's web content processes, 0 if unknown; see markMemory
+	CPU           float64   // Estimated share of their CPU use, in percent of a core
+	SuspendedURL  string    // Page a suspended tab stands in for, empty if not suspended; see suspend.go
+	HistoryURL    string    // Variant of the URL the visit times came from, if not the URL itself; see historyMatch
+
+	ReadingMinutes int    // Estimated reading time, 0 if unknown
+	Category       string // e.g. "news" or "docs", empty if unknown
+
+	Video         bool // True for a video page, see isVideo
+	VideoSeconds  int  // Length of the video, 0 if unknown
+	VideoPosition int  // How far the video was watched in seconds, 0 if unknown
+
+	Protected     bool     // Set by a rule; never selected or closed
+	Tags          []string // Set by rules
+	ArchiveTarget string   // Markdown file set by a rule, empty for the default
+
+	ReadElsewhere bool   // Read on another device well after the last visit here, so this copy is stale
+	SavedIn       string // Where the Reading List or bookmarks have the page, see markSavedElsewhere
+	Blocked       bool   // On the blocklist; preselected, and closed by serve mode
+	Expired       string // "meeting" for a meeting that is over, or the past date in the URL; empty if not expired
+	Resolved      string // "merged", "closed" or "done" for a finished pull request, issue or ticket, see -check-resolved
+
+	Display     string // Display the tab's window is on, see markDisplays; empty if unknown or there is only one
+	DisplayGone bool   // Display is no longer connected, and the window was moved off it
+
+	Source  string // For a saved item, sourceReadingList or sourceBookmarks; empty for an open tab
+	SavedID string // A saved item's WebBookmarkUUID
+
+	Pinned bool // Taken for a pinned tab by the pinned heuristic; never selected or closed
+}
+
+// lockReason says why a tab can never be selected, or is empty if it can.
+func (t Tab) lockReason() string {
+	switch {
+	case t.Pinned:
+		return tr("pinned")
+	case t.Protected:
+		return tr("protected")
+	}
+	return ""
+}
+
+func (t Tab) locked() bool {
+	return t.Pinned || t.Protected
 }
 
+// An item is a list row for a tab. It points into the model's tabs and
+// selection instead of copying the tab, so the list stays small however many
+// tabs there are, and selection changes show without rebuilding it.
 type item struct {
-	tab   Tab
-	index int
+	tabs     []Tab  // The model's tabs
+	selected bitset // The model's selection
+	index    int    // Index of the tab in tabs
+	excluded string // Why the tab is normally hidden, empty if it isn't
+	group    int    // Index of the original of the tab's duplicate group, -1 if it has no duplicates
+	similar  string // URL of the tab this one is a duplicate of, when the URLs are only similar
+	heading  string // Heading of the group the item starts, when the list is grouped
 }
 
-func (i item) FilterValue() string { return i.tab.Title }
+func (i item) tab() *Tab { return &i.tabs[i.index] }
+
+func (i item) FilterValue() string { return i.tab().Title }
+
+type itemDelegate struct {
+	icons   map[string]string // Rendered favicon per domain, nil when disabled
+	badges  bool              // Show [DUP]/[OLD] text badges in addition to color
+	wrap    bool              // Wrap long titles and URLs over two lines instead of truncating
+	grouped bool              // Start every item with a line for its group's heading
+	layout  itemLayout        // Fields on each line, see itemLayout
+}
 
-type itemDelegate struct{}
+const minTextWidth = 10
+
+// Height is a line per line of the layout, with the title and a URL on a
+// line of its own doubled when wrapping.
+func (d itemDelegate) Height() int {
+	height := len(d.layout)
+	if d.wrap {
+		height++
+		for _, fields := range d.layout[1:] {
+			if urlLine(fields) {
+				height++
+			}
+		}
+	}
+	if d.grouped {
+		// The heading line takes the place of the spacing
+		height++
+	}
+	return height
+}
+
+func (d itemDelegate) Spacing() int {
+	if d.grouped {
+		return 0
+	}
+	return 1
+}
 
-func (d itemDelegate) Height() int                             { return 3 }
-func (d itemDelegate) Spacing() int                            { return 1 }
 func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
 	i, ok := listItem.(item)
@@ -62,59 +218,184 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		return
 	}
 
-	// Check if this item is currently focused
+	// Check if this item is currently focused, or in the focused tab's
+	// duplicate group
 	isFocused := index == m.Index()
-	cursor := "  "
+	cursor := strings.Repeat(" ", textWidth(sym.cursor))
 	if isFocused {
-		cursor = "→ "
+		cursor = sym.cursor
+	} else if focused, ok := m.SelectedItem().(item); ok && i.group >= 0 && i.group == focused.group {
+		cursor = sym.group
 	}
 
-	checkbox := "[ ]"
-	if i.tab.Selected {
-		checkbox = "[✓]"
+	checkbox := sym.unchecked
+	if i.selected.has(i.index) {
+		checkbox = sym.checked
 	}
 
-	var title string
 	var ageIndicator string
-	if i.tab.IsOld {
-		ageIndicator = " 🕐" // Clock emoji for old tabs
+	if i.tab().IsOld {
+		ageIndicator = sym.old
 	}
 
-	titleText := fmt.Sprintf("%s%s %s%s", cursor, checkbox, i.tab.Title, ageIndicator)
+	var stateIndicator string
+	if i.tab().PlaysAudio {
+		stateIndicator += sym.audio
+	} else if i.tab().Video && i.tab().PlaysVideo {
+		stateIndicator += sym.video
+	}
+	if i.tab().Loading {
+		stateIndicator += sym.loading
+	}
 
-	if i.tab.DuplicateOf != nil {
-		title = duplicateStyle.Render(titleText)
-	} else if i.tab.IsOld {
-		title = oldTabStyle.Render(titleText)
-	} else {
-		title = normalStyle.Render(titleText)
+	var icon string
+	if d.icons != nil {
+		if cell, ok := d.icons[extractDomain(i.tab().URL)]; ok {
+			icon = cell + " "
+		}
+	}
+
+	badges := sourceLabel(i.tab().Source)
+	if badges != "" {
+		badges += " "
+	}
+	if i.tab().Blocked {
+		badges += tr("[BLOCKED]") + " "
+	}
+	if d.badges {
+		if i.tab().DuplicateOf != 0 {
+			badges += tr("[DUP]") + " "
+		}
+		if i.tab().IsOld {
+			badges += tr("[OLD]") + " "
+		}
+	}
+
+	// Fit the title between its prefix and indicators, and whatever else
+	// the first line of the layout holds, to the list width
+	prefix := fmt.Sprintf("%s%s %s", cursor, checkbox, badges)
+	suffix := ageIndicator + stateIndicator
+	indent := strings.Repeat(" ", textWidth(prefix))
+	if icon != "" {
+		indent += "   " // Icons are two cells plus a space
+	}
+	width := max(minTextWidth, m.Width())
+	var rest string
+	if fields := d.layout[0][1:]; len(fields) > 0 {
+		if rest = layoutText(fields, i, width/2); rest != "" {
+			rest = sym.separator + rest
+		}
+	}
+	titleWidth := max(minTextWidth, width-textWidth(indent+suffix+rest))
+
+	// Every item renders exactly Height() lines so the pages stay aligned
+	shownTitle := singleLine(i.tab().Title)
+	titleLines := []string{truncateEnd(shownTitle, titleWidth)}
+	if d.wrap {
+		titleLines = wrapText(shownTitle, titleWidth, 2)
+		for len(titleLines) < 2 {
+			titleLines = append(titleLines, "")
+		}
+	}
+
+	titleText := prefix + titleLines[0] + suffix
+	for _, line := range titleLines[1:] {
+		titleText += "\n" + indent + line
+	}
+
+	// Lines are styled one by one, as lipgloss would pad a wrapped title's
+	// lines to the width it measures, which differs from textWidth's for
+	// emoji
+	style := normalStyle
+	if i.excluded != "" {
+		style = helpStyle
+	} else if i.tab().DuplicateOf != 0 {
+		style = duplicateStyle
+	} else if i.tab().IsOld {
+		style = oldTabStyle
 	}
+	title := renderLines(style, titleText)
 
 	// Add visual emphasis to focused item
 	if isFocused {
-		title = lipgloss.NewStyle().Bold(true).Render(title)
+		title = renderLines(lipgloss.NewStyle().Bold(true), title)
+	}
+	if rest != "" {
+		first, more, _ := strings.Cut(title, "\n")
+		title = first + helpStyle.Render(rest)
+		if more != "" {
+			title += "\n" + more
+		}
 	}
 
-	urlLine := helpStyle.Render(fmt.Sprintf("    URL: %s", i.tab.URL))
+	// The icon carries its own color escapes, so it stays outside the styles
+	lines := []string{icon + title}
+	for _, fields := range d.layout[1:] {
+		if urlLine(fields) {
+			lines = append(lines, d.renderURL(i, width))
+		} else {
+			lines = append(lines, helpStyle.Render("    "+layoutText(fields, i, width-4)))
+		}
+	}
 
-	var duplicateInfo string
-	if i.tab.DuplicateOf != nil {
-		duplicateInfo = helpStyle.Render(fmt.Sprintf("    → Duplicate of tab #%d", *i.tab.DuplicateOf+1))
-	} else {
-		infoStr := fmt.Sprintf("    Window %d, Tab %d", i.tab.WindowIndex, i.tab.TabIndex)
-		if i.tab.IsOld && !i.tab.LastVisit.IsZero() {
-			daysSince := int(time.Since(i.tab.LastVisit).Hours() / 24)
-			infoStr += fmt.Sprintf(" • Last visited %d days ago", daysSince)
+	if d.grouped {
+		// Blank unless the item starts a group, spacing the items apart
+		var heading string
+		if i.heading != "" {
+			heading = lipgloss.NewStyle().Bold(true).Render(truncateEnd(i.heading, width))
 		}
-		duplicateInfo = helpStyle.Render(infoStr)
+		fmt.Fprintln(w, heading)
+	}
+	fmt.Fprint(w, strings.Join(lines, "\n"))
+}
+
+// renderURL renders an item's URL after its label, on two lines when
+// wrapping, with what differs from a similar original highlighted.
+func (d itemDelegate) renderURL(i item, width int) string {
+	urlLabel := "    " + tr("URL:") + " "
+	urlWidth := max(minTextWidth, width-textWidth(urlLabel))
+
+	urlMaxLines := 1
+	if d.wrap {
+		urlMaxLines = 2
+	}
+
+	var urlLines []string
+	shownURL := displayURL(i.tab().URL)
+	switch {
+	case i.similar != "":
+		// Highlight what differs from the original, already styled
+		urlLines = highlightDiff(shownURL, urlDiff(shownURL, displayURL(i.similar)), urlWidth, urlMaxLines)
+	case d.wrap:
+		urlLines = wrapURL(shownURL, urlWidth, 2)
+	default:
+		urlLines = []string{truncateMiddle(shownURL, urlWidth)}
+	}
+	for len(urlLines) < urlMaxLines {
+		urlLines = append(urlLines, "")
 	}
 
-	fmt.Fprintf(w, "%s\n%s\n%s", title, urlLine, duplicateInfo)
+	var urlLine string
+	for n, line := range urlLines {
+		label := urlLabel
+		if n > 0 {
+			urlLine += "\n"
+			label = strings.Repeat(" ", textWidth(urlLabel))
+		}
+		if i.similar == "" {
+			line = helpStyle.Render(line)
+		}
+		urlLine += helpStyle.Render(label) + line
+	}
+	return urlLine
 }
 
 type model struct {
 	list                   list.Model
 	tabs                   []Tab
+	selected               bitset // Selected tabs, by index in tabs
+	ids                    *tabIDs
+	dupes                  *dedupeCache // Duplicates found so far, so refreshes only compare the tabs that changed
 	quitting               bool
 	closing                bool
 	ageDays                int // Age threshold in days
@@ -124,6 +405,198 @@ type model struct {
 	closingDone            bool
 	message                string
 	emptyPinnedOnlyWindows []int // Windows that only contain pinned tabs
+	archiver               archiver
+	archiving              []archiveStatus // Targets of the archive in progress
+	archiveUpdates         <-chan archiveProgressMsg
+	filter                 tabFilter
+	delegate               itemDelegate
+	toast                  string // Transient action result shown in the status bar
+	toastID                int
+	closedCount            int                 // Tabs closed by the last close, reported after the refresh
+	closeNotes             string              // What to add about those, see queuedNote and savedNote
+	showHelp               bool                // Show every key instead of context hints
+	showExcluded           bool                // Show pinned, protected and filtered out tabs, dimmed
+	jumpedFrom             int                 // ID of the duplicate the last jump to an original started at, 0 if none
+	review                 *dedupeReview       // Set while reviewing duplicates pair by pair
+	domainStats            *domainStatsScreen  // Set while the stats screen is shown
+	mover                  *moverScreen        // Set while moving tabs between windows
+	sessions               *sessionBrowser     // Set while browsing saved sessions
+	stages                 *pipeline           // Set while the history, page checks or duplicates are still coming in
+	growthWarning          string              // Growth alert shown in the header, if any
+	unavailable            string              // Features turned off for want of a permission, see unavailableNote
+	macros                 map[string][]string // Saved macros by key
+	recording              bool
+	macroKeys              []string // Keys recorded so far
+	macroPrompt            string   // macroPromptSave or macroPromptPlay while waiting for a macro key
+	macroQueue             []string // Keys of the playing macro still to be fed to Update
+	sortBy                 string   // One of sortOrders, empty for window order
+	groupBy                string   // One of groupings
+	views                  []savedView
+	viewMenu               bool   // Show the view menu instead of the list
+	naming                 bool   // Typing the name to save the current view under
+	viewName               string // Name typed so far
+	actionMenu             string // Page of the action menu shown instead of the list, empty when closed
+	actionInput            string // Tag or file typed so far
+	extraArchiveTargets    []string
+	manualTags             map[string][]string // Tags set from the action menu, by URL
+	moving                 bool                // Tabs are being moved to another window
+	confirmQuit            bool                // Asking whether to quit with unfinished work
+	confirmClose           []Tab               // Tabs of a large close waiting for their count to be typed, see largeClose
+	confirmCount           string              // Count typed so far
+	closeSnapshot          string              // Session the tabs of a large close were saved as
+	quitAfterClosing       bool
+	quitMessage            string   // Shown on quitting instead of the cancel message
+	announcements          []string // Lines to print after the current update, see announce
+}
+
+// suggestedSelection turns the selection tabs are loaded with, from
+// duplicate detection, age and rules, into the model's selection.
+func suggestedSelection(tabs []Tab) bitset {
+	selected := newBitset(len(tabs))
+	for i := range tabs {
+		selected.set(i, tabs[i].Selected)
+	}
+	return selected
+}
+
+// selectedTabs returns the selected tabs in window order.
+func (m model) selectedTabs() []Tab {
+	var tabs []Tab
+	for i := range m.tabs {
+		if m.selected.has(i) {
+			tabs = append(tabs, m.tabs[i])
+		}
+	}
+	return tabs
+}
+
+func (m model) countSelected() int {
+	return m.selected.count()
+}
+
+// refreshItems rebuilds the list items from m.tabs, leaving out excluded
+// tabs unless showExcluded is on.
+func (m *model) refreshItems() {
+	items := make([]item, 0, len(m.tabs))
+	groups := duplicateGroups(m.tabs)
+	byID := indexByID(m.tabs)
+	for i, tab := range m.tabs {
+		excluded := m.excludeReason(tab)
+		if excluded == "" || m.showExcluded {
+			var similar string
+			if original, ok := byID[tab.DuplicateOf]; ok && canonicalURL(m.tabs[original].URL) != canonicalURL(tab.URL) {
+				similar = m.tabs[original].URL
+			}
+			items = append(items, item{tabs: m.tabs, selected: m.selected, index: i, excluded: excluded, group: groups[i], similar: similar})
+		}
+	}
+	orderItems(items, m.sortBy, m.groupBy)
+
+	listItems := make([]list.Item, len(items))
+	for i := range items {
+		listItems[i] = items[i]
+	}
+	m.list.SetItems(listItems)
+}
+
+// duplicateGroups returns, for every tab, the index of the original its
+// duplicate group is built around, or -1 for tabs without duplicates.
+func duplicateGroups(tabs []Tab) []int {
+	groups := make([]int, len(tabs))
+	for i := range groups {
+		groups[i] = -1
+	}
+	byID := indexByID(tabs)
+	for i := range tabs {
+		root, ok := byID[tabs[i].DuplicateOf]
+		if !ok {
+			continue
+		}
+		// Follow the chain, since an original can itself be a duplicate
+		for steps := 0; steps < len(tabs); steps++ {
+			next, ok := byID[tabs[root].DuplicateOf]
+			if !ok {
+				break
+			}
+			root = next
+		}
+		groups[i] = root
+		groups[root] = root
+	}
+	return groups
+}
+
+// focusTab moves the cursor to the tab at index in m.tabs, reporting false
+// if the tab isn't in the list.
+func (m *model) focusTab(index int) bool {
+	for pos, listItem := range m.list.Items() {
+		if listItem.(item).index == index {
+			m.list.Select(pos)
+			return true
+		}
+	}
+	return false
+}
+
+// jumpInGroup moves from a duplicate to its original, and from an original
+// back to the duplicate last jumped from, or else its first duplicate.
+func (m *model) jumpInGroup() tea.Cmd {
+	focused, ok := m.list.SelectedItem().(item)
+	if !ok || focused.group < 0 {
+		return m.showToast(tr("This tab has no duplicates."))
+	}
+
+	target := focused.group
+	if focused.index == focused.group {
+		groups := duplicateGroups(m.tabs)
+		target = -1
+		if from := m.indexOf(m.jumpedFrom); from >= 0 && groups[from] == focused.index {
+			target = from
+		} else {
+			for i, group := range groups {
+				if group == focused.index && i != focused.index {
+					target = i
+					break
+				}
+			}
+		}
+	} else {
+		m.jumpedFrom = focused.tab().ID
+	}
+
+	if target < 0 || !m.focusTab(target) {
+		return m.showToast(tr("The other tab is excluded from the list; press 'h' to show it."))
+	}
+	return nil
+}
+
+// excludeReason says why a tab is left out of the list: it's pinned,
+// protected, or doesn't match the filter. It is empty for tabs that are shown.
+func (m model) excludeReason(tab Tab) string {
+	if reason := tab.lockReason(); reason != "" {
+		return reason
+	}
+	if !m.filter.matches(tab) {
+		return tr("filtered out by %s", m.filter.String())
+	}
+	return ""
+}
+
+// nextCategoryFilter cycles the filter through the categories present in
+// the tabs, ending with no filter.
+func (m *model) nextCategoryFilter() {
+	categories := tabCategories(m.tabs)
+	next := ""
+	if m.filter.category == "" && len(categories) > 0 {
+		next = categories[0]
+	}
+	for i, category := range categories {
+		if category == m.filter.category && i+1 < len(categories) {
+			next = categories[i+1]
+		}
+	}
+	m.filter.category = next
+	m.refreshItems()
 }
 
 // Messages for async operations
@@ -133,23 +606,55 @@ type tabClosedMsg struct {
 }
 
 type closingCompleteMsg struct {
-	count int
+	count        int
+	closed       []Tab // The tabs closed, as they were in the list
+	queued       int   // Tabs closed that are queued to archive later
+	savedPending int   // Saved items closed that wait for Safari to quit to be removed
+	savedErr     error // Why saved items couldn't be removed
+}
+
+// closeAbortedMsg reports that a pre_close hook cancelled closing
+type closeAbortedMsg struct {
+	err error
 }
 
 type tabsRefreshedMsg struct {
 	tabs         []Tab
 	emptyWindows []int
+	err          error
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	if m.stages != nil {
+		return tea.Batch(m.setWindowTitle(), m.stages.start(m.ageDays, m.dupes))
+	}
+	return m.setWindowTitle()
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+// update handles msg, see Update.
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.list.SetWidth(msg.Width)
-		m.list.SetHeight(msg.Height - 4)
+		// Leave room for the header with its category, domain, budget and
+		// growth lines, the message line and the status bar
+		height := msg.Height - 7
+		if tabBudget > 0 {
+			height--
+		}
+		if m.growthWarning != "" {
+			height--
+		}
+		if m.unavailable != "" {
+			height--
+		}
+		m.list.SetHeight(height)
+		return m, nil
+
+	case toastExpiredMsg:
+		if msg.id == m.toastID {
+			m.toast = ""
+		}
 		return m, nil
 
 	case tabClosedMsg:
@@ -159,116 +664,346 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case archiveProgressMsg:
+		if msg.index < len(m.archiving) {
+			m.archiving[msg.index].done = true
+			m.archiving[msg.index].err = msg.err
+		}
+		return m, waitArchiveProgress(m.archiveUpdates)
+
+	case archiveFailedMsg:
+		m.closing = false
+		m.archiving = nil
+		m.macroQueue = nil
+		return m, m.showToast(tr("Archiving failed, no tabs were closed: %v", msg.err))
+
+	case closeAbortedMsg:
+		m.closing = false
+		m.archiving = nil
+		m.macroQueue = nil
+		return m, m.showToast(tr("Closing cancelled, no tabs were closed: %v", msg.err))
+
 	case closingCompleteMsg:
+		if m.quitAfterClosing {
+			m.quitting = true
+			m.quitMessage = tr("Successfully closed %d tabs.", msg.count) + queuedNote(msg.queued) + savedNote(msg.savedPending, msg.savedErr) + snapshotNote(m.closeSnapshot)
+			return m, tea.Quit
+		}
 		m.closingDone = true
-		m.message = fmt.Sprintf("Successfully closed %d tabs. Refreshing...", msg.count)
-		return m, refreshTabsCmd(m.ageDays)
+		m.closedCount = msg.count
+		m.closeNotes = queuedNote(msg.queued) + savedNote(msg.savedPending, msg.savedErr) + snapshotNote(m.closeSnapshot)
+		m.closeSnapshot = ""
+		for _, tab := range msg.closed {
+			if i := m.indexOf(tab.ID); i >= 0 {
+				m.selected.set(i, false)
+			}
+			m.announce(tr("Closed tab: %s", tab.Title))
+		}
+		m.ids.forget(msg.closed)
+		m.message = tr("Successfully closed %d tabs. Refreshing...", msg.count)
+		return m, refreshTabsCmd(m.ageDays, m.dupes)
+
+	case sessionRestoredMsg:
+		if m.sessions != nil {
+			m.sessions.restored(msg)
+		}
+		return m, refreshTabsCmd(m.ageDays, m.dupes)
+
+	case tabsMovedMsg:
+		m.moving = false
+		if msg.err != nil {
+			return m, m.showToast(tr("Moving failed: %v", msg.err))
+		}
+		return m, tea.Batch(m.showToast(tr("Moved %d tabs.", msg.count)), refreshTabsCmd(m.ageDays, m.dupes))
+
+	case tabsSuspendedMsg:
+		if msg.err != nil && msg.restore {
+			return m, m.showToast(tr("Restoring failed: %v", msg.err))
+		}
+		if msg.err != nil {
+			return m, m.showToast(tr("Suspending failed: %v", msg.err))
+		}
+		toast := tr("Suspended %d tabs.", msg.count)
+		if msg.restore {
+			toast = tr("Restored %d tabs.", msg.count)
+		}
+		return m, tea.Batch(m.showToast(toast), refreshTabsCmd(m.ageDays, m.dupes))
+
+	case windowsLabeledMsg:
+		if msg.err != nil {
+			return m, m.showToast(tr("Labeling windows failed: %v", msg.err))
+		}
+		if msg.count == 0 {
+			return m, m.showToast(tr("No window has a project to label it with."))
+		}
+		return m, tea.Batch(m.showToast(tr("Labeled %d windows.", msg.count)), refreshTabsCmd(m.ageDays, m.dupes))
+
+	case tabsSharedMsg:
+		return m, m.copyShared(msg)
+
+	case stageDoneMsg:
+		if msg.pipeline != m.stages {
+			return m, nil
+		}
+		m.stages.finish(msg)
+		return m, m.applyStages()
+
+	case stageTickMsg:
+		if msg.pipeline != m.stages {
+			return m, nil
+		}
+		cmd := m.applyStages()
+		if m.stages != nil {
+			cmd = tea.Batch(cmd, m.stages.tick())
+		}
+		return m, cmd
 
 	case tabsRefreshedMsg:
-		m.tabs = msg.tabs
-		m.emptyPinnedOnlyWindows = msg.emptyWindows
+		toast := tr("Tabs refreshed.")
+		if msg.err != nil {
+			// Keep the list and its selection rather than losing both
+			toast = tr("Refreshing failed, the list may be out of date: %v", msg.err)
+		} else {
+			if m.stages != nil {
+				// The refresh ran every stage, so the run can be logged
+				// and what's still running is stale
+				logRun(msg.tabs)
+				m.stages = nil
+			}
+			m.ids.assign(msg.tabs)
+			m.selected = m.keepSelection(msg.tabs)
+			m.tabs = msg.tabs
+			m.emptyPinnedOnlyWindows = msg.emptyWindows
+			if m.mover != nil {
+				m.syncMover()
+			}
+		}
+		if m.closingDone {
+			toast = tr("Successfully closed %d tabs.", m.closedCount) + " " + toast + m.closeNotes
+		}
+
 		m.closing = false
 		m.closingDone = false
 		m.closingTotal = 0
 		m.closingCurrent = 0
+		m.archiving = nil
+		m.closeNotes = ""
 
 		// Update list items
-		items := make([]list.Item, len(m.tabs))
-		for i, tab := range m.tabs {
-			items[i] = item{tab: tab, index: i}
+		m.applyManualTags()
+		m.refreshItems()
+		m.message = ""
+		cmd := tea.Batch(m.showToast(toast), m.setWindowTitle())
+		if len(m.macroQueue) > 0 {
+			// Carry on with a macro that closed or archived tabs
+			var play tea.Cmd
+			m, play = m.playMacro()
+			cmd = tea.Batch(cmd, play)
 		}
-		m.list.SetItems(items)
-		m.message = fmt.Sprintf("Tabs refreshed. Press 'q' to quit.")
-		return m, nil
+		return m, cmd
 
 	case tea.KeyMsg:
-		// Don't accept input while closing
+		if m.confirmQuit {
+			return m, m.updateConfirmQuit(msg)
+		}
+		if m.confirmClose != nil {
+			return m, m.updateConfirmClose(msg)
+		}
+
+		// Don't accept input while closing, except for quitting
 		if m.closing && !m.closingDone {
+			if key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))) {
+				return m, m.requestQuit()
+			}
 			return m, nil
 		}
+		if cmd, handled := m.updateMacro(msg); handled {
+			if len(m.macroQueue) > 0 {
+				var play tea.Cmd
+				m, play = m.playMacro()
+				cmd = tea.Batch(cmd, play)
+			}
+			return m, cmd
+		}
+
+		if m.review != nil {
+			return m, m.updateReview(msg)
+		}
+		if m.domainStats != nil {
+			return m, m.updateDomainStats(msg)
+		}
+		if m.mover != nil {
+			return m, m.updateMover(msg)
+		}
+		if m.sessions != nil {
+			return m, m.updateSessions(msg)
+		}
+		if m.viewMenu {
+			return m, m.updateViewMenu(msg)
+		}
+		if m.actionMenu != "" {
+			return m, m.updateActionMenu(msg)
+		}
 
 		switch {
 		case key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))):
-			m.quitting = true
-			return m, tea.Quit
+			return m, m.requestQuit()
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
 			m.list.CursorDown()
+			m.announceTab()
 			return m, nil
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
 			m.list.CursorUp()
+			m.announceTab()
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))) && m.countSelected() > 0:
+			m.openActionMenu()
 			return m, nil
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys(" ", "enter"))):
 			if i, ok := m.list.SelectedItem().(item); ok {
-				m.tabs[i.index].Selected = !m.tabs[i.index].Selected
-				items := make([]list.Item, len(m.tabs))
-				for idx, tab := range m.tabs {
-					items[idx] = item{tab: tab, index: idx}
+				if m.tabs[i.index].Pinned {
+					return m, m.showToast(tr("Pinned tabs are never closed."))
+				}
+				if m.tabs[i.index].Protected {
+					return m, m.showToast(tr("This tab is protected by a rule."))
 				}
-				m.list.SetItems(items)
+				m.selected.set(i.index, !m.selected.has(i.index))
+				m.announceSelection(i.index)
 			}
 			return m, nil
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys("c"))):
-			// Collect tabs to close
-			tabsToClose := []Tab{}
-			for _, tab := range m.tabs {
-				if tab.Selected {
-					tabsToClose = append(tabsToClose, tab)
-				}
-			}
+			tabsToClose := m.selectedTabs()
 
 			if len(tabsToClose) == 0 {
-				m.message = "No tabs selected for closing."
-				return m, nil
+				return m, m.showToast(tr("No tabs selected for closing."))
 			}
 
-			m.closing = true
-			m.closingTotal = len(tabsToClose)
-			m.closingCurrent = 0
-			m.closingDone = false
-			return m, closeTabsAsync(tabsToClose, m.emptyPinnedOnlyWindows)
+			return m, m.closeTabs(tabsToClose)
 
-		case key.Matches(msg, key.NewBinding(key.WithKeys("a"))):
-			for i := range m.tabs {
-				if m.tabs[i].DuplicateOf != nil {
-					m.tabs[i].Selected = true
-				}
-			}
-			items := make([]list.Item, len(m.tabs))
-			for idx, tab := range m.tabs {
-				items[idx] = item{tab: tab, index: idx}
+		case key.Matches(msg, key.NewBinding(key.WithKeys("A"))):
+			// Archive selected tabs, then close them
+			tabsToArchive := m.selectedTabs()
+
+			if len(tabsToArchive) == 0 {
+				return m, m.showToast(tr("No tabs selected for archiving."))
 			}
-			m.list.SetItems(items)
+
+			return m, m.archiveTabs(m.archiver, tabsToArchive)
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("a"))):
+			return m, m.selectWhere("Selected %d duplicates", func(t Tab) bool { return t.DuplicateOf != 0 })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("b"))):
+			return m, m.selectBurst()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("p"))):
+			return m, m.selectProject()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("e"))):
+			return m, m.selectWhere("Selected %d expired tabs", func(t Tab) bool { return t.Expired != "" })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("R"))):
+			return m, m.selectWhere("Selected %d resolved tabs", func(t Tab) bool { return t.Resolved != "" })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("i"))):
+			return m, m.selectDocSet()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("x"))):
+			return m, m.selectWhere("Selected %d stale searches", func(t Tab) bool { return t.SearchQuery != "" && t.DuplicateOf != 0 })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("$"))):
+			return m, m.selectWhere("Selected %d product pages", func(t Tab) bool { return t.Product })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("V"))):
+			return m, m.selectWhere("Selected %d videos", func(t Tab) bool { return t.Video })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("r"))):
+			return m, m.selectWhere("Selected %d long reads", func(t Tab) bool { return t.ReadingMinutes >= longReadMinutes })
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("H"))):
+			return m, m.selectHeaviest()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("U"))):
+			return m, m.restoreSuspended()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("w"))):
+			m.delegate.wrap = !m.delegate.wrap
+			m.list.SetDelegate(m.delegate)
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("v"))):
+			m.viewMenu = true
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("t"))):
+			return m, m.startDomainStats()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("M"))):
+			return m, m.startMover()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("O"))):
+			return m, m.startSessions()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("W"))):
+			return m, tea.Batch(m.showToast(tr("Labeling windows...")), labelWindowsAsync())
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("S"))):
+			m.nextSortOrder()
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("g"))):
+			m.nextGrouping()
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("D"))):
+			return m, m.startReview()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("d"))):
+			return m, m.jumpInGroup()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("h"))):
+			m.showExcluded = !m.showExcluded
+			m.refreshItems()
 			return m, nil
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("?"))):
+			m.showHelp = !m.showHelp
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("f"))):
+			m.nextCategoryFilter()
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("L"))):
+			return m, m.nextLanguageFilter()
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("1", "2", "3", "4", "5"))):
+			return m, m.quickFilter(int(msg.String()[0] - '1'))
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("s"))):
+			// Select everything the current filter shows
+			if !m.filter.active() {
+				return m, m.showToast(tr("Press 'f' or 1-5 to filter first."))
+			}
+			return m, m.selectWhere("Selected %d shown tabs", m.filter.matches)
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("n"))):
+			count := 0
 			for i := range m.tabs {
-				if m.tabs[i].DuplicateOf != nil {
-					m.tabs[i].Selected = false
+				if m.tabs[i].DuplicateOf != 0 && m.selected.has(i) {
+					m.selected.set(i, false)
+					count++
 				}
 			}
-			items := make([]list.Item, len(m.tabs))
-			for idx, tab := range m.tabs {
-				items[idx] = item{tab: tab, index: idx}
-			}
-			m.list.SetItems(items)
-			return m, nil
+			return m, m.showToast(tr("Deselected %d tabs", count))
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys("o"))):
-			for i := range m.tabs {
-				if m.tabs[i].IsOld {
-					m.tabs[i].Selected = true
-				}
-			}
-			items := make([]list.Item, len(m.tabs))
-			for idx, tab := range m.tabs {
-				items[idx] = item{tab: tab, index: idx}
-			}
-			m.list.SetItems(items)
-			return m, nil
+			return m, m.selectWhere("Selected %d old tabs", func(t Tab) bool { return t.IsOld })
 		}
 	}
 
@@ -279,7 +1014,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	if m.quitting {
-		return "Cancelled. No tabs were closed.\n"
+		if m.quitMessage != "" {
+			return m.quitMessage + "\n"
+		}
+		return tr("Cancelled. No tabs were closed.") + "\n"
+	}
+
+	if m.confirmQuit {
+		return m.confirmQuitView()
+	}
+	if m.confirmClose != nil {
+		return m.confirmCloseView()
 	}
 
 	if m.closing {
@@ -289,16 +1034,38 @@ func (m model) View() string {
 		} else {
 			percent := float64(m.closingCurrent) / float64(m.closingTotal)
 			bar := m.progress.ViewAs(percent)
-			status = fmt.Sprintf("Closing tabs... %d/%d\n%s", m.closingCurrent, m.closingTotal, bar)
+			status = tr("Closing tabs... %d/%d", m.closingCurrent, m.closingTotal) + "\n" + bar
 		}
-		return titleStyle.Render(status) + "\n"
+		return titleStyle.Render(status) + "\n" + m.archivingView()
+	}
+
+	if m.review != nil {
+		return m.reviewView()
+	}
+	if m.domainStats != nil {
+		return m.domainStatsView()
+	}
+	if m.mover != nil {
+		return m.moverView()
+	}
+	if m.sessions != nil {
+		return m.sessions.view(m.list.Width(), m.list.Height())
+	}
+	if m.viewMenu {
+		return m.viewMenuView()
+	}
+	if m.actionMenu != "" {
+		return m.actionMenuView()
 	}
 
 	duplicateCount := 0
 	uniqueCount := 0
 	oldCount := 0
 	for _, tab := range m.tabs {
-		if tab.DuplicateOf != nil {
+		if tab.Pinned {
+			continue
+		}
+		if tab.DuplicateOf != 0 {
 			duplicateCount++
 		} else {
 			uniqueCount++
@@ -308,14 +1075,9 @@ func (m model) View() string {
 		}
 	}
 
-	selectedCount := 0
-	for _, tab := range m.tabs {
-		if tab.Selected {
-			selectedCount++
-		}
-	}
+	selectedCount := m.countSelected()
 
-	header := titleStyle.Render(fmt.Sprintf(
+	header := titleStyle.Render(tr(
 		"Safari Tab Manager %s - %d unique, %d duplicates, %d old (>%d days), %d selected to close",
 		Version,
 		uniqueCount,
@@ -325,77 +1087,182 @@ func (m model) View() string {
 		selectedCount,
 	))
 
-	help := helpStyle.Render(
-		"\nk/↑ j/↓: navigate • space/enter: toggle • a: select all duplicates • o: select all old • n: deselect all • c: close selected • q: quit\n",
-	)
+	// Per-category counts, with the active filter highlighted
+	if categories := tabCategories(m.tabs); len(categories) > 0 {
+		counts := make(map[string]int)
+		for _, tab := range m.tabs {
+			if tab.Pinned {
+				continue
+			}
+			counts[tab.Category]++
+		}
+		parts := make([]string, 0, len(categories)+1)
+		for _, category := range categories {
+			part := fmt.Sprintf("%s %d", category, counts[category])
+			if category == m.filter.category {
+				part = "[" + part + "]"
+			}
+			parts = append(parts, part)
+		}
+		if counts[""] > 0 {
+			parts = append(parts, tr("uncategorized %d", counts[""]))
+		}
+		header += "\n" + titleStyle.Render(helpStyle.Render(strings.Join(parts, sym.separator)))
+	}
 
-	var messageDisplay string
-	if m.message != "" {
-		messageDisplay = "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render(m.message) + "\n"
+	// The top domains, as quick filters on the number keys
+	counts := domainCounts(m.tabs)
+	if top := topDomains(counts, quickFilterCount); len(top) > 1 {
+		parts := make([]string, len(top))
+		for i, domain := range top {
+			parts[i] = fmt.Sprintf("%d %s %d", i+1, domain, counts[domain])
+			if domain == m.filter.domain {
+				parts[i] = "[" + parts[i] + "]"
+			}
+		}
+		header += "\n" + titleStyle.Render(helpStyle.Render(strings.Join(parts, sym.separator)))
 	}
 
-	return fmt.Sprintf("%s%s\n\n%s%s", header, messageDisplay, m.list.View(), help)
+	if tabBudget > 0 {
+		header += "\n" + titleStyle.Render(m.budgetBar(len(withoutPinned(openTabs(m.tabs)))))
+	}
+	if m.growthWarning != "" {
+		header += "\n" + titleStyle.Render(duplicateStyle.Render(m.growthWarning))
+	}
+	if m.unavailable != "" {
+		header += "\n" + titleStyle.Render(helpStyle.Render(m.unavailable))
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n%s", header, m.list.View(), m.statusBar())
 }
 
+// closeTabsAsync closes tabs, and removes saved items among them from
+// Safari's bookmarks, see removeSaved.
 func closeTabsAsync(tabsToClose []Tab, emptyWindows []int) tea.Cmd {
+	open, saved := splitSaved(tabsToClose)
+	if len(saved) == 0 {
+		return closeOpenTabsAsync(open, emptyWindows)
+	}
+	// Only made when it's run: making it counts a close in flight
+	var closeOpen tea.Cmd
+	if len(open) > 0 {
+		closeOpen = closeOpenTabsAsync(open, emptyWindows)
+	}
 	return func() tea.Msg {
-		// Get current Safari state to match tabs by URL
-		currentTabs, err := getSafariTabsRaw()
-		if err != nil {
-			log.Printf("Error getting current tabs: %v", err)
-			return closingCompleteMsg{count: 0}
+		var complete closingCompleteMsg
+		if closeOpen != nil {
+			msg := closeOpen()
+			var ok bool
+			if complete, ok = msg.(closingCompleteMsg); !ok || stopClosing.Load() {
+				return msg
+			}
 		}
-
-		// Build a set of URLs to close
-		urlsToClose := make(map[string]bool)
-		for _, tab := range tabsToClose {
-			urlsToClose[tab.URL] = true
+		complete.savedPending, complete.savedErr = removeSaved(saved)
+		if complete.savedErr == nil {
+			complete.count += len(saved)
+			complete.closed = append(complete.closed, saved...)
 		}
+		return complete
+	}
+}
 
-		// Find matching tabs in current Safari state
-		type windowTab struct {
-			window int
-			tab    int
-			url    string
-		}
+// closeOpenTabsAsync closes open tabs, and the windows left with only pinned
+// tabs. The close counts as in flight from when the command is made, so
+// quitting before Bubble Tea gets to run it still waits for it; it must be
+// run once made.
+func closeOpenTabsAsync(tabsToClose []Tab, emptyWindows []int) tea.Cmd {
+	closesRunning.Add(1)
+	return func() tea.Msg {
+		defer closesRunning.Done()
 
-		tabsToCloseNow := []windowTab{}
-		for _, tab := range currentTabs {
-			if urlsToClose[tab.URL] {
-				tabsToCloseNow = append(tabsToCloseNow, windowTab{
-					window: tab.WindowIndex,
-					tab:    tab.TabIndex,
-					url:    tab.URL,
-				})
-				delete(urlsToClose, tab.URL)
-			}
+		if err := runHook("pre_close", hooks.PreClose, tabsToClose); err != nil {
+			return closeAbortedMsg{err: err}
 		}
 
-		// Sort by window (desc) and tab index (desc)
-		sort.Slice(tabsToCloseNow, func(i, j int) bool {
-			if tabsToCloseNow[i].window != tabsToCloseNow[j].window {
-				return tabsToCloseNow[i].window > tabsToCloseNow[j].window
+		// Close tabs in batches, paced so Safari keeps up, and a large
+		// close chunk by chunk, see closeChunks. The journal keeps the tabs
+		// still to close, so a close cut short can be finished later
+		startedAt := time.Now()
+		var closed []Tab
+		finish := func() closingCompleteMsg {
+			recordStats(len(closed), 0)
+			logActions("close", closed)
+			runHookAndLog("post_close", hooks.PostClose, closed)
+			postClosed(closed)
+			return closingCompleteMsg{count: len(closed), closed: closed}
+		}
+		chunks := closeChunks(tabsToClose)
+		p := newPacer()
+		for c, chunk := range chunks {
+			later := slices.Concat(chunks[c+1:]...)
+			tabsToCloseNow, err := matchOpenTabs(chunk)
+			if err != nil {
+				log.Printf("Error getting current tabs: %v", err)
+				if c == 0 {
+					return closingCompleteMsg{count: 0}
+				}
+				// The rest stay in the journal
+				return finish()
 			}
-			return tabsToCloseNow[i].tab > tabsToCloseNow[j].tab
-		})
+			remaining := make([]Tab, len(tabsToCloseNow))
+			for i, wt := range tabsToCloseNow {
+				remaining[i] = wt.listed
+			}
+			writeJournal(startedAt, slices.Concat(remaining, later))
+
+			// Note the window:tab of the tabs that failed to close
+			failed := make(map[string]bool)
+			for start := 0; start < len(tabsToCloseNow); start += p.batchSize() {
+				if stopClosing.Load() {
+					// Quitting: the rest stay in the journal
+					return finish()
+				}
 
-		// Close tabs one by one
-		for idx, wt := range tabsToCloseNow {
-			applescript := fmt.Sprintf(`
-			tell application "%s"
-				close tab %d of window %d
-			end tell
-			`, safariApp, wt.tab, wt.window)
+				end := min(start+p.batchSize(), len(tabsToCloseNow))
+				var closes strings.Builder
+				for _, wt := range tabsToCloseNow[start:end] {
+					fmt.Fprintf(&closes, `
+					try
+						close tab %d of window %d
+					on error
+						set failed to failed & "%d:%d "
+					end try`, wt.tab, wt.window, wt.window, wt.tab)
+				}
+				applescript := fmt.Sprintf(`
+				tell application "%s"
+					set failed to ""%s
+					return failed
+				end tell
+				`, safariApp, closes.String())
+
+				began := time.Now()
+				output, err := exec.Command("osascript", "-e", applescript).Output()
+				if err != nil {
+					// Without a reply there's no telling which closed, so
+					// none count as closed
+					log.Printf("Warning: failed to close tabs: %v", err)
+					for _, wt := range tabsToCloseNow[start:end] {
+						failed[fmt.Sprintf("%d:%d", wt.window, wt.tab)] = true
+					}
+				} else if failures := strings.TrimSpace(string(output)); failures != "" {
+					log.Printf("Warning: failed to close tabs (window:tab) %s", failures)
+					for _, f := range strings.Fields(failures) {
+						failed[f] = true
+					}
+				}
+				for _, wt := range tabsToCloseNow[start:end] {
+					if !failed[fmt.Sprintf("%d:%d", wt.window, wt.tab)] {
+						closed = append(closed, wt.listed)
+					}
+				}
 
-			cmd := exec.Command("osascript", "-e", applescript)
-			if err := cmd.Run(); err != nil {
-				log.Printf("Warning: failed to close tab %d in window %d: %v", wt.tab, wt.window, err)
+				writeJournal(startedAt, slices.Concat(remaining[end:], later))
+				if end < len(tabsToCloseNow) || len(later) > 0 {
+					p.pace(time.Since(began))
+				}
 			}
-
-			// Send progress update (note: in real bubbletea, we'd use tea.Cmd properly)
-			// For now, we'll just close all at once
-			_ = idx
 		}
+		removeJournal()
 
 		// Close windows that only contained pinned tabs (in descending order)
 		sort.Sort(sort.Reverse(sort.IntSlice(emptyWindows)))
@@ -412,98 +1279,135 @@ func closeTabsAsync(tabsToClose []Tab, emptyWindows []int) tea.Cmd {
 			}
 		}
 
-		return closingCompleteMsg{count: len(tabsToCloseNow)}
+		return finish()
 	}
 }
 
-func refreshTabsCmd(ageDays int) tea.Cmd {
+// A windowTab is a tab where Safari has it, to close, move or navigate.
+type windowTab struct {
+	window int
+	tab    int
+	url    string
+	listed Tab // The tab as it was in the list
+}
+
+// matchOpenTabs finds tabs in Safari as it is now, in the order they can
+// be closed or moved without shifting the indices of those still to go: by
+// window and tab index, last first. A tab still at its place with its URL
+// is taken for itself, so of several tabs with a URL the one asked for is
+// closed; one that moved is taken for the first tab with its URL not
+// taken already.
+func matchOpenTabs(tabs []Tab) ([]windowTab, error) {
+	currentTabs, err := getSafariTabsRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	type place struct{ window, tab int }
+	byPlace := make(map[place]int, len(currentTabs))
+	byURL := make(map[string][]int)
+	for i, tab := range currentTabs {
+		byPlace[place{tab.WindowIndex, tab.TabIndex}] = i
+		byURL[tab.URL] = append(byURL[tab.URL], i)
+	}
+	taken := make([]bool, len(currentTabs))
+
+	var matched []windowTab
+	take := func(i int, listed Tab) {
+		taken[i] = true
+		matched = append(matched, windowTab{
+			window: currentTabs[i].WindowIndex,
+			tab:    currentTabs[i].TabIndex,
+			url:    currentTabs[i].URL,
+			listed: listed,
+		})
+	}
+	var moved []Tab
+	for _, tab := range tabs {
+		i, ok := byPlace[place{tab.WindowIndex, tab.TabIndex}]
+		if ok && !taken[i] && currentTabs[i].URL == tab.URL {
+			take(i, tab)
+		} else {
+			moved = append(moved, tab)
+		}
+	}
+	for _, tab := range moved {
+		for _, i := range byURL[tab.URL] {
+			if !taken[i] {
+				take(i, tab)
+				break
+			}
+		}
+	}
+
+	// Sort by window (desc) and tab index (desc)
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].window != matched[j].window {
+			return matched[i].window > matched[j].window
+		}
+		return matched[i].tab > matched[j].tab
+	})
+	return matched, nil
+}
+
+// refreshTabsCmd reads the tabs again, only redoing the duplicate
+// comparisons dupes doesn't have.
+func refreshTabsCmd(ageDays int, dupes *dedupeCache) tea.Cmd {
 	return func() tea.Msg {
 		tabs, emptyWindows, err := getSafariTabs(ageDays)
 		if err != nil {
 			log.Printf("Error refreshing tabs: %v", err)
-			return tabsRefreshedMsg{tabs: []Tab{}, emptyWindows: []int{}}
+			return tabsRefreshedMsg{err: err}
 		}
 
-		tabs = findDuplicates(tabs)
+		tabs = applyRules(dupes.findDuplicates(tabs))
 		return tabsRefreshedMsg{tabs: tabs, emptyWindows: emptyWindows}
 	}
 }
 
+// readTabState is whether getSafariTabsRaw reads the loading, audio and
+// video state of tabs. Commands that only count tabs turn it off, since it
+// takes a JavaScript call per tab.
+var readTabState = true
+
+// getSafariTabsRaw lists Safari's tabs, or those of onlyWindowID, as
+// Safari has them. Loading, audio and video state can only be read with
+// "Allow JavaScript from Apple Events" enabled; without it, or past
+// tabStateBudget, the state is left empty.
 func getSafariTabsRaw() ([]Tab, error) {
-	applescript := fmt.Sprintf(`
-	tell application "%s"
-		set output to ""
-		repeat with w from 1 to count of windows
-			repeat with t from 1 to count of tabs of window w
-				set tabTitle to name of tab t of window w
-				set tabURL to URL of tab t of window w
-				set output to output & w & "|||" & t & "|||" & tabTitle & "|||" & tabURL & "###"
-			end repeat
-		end repeat
-		return output
-	end tell
-	`, safariApp)
-
-	cmd := exec.Command("osascript", "-e", applescript)
-	output, err := cmd.Output()
-	if err != nil {
+	if err := featureTabs.require(); err != nil {
 		return nil, fmt.Errorf("failed to get Safari tabs: %w", err)
 	}
-
-	allTabs := []Tab{}
-	lines := strings.Split(strings.TrimSpace(string(output)), "###")
-
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-
-		parts := strings.Split(line, "|||")
-		if len(parts) != 4 {
-			continue
-		}
-
-		var windowIndex, tabIndex int
-		fmt.Sscanf(parts[0], "%d", &windowIndex)
-		fmt.Sscanf(parts[1], "%d", &tabIndex)
-
-		allTabs = append(allTabs, Tab{
-			WindowIndex: windowIndex,
-			TabIndex:    tabIndex,
-			Title:       parts[2],
-			URL:         parts[3],
-			Selected:    false,
-		})
+	script := fmt.Sprintf(tabsScript, appleScriptString(safariApp))
+	budget := 0
+	if readTabState {
+		budget = int(tabStateBudget / time.Second)
 	}
-
-	return allTabs, nil
-}
-
-func getSafariTabs(ageDays int) ([]Tab, []int, error) {
-	allTabs, err := getSafariTabsRaw()
+	output, err := exec.Command("osascript", "-e", script, strconv.Itoa(onlyWindowID), tabStateScript, strconv.Itoa(budget)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Safari tabs: %w", err)
+	}
+	tabs, err := parseTabsReply(output)
 	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("failed to get Safari tabs: %w", err)
 	}
-
-	// Filter out pinned tabs: tabs that appear at the same early position
-	// across multiple windows with the same URL are likely pinned
-	tabs, emptyWindows := filterPinnedTabs(allTabs)
-
-	// Enrich tabs with visit history data
-	tabs = enrichWithVisitData(tabs, ageDays)
-
-	return tabs, emptyWindows, nil
+	return tabs, nil
 }
 
+// readElsewhereGap is how much later than the last local visit a page must
+// have been visited on another device for the local tab to count as read
+// elsewhere.
+const readElsewhereGap = 24 * time.Hour
+
 func enrichWithVisitData(tabs []Tab, ageDays int) []Tab {
-	// Get Safari history database path
-	homeDir, err := os.UserHomeDir()
+	if !capHistory.available() {
+		return tabs
+	}
+	historyPath, err := safariHistoryPath()
 	if err != nil {
 		log.Printf("Warning: could not get home directory: %v", err)
 		return tabs
 	}
-
-	historyPath := filepath.Join(homeDir, "Library", "Safari", "History.db")
 	db, err := sql.Open("sqlite", historyPath)
 	if err != nil {
 		log.Printf("Warning: could not open Safari history: %v", err)
@@ -511,17 +1415,32 @@ func enrichWithVisitData(tabs []Tab, ageDays int) []Tab {
 	}
 	defer db.Close()
 
-	// Build map of URL to last visit time
+	// Build maps of URL to last visit time, overall and per device, and to
+	// the first visit here. Visits synced from other devices have a non-zero
+	// origin.
 	visitTimes := make(map[string]time.Time)
+	variants := make(map[string][]string) // History URLs by historyKey
+	localVisits := make(map[string]time.Time)
+	remoteVisits := make(map[string]time.Time)
+	firstVisits := make(map[string]time.Time)
 
 	query := `
-		SELECT hi.url, MAX(hv.visit_time) as last_visit
+		SELECT hi.url, hv.origin, MAX(hv.visit_time) as last_visit, MIN(hv.visit_time) as first_visit
 		FROM history_items hi
 		JOIN history_visits hv ON hi.id = hv.history_item
-		GROUP BY hi.url
+		GROUP BY hi.url, hv.origin
 	`
 
 	rows, err := db.Query(query)
+	if err != nil {
+		// Older history databases have no origin column
+		rows, err = db.Query(`
+			SELECT hi.url, 0, MAX(hv.visit_time) as last_visit, MIN(hv.visit_time) as first_visit
+			FROM history_items hi
+			JOIN history_visits hv ON hi.id = hv.history_item
+			GROUP BY hi.url
+		`)
+	}
 	if err != nil {
 		log.Printf("Warning: could not query Safari history: %v", err)
 		return tabs
@@ -534,38 +1453,84 @@ func enrichWithVisitData(tabs []Tab, ageDays int) []Tab {
 
 	for rows.Next() {
 		var url string
-		var visitTime float64
-		if err := rows.Scan(&url, &visitTime); err != nil {
+		var origin int
+		var visitTime, firstTime float64
+		if err := rows.Scan(&url, &origin, &visitTime, &firstTime); err != nil {
 			continue
 		}
 
 		// Convert CF Absolute Time to Go time
 		unixTime := int64(visitTime) + cfAbsoluteTimeOffset
-		visitTimes[url] = time.Unix(unixTime, 0)
+		visit := time.Unix(unixTime, 0)
+		if _, ok := visitTimes[url]; !ok {
+			key := historyKey(url)
+			variants[key] = append(variants[key], url)
+		}
+		if visit.After(visitTimes[url]) {
+			visitTimes[url] = visit
+		}
+		if origin == 0 {
+			localVisits[url] = visit
+			firstVisits[url] = time.Unix(int64(firstTime)+cfAbsoluteTimeOffset, 0)
+		} else if visit.After(remoteVisits[url]) {
+			remoteVisits[url] = visit
+		}
 	}
 
 	// Enrich tabs with visit data
 	ageThreshold := time.Now().AddDate(0, 0, -ageDays)
 
 	for i := range tabs {
-		if lastVisit, ok := visitTimes[tabs[i].URL]; ok {
+		// History may have the page under other URLs, see historyMatch
+		url := pickHistoryURL(tabs[i].URL, variants[historyKey(tabs[i].URL)], visitTimes, historyMatch)
+		if url != tabs[i].URL {
+			tabs[i].HistoryURL = url
+		}
+		if lastVisit, ok := visitTimes[url]; ok {
 			tabs[i].LastVisit = lastVisit
 			tabs[i].IsOld = lastVisit.Before(ageThreshold)
 		} else {
 			// If no visit history, consider it old (never visited or very old)
 			tabs[i].IsOld = true
 		}
+		tabs[i].FirstVisit = firstVisits[url]
+
+		// A page read on another device well after it was last looked at
+		// here leaves this copy stale
+		if remote, ok := remoteVisits[url]; ok && remote.Sub(localVisits[url]) > readElsewhereGap {
+			tabs[i].ReadElsewhere = true
+			tabs[i].Selected = !tabs[i].playing() && !tabs[i].Pinned
+		}
 	}
 
+	markBursts(tabs)
 	return tabs
 }
 
-func filterPinnedTabs(allTabs []Tab) ([]Tab, []int) {
-	// Count how many windows have each URL at low tab indices (1-4)
+// pinnedConfig tunes the pinned tab heuristic. Safari doesn't report which
+// tabs are pinned, but pinned tabs appear at the same early position in
+// every window.
+type pinnedConfig struct {
+	MaxPosition int `json:"max_position"` // Only tabs at this position or earlier can be pinned
+	MinWindows  int `json:"min_windows"`  // Windows a URL must share a position in
+}
+
+var pinnedHeuristic = pinnedConfig{MaxPosition: 4, MinWindows: 3} // Overridden from config.json
+
+var includePinned bool // Set by the -include-pinned flag
+
+// markPinnedTabs marks the tabs the pinned heuristic takes for pinned tabs,
+// and returns the windows that contain nothing else.
+func markPinnedTabs(allTabs []Tab) ([]Tab, []int) {
+	if includePinned {
+		return allTabs, nil
+	}
+
+	// Count how many windows have each URL at early tab indices
 	urlPositionCount := make(map[string]map[int]int) // url -> tabIndex -> count
 
 	for _, tab := range allTabs {
-		if tab.TabIndex <= 4 {
+		if tab.TabIndex <= pinnedHeuristic.MaxPosition {
 			if urlPositionCount[tab.URL] == nil {
 				urlPositionCount[tab.URL] = make(map[int]int)
 			}
@@ -573,26 +1538,27 @@ func filterPinnedTabs(allTabs []Tab) ([]Tab, []int) {
 		}
 	}
 
-	// Determine which URLs are pinned (appear at same position in 3+ windows)
+	// Determine which URLs are pinned (appear at the same position in enough windows)
 	pinnedURLs := make(map[string]bool)
 	for url, positionCounts := range urlPositionCount {
 		for _, count := range positionCounts {
-			if count >= 3 {
+			if count >= pinnedHeuristic.MinWindows {
 				pinnedURLs[url] = true
 				break
 			}
 		}
 	}
 
-	// Group tabs by window and track pinned tabs per window
-	windowTabs := make(map[int][]Tab)
+	// Mark pinned tabs and count them per window
 	windowPinnedCount := make(map[int]int)
 	windowTotalCount := make(map[int]int)
 
-	for _, tab := range allTabs {
-		windowTabs[tab.WindowIndex] = append(windowTabs[tab.WindowIndex], tab)
+	for i, tab := range allTabs {
 		windowTotalCount[tab.WindowIndex]++
-		if tab.TabIndex <= 4 && pinnedURLs[tab.URL] {
+		// Only tabs at early positions that match pinned URLs are pinned
+		if tab.TabIndex <= pinnedHeuristic.MaxPosition && pinnedURLs[tab.URL] {
+			allTabs[i].Pinned = true
+			allTabs[i].Selected = false
 			windowPinnedCount[tab.WindowIndex]++
 		}
 	}
@@ -606,45 +1572,42 @@ func filterPinnedTabs(allTabs []Tab) ([]Tab, []int) {
 		}
 	}
 
-	// Filter out pinned tabs
+	return allTabs, emptyWindows
+}
+
+// withoutPinned returns the tabs that aren't pinned.
+func withoutPinned(tabs []Tab) []Tab {
 	var result []Tab
-	for _, tab := range allTabs {
-		// Only exclude tabs at early positions that match pinned URLs
-		if tab.TabIndex <= 4 && pinnedURLs[tab.URL] {
-			continue
+	for _, tab := range tabs {
+		if !tab.Pinned {
+			result = append(result, tab)
 		}
-		result = append(result, tab)
 	}
-
-	return result, emptyWindows
+	return result
 }
 
 func findDuplicates(tabs []Tab) []Tab {
-	for i := range tabs {
-		for j := 0; j < i; j++ {
-			// Exact URL match
-			if tabs[i].URL == tabs[j].URL {
-				idx := j
-				tabs[i].DuplicateOf = &idx
-				tabs[i].Selected = true
-				break
-			}
+	return new(dedupeCache).findDuplicates(tabs)
+}
 
-			// Similar URL (same domain and similar path)
-			if areSimilarURLs(tabs[i].URL, tabs[j].URL) {
-				idx := j
-				tabs[i].DuplicateOf = &idx
-				tabs[i].Selected = true
-				break
-			}
+// markDuplicates points the duplicates dedupeCache.originals found at their
+// originals, and the stale searches at the latest, and suggests closing
+// them.
+func markDuplicates(tabs []Tab, originals []int) {
+	markStaleSearches(tabs)
+	for i, original := range originals {
+		if original < 0 {
+			continue
 		}
+		tabs[i].DuplicateOf = tabs[original].ID
+		tabs[i].Selected = !tabs[i].playing()
 	}
-
-	return tabs
 }
 
 func areSimilarURLs(url1, url2 string) bool {
-	// Simple similarity check: same domain
+	// Simple similarity check: same host. Hosts grouped into one site by
+	// the subdomains policy are still different pages, like mail. and
+	// calendar.google.com
 	domain1 := extractDomain(url1)
 	domain2 := extractDomain(url2)
 
@@ -668,31 +1631,40 @@ func areSimilarURLs(url1, url2 string) bool {
 	return similarity > 0.7
 }
 
-func extractDomain(url string) string {
-	// Simple domain extraction
-	url = strings.TrimPrefix(url, "http://")
-	url = strings.TrimPrefix(url, "https://")
-	url = strings.TrimPrefix(url, "www.")
-
-	parts := strings.Split(url, "/")
-	if len(parts) > 0 {
-		return strings.ToLower(parts[0])
+// extractDomain returns the host of a URL without "www.", see parseURL, or
+// "" for URLs without one, like about:blank or file:// URLs.
+func extractDomain(raw string) string {
+	u, ok := parseURL(raw)
+	if !ok || u.Host == "" {
+		return ""
 	}
-	return ""
+	return strings.TrimPrefix(unicodeHost(u.Host), "www.")
 }
 
-func extractPath(url string) string {
-	parts := strings.SplitN(url, "//", 2)
-	if len(parts) < 2 {
-		return ""
+// domainLabel names a domain for people, including the "" of URLs without
+// one.
+func domainLabel(domain string) string {
+	if domain == "" {
+		return tr("(no domain)")
 	}
+	return domain
+}
 
-	parts = strings.SplitN(parts[1], "/", 2)
-	if len(parts) < 2 {
+// extractPath returns the cleaned path of a URL without a trailing slash,
+// followed by its query and fragment, or "" for the root.
+func extractPath(raw string) string {
+	u, ok := parseURL(raw)
+	if !ok {
 		return ""
 	}
-
-	return "/" + strings.TrimSuffix(parts[1], "/")
+	p := strings.TrimSuffix(cleanPath(u.EscapedPath()), "/")
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		p += "#" + u.EscapedFragment()
+	}
+	return p
 }
 
 func calculateSimilarity(s1, s2 string) float64 {
@@ -764,12 +1736,131 @@ func max(a, b int) int {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "menubar":
+			runMenubar(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "block":
+			runBlock(os.Args[2:])
+			return
+		case "focus":
+			runFocus(os.Args[2:])
+			return
+		case "history":
+			runHistory(os.Args[2:])
+			return
+		case "permissions":
+			runPermissions(os.Args[2:])
+			return
+		case "doctor":
+			runDoctor(os.Args[2:])
+			return
+		case "compat":
+			runCompat(os.Args[2:])
+			return
+		case "restore":
+			runRestore(os.Args[2:])
+			return
+		case "sessions":
+			runSessions(os.Args[2:])
+			return
+		case "autosaves":
+			runAutosaves(os.Args[2:])
+			return
+		case "gc":
+			runGC(os.Args[2:])
+			return
+		case "state":
+			runState(os.Args[2:])
+			return
+		case "downloads":
+			runDownloads(os.Args[2:])
+			return
+		case "saved":
+			runSaved(os.Args[2:])
+			return
+		case "report":
+			runReport(os.Args[2:])
+			return
+		case "count":
+			runCount(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "archive":
+			runArchive(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command-line flags
-	ageDays := flag.Int("age", 30, "Age threshold in days for highlighting old tabs")
-	version := flag.Bool("version", false, "Print version and exit")
-	preview := flag.Bool("preview", false, "Use Safari Technology Preview instead of Safari")
+	ageDays := flag.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	version := flag.Bool("version", false, tr("Print version and exit"))
+	preview := flag.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flag.BoolVar(&fetchTitles, "fetch-titles", false, tr("Fetch page titles over HTTP for blank or \"Untitled\" tabs"))
+	flag.BoolVar(&showFavicons, "favicons", false, tr("Show favicons next to tab titles"))
+	flag.BoolVar(&estimateReadingTime, "reading-time", false, tr("Fetch pages over HTTP to estimate each tab's reading time"))
+	flag.BoolVar(&fetchPrices, "prices", false, tr("Fetch product pages over HTTP for their name and price"))
+	flag.BoolVar(&checkResolved, "check-resolved", false, tr("Ask GitHub, GitLab, Jira and Linear which pull requests, issues and tickets in tabs are finished"))
+	flag.StringVar(&archiveFile, "archive-file", defaultArchiveFile(), tr("Markdown file that archived tabs are appended to, or a Slack or Discord webhook URL to post them to"))
+	ascii := flag.Bool("ascii", false, tr("Use plain ASCII instead of emoji and symbols, and disable styling"))
+	plain := flag.Bool("plain", false, tr("Use numbered prompts instead of the full-screen list (screen reader friendly)"))
+	flag.BoolVar(&announceActions, "announce", false, tr("Describe every action in a line of its own above the list, for screen readers"))
+	profile := flag.String("profile", "", tr("Use the named profile from config.json"))
+	flag.BoolVar(&includePinned, "include-pinned", false, tr("Treat every tab as unpinned, turning off the pinned tab heuristic"))
+	viewName := flag.String("view", "", tr("Start with the named view from config.json"))
+	flag.IntVar(&tabBudget, "budget", 0, tr("Tab budget, the most tabs to keep open, 0 for none"))
+	windowNumber := flag.Int("window", 0, tr("Only scan and show the tabs of window N, numbered frontmost first"))
+	chooseWindow := flag.Bool("pick-window", false, tr("Choose the window to scan from a list at startup"))
+	flag.BoolVar(&includeReadingList, "reading-list", false, tr("Add the Reading List to the list, to triage it along with the tabs"))
+	flag.StringVar(&bookmarksFolder, "bookmarks", "", tr("Add the bookmarks in this folder, like \"Favorites/Read later\", to the list"))
+	var only tabFilter
+	flag.Func("only", tr("Only show tabs matching old, duplicates, read-elsewhere, saved-elsewhere, heavy, age:N, domain:NAME, window:N, display:NAME, category:NAME or lang:CODE (repeatable)"), only.add)
 	flag.Parse()
 
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flag.CommandLine, cfg, ageDays)
+
+	// A view's filter narrows the cleanup just like -only
+	var view savedView
+	if *viewName != "" {
+		var ok bool
+		if view, ok = findView(cfg.Views, *viewName); !ok {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", fmt.Errorf("no view %q in config.json", *viewName)))
+			os.Exit(1)
+		}
+		if err := view.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		for _, term := range strings.Fields(view.Filter) {
+			only.add(term)
+		}
+	}
+
+	// NO_COLOR (https://no-color.org) disables styling; --ascii also
+	// replaces every non-ASCII glyph
+	noColor := os.Getenv("NO_COLOR") != "" || *ascii
+	if noColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if *ascii {
+		sym = asciiSymbols
+		showFavicons = false
+	}
+
 	// Set Safari application based on --preview flag
 	if *preview {
 		safariApp = "Safari Technology Preview"
@@ -777,40 +1868,102 @@ func main() {
 
 	// Handle version flag
 	if *version {
-		fmt.Printf("Safari Tab Manager %s\n", Version)
+		fmt.Println(tr("Safari Tab Manager %s", Version))
 		os.Exit(0)
 	}
 
 	// Validate age
 	if *ageDays < 1 {
-		fmt.Fprintf(os.Stderr, "Error: age must be at least 1 day\n")
+		fmt.Fprintln(os.Stderr, tr("Error: age must be at least 1 day"))
 		os.Exit(1)
 	}
 
-	tabs, emptyWindows, err := getSafariTabs(*ageDays)
+	offerResume(os.Stdin, os.Stdout)
+	applyPendingRemovals()
+
+	switch {
+	case *windowNumber > 0:
+		windows, err := listWindows()
+		if err == nil {
+			onlyWindowID, err = windowByIndex(windows, *windowNumber)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+	case *chooseWindow:
+		pick := pickWindow
+		if *plain {
+			pick = func() (bool, error) { return pickWindowPlain(os.Stdin, os.Stdout) }
+		}
+		chose, err := pick()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		if !chose {
+			return
+		}
+	}
+
+	// The list starts with the tabs as Safari has them and takes in the
+	// history, page checks and duplicates as they come; plain mode waits
+	scan := scanTabs
+	if *plain {
+		scan = func() ([]Tab, []int, error) { return getSafariTabs(*ageDays) }
+	}
+	tabs, emptyWindows, err := scan()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
 		os.Exit(1)
 	}
 
 	if len(tabs) == 0 {
-		fmt.Println("No Safari tabs found. Is Safari running?")
+		fmt.Println(tr("No Safari tabs found. Is Safari running?"))
 		os.Exit(0)
 	}
 
-	tabs = findDuplicates(tabs)
-
-	// Convert tabs to list items
-	items := make([]list.Item, len(tabs))
-	for i, tab := range tabs {
-		items[i] = item{tab: tab, index: i}
+	growth := growthWarningBefore(tabs)
+	unavailable := unavailableNote()
+
+	if *plain {
+		tabs = applyRules(findDuplicates(tabs))
+		logRun(tabs)
+		// A focused cleanup only closes what it shows
+		if only.active() {
+			for i := range tabs {
+				if !only.matches(tabs[i]) {
+					tabs[i].Selected = false
+				}
+			}
+		}
+		var shown []Tab
+		for _, tab := range tabs {
+			if only.matches(tab) {
+				shown = append(shown, tab)
+			}
+		}
+		if growth != "" {
+			fmt.Println(growth)
+		}
+		if unavailable != "" {
+			fmt.Println(unavailable)
+		}
+		runPlain(os.Stdin, os.Stdout, shown, tabs, emptyWindows, *ageDays, archiverFor(archiveFile))
+		return
 	}
 
 	const defaultWidth = 80
 	const listHeight = 20
 
-	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
-	l.Title = "Safari Tabs"
+	// Favicons are transmitted to the terminal before the alt screen starts
+	delegate := itemDelegate{badges: cfg.Badges, layout: cfg.Layout}
+	if showFavicons {
+		delegate.icons = loadFavicons(tabs, os.Stdout)
+	}
+
+	l := list.New(nil, delegate, defaultWidth, listHeight)
+	l.Title = tr("Safari Tabs")
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
 	l.Styles.Title = titleStyle
@@ -821,13 +1974,41 @@ func main() {
 	l.KeyMap.CursorDown.SetEnabled(false)
 
 	// Initialize progress bar
-	prog := progress.New(progress.WithDefaultGradient())
-
-	m := model{list: l, tabs: tabs, ageDays: *ageDays, progress: prog, emptyPinnedOnlyWindows: emptyWindows}
+	progressOpts := []progress.Option{progress.WithDefaultGradient()}
+	if noColor {
+		progressOpts = append(progressOpts, progress.WithColorProfile(termenv.Ascii))
+	}
+	prog := progress.New(progressOpts...)
+	if *ascii {
+		prog.Full = '#'
+		prog.Empty = '-'
+	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+	stages := newPipeline(tabs, only)
+	tabs = stages.tabs()
+	stages.suggested = suggestedSelection(tabs)
+
+	ids := new(tabIDs)
+	ids.assign(tabs)
+	m := model{list: l, tabs: tabs, selected: suggestedSelection(tabs), ids: ids, dupes: new(dedupeCache), ageDays: *ageDays, progress: prog, emptyPinnedOnlyWindows: emptyWindows, archiver: archiverFor(archiveFile), delegate: delegate, filter: only, macros: loadMacros(), views: cfg.Views, extraArchiveTargets: cfg.ArchiveTargets, manualTags: loadManualTags(), growthWarning: growth, unavailable: unavailable, stages: stages}
+	m.applyManualTags()
+	m.sortBy = view.Sort
+	m.setGrouping(view.Group)
+
+	var options []tea.ProgramOption
+	if !announceActions {
+		// Announcements are printed above the list, which the alternate
+		// screen has no room for
+		options = append(options, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(m, options...)
+	stopOnSignal(p)
+	_, err = p.Run()
+	if left := finishClosing(); left != "" {
+		fmt.Println(left)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error running program: %v", err))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Safari runs pages in web content processes and names each one after the
+// site it shows, the names Activity Monitor shows, like
+// "https://github.com". lsappinfo lists those names and ps the memory and
+// CPU use of each process, and a site's processes are shared out among its
+// open tabs. So a tab's cost is an estimate: tabs on one site get equal
+// shares however heavy each page is, and processes without a site name,
+// like cached ones, aren't counted at all. CPU use stands in for energy.
+
+// heavyTabMB and heavyTabCPU are the memory and CPU use, in percent of a
+// core, from which a tab is flagged as heavy.
+const (
+	heavyTabMB  = 300
+	heavyTabCPU = 10.0
+)
+
+// heaviestCount is how many tabs H selects at a time.
+const heaviestCount = 5
+
+// webContentPrefix starts the bundle ids of WebKit's web content
+// processes, Safari's and Safari Technology Preview's alike.
+const webContentPrefix = "com.apple.WebKit.WebContent"
+
+// processUse is what a process uses: resident memory in kilobytes and CPU
+// in percent of a core.
+type processUse struct {
+	memoryKB int
+	cpu      float64
+}
+
+// webContentSites returns the pids of web content processes named after a
+// site, with the site's domain.
+func webContentSites() (map[int]string, error) {
+	output, err := exec.Command("lsappinfo", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list web content processes: %w", err)
+	}
+
+	// Each process is a block starting with its number and quoted name,
+	// followed by indented lines with its bundle id and pid
+	sites := make(map[int]string)
+	var name, bundleID string
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if number, rest, ok := strings.Cut(line, ") \""); ok && isDigits(number) {
+			name, _, _ = strings.Cut(rest, "\"")
+			bundleID = ""
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "bundleID=\""); ok {
+			bundleID = strings.TrimSuffix(value, "\"")
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "pid = ")
+		if !ok || !strings.HasPrefix(bundleID, webContentPrefix) {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.Fields(value)[0])
+		if err != nil {
+			continue
+		}
+		// Processes not showing a site are named "Safari Web Content"
+		if domain := extractDomain(name); strings.Contains(domain, ".") && !strings.Contains(domain, " ") {
+			sites[pid] = domain
+		}
+	}
+	return sites, nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// processUses returns what every process uses, by pid.
+func processUses() (map[int]processUse, error) {
+	output, err := exec.Command("ps", "-axo", "pid=,rss=,%cpu=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list processes: %w", err)
+	}
+	uses := make(map[int]processUse)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		var use processUse
+		use.memoryKB, _ = strconv.Atoi(fields[1])
+		use.cpu, _ = strconv.ParseFloat(strings.Replace(fields[2], ",", ".", 1), 64)
+		uses[pid] = use
+	}
+	return uses, nil
+}
+
+// markMemory estimates what each open tab uses, sharing each site's web
+// content processes out among the site's tabs. Without it tabs are simply
+// not told apart by cost, so errors are only logged.
+func markMemory(tabs []Tab) {
+	sites, err := webContentSites()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if len(sites) == 0 {
+		return
+	}
+	uses, err := processUses()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	bySite := make(map[string]processUse)
+	for pid, site := range sites {
+		use := bySite[site]
+		use.memoryKB += uses[pid].memoryKB
+		use.cpu += uses[pid].cpu
+		bySite[site] = use
+	}
+	counts := make(map[string]int)
+	for _, tab := range openTabs(tabs) {
+		counts[extractDomain(tab.URL)]++
+	}
+	for i := range tabs {
+		domain := extractDomain(tabs[i].URL)
+		use, ok := bySite[domain]
+		if tabs[i].Source != "" || !ok {
+			continue
+		}
+		tabs[i].MemoryMB = use.memoryKB / 1024 / counts[domain]
+		tabs[i].CPU = use.cpu / float64(counts[domain])
+	}
+}
+
+// heavy reports whether a tab uses enough memory or CPU to be worth
+// closing for them alone.
+func (t Tab) heavy() bool {
+	return t.MemoryMB >= heavyTabMB || t.CPU >= heavyTabCPU
+}
+
+// memoryInfo describes what a heavy tab uses.
+func memoryInfo(tab *Tab) string {
+	if tab.CPU >= 1 {
+		return tr("heavy: ~%d MB, %.0f%% CPU", tab.MemoryMB, tab.CPU)
+	}
+	return tr("heavy: ~%d MB", tab.MemoryMB)
+}
+
+// selectHeaviest selects the heaviestCount tabs using the most memory of
+// those not selected yet, so pressing it again selects the next ones.
+func (m *model) selectHeaviest() tea.Cmd {
+	var candidates []int
+	for i, tab := range m.tabs {
+		if tab.MemoryMB > 0 && !tab.playing() && !tab.locked() && !m.selected.has(i) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return m.showToast(tr("No tabs with a known memory use left to select."))
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return m.tabs[candidates[a]].MemoryMB > m.tabs[candidates[b]].MemoryMB
+	})
+
+	candidates = candidates[:min(heaviestCount, len(candidates))]
+	total := 0
+	for _, i := range candidates {
+		m.selected.set(i, true)
+		total += m.tabs[i].MemoryMB
+	}
+	return m.showToast(tr("Selected the %d heaviest tabs, about %d MB.", len(candidates), total))
+}
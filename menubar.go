@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// menubarConfig sets when the menu bar item turns into an alert. Zero
+// disables a threshold.
+type menubarConfig struct {
+	AlertTabs       int `json:"alert_tabs"`
+	AlertDuplicates int `json:"alert_duplicates"`
+	AlertOld        int `json:"alert_old"`
+}
+
+// runMenubar is the menubar subcommand. Menu bar apps need cgo, which the
+// release build doesn't use, so this prints an xbar/SwiftBar plugin instead:
+// the app runs it on an interval and turns the output into a menu.
+func runMenubar(args []string) {
+	flags := flag.NewFlagSet("menubar", flag.ExitOnError)
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	closeDuplicates := flags.Bool("close-duplicates", false, tr("Close duplicate tabs and exit (used by the menu)"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args)
+
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flags, cfg, ageDays)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	// Only closing needs to know which tabs are playing, to leave them
+	readTabState = *closeDuplicates
+
+	tabs, _, err := getSafariTabs(*ageDays)
+	if err != nil {
+		// The plugin output is the only place an error would be seen
+		fmt.Printf("%s | color=red\n---\n%s\n", tr("Tabs: ?"), menubarText(err.Error()))
+		return
+	}
+	tabs = applyRules(findDuplicates(withoutPinned(tabs)))
+
+	if *closeDuplicates {
+		var duplicates []Tab
+		for _, tab := range tabs {
+			if tab.DuplicateOf != 0 && tab.Selected {
+				duplicates = append(duplicates, tab)
+			}
+		}
+		switch msg := closeTabsAsync(duplicates, nil)().(type) {
+		case closeAbortedMsg:
+			fmt.Fprintln(os.Stderr, tr("Closing cancelled, no tabs were closed: %v", msg.err))
+			os.Exit(1)
+		case closingCompleteMsg:
+			fmt.Println(tr("Successfully closed %d tabs.", msg.count))
+		}
+		return
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		self = "safari-tab-manager"
+	}
+	// Actions rerun this binary with the same options
+	options := []string{"-age", strconv.Itoa(*ageDays)}
+	if *preview {
+		options = append(options, "-preview")
+	}
+	if *profile != "" {
+		options = append(options, "-profile", *profile)
+	}
+	printMenubar(os.Stdout, tabs, *ageDays, cfg.Menubar, self, options)
+}
+
+// printMenubar writes the plugin output: the title line, then the menu.
+// Menu actions run self with options.
+func printMenubar(w io.Writer, tabs []Tab, ageDays int, cfg menubarConfig, self string, options []string) {
+	duplicates, closable, old := 0, 0, 0
+	for _, tab := range tabs {
+		if tab.DuplicateOf != 0 {
+			duplicates++
+			if tab.Selected {
+				closable++
+			}
+		}
+		if tab.IsOld {
+			old++
+		}
+	}
+
+	var alerts []string
+	if cfg.AlertTabs > 0 && len(tabs) >= cfg.AlertTabs {
+		alerts = append(alerts, tr("%d tabs open, alert at %d", len(tabs), cfg.AlertTabs))
+	}
+	if cfg.AlertDuplicates > 0 && duplicates >= cfg.AlertDuplicates {
+		alerts = append(alerts, tr("%d duplicates, alert at %d", duplicates, cfg.AlertDuplicates))
+	}
+	if cfg.AlertOld > 0 && old >= cfg.AlertOld {
+		alerts = append(alerts, tr("%d old tabs, alert at %d", old, cfg.AlertOld))
+	}
+
+	if len(alerts) > 0 {
+		fmt.Fprintf(w, "%s | color=red\n", tr("Tabs: %d !", len(tabs)))
+	} else {
+		fmt.Fprintln(w, tr("Tabs: %d", len(tabs)))
+	}
+	fmt.Fprintln(w, "---")
+
+	for _, alert := range alerts {
+		fmt.Fprintf(w, "%s | color=red\n", alert)
+	}
+	fmt.Fprintln(w, tr("%d duplicates, %d old (>%d days)", duplicates, old, ageDays))
+	fmt.Fprintln(w, "---")
+
+	if closable > 0 {
+		menubarAction(w, tr("Close %d duplicates", closable), false, self, append([]string{"menubar", "-close-duplicates"}, options...))
+	} else {
+		fmt.Fprintln(w, tr("No duplicates to close"))
+	}
+	menubarAction(w, tr("Open manager"), true, self, options)
+	fmt.Fprintf(w, "%s | refresh=true\n", tr("Refresh"))
+}
+
+// menubarAction writes a menu item that runs command with args, in a
+// Terminal window if terminal is set.
+func menubarAction(w io.Writer, label string, terminal bool, command string, args []string) {
+	fmt.Fprintf(w, "%s | shell=%s", menubarText(label), menubarParam(command))
+	for i, arg := range args {
+		fmt.Fprintf(w, " param%d=%s", i+1, menubarParam(arg))
+	}
+	fmt.Fprintf(w, " terminal=%t refresh=true\n", terminal)
+}
+
+// menubarText keeps text from being read as plugin syntax: a "|" starts
+// the item's parameters and a newline ends the item.
+func menubarText(s string) string {
+	return strings.NewReplacer("|", "/", "\n", " ").Replace(s)
+}
+
+// menubarParam quotes a parameter value that contains spaces, such as an
+// executable under "Application Support".
+func menubarParam(s string) string {
+	if strings.ContainsAny(s, " |") {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var metricsTopDomains = 10 // Set by the serve -top-domains flag
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// handleMetrics serves the latest scan in the Prometheus text format.
+func (s *scanner) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	tabs, scannedAt, scanErrors := s.snapshot()
+
+	stats, err := loadStats()
+	if err != nil {
+		log.Printf("Warning: could not read stats: %v", err)
+	}
+
+	duplicates, old := 0, 0
+	domains := make(map[string]int)
+	for _, tab := range tabs {
+		if tab.DuplicateOf != 0 {
+			duplicates++
+		}
+		if tab.IsOld {
+			old++
+		}
+		domains[extractDomain(tab.URL)]++
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	writeMetric(w, "safari_tabs", "gauge", "Open Safari tabs, excluding pinned tabs.", float64(len(tabs)))
+	writeMetric(w, "safari_duplicate_tabs", "gauge", "Tabs that duplicate another open tab.", float64(duplicates))
+	writeMetric(w, "safari_old_tabs", "gauge", "Tabs last visited before the age threshold.", float64(old))
+
+	fmt.Fprintln(w, "# HELP safari_domain_tabs Open tabs per domain, for the domains with the most tabs.")
+	fmt.Fprintln(w, "# TYPE safari_domain_tabs gauge")
+	for _, domain := range topDomains(domains, metricsTopDomains) {
+		fmt.Fprintf(w, "safari_domain_tabs{domain=\"%s\"} %d\n", labelEscaper.Replace(domain), domains[domain])
+	}
+
+	writeMetric(w, "safari_tabs_closed_total", "counter", "Tabs closed by safari-tab-manager.", float64(stats.Closed))
+	writeMetric(w, "safari_tabs_archived_total", "counter", "Tabs archived by safari-tab-manager.", float64(stats.Archived))
+	writeMetric(w, "safari_scan_errors_total", "counter", "Scans of Safari that failed.", float64(scanErrors))
+	if !scannedAt.IsZero() {
+		writeMetric(w, "safari_last_scan_timestamp_seconds", "gauge", "When Safari was last scanned successfully.", float64(scannedAt.Unix()))
+	}
+}
+
+func writeMetric(w io.Writer, name, kind, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// topDomains returns up to n domains with the most tabs, most first.
+func topDomains(counts map[string]int, n int) []string {
+	domains := make([]string, 0, len(counts))
+	for domain := range counts {
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if counts[domains[i]] != counts[domains[j]] {
+			return counts[domains[i]] > counts[domains[j]]
+		}
+		return domains[i] < domains[j]
+	})
+	if len(domains) > n {
+		domains = domains[:n]
+	}
+	return domains
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The mover shows two windows side by side, where tabs come from on the
+// left and where they go on the right, and sends tabs across with a key,
+// so sorting tabs into windows doesn't take closing and reopening them.
+// Safari's AppleScript doesn't expose Tab Groups, so the panes show windows
+// only. Pinned tabs stay where they are.
+
+// moverScreen is the state of the mover.
+type moverScreen struct {
+	windows []int  // Windows to pick from, by number, then 0 for a new window
+	pane    [2]int // Window shown in the left and right pane, 0 for a new one
+	cursor  [2]int // Cursor among each pane's tabs
+	focus   int    // Pane with the focus, 0 for the left
+}
+
+// moverWindows returns the numbers of the windows with tabs that can be
+// moved, followed by 0 for a new window.
+func moverWindows(tabs []Tab) []int {
+	seen := make(map[int]bool)
+	var windows []int
+	for _, tab := range openTabs(tabs) {
+		if !tab.Pinned && !seen[tab.WindowIndex] {
+			seen[tab.WindowIndex] = true
+			windows = append(windows, tab.WindowIndex)
+		}
+	}
+	sort.Ints(windows)
+	return append(windows, 0)
+}
+
+// startMover opens the mover on the focused tab's window, with the next
+// window, or a new one, across.
+func (m *model) startMover() tea.Cmd {
+	windows := moverWindows(m.tabs)
+	if len(windows) == 1 {
+		return m.showToast(tr("No tabs to move."))
+	}
+	s := &moverScreen{windows: windows, pane: [2]int{windows[0], windows[1]}}
+	if focused, ok := m.list.SelectedItem().(item); ok && focused.tab().Source == "" && !focused.tab().Pinned {
+		for i, window := range windows {
+			if window == focused.tab().WindowIndex {
+				s.pane = [2]int{window, windows[i+1]}
+				s.cursor[0] = max(0, slices.Index(m.paneTabs(window), focused.index))
+			}
+		}
+	}
+	m.mover = s
+	return nil
+}
+
+// paneTabs returns the indexes of the tabs in a window that can be moved.
+func (m model) paneTabs(window int) []int {
+	var tabs []int
+	if window == 0 {
+		return tabs
+	}
+	for i, tab := range m.tabs {
+		if tab.Source == "" && !tab.Pinned && tab.WindowIndex == window {
+			tabs = append(tabs, i)
+		}
+	}
+	return tabs
+}
+
+// syncMover follows a refresh of the tabs, dropping windows that are gone.
+func (m *model) syncMover() {
+	s := m.mover
+	s.windows = moverWindows(m.tabs)
+	for p, window := range s.pane {
+		if slices.Index(s.windows, window) < 0 {
+			s.pane[p] = s.windows[0]
+		}
+		s.cursor[p] = max(0, min(s.cursor[p], len(m.paneTabs(s.pane[p]))-1))
+	}
+}
+
+// updateMover handles a key in the mover.
+func (m *model) updateMover(msg tea.KeyMsg) tea.Cmd {
+	s := m.mover
+	if key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))) {
+		return m.requestQuit()
+	}
+	tabs := m.paneTabs(s.pane[s.focus])
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "M"))):
+		m.mover = nil
+	case key.Matches(msg, key.NewBinding(key.WithKeys("tab"))):
+		s.focus = 1 - s.focus
+	case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+		s.cursor[s.focus] = max(0, s.cursor[s.focus]-1)
+	case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+		s.cursor[s.focus] = max(0, min(len(tabs)-1, s.cursor[s.focus]+1))
+	case key.Matches(msg, key.NewBinding(key.WithKeys("h", "left", "l", "right"))):
+		// Step to the previous or next window, past the one across
+		step := 1
+		if msg.String() == "h" || msg.String() == "left" {
+			step = len(s.windows) - 1
+		}
+		i := slices.Index(s.windows, s.pane[s.focus])
+		for {
+			i = (i + step) % len(s.windows)
+			if s.windows[i] != s.pane[1-s.focus] || s.windows[i] == 0 {
+				break
+			}
+		}
+		s.pane[s.focus] = s.windows[i]
+		s.cursor[s.focus] = 0
+	case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
+		if len(tabs) > 0 {
+			i := tabs[s.cursor[s.focus]]
+			m.selected.set(i, !m.selected.has(i))
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+		return m.sendAcross()
+	}
+	return nil
+}
+
+// sendAcross moves the selected tabs of the focused pane, or else its
+// focused tab, to the window across. The moved tabs are deselected.
+func (m *model) sendAcross() tea.Cmd {
+	s := m.mover
+	if m.moving {
+		return m.showToast(tr("Still moving tabs..."))
+	}
+	tabs := m.paneTabs(s.pane[s.focus])
+	if len(tabs) == 0 {
+		return nil
+	}
+	var indexes []int
+	for _, i := range tabs {
+		if m.selected.has(i) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		indexes = []int{tabs[s.cursor[s.focus]]}
+	}
+	moving := make([]Tab, len(indexes))
+	for n, i := range indexes {
+		moving[n] = m.tabs[i]
+		m.selected.set(i, false)
+	}
+
+	to := s.pane[1-s.focus]
+	if to == 0 {
+		// A new window comes to the front as window 1, moving the others
+		// down
+		s.pane[1-s.focus] = 1
+		s.pane[s.focus]++
+	}
+	return m.moveTo(to, moving)
+}
+
+// moverView shows the two panes side by side.
+func (m model) moverView() string {
+	s := m.mover
+	width := max(2*minTextWidth, m.list.Width()-2)
+	paneWidth := width/2 - 2
+	rows := max(3, m.list.Height()-2)
+
+	panes := make([][]string, 2)
+	for p := range panes {
+		window := s.pane[p]
+		tabs := m.paneTabs(window)
+		title := tr("New window")
+		if window != 0 {
+			title = tr("Window %d - %d tabs", window, len(tabs))
+			if len(tabs) > 0 {
+				if tab := m.tabs[tabs[0]]; tab.Display != "" || tab.DisplayGone {
+					title += sym.separator + displayLabel(tab.Display, tab.DisplayGone)
+				}
+			}
+		}
+		style := titleStyle
+		if p == s.focus {
+			style = style.Bold(true).Underline(true)
+		}
+		lines := []string{style.Render(truncateEnd(title, paneWidth)), ""}
+
+		start := max(0, min(s.cursor[p]-rows/2, len(tabs)-rows))
+		for n := start; n < min(len(tabs), start+rows); n++ {
+			i := tabs[n]
+			prefix := strings.Repeat(" ", textWidth(sym.cursor))
+			if p == s.focus && n == s.cursor[p] {
+				prefix = sym.cursor
+			}
+			check := sym.unchecked
+			if m.selected.has(i) {
+				check = sym.checked
+			}
+			rowStyle := normalStyle
+			if m.tabs[i].DuplicateOf != 0 {
+				rowStyle = duplicateStyle
+			}
+			name := truncateEnd(tabName(m.tabs[i]), max(minTextWidth, paneWidth-textWidth(prefix+check+" ")))
+			lines = append(lines, prefix+check+" "+rowStyle.Render(name))
+		}
+		if window == 0 {
+			lines = append(lines, helpStyle.Render(truncateEnd(tr("Tabs sent here open in a new window."), paneWidth)))
+		}
+		panes[p] = lines
+	}
+
+	hints := []string{
+		sym.up + "/" + sym.down + ": " + tr("move"),
+		tr("tab: other side"),
+		tr("left/right: change window"),
+		tr("space: toggle"),
+		tr("enter: send selected, or the focused tab, across"),
+		tr("esc: back to the list"),
+	}
+	lines := []string{
+		titleStyle.Render(tr("Move tabs between windows")),
+		"",
+		joinColumns("  ", []int{paneWidth}, panes[0], panes[1]),
+		"",
+		helpStyle.Render(" " + strings.Join(hints, sym.separator)),
+	}
+	if m.moving {
+		lines = append(lines, messageStyle.Render(" "+tr("Moving tabs...")))
+	} else if m.toast != "" {
+		lines = append(lines, messageStyle.Render(" "+m.toast))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+)
+
+// By default a tab is old when it hasn't been active, or failing that its
+// page hasn't been visited, for -age days, or was never visited at all. The
+// "old" setting replaces that with a Starlark expression over the signals,
+// like
+//
+//	days_since_active > age or (never_visited and days_since_first_seen > 7)
+//
+// days_since_visit, days_since_active and days_since_first_seen are -1 when
+// unknown; age is the -age threshold.
+
+// oldSignals are the names the expression can use, in the order they are
+// passed.
+var oldSignals = []string{"days_since_visit", "days_since_active", "days_since_first_seen", "never_visited", "age"}
+
+// oldRule is the compiled "old" expression, nil for the default.
+var oldRule starlark.Callable // Set from config.json
+
+// compileOldRule turns an "old" expression into a function of the signals.
+func compileOldRule(expr string) (starlark.Callable, error) {
+	src := "lambda " + strings.Join(oldSignals, ", ") + ": (" + expr + ")"
+
+	thread := &starlark.Thread{Name: "old"}
+	thread.SetMaxExecutionSteps(ruleMaxSteps)
+	value, err := starlark.Eval(thread, "old", src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid old expression %q: %w", expr, err)
+	}
+	rule, ok := value.(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("invalid old expression %q", expr)
+	}
+	return rule, nil
+}
+
+// daysSince returns the whole days since t, or -1 for the zero time.
+func daysSince(t time.Time) int {
+	if t.IsZero() {
+		return -1
+	}
+	return int(time.Since(t).Hours() / 24)
+}
+
+// markFirstSeen sets FirstSeen from the times tabs' URLs were first seen
+// open, recording the URLs seen for the first time now. URLs no longer open
+// are forgotten, so a page opened again starts over.
+func markFirstSeen(tabs []Tab) {
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+		return
+	}
+
+	path := filepath.Join(dir, "first-seen.json")
+	seen := make(map[string]time.Time)
+	if err := loadJSON(path, &seen); err != nil {
+		log.Printf("Warning: could not read first-seen times: %v", err)
+	}
+
+	now := time.Now()
+	open := make(map[string]time.Time, len(tabs))
+	for i := range tabs {
+		first, ok := seen[tabs[i].URL]
+		if !ok {
+			first = now
+		}
+		open[tabs[i].URL] = first
+		tabs[i].FirstSeen = first
+	}
+
+	if err := saveJSON(path, open); err != nil {
+		log.Printf("Warning: could not write first-seen times: %v", err)
+	}
+}
+
+// applyOldRule decides which tabs are old with the "old" expression, if
+// there is one. A tab the expression fails on keeps the default.
+func applyOldRule(tabs []Tab, ageDays int) []Tab {
+	markFirstSeen(tabs)
+	if oldRule == nil {
+		return tabs
+	}
+
+	for i := range tabs {
+		thread := &starlark.Thread{Name: "old"}
+		thread.SetMaxExecutionSteps(ruleMaxSteps)
+		args := starlark.Tuple{
+			starlark.MakeInt(daysSince(tabs[i].LastVisit)),
+			starlark.MakeInt(daysSince(tabs[i].LastActivated)),
+			starlark.MakeInt(daysSince(tabs[i].FirstSeen)),
+			starlark.Bool(tabs[i].LastVisit.IsZero()),
+			starlark.MakeInt(ageDays),
+		}
+		result, err := starlark.Call(thread, oldRule, args, nil)
+		if err != nil {
+			log.Printf("Warning: old expression failed on %s: %v", tabs[i].URL, err)
+			continue
+		}
+		tabs[i].IsOld = bool(result.Truth())
+	}
+	return tabs
+}
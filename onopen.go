@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// Serve mode notices tabs as they are opened, between one scan and the
+// next, and can act on them right away rather than at the next cleanup: the
+// rules script's on_open(tab) can tag a new tab, warn about it or close it,
+// the on_open hook receives the new tabs, and the blocklist can warn instead
+// of closing.
+//
+//	def on_open(tab):
+//	    if tab.duplicate:
+//	        return {"close": True}
+//	    if tab.domain == "twitter.com":
+//	        return {"warn": "Again?"}
+//	    return {"tag": "work"} if "jira" in tab.domain else None
+//
+// For a new tab, duplicate means the page was already open in another tab.
+// Without a script, duplicate_on_open warns about such tabs or switches to
+// the tab that was open already, closing the new copy.
+
+var duplicateOnOpen string // Set from config.json: "", "warn" or "switch"
+
+// openActions is what on_open(tab) asked for.
+type openActions struct {
+	tags  []string
+	warn  string
+	close bool
+}
+
+// evaluateOpen calls on_open(tab).
+func (r *ruleScript) evaluateOpen(tab Tab) (openActions, error) {
+	var actions openActions
+	if r.onOpen == nil {
+		return actions, nil
+	}
+
+	dict, err := callRule("on_open", r.onOpen, tab)
+	if err != nil || dict == nil {
+		return actions, err
+	}
+
+	for _, entry := range dict.Items() {
+		name, ok := starlark.AsString(entry[0])
+		if !ok {
+			return actions, fmt.Errorf("on_open returned a non-string key %s", entry[0])
+		}
+		value := entry[1]
+
+		switch name {
+		case "tag":
+			tags, err := ruleStrings(value)
+			if err != nil {
+				return actions, fmt.Errorf("tag: %w", err)
+			}
+			actions.tags = tags
+		case "warn":
+			message, ok := starlark.AsString(value)
+			if !ok {
+				return actions, fmt.Errorf("warn must be a string, got %s", value.Type())
+			}
+			actions.warn = message
+		case "close":
+			actions.close = bool(value.Truth())
+		default:
+			return actions, fmt.Errorf("unknown action %q", name)
+		}
+	}
+	return actions, nil
+}
+
+// openedTabs returns the tabs in after that weren't in before. A page
+// that wasn't open before is only a new tab if its window has more tabs
+// than it had; in a window that didn't grow, a tab followed a link or was
+// sent elsewhere, and is left alone. A new tab counts as a duplicate only
+// of a tab that was open already, so of two copies of a page it's always
+// the new one that is the duplicate.
+func openedTabs(before, after []Tab) []Tab {
+	_, previous := keyedTabs(before)
+	afterKeys, _ := keyedTabs(after)
+	grown := grownWindows(after, afterKeys, before, previous)
+
+	isNew := make([]bool, len(after))
+	for i, key := range afterKeys {
+		_, ok := previous[key]
+		isNew[i] = !ok && after[i].Source == "" && grown[after[i].WindowIndex]
+	}
+
+	// The first tab of each duplicate group that was open before
+	openBefore := make(map[int]int)
+	byID := indexByID(after)
+	group := func(i int) int {
+		if original, ok := byID[after[i].DuplicateOf]; ok {
+			return original
+		}
+		return i
+	}
+	for i := range after {
+		if _, ok := openBefore[group(i)]; !ok && !isNew[i] {
+			openBefore[group(i)] = i
+		}
+	}
+
+	var opened []Tab
+	for i, tab := range after {
+		if !isNew[i] {
+			continue
+		}
+		tab.DuplicateOf = 0
+		if original, ok := openBefore[group(i)]; ok {
+			tab.DuplicateOf = after[original].ID
+		}
+		opened = append(opened, tab)
+	}
+	return opened
+}
+
+// grownWindows reports for each window of after whether it has more tabs
+// than in before. Window indexes change as windows come to the front, so a
+// window is known by its tabs: it's the window of before most of them were
+// in, and new if none were open before.
+func grownWindows(after []Tab, afterKeys []string, before []Tab, previous map[string]Tab) map[int]bool {
+	count := func(tabs []Tab) map[int]int {
+		counts := make(map[int]int)
+		for _, tab := range tabs {
+			if tab.Source == "" {
+				counts[tab.WindowIndex]++
+			}
+		}
+		return counts
+	}
+	countBefore, countAfter := count(before), count(after)
+
+	// How many of each window's tabs were in each window of before
+	came := make(map[int]map[int]int)
+	for i, tab := range after {
+		old, ok := previous[afterKeys[i]]
+		if !ok || tab.Source != "" || old.Source != "" {
+			continue
+		}
+		if came[tab.WindowIndex] == nil {
+			came[tab.WindowIndex] = make(map[int]int)
+		}
+		came[tab.WindowIndex][old.WindowIndex]++
+	}
+
+	grown := make(map[int]bool, len(countAfter))
+	for window, count := range countAfter {
+		was, most := 0, 0
+		for w, n := range came[window] {
+			if n > most || n == most && w < was {
+				was, most = w, n
+			}
+		}
+		grown[window] = most == 0 || count > countBefore[was]
+	}
+	return grown
+}
+
+// openReaction is what to do about the tabs one scan found opened.
+type openReaction struct {
+	opened   []Tab
+	tags     map[string][]string // Tags on_open gave, by URL
+	warnings []string
+	toClose  []Tab
+	switchTo *Tab // The tab to show once toClose are closed
+}
+
+// evaluateOpened runs on_open for tabs opened since the last scan, found in
+// tabs by openedTabs. The scan adds the tags it gives before sending its
+// events; the rest of the reaction waits for act.
+func evaluateOpened(opened, tabs []Tab) openReaction {
+	reaction := openReaction{opened: opened, tags: make(map[string][]string)}
+	for _, tab := range opened {
+		if tab.Blocked && blocklist.Action == "warn" {
+			reaction.warnings = append(reaction.warnings, tr("%s is on the blocklist.", extractDomain(tab.URL)))
+		}
+		if tab.DuplicateOf != 0 {
+			switch duplicateOnOpen {
+			case "warn":
+				reaction.warnings = append(reaction.warnings, tr("%s is already open.", tabName(tab)))
+			case "switch":
+				if !tab.locked() {
+					reaction.toClose = append(reaction.toClose, tab)
+					if original, ok := tabByID(tabs, tab.DuplicateOf); ok {
+						reaction.switchTo = &original
+					}
+				}
+				continue
+			}
+		}
+		if rules == nil {
+			continue
+		}
+
+		actions, err := rules.evaluateOpen(tab)
+		if err != nil {
+			log.Printf("Warning: on_open failed for %s: %v", tab.URL, err)
+			continue
+		}
+		if len(actions.tags) > 0 {
+			reaction.tags[tab.URL] = append(reaction.tags[tab.URL], actions.tags...)
+		}
+		if actions.warn != "" {
+			reaction.warnings = append(reaction.warnings, actions.warn)
+		}
+		if actions.close && !tab.locked() {
+			reaction.toClose = append(reaction.toClose, tab)
+		}
+	}
+	return reaction
+}
+
+// tagOpened adds the tags on_open gave to tabs, and forgets those of tabs
+// no longer open. Called with s.mu held.
+func (s *scanner) tagOpened(tabs []Tab) {
+	open := make(map[string]bool, len(tabs))
+	for i := range tabs {
+		open[tabs[i].URL] = true
+		tabs[i].Tags = append(tabs[i].Tags, s.openTags[tabs[i].URL]...)
+	}
+	for url := range s.openTags {
+		if !open[url] {
+			delete(s.openTags, url)
+		}
+	}
+}
+
+// act shows the warnings, runs the on_open hook and closes the tabs on_open
+// asked to close.
+func (s *scanner) act(reaction openReaction) {
+	for _, warning := range reaction.warnings {
+		notify(warning)
+	}
+	runHookAndLog("on_open", hooks.OnOpen, reaction.opened)
+
+	if len(reaction.toClose) > 0 {
+		closed, err := s.closeTabs(reaction.toClose, false)
+		if err != nil {
+			log.Printf("Warning: could not close new tabs: %v", err)
+			return
+		}
+		log.Print(tr("Closed %d new tabs.", closed))
+	}
+	if reaction.switchTo != nil {
+		if err := showTab(*reaction.switchTo); err != nil {
+			log.Printf("Warning: could not switch to %s: %v", reaction.switchTo.URL, err)
+		}
+	}
+}
+
+// tabName names a tab by its title, or its URL if it has none.
+func tabName(tab Tab) string {
+	if strings.TrimSpace(tab.Title) == "" {
+		return tab.URL
+	}
+	return singleLine(tab.Title)
+}
+
+// showTab brings tab to the front, with its window: the tab at its place if
+// it's still there with its URL, or else the first tab with the URL.
+func showTab(tab Tab) error {
+	script := fmt.Sprintf(`
+	tell application %[1]s
+		try
+			set w to window %[3]d
+			set t to tab %[4]d of w
+			if URL of t is %[2]s then
+				set current tab of w to t
+				set index of w to 1
+				activate
+				return
+			end if
+		end try
+		repeat with w in windows
+			repeat with t in tabs of w
+				if URL of t is %[2]s then
+					set current tab of w to t
+					set index of w to 1
+					activate
+					return
+				end if
+			end repeat
+		end repeat
+	end tell
+	`, appleScriptString(safariApp), appleScriptString(tab.URL), tab.WindowIndex, tab.TabIndex)
+	if output, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// notify shows a macOS notification, and logs the message for when nobody
+// is looking.
+func notify(message string) {
+	log.Print(message)
+	script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(tr("Safari Tab Manager")))
+	if err := exec.Command("osascript", "-e", script).Run(); err != nil {
+		log.Printf("Warning: could not show notification: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "time"
+
+// pacingConfig paces the AppleScript that closes and moves tabs. Sending
+// Safari hundreds of closes back to back can make it beachball, so tabs go
+// in batches with a pause between them, and the pause grows while Safari is
+// slow to answer.
+type pacingConfig struct {
+	BatchSize int `json:"batch_size"` // Tabs closed per osascript run
+	DelayMS   int `json:"delay_ms"`   // Pause between batches
+	SlowMS    int `json:"slow_ms"`    // A batch taking longer than this backs off
+}
+
+var pacing = pacingConfig{BatchSize: 10, DelayMS: 100, SlowMS: 1000} // Overridden from config.json
+
+// maxPacingDelay caps the pause however slow Safari gets.
+const maxPacingDelay = 5 * time.Second
+
+// A pacer spaces out batches. A slow batch doubles the pause; a fast one
+// halves it again, down to the configured delay.
+type pacer struct {
+	delay time.Duration
+}
+
+func newPacer() *pacer {
+	return &pacer{delay: pacing.delay()}
+}
+
+func (c pacingConfig) delay() time.Duration {
+	return time.Duration(c.DelayMS) * time.Millisecond
+}
+
+// batchSize is how many tabs to close in one osascript run.
+func (p *pacer) batchSize() int {
+	return max(pacing.BatchSize, 1)
+}
+
+// pace pauses before the next batch, given how long the last one took.
+func (p *pacer) pace(took time.Duration) {
+	if took > time.Duration(pacing.SlowMS)*time.Millisecond {
+		p.delay = 2 * p.delay
+		if p.delay < 100*time.Millisecond {
+			p.delay = 100 * time.Millisecond
+		}
+		if p.delay > maxPacingDelay {
+			p.delay = maxPacingDelay
+		}
+	} else {
+		p.delay /= 2
+		if p.delay < pacing.delay() {
+			p.delay = pacing.delay()
+		}
+	}
+	time.Sleep(p.delay)
+}
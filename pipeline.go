@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Listing the tabs is quick; what makes startup slow is what comes after:
+// looking every tab up in the history, fetching pages for -fetch-titles,
+// -reading-time, -check-resolved and -prices, and comparing every tab with
+// every other for duplicates. None of these needs another's result, so
+// they run as stages at once. getSafariTabs waits for them, the list
+// starts with the tabs as Safari has them and takes in each stage's result
+// as it comes, with how far each has got in the status bar.
+
+// A stage is one of the slow steps run on the scanned tabs.
+type stage int
+
+const (
+	stageHistory    stage = iota // Visit times, activity, the old rule and expiry
+	stageChecks                  // Pages fetched for titles, reading times, resolved states and prices
+	stageDuplicates              // Originals of duplicate tabs
+	stageCount
+)
+
+// name is the stage as the status bar shows it.
+func (s stage) name() string {
+	switch s {
+	case stageHistory:
+		return tr("history")
+	case stageChecks:
+		return tr("page checks")
+	}
+	return tr("duplicates")
+}
+
+// stageProgress counts a stage's steps as it runs, for the status bar. A
+// nil stageProgress counts nothing.
+type stageProgress struct {
+	done  atomic.Int64
+	total atomic.Int64
+}
+
+func (p *stageProgress) start(total int) {
+	if p != nil {
+		p.total.Store(int64(total))
+	}
+}
+
+func (p *stageProgress) step() {
+	p.add(1)
+}
+
+func (p *stageProgress) add(steps int) {
+	if p != nil {
+		p.done.Add(int64(steps))
+	}
+}
+
+// percent is how far the stage has got, from 0 to 100.
+func (p *stageProgress) percent() int {
+	total := int(p.total.Load())
+	if total == 0 {
+		return 0
+	}
+	return 100 * min(int(p.done.Load()), total) / total
+}
+
+// scanTabs lists the tabs and saved items with what can be told from
+// their URLs and from Safari itself, leaving the stages to fill in the
+// rest.
+func scanTabs() ([]Tab, []int, error) {
+	allTabs, err := getSafariTabsRaw()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Mark pinned tabs: tabs that appear at the same early position
+	// across multiple windows with the same URL are likely pinned
+	tabs, emptyWindows := markPinnedTabs(allTabs)
+	// Without access to the bookmarks the tabs are still worth listing,
+	// and the header says what's missing
+	if capBookmarks.state() != capNeedsAccess {
+		saved, err := savedItems()
+		if err != nil {
+			return nil, nil, err
+		}
+		tabs = append(tabs, saved...)
+	}
+	if err := markSavedElsewhere(tabs); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	markVideos(tabs)
+	markDocSets(tabs)
+	markProducts(tabs)
+
+	tabs = categorizeTabs(tabs, loadCategories())
+	markLanguages(tabs)
+	markProjects(tabs)
+	markWindowLabels(tabs)
+	markSuspended(tabs)
+	markDisplays(tabs)
+	markMemory(tabs)
+	numberTabs(tabs)
+	return tabs, emptyWindows, nil
+}
+
+// historyStage adds what Safari's history and session state tell about
+// tabs: visit times, when each was last active, whether it's old and
+// whether it expired.
+func historyStage(tabs []Tab, ageDays int, progress *stageProgress) []Tab {
+	progress.start(4)
+	tabs = enrichWithVisitData(tabs, ageDays)
+	progress.step()
+	tabs = addTabActivity(tabs, ageDays)
+	progress.step()
+	tabs = applyOldRule(tabs, ageDays)
+	progress.step()
+	markExpired(tabs)
+	progress.step()
+	return tabs
+}
+
+// checks are the page fetches the flags turned on, in the order they run.
+func checks() []func([]Tab) []Tab {
+	var steps []func([]Tab) []Tab
+	// Fetch readable titles for tabs that never finished loading
+	if fetchTitles {
+		steps = append(steps, fetchMissingTitles)
+	}
+	if estimateReadingTime {
+		steps = append(steps, addReadingTimes)
+	}
+	if checkResolved {
+		steps = append(steps, addResolvedStates)
+	}
+	if fetchPrices {
+		steps = append(steps, addProductDetails)
+	}
+	return steps
+}
+
+// checksStage fetches the pages the checks turned on need.
+func checksStage(tabs []Tab, progress *stageProgress) []Tab {
+	steps := checks()
+	progress.start(len(steps))
+	for _, step := range steps {
+		tabs = step(tabs)
+		progress.step()
+	}
+	return tabs
+}
+
+// mergeStages returns the scanned tabs with what the history and checks
+// stages found, either nil while it's still running. The stages ran on
+// copies of the same tabs, so they line up by index.
+func mergeStages(scanned, history, checked []Tab) []Tab {
+	tabs := make([]Tab, len(scanned))
+	for i, tab := range scanned {
+		tab.Tags = slices.Clone(tab.Tags)
+		if history != nil {
+			h := history[i]
+			tab.LastVisit, tab.FirstVisit, tab.LastActivated, tab.FirstSeen = h.LastVisit, h.FirstVisit, h.LastActivated, h.FirstSeen
+			tab.Burst, tab.HistoryURL, tab.ReadElsewhere = h.Burst, h.HistoryURL, h.ReadElsewhere
+			tab.IsOld, tab.Expired = h.IsOld, h.Expired
+			tab.Selected = tab.Selected || h.Selected
+		}
+		if checked != nil {
+			c := checked[i]
+			tab.Title, tab.ReadingMinutes, tab.VideoSeconds = c.Title, c.ReadingMinutes, c.VideoSeconds
+			tab.Resolved, tab.ProductName, tab.Price = c.Resolved, c.ProductName, c.Price
+		}
+		tabs[i] = tab
+	}
+	if checked != nil {
+		// Titles fetched tell the language better than the URL
+		markLanguages(tabs)
+	}
+	return tabs
+}
+
+// getSafariTabs lists the tabs with what the history and checks stages
+// find, for commands that wait for everything; duplicates are left to
+// findDuplicates, on whichever tabs the command counts.
+func getSafariTabs(ageDays int) ([]Tab, []int, error) {
+	tabs, emptyWindows, err := scanTabs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var history, checked []Tab
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		history = historyStage(slices.Clone(tabs), ageDays, nil)
+	}()
+	go func() {
+		defer wg.Done()
+		checked = checksStage(slices.Clone(tabs), nil)
+	}()
+	wg.Wait()
+	return mergeStages(tabs, history, checked), emptyWindows, nil
+}
+
+// pipeline is the list's stages while they run. The model's copies share
+// it, and only Update touches it besides the stages' progress.
+type pipeline struct {
+	scanned   []Tab
+	only      tabFilter // Tabs it doesn't match aren't suggested, as with -only
+	progress  [stageCount]*stageProgress
+	running   [stageCount]bool
+	history   []Tab // The history stage's tabs, nil until it's done
+	checked   []Tab // The checks stage's tabs, nil until it's done
+	originals []int // See dedupeCache.originals, nil until the stage is done
+	pending   bool  // A stage finished that the list hasn't taken in yet
+	suggested bitset
+}
+
+// stageDoneMsg carries a finished stage's result.
+type stageDoneMsg struct {
+	pipeline  *pipeline
+	stage     stage
+	tabs      []Tab
+	originals []int
+}
+
+// stageTickMsg redraws the stages' progress, and takes in results that had
+// to wait, see applyStages.
+type stageTickMsg struct {
+	pipeline *pipeline
+}
+
+const stageTickInterval = 200 * time.Millisecond
+
+func newPipeline(scanned []Tab, only tabFilter) *pipeline {
+	p := &pipeline{scanned: scanned, only: only}
+	for s := range stageCount {
+		p.progress[s] = new(stageProgress)
+		p.running[s] = s != stageChecks || len(checks()) > 0
+	}
+	return p
+}
+
+// tabs returns the tabs with what the stages found so far, and with the
+// selection they suggest.
+func (p *pipeline) tabs() []Tab {
+	tabs := mergeStages(p.scanned, p.history, p.checked)
+	if p.originals != nil {
+		markDuplicates(tabs, p.originals)
+	}
+	tabs = applyRules(tabs)
+	// A focused cleanup only closes what it shows
+	if p.only.active() {
+		for i := range tabs {
+			if !p.only.matches(tabs[i]) {
+				tabs[i].Selected = false
+			}
+		}
+	}
+	return tabs
+}
+
+// start runs the stages, each reporting with a stageDoneMsg. The
+// duplicates found go into dupes for refreshes to build on.
+func (p *pipeline) start(ageDays int, dupes *dedupeCache) tea.Cmd {
+	cmds := []tea.Cmd{p.tick()}
+	if p.running[stageHistory] {
+		cmds = append(cmds, func() tea.Msg {
+			tabs := historyStage(slices.Clone(p.scanned), ageDays, p.progress[stageHistory])
+			return stageDoneMsg{pipeline: p, stage: stageHistory, tabs: tabs}
+		})
+	}
+	if p.running[stageChecks] {
+		cmds = append(cmds, func() tea.Msg {
+			tabs := checksStage(slices.Clone(p.scanned), p.progress[stageChecks])
+			return stageDoneMsg{pipeline: p, stage: stageChecks, tabs: tabs}
+		})
+	}
+	if p.running[stageDuplicates] {
+		cmds = append(cmds, func() tea.Msg {
+			originals := dupes.originals(p.scanned, p.progress[stageDuplicates])
+			return stageDoneMsg{pipeline: p, stage: stageDuplicates, originals: originals}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (p *pipeline) tick() tea.Cmd {
+	return tea.Tick(stageTickInterval, func(time.Time) tea.Msg { return stageTickMsg{pipeline: p} })
+}
+
+// finish keeps a finished stage's result for the list to take in.
+func (p *pipeline) finish(msg stageDoneMsg) {
+	switch msg.stage {
+	case stageHistory:
+		p.history = msg.tabs
+	case stageChecks:
+		p.checked = msg.tabs
+	case stageDuplicates:
+		p.originals = msg.originals
+	}
+	p.running[msg.stage] = false
+	p.pending = true
+}
+
+// done reports whether every stage finished.
+func (p *pipeline) done() bool {
+	return !slices.Contains(p.running[:], true)
+}
+
+// status says how far the running stages have got, like "history 50%".
+func (p *pipeline) status() string {
+	var parts []string
+	for s := range stageCount {
+		if p.running[s] {
+			parts = append(parts, fmt.Sprintf("%s %d%%", s.name(), p.progress[s].percent()))
+		}
+	}
+	return tr("loading %s", strings.Join(parts, ", "))
+}
+
+// applyStages takes the results of the stages finished since the last
+// call into the list. Tabs still selected as the stages last suggested
+// follow the new suggestion; those changed by hand keep their selection.
+// Closing and the duplicate review go by tab indexes and duplicate groups,
+// so results wait for them to end. Once every stage is in, the run is
+// logged and the pipeline is done.
+func (m *model) applyStages() tea.Cmd {
+	p := m.stages
+	if p == nil || !p.pending || m.closing || m.review != nil {
+		return nil
+	}
+	p.pending = false
+	tabs := p.tabs()
+	suggested := suggestedSelection(tabs)
+	selected := newBitset(len(tabs))
+	for i := range tabs {
+		if m.selected.has(i) == p.suggested.has(i) {
+			selected.set(i, suggested.has(i))
+		} else {
+			selected.set(i, m.selected.has(i))
+		}
+	}
+	p.suggested = suggested
+	m.ids.assign(tabs)
+	m.tabs = tabs
+	m.selected = selected
+	if m.mover != nil {
+		m.syncMover()
+	}
+	m.applyManualTags()
+	m.refreshItems()
+
+	if p.done() {
+		logRun(tabs)
+		m.stages = nil
+	}
+	return m.setWindowTitle()
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runPlain is the --plain interface: a numbered list followed by line-based
+// prompts, with no alt screen, cursor movement or box drawing. It works with
+// screen readers such as VoiceOver and in dumb terminals. tabs are the tabs
+// shown, all every tab, among which duplicates' originals are found.
+func runPlain(in io.Reader, out io.Writer, tabs, all []Tab, emptyWindows []int, ageDays int, a archiver) {
+	reader := bufio.NewReader(in)
+	printPlainList(out, tabs, all, ageDays)
+
+	for {
+		fmt.Fprintf(out, "\n%s\n> ", tr("%d selected. Enter tab numbers to toggle (e.g. \"3 5-8\"), a: select duplicates, o: select old, n: deselect all, l: list, c: close selected, A: archive selected, q: quit", countSelected(tabs)))
+
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(out, "\n"+tr("Cancelled. No tabs were closed."))
+			return
+		}
+		line = strings.TrimSpace(line)
+
+		switch line {
+		case "":
+			continue
+
+		case "q":
+			if selected := selectedTabs(tabs); len(selected) > 0 {
+				fmt.Fprint(out, tr("%d tabs are selected. Type y to quit anyway, or s to save them as a session and quit:", len(selected))+" ")
+				answer, _ := reader.ReadString('\n')
+				switch strings.TrimSpace(strings.ToLower(answer)) {
+				case tr("y"):
+				case "s":
+					saved, err := saveSession("", selected)
+					if err != nil {
+						fmt.Fprintln(out, tr("Could not save session: %v", err))
+						continue
+					}
+					fmt.Fprintln(out, tr("Saved %d tabs as session %q.", len(saved.Tabs), saved.Name))
+					return
+				default:
+					continue
+				}
+			}
+			fmt.Fprintln(out, tr("Cancelled. No tabs were closed."))
+			return
+
+		case "l":
+			printPlainList(out, tabs, all, ageDays)
+
+		case "a":
+			count := 0
+			for i := range tabs {
+				if tabs[i].DuplicateOf != 0 && !tabs[i].playing() && !tabs[i].locked() && !tabs[i].Selected {
+					tabs[i].Selected = true
+					count++
+				}
+			}
+			fmt.Fprintln(out, tr("Selected %d duplicates", count))
+
+		case "o":
+			count := 0
+			for i := range tabs {
+				if tabs[i].IsOld && !tabs[i].playing() && !tabs[i].locked() && !tabs[i].Selected {
+					tabs[i].Selected = true
+					count++
+				}
+			}
+			fmt.Fprintln(out, tr("Selected %d old tabs", count))
+
+		case "n":
+			count := 0
+			for i := range tabs {
+				if tabs[i].Selected {
+					tabs[i].Selected = false
+					count++
+				}
+			}
+			fmt.Fprintln(out, tr("Deselected %d tabs", count))
+
+		case "c", "A":
+			selected := selectedTabs(tabs)
+			if len(selected) == 0 {
+				fmt.Fprintln(out, tr("No tabs selected."))
+				continue
+			}
+
+			// A large close needs its count typed, see largeClose
+			guarded := line == "c" && largeClose.guards(len(selected))
+			prompt, confirm := tr("Close %d tabs? Type y to confirm:", len(selected)), tr("y")
+			switch {
+			case guarded:
+				prompt, confirm = tr("Close %d tabs? They will be saved as a session first. Type %d to confirm:", len(selected), len(selected)), strconv.Itoa(len(selected))
+			case line == "A":
+				prompt = tr("Archive and close %d tabs? Type y to confirm:", len(selected))
+			}
+			fmt.Fprint(out, prompt+" ")
+			answer, _ := reader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(answer)) != confirm {
+				fmt.Fprintln(out, tr("Nothing closed."))
+				continue
+			}
+			var snapshot string
+			if guarded {
+				saved, err := snapshotBeforeClose(selected)
+				if err != nil {
+					fmt.Fprintln(out, tr("Could not save session, no tabs were closed: %v", err))
+					continue
+				}
+				snapshot = saved.Name
+			}
+
+			var cmd tea.Cmd
+			if line != "A" {
+				cmd = closeTabsAsync(selected, emptyWindows)
+			} else {
+				batches := archiveBatches(a, selected)
+				cmd = archiveTabsAsync(a, selected, emptyWindows, func(msg archiveProgressMsg) {
+					batch := batches[msg.index]
+					if msg.err != nil {
+						fmt.Fprintln(out, tr("%s: %d tabs queued to retry (%v)", batch.to.Name(), len(batch.tabs), msg.err))
+					} else {
+						fmt.Fprintln(out, tr("%s: %d tabs archived", batch.to.Name(), len(batch.tabs)))
+					}
+				})
+			}
+			switch msg := cmd().(type) {
+			case archiveFailedMsg:
+				fmt.Fprintln(out, tr("Archiving failed, no tabs were closed: %v", msg.err))
+			case closeAbortedMsg:
+				fmt.Fprintln(out, tr("Closing cancelled, no tabs were closed: %v", msg.err))
+			case closingCompleteMsg:
+				fmt.Fprintln(out, tr("Successfully closed %d tabs.", msg.count)+queuedNote(msg.queued)+savedNote(msg.savedPending, msg.savedErr)+snapshotNote(snapshot))
+			}
+			return
+
+		default:
+			numbers, err := parseTabNumbers(line, len(tabs))
+			if err != nil {
+				fmt.Fprintf(out, "%v\n", err)
+				continue
+			}
+			for _, n := range numbers {
+				tab := &tabs[n-1]
+				if reason := tab.lockReason(); reason != "" {
+					fmt.Fprintln(out, tr("%d can't be selected (%s): %s", n, reason, tab.Title))
+					continue
+				}
+				tab.Selected = !tab.Selected
+				if tab.Selected {
+					fmt.Fprintln(out, tr("%d selected: %s", n, tab.Title))
+				} else {
+					fmt.Fprintln(out, tr("%d deselected: %s", n, tab.Title))
+				}
+			}
+		}
+	}
+}
+
+// printPlainList lists tabs, naming the original of a duplicate from all
+// by title and window, as the list does, since it may not be among tabs.
+func printPlainList(out io.Writer, tabs, all []Tab, ageDays int) {
+	fmt.Fprintf(out, "%s\n\n", tr("Safari Tab Manager %s. %d tabs. Tabs marked old were last visited more than %d days ago.", Version, len(tabs), ageDays))
+	for i, tab := range tabs {
+		var notes []string
+		if tab.Selected {
+			notes = append(notes, tr("selected"))
+		}
+		if original, ok := tabByID(all, tab.DuplicateOf); ok {
+			name := singleLine(original.Title)
+			if strings.TrimSpace(name) == "" {
+				name = displayURL(original.URL)
+			}
+			notes = append(notes, tr("duplicate of %s (%s)", name, original.container()))
+		}
+		if tab.IsOld {
+			notes = append(notes, tr("old"))
+		}
+		if tab.ReadElsewhere {
+			notes = append(notes, tr("read on another device"))
+		}
+		if tab.SavedIn != "" {
+			notes = append(notes, tr("saved in %s", tab.SavedIn))
+		}
+		if tab.Expired != "" {
+			notes = append(notes, expiredReason(&tab))
+		}
+		if tab.Resolved != "" {
+			notes = append(notes, resolvedReason(&tab))
+		}
+		if tab.Video {
+			notes = append(notes, videoInfo(&tab))
+		}
+		if tab.Product {
+			notes = append(notes, productInfo(&tab))
+		}
+		if tab.PlaysAudio {
+			notes = append(notes, tr("playing audio"))
+		} else if tab.Video && tab.PlaysVideo {
+			notes = append(notes, tr("playing"))
+		}
+		if tab.Loading {
+			notes = append(notes, tr("loading"))
+		}
+		if reason := tab.lockReason(); reason != "" {
+			notes = append(notes, reason)
+		}
+		for _, tag := range tab.Tags {
+			notes = append(notes, "#"+tag)
+		}
+
+		line := fmt.Sprintf("%d. %s", i+1, tab.Title)
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Fprintf(out, "%s\n   %s\n", line, tab.URL)
+	}
+}
+
+// parseTabNumbers parses space or comma separated numbers and ranges such as
+// "3 5-8", validating them against the number of tabs.
+func parseTabNumbers(input string, count int) ([]int, error) {
+	var numbers []int
+	fields := strings.FieldsFunc(input, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, field := range fields {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, errors.New(tr("not a tab number: %q", field))
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, errors.New(tr("not a tab range: %q", field))
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, errors.New(tr("tab numbers must be between 1 and %d: %q", count, field))
+		}
+		for n := start; n <= end; n++ {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers, nil
+}
+
+func selectedTabs(tabs []Tab) []Tab {
+	var result []Tab
+	for _, tab := range tabs {
+		if tab.Selected {
+			result = append(result, tab)
+		}
+	}
+	return result
+}
+
+func countSelected(tabs []Tab) int {
+	return len(selectedTabs(tabs))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Projects are inferred from URLs: tabs that share a site and a distinctive
+// path word, like the repository, ticket or topic they are about, or that
+// share two path words across sites, are clustered together and named after
+// the words most of them share.
+
+// projectMinTabs is how many tabs it takes to make a project.
+const projectMinTabs = 3
+
+// projectStopwords are path words too generic to tie tabs together.
+var projectStopwords = map[string]bool{
+	"www": true, "com": true, "org": true, "net": true, "html": true, "htm": true, "php": true, "aspx": true,
+	"index": true, "search": true, "watch": true, "wiki": true, "blob": true, "tree": true, "main": true,
+	"master": true, "issues": true, "pull": true, "pulls": true, "commit": true, "commits": true,
+	"article": true, "articles": true, "post": true, "posts": true, "news": true, "docs": true,
+	"the": true, "and": true, "for": true, "with": true, "how": true, "what": true, "why": true,
+	"page": true, "pages": true, "questions": true, "comments": true, "story": true, "view": true,
+}
+
+// urlWords splits a URL into its site, the host without "www.", and the
+// words of its path that can tell a project apart.
+func urlWords(rawURL string) (string, []string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", nil
+	}
+	site := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(u.Path), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		// Skip short and generic words, and numbers and ids
+		if len([]rune(word)) < 3 || len(word) > 30 || projectStopwords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		digits := 0
+		for _, r := range word {
+			if unicode.IsDigit(r) {
+				digits++
+			}
+		}
+		if digits*2 > len(word) {
+			continue
+		}
+		if !slices.Contains(words, word) {
+			words = append(words, word)
+		}
+	}
+	return site, words
+}
+
+// markProjects sets the Project of tabs that cluster into a project.
+func markProjects(tabs []Tab) {
+	sites := make([]string, len(tabs))
+	words := make([][]string, len(tabs))
+	byWord := make(map[string][]int)
+	for i := range tabs {
+		tabs[i].Project = ""
+		if tabs[i].Pinned {
+			continue
+		}
+		sites[i], words[i] = urlWords(tabs[i].URL)
+		for _, word := range words[i] {
+			byWord[word] = append(byWord[word], i)
+		}
+	}
+
+	// Count the words each pair of tabs shares. Words on too many tabs say
+	// nothing about a project and would make this quadratic.
+	common := max(10, len(tabs)/4)
+	type pair struct{ a, b int }
+	shared := make(map[pair]int)
+	for _, indexes := range byWord {
+		if len(indexes) < 2 || len(indexes) > common {
+			continue
+		}
+		for x, a := range indexes {
+			for _, b := range indexes[x+1:] {
+				shared[pair{a, b}]++
+			}
+		}
+	}
+
+	parent := make([]int, len(tabs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for p, n := range shared {
+		if n >= 2 || (sites[p.a] != "" && sites[p.a] == sites[p.b]) {
+			parent[find(p.a)] = find(p.b)
+		}
+	}
+
+	clusters := make(map[int][]int)
+	for i := range tabs {
+		if len(words[i]) > 0 {
+			clusters[find(i)] = append(clusters[find(i)], i)
+		}
+	}
+	// Name clusters in tab order, so names stay put across refreshes
+	var roots []int
+	for root, members := range clusters {
+		if len(members) >= projectMinTabs {
+			roots = append(roots, root)
+		}
+	}
+	sort.Slice(roots, func(a, b int) bool { return clusters[roots[a]][0] < clusters[roots[b]][0] })
+
+	taken := make(map[string]bool)
+	for _, root := range roots {
+		members := clusters[root]
+		base := projectName(members, sites, words)
+		name := base
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s %d", base, n)
+		}
+		taken[name] = true
+		for _, i := range members {
+			tabs[i].Project = name
+		}
+	}
+}
+
+// projectName names a cluster after the two words most of its tabs share,
+// or its site if they share none.
+func projectName(members []int, sites []string, words [][]string) string {
+	counts := make(map[string]int)
+	siteCounts := make(map[string]int)
+	for _, i := range members {
+		for _, word := range words[i] {
+			counts[word]++
+		}
+		siteCounts[sites[i]]++
+	}
+	var names []string
+	for word, n := range counts {
+		if n*2 >= len(members) {
+			names = append(names, word)
+		}
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if counts[names[a]] != counts[names[b]] {
+			return counts[names[a]] > counts[names[b]]
+		}
+		return names[a] < names[b]
+	})
+	if len(names) > 2 {
+		names = names[:2]
+	}
+	if len(names) == 0 {
+		for site, n := range siteCounts {
+			if len(names) == 0 || n > siteCounts[names[0]] || (n == siteCounts[names[0]] && site < names[0]) {
+				names = []string{site}
+			}
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// projectLabel names the project group a tab belongs to.
+func projectLabel(project string) string {
+	if project == "" {
+		return tr("no project")
+	}
+	return tr("project %s", project)
+}
+
+// selectProject selects every tab of the focused tab's project.
+func (m *model) selectProject() tea.Cmd {
+	focused, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return nil
+	}
+	project := focused.tab().Project
+	if project == "" {
+		return m.showToast(tr("This tab isn't part of a project."))
+	}
+	return m.selectWhere("Selected %d tabs of this project", func(t Tab) bool { return t.Project == project })
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// unfinishedWork lists what quitting now would throw away: the selection,
+// tabs still being closed or moved, a macro still playing and a recording
+// that wasn't saved.
+func (m model) unfinishedWork() []string {
+	var work []string
+	if m.closing && !m.closingDone {
+		work = append(work, tr("Tabs are still being closed."))
+	} else if selected := m.countSelected(); selected > 0 {
+		work = append(work, tr("%d tabs are selected.", selected))
+	}
+	if m.moving {
+		work = append(work, tr("Tabs are still being moved."))
+	}
+	if len(m.macroQueue) > 0 {
+		work = append(work, tr("A macro is still playing."))
+	}
+	if m.recording && len(m.macroKeys) > 0 {
+		work = append(work, tr("The macro being recorded isn't saved."))
+	}
+	return work
+}
+
+// requestQuit quits, unless that would throw work away, in which case it
+// asks first.
+func (m *model) requestQuit() tea.Cmd {
+	if len(m.unfinishedWork()) == 0 {
+		m.quitting = true
+		return tea.Quit
+	}
+	m.confirmQuit = true
+	return nil
+}
+
+// updateConfirmQuit handles a key while asking whether to quit.
+func (m *model) updateConfirmQuit(msg tea.KeyMsg) tea.Cmd {
+	idle := !m.closing && !m.moving
+	tabs := m.selectedTabs()
+
+	switch msg.String() {
+	case "y", "q", "ctrl+c":
+		// A close in flight stops after the current tab, leaving the rest
+		// in the close journal
+		stopClosing.Store(true)
+		m.quitting = true
+		return tea.Quit
+
+	case "w":
+		if !m.closing || m.closingDone {
+			return nil
+		}
+		m.confirmQuit = false
+		m.quitAfterClosing = true
+		m.macroQueue = nil
+
+	case "c":
+		if !idle || len(tabs) == 0 {
+			return nil
+		}
+		m.confirmQuit = false
+		m.quitAfterClosing = true
+		m.macroQueue = nil
+		return m.closeTabs(tabs)
+
+	case "s":
+		if len(tabs) == 0 {
+			return nil
+		}
+		saved, err := saveSession("", tabs)
+		if err != nil {
+			m.confirmQuit = false
+			return m.showToast(tr("Could not save session: %v", err))
+		}
+		m.quitting = true
+		m.quitMessage = tr("Saved %d tabs as session %q.", len(saved.Tabs), saved.Name)
+		return tea.Quit
+
+	case "esc", "n":
+		m.confirmQuit = false
+	}
+	return nil
+}
+
+// confirmQuitView asks whether to quit, with what would be lost.
+func (m model) confirmQuitView() string {
+	lines := []string{titleStyle.Render(tr("Quit?")), ""}
+	for _, work := range m.unfinishedWork() {
+		lines = append(lines, "  "+work)
+	}
+
+	lines = append(lines, "", "  "+tr("y: quit anyway"))
+	if m.closing && !m.closingDone {
+		lines = append(lines, "  "+tr("w: finish closing, then quit"))
+	}
+	if selected := m.countSelected(); selected > 0 {
+		if !m.closing && !m.moving {
+			lines = append(lines, "  "+tr("c: close the %d selected tabs, then quit", selected))
+		}
+		lines = append(lines, "  "+tr("s: save the %d selected tabs as a session, then quit", selected))
+	}
+	lines = append(lines, "  "+tr("esc: keep going"))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
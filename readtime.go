@@ -0,0 +1,132 @@
+package main
+
+import (
+	"html"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fetchArticleBytes = 4 * 1024 * 1024
+	wordsPerMinute    = 230
+	longReadMinutes   = 20 // Tabs at or above this are "long reads"
+)
+
+var estimateReadingTime bool // Set by the -reading-time flag
+
+var (
+	// Boilerplate that never counts towards the article text
+	boilerplateRegexp = regexp.MustCompile(`(?is)<(script|style|noscript|nav|header|footer|aside|form|svg)\b.*?</(script|style|noscript|nav|header|footer|aside|form|svg)>`)
+	articleRegexp     = regexp.MustCompile(`(?is)<article\b[^>]*>(.*)</article>`)
+	mainRegexp        = regexp.MustCompile(`(?is)<main\b[^>]*>(.*)</main>`)
+	bodyRegexp        = regexp.MustCompile(`(?is)<body\b[^>]*>(.*)</body>`)
+	tagRegexp         = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+type cachedWordCount struct {
+	Words        int       `json:"words"`
+	VideoSeconds int       `json:"video_seconds,omitempty"` // Length of a video page's video
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// countArticleWords is a rough readability extraction: it prefers the
+// <article> or <main> element, drops navigation and scripts, and counts the
+// words left in the text.
+func countArticleWords(page []byte) int {
+	content := boilerplateRegexp.ReplaceAll(page, nil)
+	for _, re := range []*regexp.Regexp{articleRegexp, mainRegexp, bodyRegexp} {
+		if match := re.FindSubmatch(content); match != nil {
+			content = match[1]
+			break
+		}
+	}
+	text := html.UnescapeString(string(tagRegexp.ReplaceAll(content, []byte(" "))))
+	return len(strings.Fields(text))
+}
+
+func readingMinutes(words int) int {
+	if words == 0 {
+		return 0
+	}
+	return max(1, (words+wordsPerMinute/2)/wordsPerMinute)
+}
+
+// addReadingTimes estimates how long each tab takes to read by fetching the
+// page and counting its words, or for a video, takes its length from the
+// page. Word counts and lengths are cached on disk.
+func addReadingTimes(tabs []Tab) []Tab {
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+		return tabs
+	}
+
+	cachePath := filepath.Join(dir, "wordcounts.json")
+	cache := make(map[string]cachedWordCount)
+	if err := loadJSON(cachePath, &cache); err != nil {
+		log.Printf("Warning: could not read word count cache: %v", err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+
+	for i := range tabs {
+		if !strings.HasPrefix(tabs[i].URL, "http://") && !strings.HasPrefix(tabs[i].URL, "https://") {
+			continue
+		}
+
+		if cached, ok := cache[tabs[i].URL]; ok && time.Since(cached.FetchedAt) < fetchCacheTTL {
+			setReadingTime(&tabs[i], cached)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			page, err := fetchPage(tabs[i].URL, fetchArticleBytes)
+			if err != nil {
+				log.Printf("Warning: could not fetch %s: %v", tabs[i].URL, err)
+				return
+			}
+
+			count := cachedWordCount{FetchedAt: time.Now()}
+			if tabs[i].Video {
+				count.VideoSeconds = videoSeconds(page)
+			} else {
+				count.Words = countArticleWords(page)
+			}
+			mu.Lock()
+			cache[tabs[i].URL] = count
+			mu.Unlock()
+
+			setReadingTime(&tabs[i], count)
+		}(i)
+	}
+	wg.Wait()
+
+	if err := saveJSON(cachePath, cache); err != nil {
+		log.Printf("Warning: could not write word count cache: %v", err)
+	}
+
+	return tabs
+}
+
+// setReadingTime sets a tab's reading time, or the length of its video if
+// the player didn't report one.
+func setReadingTime(tab *Tab, count cachedWordCount) {
+	if tab.Video {
+		if tab.VideoSeconds == 0 {
+			tab.VideoSeconds = count.VideoSeconds
+		}
+		return
+	}
+	tab.ReadingMinutes = readingMinutes(count.Words)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The report subcommand writes a summary of the open tabs to a file and
+// exits, for scheduled runs from launchd or cron. It never reads from the
+// terminal or starts the interactive list, so it runs without a TTY.
+
+// reportTopDomains is how many domains the report lists.
+const reportTopDomains = 10
+
+// runReport is the report subcommand.
+func runReport(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	out := flags.String("out", "-", tr("File to write the report to, - for standard output; {date} is replaced by today's date"))
+	format := flags.String("format", "markdown", tr("Report format: markdown or json"))
+	flags.Parse(args)
+
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", fmt.Errorf("unknown report format %q, want markdown or json", *format)))
+		os.Exit(1)
+	}
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flags, cfg, ageDays)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	tabs, _, err := getSafariTabs(*ageDays)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	pinned := len(tabs)
+	tabs = findDuplicates(withoutPinned(tabs))
+	pinned -= len(tabs)
+	logRun(tabs)
+
+	var b strings.Builder
+	if *format == "json" {
+		err = writeReportJSON(&b, tabs)
+	} else {
+		writeReportMarkdown(&b, tabs, pinned, *ageDays, time.Now())
+	}
+	if err == nil {
+		err = writeReport(*out, b.String())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+}
+
+// reportPath expands ~/ and {date} in the -out path.
+func reportPath(path string, now time.Time) string {
+	return expandHome(strings.ReplaceAll(path, "{date}", now.Format("2006-01-02")))
+}
+
+// writeReport writes a report to its file, or to standard output for -.
+// The file is replaced in one step, so a reader never sees half a report.
+func writeReport(out, report string) error {
+	if out == "-" {
+		_, err := io.WriteString(os.Stdout, report)
+		return err
+	}
+
+	path := reportPath(out, time.Now())
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(report), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeReportJSON writes the tabs as a JSON array, like hooks receive them.
+func writeReportJSON(w io.Writer, tabs []Tab) error {
+	records := make([]tabRecord, len(tabs))
+	for i, tab := range tabs {
+		records[i] = newTabRecord(tab)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// writeReportMarkdown writes a summary of the tabs, the busiest domains, the
+// duplicates and the old tabs, and then every tab by window.
+func writeReportMarkdown(w io.Writer, tabs []Tab, pinned, ageDays int, now time.Time) {
+	var duplicates, old []Tab
+	windows := make(map[int][]Tab)
+	var windowOrder []int
+	domains := make(map[string]int)
+	for _, tab := range tabs {
+		if tab.DuplicateOf != 0 {
+			duplicates = append(duplicates, tab)
+		}
+		if tab.IsOld {
+			old = append(old, tab)
+		}
+		if _, ok := windows[tab.WindowIndex]; !ok {
+			windowOrder = append(windowOrder, tab.WindowIndex)
+		}
+		windows[tab.WindowIndex] = append(windows[tab.WindowIndex], tab)
+		domains[extractDomain(tab.URL)]++
+	}
+
+	fmt.Fprintf(w, "# %s\n\n", tr("Safari tabs, %s", fullDateTime(now)))
+	fmt.Fprintf(w, "%s\n", tr("%d tabs in %d windows: %d duplicates, %d old (not active for %d days).", len(tabs), len(windows), len(duplicates), len(old), ageDays))
+	if pinned > 0 {
+		fmt.Fprintf(w, "%s\n", tr("%d pinned tabs are left out.", pinned))
+	}
+
+	if top := topDomains(domains, reportTopDomains); len(top) > 0 {
+		fmt.Fprintf(w, "\n## %s\n\n", tr("Top domains"))
+		for _, name := range top {
+			fmt.Fprintf(w, "- %s: %d\n", name, domains[name])
+		}
+	}
+
+	if len(duplicates) > 0 {
+		fmt.Fprintf(w, "\n## %s\n\n", tr("Duplicates"))
+		for _, tab := range duplicates {
+			original, _ := tabByID(tabs, tab.DuplicateOf)
+			fmt.Fprintf(w, "- %s (%s)\n", markdownLink(tab), tr("Window %d, Tab %d, duplicate of Window %d, Tab %d", tab.WindowIndex, tab.TabIndex, original.WindowIndex, original.TabIndex))
+		}
+	}
+
+	if len(old) > 0 {
+		sort.SliceStable(old, func(i, j int) bool { return old[i].lastActive().Before(old[j].lastActive()) })
+		fmt.Fprintf(w, "\n## %s\n\n", tr("Old tabs"))
+		for _, tab := range old {
+			note := tr("never visited")
+			if !tab.lastActive().IsZero() {
+				note = tr("last active %s", humanizeTime(tab.lastActive(), now))
+			}
+			fmt.Fprintf(w, "- %s (%s)\n", markdownLink(tab), note)
+		}
+	}
+
+	for _, window := range windowOrder {
+		fmt.Fprintf(w, "\n## %s\n\n", tr("Window %d", window))
+		for _, tab := range windows[window] {
+			fmt.Fprintf(w, "- %s\n", markdownLink(tab))
+		}
+	}
+}
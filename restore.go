@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Restoring a session opens its tabs in a new Safari window. Every tab
+// starts loading its page as it opens, so the tabs go in paced batches,
+// like closes, rather than all at once, and Safari stays usable while
+// dozens of pages load. In the background, Safari isn't brought to the
+// front, and the new window goes behind its other windows, so restoring
+// doesn't take over from whatever is in front.
+
+// openURLs opens the URLs as tabs of a new Safari window, in the background
+// if asked, and returns how many it opened.
+func openURLs(urls []string, background bool) (int, error) {
+	if len(urls) == 0 {
+		return 0, nil
+	}
+	// Started through AppleScript, Safari would come to the front
+	if background {
+		if running, err := safariRunning(); err == nil && !running {
+			if err := exec.Command("open", "-g", "-a", safariApp).Run(); err != nil {
+				return 0, fmt.Errorf("could not start %s: %w", safariApp, err)
+			}
+		}
+	}
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "tell application %s\n", appleScriptString(safariApp))
+	fmt.Fprintf(&script, "make new document with properties {URL:%s}\n", appleScriptString(urls[0]))
+	script.WriteString("set newWindow to id of front window\n")
+	if background {
+		script.WriteString("set index of front window to (count of windows)\n")
+	}
+	script.WriteString("return newWindow\nend tell\n")
+	output, err := exec.Command("osascript", "-e", script.String()).CombinedOutput()
+	if err != nil {
+		log.Printf("osascript: %s", output)
+		return 0, fmt.Errorf("could not open tabs: %w", err)
+	}
+	windowID, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 1, fmt.Errorf("could not find the new window: %q", strings.TrimSpace(string(output)))
+	}
+
+	opened := 1
+	p := newPacer()
+	rest := urls[1:]
+	var took time.Duration
+	for start := 0; start < len(rest); start += p.batchSize() {
+		p.pace(took)
+		end := min(start+p.batchSize(), len(rest))
+		var batch strings.Builder
+		fmt.Fprintf(&batch, "tell application %s\ntell (first window whose id is %d)\n", appleScriptString(safariApp), windowID)
+		for _, u := range rest[start:end] {
+			fmt.Fprintf(&batch, "make new tab at end of tabs with properties {URL:%s}\n", appleScriptString(u))
+		}
+		batch.WriteString("end tell\nend tell\n")
+
+		began := time.Now()
+		output, err := exec.Command("osascript", "-e", batch.String()).CombinedOutput()
+		took = time.Since(began)
+		if err != nil {
+			log.Printf("osascript: %s", output)
+			return opened, fmt.Errorf("could not open tabs: %w", err)
+		}
+		opened += end - start
+	}
+	return opened, nil
+}
+
+// sessionNames lists the saved sessions, the most recent first.
+func sessionNames() ([]string, error) {
+	return stateStore.SessionNames()
+}
+
+// runRestore is the restore subcommand: it opens a saved session's tabs in
+// a new window, or lists the sessions given no name.
+func runRestore(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	background := flags.Bool("background", false, tr("Open the tabs without bringing Safari to the front, in a window behind the others"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args)
+
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	if flags.NArg() == 0 {
+		names, err := sessionNames()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		if len(names) == 0 {
+			fmt.Println(tr("No saved sessions in %s.", stateStore.Location()))
+			return
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	s, err := loadSession(strings.Join(flags.Args(), " "))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	urls := make([]string, len(s.Tabs))
+	for i, tab := range s.Tabs {
+		urls[i] = tab.URL
+	}
+	opened, err := openURLs(urls, *background)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(tr("Opened %d tabs of session %q.", opened, s.Name))
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dedupeReview steps through the duplicate groups one pair at a time. The
+// left tab is the one kept so far, starting with the group's original; the
+// right tab is the next duplicate to decide on.
+type dedupeReview struct {
+	groups  [][]int // Tab indexes per group, original first
+	group   int
+	member  int // Position in groups[group] of the right tab
+	keep    int // Tab index of the left tab
+	pairs   int // Pairs in all groups
+	decided int // Pairs decided rather than skipped
+}
+
+func newDedupeReview(tabs []Tab) *dedupeReview {
+	r := &dedupeReview{member: 1}
+	positions := make(map[int]int) // Group position by original
+	for i, root := range duplicateGroups(tabs) {
+		if root < 0 {
+			continue
+		}
+		// Originals come before their duplicates, so they start the groups
+		pos, ok := positions[root]
+		if !ok {
+			pos = len(r.groups)
+			positions[root] = pos
+			r.groups = append(r.groups, nil)
+		} else {
+			r.pairs++
+		}
+		r.groups[pos] = append(r.groups[pos], i)
+	}
+	if len(r.groups) > 0 {
+		r.keep = r.groups[0][0]
+	}
+	return r
+}
+
+// pair returns the tab indexes of the left and right tab.
+func (r *dedupeReview) pair() (left, right int) {
+	return r.keep, r.groups[r.group][r.member]
+}
+
+// next moves on to the next pair, reporting false after the last one.
+func (r *dedupeReview) next() bool {
+	r.member++
+	if r.member < len(r.groups[r.group]) {
+		return true
+	}
+	r.group++
+	r.member = 1
+	if r.group >= len(r.groups) {
+		return false
+	}
+	r.keep = r.groups[r.group][0]
+	return true
+}
+
+// startReview enters the duplicate review.
+func (m *model) startReview() tea.Cmd {
+	review := newDedupeReview(m.tabs)
+	if len(review.groups) == 0 {
+		return m.showToast(tr("No duplicates to review."))
+	}
+	m.review = review
+	return nil
+}
+
+// endReview leaves the duplicate review, reporting what was decided.
+func (m *model) endReview() tea.Cmd {
+	toast := tr("Reviewed %d of %d duplicate pairs.", m.review.decided, m.review.pairs)
+	m.review = nil
+	return m.showToast(toast)
+}
+
+// updateReview handles a key while reviewing duplicates. Keeping one tab of
+// a pair selects the other for closing; the kept tab is compared with the
+// rest of its group.
+func (m *model) updateReview(msg tea.KeyMsg) tea.Cmd {
+	left, right := m.review.pair()
+	var keep, drop []int
+
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))):
+		return m.requestQuit()
+
+	case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+		return m.endReview()
+
+	case key.Matches(msg, key.NewBinding(key.WithKeys("left", "1"))):
+		keep, drop = []int{left}, []int{right}
+
+	case key.Matches(msg, key.NewBinding(key.WithKeys("right", "2"))):
+		keep, drop = []int{right}, []int{left}
+
+	case key.Matches(msg, key.NewBinding(key.WithKeys("b"))):
+		keep = []int{left, right}
+
+	case key.Matches(msg, key.NewBinding(key.WithKeys("s", " "))):
+		if !m.review.next() {
+			return m.endReview()
+		}
+		return nil
+
+	default:
+		return nil
+	}
+
+	for _, i := range drop {
+		if reason := m.tabs[i].lockReason(); reason != "" {
+			return m.showToast(tr("That tab is %s and can't be closed.", reason))
+		}
+	}
+	for _, i := range keep {
+		m.selected.set(i, false)
+	}
+	for _, i := range drop {
+		m.selected.set(i, true)
+	}
+	if len(drop) > 0 && drop[0] == left {
+		m.review.keep = right
+	}
+
+	m.review.decided++
+	if !m.review.next() {
+		return m.endReview()
+	}
+	return nil
+}
+
+// reviewURLLines limits how many lines a URL takes up in the review.
+const reviewURLLines = 6
+
+// reviewView shows the current pair side by side.
+func (m model) reviewView() string {
+	r := m.review
+	left, right := r.pair()
+
+	header := titleStyle.Render(tr("Duplicate review - group %d of %d, duplicate %d of %d",
+		r.group+1, len(r.groups), r.member, len(r.groups[r.group])-1))
+
+	const gap = "   "
+	width := max(minTextWidth, (m.list.Width()-textWidth(gap)-2)/2)
+	columns := joinColumns(gap, []int{width}, m.reviewColumn(left, right, width), m.reviewColumn(right, left, width))
+
+	hints := []string{
+		tr("left/1: keep left"),
+		tr("right/2: keep right"),
+		tr("b: keep both"),
+		tr("s: skip"),
+		tr("esc: back to the list"),
+	}
+
+	var toast string
+	if m.toast != "" {
+		toast = messageStyle.Render(" " + m.toast)
+	}
+	return header + "\n\n" + renderLines(titleStyle, columns) + "\n\n" +
+		helpStyle.Render(" "+strings.Join(hints, sym.separator)) + "\n" + toast
+}
+
+// reviewColumn renders the lines of one side of a pair, with the parts of
+// its URL that differ from the other side's highlighted.
+func (m model) reviewColumn(index, otherIndex int, width int) []string {
+	tab, other := m.tabs[index], m.tabs[otherIndex]
+	state := sym.unchecked + " " + tr("keep")
+	style := normalStyle
+	if m.selected.has(index) {
+		state = sym.checked + " " + tr("close")
+		style = duplicateStyle
+	}
+	if reason := tab.lockReason(); reason != "" {
+		state += sym.separator + reason
+	}
+
+	// Lines are cut to the column here, where their width is known, rather
+	// than wrapped by lipgloss, which measures emoji differently
+	dim := func(text string) string {
+		return helpStyle.Render(truncateEnd(text, width))
+	}
+	lines := []string{style.Bold(true).Render(truncateEnd(state, width))}
+	for _, line := range wrapText(singleLine(tab.Title), width, 2) {
+		lines = append(lines, style.Render(line))
+	}
+	shown := displayURL(tab.URL)
+	lines = append(lines, highlightDiff(shown, urlDiff(shown, displayURL(other.URL)), width, reviewURLLines)...)
+
+	lines = append(lines, "", dim(tab.location()))
+	now := time.Now()
+	if !tab.LastActivated.IsZero() {
+		lines = append(lines, dim(tr("Last active %s", humanizeTime(tab.LastActivated, now))))
+		lines = append(lines, dim("  "+fullDateTime(tab.LastActivated)))
+	}
+	if tab.LastVisit.IsZero() {
+		lines = append(lines, dim(tr("No recorded visits")))
+	} else {
+		lines = append(lines, dim(tr("Last visited %s", humanizeTime(tab.LastVisit, now))))
+		lines = append(lines, dim("  "+fullDateTime(tab.LastVisit)))
+	}
+	if tab.ReadingMinutes > 0 {
+		lines = append(lines, dim(tr("~%d min read", tab.ReadingMinutes)))
+	}
+	if tab.Video {
+		lines = append(lines, dim(videoInfo(&tab)))
+	}
+	if tab.PlaysAudio {
+		lines = append(lines, dim(sym.audio+tr("playing audio")))
+	} else if tab.Video && tab.PlaysVideo {
+		lines = append(lines, dim(sym.video+tr("playing")))
+	}
+
+	return lines
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const (
+	ruleTimeout  = 100 * time.Millisecond // Per tab
+	ruleMaxSteps = 1_000_000              // Per tab
+)
+
+// A ruleScript is a user Starlark script defining rule(tab), called once per
+// tab at scan time. Starlark has no access to files, the network or the
+// clock, and each call is bounded in time and steps, so a broken script
+// can't hang or harm anything.
+//
+// rule returns None to leave a tab alone, or a dict of actions:
+//
+//	def rule(tab):
+//	    if tab.category == "shopping" and tab.days_since_visit > 14:
+//	        return {"select": True, "archive": "~/Documents/Wishlist.md"}
+//	    if tab.domain == "mail.google.com":
+//	        return {"protect": True}
+//	    return {"tag": "work"} if "jira" in tab.domain else None
+//
+// It can also define on_open(tab), which serve mode calls for every tab
+// opened since its last scan; see onopen.go.
+type ruleScript struct {
+	path   string
+	rule   starlark.Callable // Nil if the script only defines on_open
+	onOpen starlark.Callable // Nil if the script only defines rule
+}
+
+// ruleActions is what rule(tab) asked for. Nil fields were not set.
+type ruleActions struct {
+	selected *bool
+	protect  *bool
+	tags     []string
+	archive  string
+}
+
+var rules *ruleScript // Set from config.json, nil without a rules script
+
+var protectedDomains []string // Set from config.json
+
+// loadRuleScript executes the script once and looks up its rule function.
+func loadRuleScript(path string) (*ruleScript, error) {
+	path = expandHome(path)
+	if !filepath.IsAbs(path) {
+		// Relative paths are relative to the config directory
+		dir, err := configDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, path)
+	}
+
+	thread := &starlark.Thread{Name: "load " + path}
+	thread.SetMaxExecutionSteps(ruleMaxSteps)
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not load rules script %s: %w", path, err)
+	}
+
+	rule, _ := globals["rule"].(starlark.Callable)
+	onOpen, _ := globals["on_open"].(starlark.Callable)
+	if rule == nil && onOpen == nil {
+		return nil, fmt.Errorf("rules script %s must define a rule(tab) or on_open(tab) function", path)
+	}
+	return &ruleScript{path: path, rule: rule, onOpen: onOpen}, nil
+}
+
+// ruleTab is the tab as seen by the script.
+func ruleTab(tab Tab) *starlarkstruct.Struct {
+	daysSinceVisit := -1
+	if !tab.LastVisit.IsZero() {
+		daysSinceVisit = int(time.Since(tab.LastVisit).Hours() / 24)
+	}
+	daysSinceActive := -1
+	if !tab.lastActive().IsZero() {
+		daysSinceActive = int(time.Since(tab.lastActive()).Hours() / 24)
+	}
+	return starlarkstruct.FromStringDict(starlark.String("tab"), starlark.StringDict{
+		"title":                 starlark.String(tab.Title),
+		"url":                   starlark.String(tab.URL),
+		"domain":                starlark.String(extractDomain(tab.URL)),
+		"window":                starlark.MakeInt(tab.WindowIndex),
+		"index":                 starlark.MakeInt(tab.TabIndex),
+		"category":              starlark.String(tab.Category),
+		"search_query":          starlark.String(tab.SearchQuery),
+		"doc_set":               starlark.String(tab.DocSet),
+		"language":              starlark.String(tab.Language),
+		"product":               starlark.Bool(tab.Product),
+		"price":                 starlark.String(tab.Price),
+		"old":                   starlark.Bool(tab.IsOld),
+		"duplicate":             starlark.Bool(tab.DuplicateOf != 0),
+		"days_since_visit":      starlark.MakeInt(daysSinceVisit),
+		"days_since_active":     starlark.MakeInt(daysSinceActive),
+		"days_since_first_seen": starlark.MakeInt(daysSince(tab.FirstSeen)),
+		"reading_minutes":       starlark.MakeInt(tab.ReadingMinutes),
+		"playing_audio":         starlark.Bool(tab.PlaysAudio),
+		"playing_video":         starlark.Bool(tab.Video && tab.PlaysVideo),
+		"video":                 starlark.Bool(tab.Video),
+		"video_seconds":         starlark.MakeInt(tab.VideoSeconds),
+		"video_watched":         starlark.Bool(tab.watched()),
+		"loading":               starlark.Bool(tab.Loading),
+		"pinned":                starlark.Bool(tab.Pinned),
+		"read_elsewhere":        starlark.Bool(tab.ReadElsewhere),
+		"saved_elsewhere":       starlark.Bool(tab.SavedIn != ""),
+		"memory_mb":             starlark.MakeInt(tab.MemoryMB),
+		"heavy":                 starlark.Bool(tab.heavy()),
+		"suspended":             starlark.Bool(tab.SuspendedURL != ""),
+		"blocked":               starlark.Bool(tab.Blocked),
+		"expired":               starlark.Bool(tab.Expired != ""),
+		"resolved":              starlark.Bool(tab.Resolved != ""),
+	})
+}
+
+// callRule calls a function of the script with the tab under the per-tab
+// time and step limits. It returns the dict of actions, or nil for None.
+func callRule(name string, fn starlark.Callable, tab Tab) (*starlark.Dict, error) {
+	thread := &starlark.Thread{Name: name}
+	thread.SetMaxExecutionSteps(ruleMaxSteps)
+	timer := time.AfterFunc(ruleTimeout, func() { thread.Cancel("time limit exceeded") })
+	defer timer.Stop()
+
+	result, err := starlark.Call(thread, fn, starlark.Tuple{ruleTab(tab)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if result == starlark.None {
+		return nil, nil
+	}
+	dict, ok := result.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("%s must return None or a dict, got %s", name, result.Type())
+	}
+	return dict, nil
+}
+
+// evaluate calls rule(tab).
+func (r *ruleScript) evaluate(tab Tab) (ruleActions, error) {
+	var actions ruleActions
+	if r.rule == nil {
+		return actions, nil
+	}
+
+	dict, err := callRule("rule", r.rule, tab)
+	if err != nil || dict == nil {
+		return actions, err
+	}
+
+	for _, entry := range dict.Items() {
+		name, ok := starlark.AsString(entry[0])
+		if !ok {
+			return actions, fmt.Errorf("rule returned a non-string key %s", entry[0])
+		}
+		value := entry[1]
+
+		switch name {
+		case "select", "protect":
+			b := bool(value.Truth())
+			if name == "select" {
+				actions.selected = &b
+			} else {
+				actions.protect = &b
+			}
+		case "tag":
+			tags, err := ruleStrings(value)
+			if err != nil {
+				return actions, fmt.Errorf("tag: %w", err)
+			}
+			actions.tags = tags
+		case "archive":
+			target, ok := starlark.AsString(value)
+			if !ok {
+				return actions, fmt.Errorf("archive must be a string, got %s", value.Type())
+			}
+			actions.archive = target
+		default:
+			return actions, fmt.Errorf("unknown action %q", name)
+		}
+	}
+	return actions, nil
+}
+
+// ruleStrings accepts a string or a list of strings.
+func ruleStrings(value starlark.Value) ([]string, error) {
+	if s, ok := starlark.AsString(value); ok {
+		return []string{s}, nil
+	}
+	list, ok := value.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("want a string or a list of strings, got %s", value.Type())
+	}
+	result := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		s, ok := starlark.AsString(list.Index(i))
+		if !ok {
+			return nil, fmt.Errorf("want a string or a list of strings, got %s", list.Index(i).Type())
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// applyRules marks tabs on protected domains and the blocklist, and runs the
+// rules script over every tab. A failing rule only affects its own tab.
+// Blocked tabs are selected; pinned and protected tabs never are.
+func applyRules(tabs []Tab) []Tab {
+	for i := range tabs {
+		tabs[i].Blocked = blocked(tabs[i].URL)
+
+		if rules != nil {
+			actions, err := rules.evaluate(tabs[i])
+			if err != nil {
+				log.Printf("Warning: rule failed for %s: %v", tabs[i].URL, err)
+			} else {
+				if actions.selected != nil {
+					tabs[i].Selected = *actions.selected
+				}
+				if actions.protect != nil {
+					tabs[i].Protected = *actions.protect
+				}
+				tabs[i].Tags = append(tabs[i].Tags, actions.tags...)
+				if actions.archive != "" {
+					tabs[i].ArchiveTarget = actions.archive
+				}
+			}
+		}
+
+		// The config's blocklist and protected domains win over rules, but
+		// a blocked tab playing audio or video is left for you to close
+		if tabs[i].Blocked && !tabs[i].playing() {
+			tabs[i].Selected = true
+		}
+		if onDomain(tabs[i].URL, protectedDomains) {
+			tabs[i].Protected = true
+		}
+		// Like every other suggestion, a rule's never selects a tab
+		// playing audio or video
+		if tabs[i].locked() || tabs[i].playing() {
+			tabs[i].Selected = false
+		}
+	}
+	return tabs
+}
+
+// onDomain reports whether url is on one of domains or their subdomains.
+func onDomain(url string, domains []string) bool {
+	host := extractDomain(url)
+	for _, domain := range domains {
+		domain = unicodeHost(strings.TrimPrefix(strings.ToLower(domain), "www."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
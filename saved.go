@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// With -reading-list and -bookmarks FOLDER the list takes in the Reading
+// List and a bookmarks folder along with the open tabs, each item labeled
+// with where it's from, so everything saved for later can be triaged in one
+// session. Closing a saved item removes it from Bookmarks.plist. Safari
+// writes that file back while it runs, so until Safari is quit the removals
+// wait in the cache directory, with the items left out of the list; they
+// are made the next time this runs with Safari quit, or with saved -apply.
+
+var (
+	includeReadingList bool   // Set by -reading-list
+	bookmarksFolder    string // Set by -bookmarks
+)
+
+// Sources of saved items, for Tab.Source.
+const (
+	sourceReadingList = "reading-list"
+	sourceBookmarks   = "bookmarks"
+)
+
+// sourceLabel is the badge of a saved item's source, "" for an open tab.
+func sourceLabel(source string) string {
+	switch source {
+	case sourceReadingList:
+		return tr("[READING LIST]")
+	case sourceBookmarks:
+		return tr("[BOOKMARK]")
+	}
+	return ""
+}
+
+// container names the window a tab is in, or a saved item's source.
+func (t Tab) container() string {
+	switch t.Source {
+	case sourceReadingList:
+		return tr("Reading List")
+	case sourceBookmarks:
+		return tr("Bookmarks")
+	}
+	return tr("Window %d", t.WindowIndex)
+}
+
+// location names where a tab is: its window and position, or a saved
+// item's source.
+func (t Tab) location() string {
+	if t.Source != "" {
+		return t.container()
+	}
+	return tr("Window %d, Tab %d", t.WindowIndex, t.TabIndex)
+}
+
+// openTabs returns the tabs that are open in Safari, leaving out saved
+// items.
+func openTabs(tabs []Tab) []Tab {
+	var result []Tab
+	for _, tab := range tabs {
+		if tab.Source == "" {
+			result = append(result, tab)
+		}
+	}
+	return result
+}
+
+// splitSaved separates saved items from open tabs.
+func splitSaved(tabs []Tab) (open, saved []Tab) {
+	for _, tab := range tabs {
+		if tab.Source != "" {
+			saved = append(saved, tab)
+		} else {
+			open = append(open, tab)
+		}
+	}
+	return open, saved
+}
+
+func bookmarksPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	library := "Safari"
+	if safariApp == "Safari Technology Preview" {
+		library = "SafariTechnologyPreview"
+	}
+	return filepath.Join(homeDir, "Library", library, "Bookmarks.plist"), nil
+}
+
+// loadBookmarks reads Bookmarks.plist, which is binary, through plutil.
+func loadBookmarks(path string) (*plistNode, error) {
+	output, err := exec.Command("plutil", "-convert", "xml1", "-o", "-", path).Output()
+	if err != nil {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			return nil, fmt.Errorf("%s has no bookmarks", safariApp)
+		}
+		return nil, fmt.Errorf("could not read the bookmarks: %w", err)
+	}
+	var root plistNode
+	if err := xml.Unmarshal(output, &root); err != nil {
+		return nil, fmt.Errorf("could not read the bookmarks: %w", err)
+	}
+	if len(root.Nodes) == 0 {
+		return nil, fmt.Errorf("could not read the bookmarks: empty property list")
+	}
+	return &root, nil
+}
+
+// plistText returns the text of a key in a dict node, or "".
+func plistText(n *plistNode, key string) string {
+	if v := n.value(key); v != nil {
+		return v.Text
+	}
+	return ""
+}
+
+// bookmarkChildren returns the entries of a bookmarks folder.
+func bookmarkChildren(folder *plistNode) []plistNode {
+	if children := folder.value("Children"); children != nil {
+		return children.Nodes
+	}
+	return nil
+}
+
+// isBookmarkFolder reports whether an entry is a folder.
+func isBookmarkFolder(n *plistNode) bool {
+	return plistText(n, "WebBookmarkType") == "WebBookmarkTypeList"
+}
+
+// folderTitle is a folder's name as Safari shows it.
+func folderTitle(n *plistNode) string {
+	switch title := plistText(n, "Title"); title {
+	case "BookmarksBar":
+		return "Favorites"
+	case "BookmarksMenu":
+		return "Bookmarks Menu"
+	default:
+		return title
+	}
+}
+
+// bookmarkFolder finds a folder by its path from the top, like
+// "Favorites/Read later", or by its name alone at any depth.
+func bookmarkFolder(top *plistNode, path string) *plistNode {
+	folder := top
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		var next *plistNode
+		children := bookmarkChildren(folder)
+		for i := range children {
+			if isBookmarkFolder(&children[i]) && strings.EqualFold(folderTitle(&children[i]), name) {
+				next = &children[i]
+				break
+			}
+		}
+		if next == nil {
+			folder = nil
+			break
+		}
+		folder = next
+	}
+	if folder == nil && !strings.Contains(path, "/") {
+		return findBookmarkFolder(top, path)
+	}
+	return folder
+}
+
+// findBookmarkFolder searches the folders below n for one named name.
+func findBookmarkFolder(n *plistNode, name string) *plistNode {
+	children := bookmarkChildren(n)
+	for i := range children {
+		if !isBookmarkFolder(&children[i]) {
+			continue
+		}
+		if strings.EqualFold(folderTitle(&children[i]), name) {
+			return &children[i]
+		}
+		if found := findBookmarkFolder(&children[i], name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// bookmarkItems returns the bookmarks in a folder and its subfolders as
+// saved items from source, leaving out those in skip.
+func bookmarkItems(folder *plistNode, source string, skip map[string]bool) []Tab {
+	var items []Tab
+	children := bookmarkChildren(folder)
+	for i := range children {
+		n := &children[i]
+		if isBookmarkFolder(n) {
+			items = append(items, bookmarkItems(n, source, skip)...)
+			continue
+		}
+		url := plistText(n, "URLString")
+		id := plistText(n, "WebBookmarkUUID")
+		// Removing goes by the id, so an item without one can't be told
+		// from others like it and is left out
+		if url == "" || id == "" || skip[id] {
+			continue
+		}
+		item := Tab{URL: url, Source: source, SavedID: id}
+		if uri := n.value("URIDictionary"); uri != nil {
+			item.Title = plistText(uri, "title")
+		}
+		if reading := n.value("ReadingList"); reading != nil {
+			item.FirstSeen, _ = time.Parse(time.RFC3339, plistText(reading, "DateAdded"))
+			item.LastActivated, _ = time.Parse(time.RFC3339, plistText(reading, "DateLastViewed"))
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// savedItems returns the Reading List items and the bookmarks in
+// bookmarksFolder, as asked for, as tabs without a window. Those waiting to
+// be removed are left out.
+func savedItems() ([]Tab, error) {
+	if !includeReadingList && bookmarksFolder == "" {
+		return nil, nil
+	}
+	path, err := bookmarksPath()
+	if err != nil {
+		return nil, err
+	}
+	root, err := loadBookmarks(path)
+	if err != nil {
+		return nil, err
+	}
+	removals, err := loadSavedRemovals()
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool)
+	for _, removal := range removals {
+		skip[removal.ID] = true
+	}
+
+	top := &root.Nodes[0]
+	var items []Tab
+	if includeReadingList {
+		for _, child := range bookmarkChildren(top) {
+			if plistText(&child, "Title") == "com.apple.ReadingList" {
+				items = append(items, bookmarkItems(&child, sourceReadingList, skip)...)
+			}
+		}
+	}
+	if bookmarksFolder != "" {
+		folder := bookmarkFolder(top, bookmarksFolder)
+		if folder == nil {
+			return nil, fmt.Errorf("no bookmarks folder %q", bookmarksFolder)
+		}
+		items = append(items, bookmarkItems(folder, sourceBookmarks, skip)...)
+	}
+	return items, nil
+}
+
+// savedRemoval is a saved item waiting to be removed from Bookmarks.plist.
+type savedRemoval struct {
+	ID       string    `json:"id"` // WebBookmarkUUID
+	Source   string    `json:"source"`
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+func savedRemovalsPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "saved-removals.json"), nil
+}
+
+// loadSavedRemovals reads the removals waiting for Safari to quit.
+func loadSavedRemovals() ([]savedRemoval, error) {
+	path, err := savedRemovalsPath()
+	if err != nil {
+		return nil, err
+	}
+	var removals []savedRemoval
+	if err := loadJSON(path, &removals); err != nil {
+		return nil, fmt.Errorf("could not read the saved items to remove: %w", err)
+	}
+	return removals, nil
+}
+
+// removeSaved queues saved items for removal from Bookmarks.plist, and
+// removes them right away if Safari isn't running. It returns how many of
+// items wait for Safari to quit.
+func removeSaved(items []Tab) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	path, err := savedRemovalsPath()
+	if err != nil {
+		return 0, err
+	}
+	removals, err := loadSavedRemovals()
+	if err != nil {
+		return 0, err
+	}
+	var queued []Tab
+	for _, item := range items {
+		if item.SavedID == "" {
+			continue
+		}
+		queued = append(queued, item)
+		removals = append(removals, savedRemoval{ID: item.SavedID, Source: item.Source, Title: item.Title, URL: item.URL, QueuedAt: time.Now()})
+	}
+	if len(queued) == 0 {
+		return 0, nil
+	}
+	if err := saveJSON(path, removals); err != nil {
+		return 0, fmt.Errorf("could not queue the saved items to remove: %w", err)
+	}
+	logActions("close", queued)
+
+	if running, err := safariRunning(); err != nil || running {
+		return len(queued), nil
+	}
+	if _, _, err := applySavedRemovals(); err != nil {
+		return len(queued), err
+	}
+	return 0, nil
+}
+
+// applySavedRemovals removes the queued saved items from Bookmarks.plist,
+// after backing it up. Safari must be quit, since it writes the file back.
+func applySavedRemovals() (removed int, backup string, err error) {
+	removals, err := loadSavedRemovals()
+	if err != nil || len(removals) == 0 {
+		return 0, "", err
+	}
+	if running, err := safariRunning(); err != nil {
+		return 0, "", err
+	} else if running {
+		return 0, "", fmt.Errorf("quit %s first, it would write its bookmarks back", safariApp)
+	}
+
+	path, err := bookmarksPath()
+	if err != nil {
+		return 0, "", err
+	}
+	root, err := loadBookmarks(path)
+	if err != nil {
+		return 0, "", err
+	}
+	ids := make(map[string]bool)
+	for _, removal := range removals {
+		if removal.ID != "" {
+			ids[removal.ID] = true
+		}
+	}
+	removed = removeBookmarks(&root.Nodes[0], ids)
+
+	if removed > 0 {
+		dir, err := cacheDir()
+		if err != nil {
+			return 0, "", err
+		}
+		backup = filepath.Join(dir, "bookmarks-backups", time.Now().Format("2006-01-02 15.04.05"))
+		if err := os.MkdirAll(backup, 0o755); err != nil {
+			return 0, "", err
+		}
+		if err := copyFile(path, filepath.Join(backup, filepath.Base(path))); err != nil {
+			return 0, "", fmt.Errorf("could not back up the bookmarks: %w", err)
+		}
+		if err := writePlist(path, root); err != nil {
+			return 0, backup, fmt.Errorf("could not write the bookmarks: %w", err)
+		}
+	}
+
+	// Items already gone, removed in Safari or on another device, are done
+	// too
+	queuePath, err := savedRemovalsPath()
+	if err != nil {
+		return removed, backup, err
+	}
+	if err := os.Remove(queuePath); err != nil && !os.IsNotExist(err) {
+		return removed, backup, err
+	}
+	return removed, backup, nil
+}
+
+// removeBookmarks removes the entries with the given ids from a folder and
+// its subfolders, returning how many it removed.
+func removeBookmarks(folder *plistNode, ids map[string]bool) int {
+	children := folder.value("Children")
+	if children == nil {
+		return 0
+	}
+	removed := 0
+	var kept []plistNode
+	for _, child := range children.Nodes {
+		if ids[plistText(&child, "WebBookmarkUUID")] {
+			removed++
+			continue
+		}
+		if isBookmarkFolder(&child) {
+			removed += removeBookmarks(&child, ids)
+		}
+		kept = append(kept, child)
+	}
+	children.Nodes = kept
+	if len(kept) == 0 {
+		children.Text = "" // Just the indentation, see trimPlist
+	}
+	return removed
+}
+
+// applyPendingRemovals makes the queued removals if Safari isn't running,
+// at the start of a cleanup.
+func applyPendingRemovals() {
+	removals, err := loadSavedRemovals()
+	if err != nil || len(removals) == 0 {
+		return
+	}
+	if running, err := safariRunning(); err != nil || running {
+		return
+	}
+	removed, backup, err := applySavedRemovals()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Warning: could not remove saved items: %v", err))
+		return
+	}
+	if removed > 0 {
+		fmt.Println(tr("Removed %d saved items from %s's bookmarks. The old bookmarks are backed up in %s.", removed, safariApp, backup))
+	}
+}
+
+// savedNote tells how many of the items just closed are saved items
+// waiting for Safari to quit, if any, to follow a message about closing
+// them.
+func savedNote(pending int, err error) string {
+	if err != nil {
+		return " " + tr("Saved items couldn't be removed: %v", err)
+	}
+	if pending == 0 {
+		return ""
+	}
+	return " " + tr("%d saved items will be removed once %s is quit, the next time this runs or with saved -apply.", pending, safariApp)
+}
+
+// runSaved is the saved subcommand: it lists the saved items waiting to be
+// removed, or with -apply removes them.
+func runSaved(args []string) {
+	flags := flag.NewFlagSet("saved", flag.ExitOnError)
+	apply := flags.Bool("apply", false, tr("Remove the closed Reading List items and bookmarks from Safari, which must be quit"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.Parse(args)
+
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	if !*apply {
+		removals, err := loadSavedRemovals()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		if len(removals) == 0 {
+			fmt.Println(tr("No saved items are waiting to be removed."))
+			return
+		}
+		for _, removal := range removals {
+			fmt.Printf("%s %s\n   %s\n", sourceLabel(removal.Source), removal.Title, removal.URL)
+		}
+		fmt.Println(tr("Quit %s and run saved -apply to remove them.", safariApp))
+		return
+	}
+
+	removed, backup, err := applySavedRemovals()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if removed == 0 {
+		fmt.Println(tr("No saved items are waiting to be removed."))
+		return
+	}
+	fmt.Println(tr("Removed %d saved items from %s's bookmarks. The old bookmarks are backed up in %s.", removed, safariApp, backup))
+}
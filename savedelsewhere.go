@@ -0,0 +1,149 @@
+package main
+
+import "fmt"
+
+// A tab whose page is already in the Reading List or the bookmarks can be
+// closed without losing it. Such tabs are marked "saved in" the place
+// that has them, compared the way dedupe compares tabs, and
+// "saved_elsewhere" in the config decides, for the Reading List and the
+// bookmarks each, whether they are preselected for closing like
+// duplicates, only marked, or not looked for at all, apart from how tab
+// duplicates are handled. A page in the Reading List was put there to read
+// later, while a bookmarked one is as often a site used every day, so by
+// default only the first are preselected.
+
+// Saved elsewhere policies.
+const (
+	savedElsewhereSelect = "select" // Mark the tabs and preselect them
+	savedElsewhereMark   = "mark"   // Only mark the tabs
+	savedElsewhereOff    = "off"    // Don't look
+)
+
+// savedElsewhereConfig sets the policy for each place.
+type savedElsewhereConfig struct {
+	ReadingList string `json:"reading_list"` // Default select
+	Bookmarks   string `json:"bookmarks"`    // Default mark
+}
+
+var savedElsewhere = savedElsewhereConfig{ReadingList: savedElsewhereSelect, Bookmarks: savedElsewhereMark} // Set from config.json
+
+// validate checks the policies, and fills in the defaults of those not
+// given.
+func (c *savedElsewhereConfig) validate() error {
+	for _, policy := range []*string{&c.ReadingList, &c.Bookmarks} {
+		if *policy != "" && *policy != savedElsewhereSelect && *policy != savedElsewhereMark && *policy != savedElsewhereOff {
+			return fmt.Errorf("unknown saved_elsewhere policy %q, want select, mark or off", *policy)
+		}
+	}
+	if c.ReadingList == "" {
+		c.ReadingList = savedElsewhereSelect
+	}
+	if c.Bookmarks == "" {
+		c.Bookmarks = savedElsewhereMark
+	}
+	return nil
+}
+
+// policy returns the policy for a source.
+func (c savedElsewhereConfig) policy(source string) string {
+	if source == sourceReadingList {
+		return c.ReadingList
+	}
+	return c.Bookmarks
+}
+
+// on reports whether any place is looked in.
+func (c savedElsewhereConfig) on() bool {
+	return c.ReadingList != savedElsewhereOff || c.Bookmarks != savedElsewhereOff
+}
+
+// A savedPlace is where a page is saved.
+type savedPlace struct {
+	source   string // sourceReadingList or sourceBookmarks
+	location string // For people, like "Reading List" or "Bookmarks: Favorites/Recipes"
+}
+
+// savedLocations maps the URLs in the Reading List and the bookmarks, as
+// dedupe compares them, to where they are saved, the first place first.
+// Items waiting to be removed don't count.
+func savedLocations() (map[string]savedPlace, error) {
+	path, err := bookmarksPath()
+	if err != nil {
+		return nil, err
+	}
+	root, err := loadBookmarks(path)
+	if err != nil {
+		return nil, err
+	}
+	removals, err := loadSavedRemovals()
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool)
+	for _, removal := range removals {
+		skip[removal.ID] = true
+	}
+
+	locations := make(map[string]savedPlace)
+	var walk func(folder *plistNode, place savedPlace)
+	walk = func(folder *plistNode, place savedPlace) {
+		children := bookmarkChildren(folder)
+		for i := range children {
+			n := &children[i]
+			if isBookmarkFolder(n) {
+				switch {
+				case place.location != "":
+					walk(n, savedPlace{place.source, place.location + "/" + folderTitle(n)})
+				case plistText(n, "Title") == "com.apple.ReadingList":
+					walk(n, savedPlace{sourceReadingList, tr("Reading List")})
+				default:
+					walk(n, savedPlace{sourceBookmarks, tr("Bookmarks") + ": " + folderTitle(n)})
+				}
+				continue
+			}
+			u := plistText(n, "URLString")
+			if u == "" || skip[plistText(n, "WebBookmarkUUID")] {
+				continue
+			}
+			key := canonicalURL(withoutIgnoredParams(u))
+			if _, ok := locations[key]; ok {
+				continue
+			}
+			if place.location == "" {
+				place = savedPlace{sourceBookmarks, tr("Bookmarks")}
+			}
+			locations[key] = place
+		}
+	}
+	walk(&root.Nodes[0], savedPlace{})
+	return locations, nil
+}
+
+// markSavedElsewhere sets SavedIn on the open tabs whose page is in the
+// Reading List or the bookmarks, and preselects them where the policy says
+// so. Without access to the bookmarks it does nothing; the header says
+// what's missing.
+func markSavedElsewhere(tabs []Tab) error {
+	if !savedElsewhere.on() || !capBookmarks.available() {
+		return nil
+	}
+	locations, err := savedLocations()
+	if err != nil {
+		return fmt.Errorf("could not look for tabs saved elsewhere: %w", err)
+	}
+	for i := range tabs {
+		if tabs[i].Source != "" || tabs[i].Pinned {
+			continue
+		}
+		place, ok := locations[canonicalURL(withoutIgnoredParams(tabs[i].URL))]
+		policy := savedElsewhere.policy(place.source)
+		if !ok || policy == savedElsewhereOff {
+			continue
+		}
+		tabs[i].SavedIn = place.location
+		if policy == savedElsewhereSelect {
+			tabs[i].Selected = !tabs[i].playing()
+		}
+	}
+	return nil
+}
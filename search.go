@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Searching opens a tab of results, and searching again for the same thing,
+// on the same engine or another, opens another. Result tabs of Google,
+// DuckDuckGo, Bing and other engines are recognized by their query, and only
+// the latest search for a query is kept: the older ones are duplicates of
+// it, stale searches. URL similarity doesn't apply to them, since results
+// for different queries share everything but the query.
+
+// searchQuery returns the normalized query of a search results URL, or an
+// empty string if the URL isn't one.
+func searchQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	param := "q"
+	switch {
+	case strings.HasPrefix(host, "google.") && u.Path == "/search":
+	case host == "bing.com" && u.Path == "/search":
+	case host == "duckduckgo.com" || host == "html.duckduckgo.com" || host == "lite.duckduckgo.com":
+	case (host == "search.brave.com" || host == "kagi.com" || host == "ecosia.org") && u.Path == "/search":
+	case host == "search.yahoo.com" && u.Path == "/search":
+		param = "p"
+	default:
+		return ""
+	}
+	return strings.Join(strings.Fields(strings.ToLower(u.Query().Get(param))), " ")
+}
+
+// markStaleSearches sets SearchQuery on search result tabs and makes every
+// search but the most recently visited for a query a duplicate of it. Of
+// searches visited at the same time, the first is kept.
+func markStaleSearches(tabs []Tab) {
+	latest := make(map[string]int)
+	for i := range tabs {
+		tabs[i].SearchQuery = searchQuery(tabs[i].URL)
+		query := tabs[i].SearchQuery
+		if query == "" || tabs[i].Pinned {
+			continue
+		}
+		if j, ok := latest[query]; !ok || tabs[i].LastVisit.After(tabs[j].LastVisit) {
+			latest[query] = i
+		}
+	}
+
+	for i := range tabs {
+		keep, ok := latest[tabs[i].SearchQuery]
+		if !ok || i == keep || tabs[i].Pinned {
+			continue
+		}
+		tabs[i].DuplicateOf = tabs[keep].ID
+		tabs[i].Selected = !tabs[i].playing()
+	}
+}
+
+// searchLabel names the group of searches for a query.
+func searchLabel(query string) string {
+	if query == "" {
+		return tr("not a search")
+	}
+	return tr("search %q", query)
+}
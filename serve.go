@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// scanner rescans Safari on an interval and keeps the latest tabs for the
+// HTTP handlers of serve mode.
+type scanner struct {
+	ageDays int
+	events  *eventHub
+
+	scanMu     sync.Mutex // One scan at a time, since on_open runs without mu
+	mu         sync.RWMutex
+	tabs       []Tab
+	scannedAt  time.Time
+	scanErrors int64
+	openTags   map[string][]string // Tags on_open gave to tabs still open, by URL
+	overBudget bool                // Warned about the tab budget already
+
+	growthNotified time.Time // When the growth alert was last shown
+	autosavedAt    time.Time // When the tabs were last autosaved
+}
+
+// scan reads Safari's tabs once and broadcasts what changed since the last
+// scan. On failure the previous tabs are kept.
+func (s *scanner) scan() {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+	tabs, _, err := getSafariTabs(s.ageDays)
+	if err == nil {
+		tabs = applyRules(findDuplicates(withoutPinned(tabs)))
+	}
+	if err != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		log.Printf("Warning: scan failed: %v", err)
+		s.scanErrors++
+		return
+	}
+
+	// on_open can take a while over many new tabs, so it runs before the
+	// lock is taken; only scans change s.tabs, and scanMu keeps them apart
+	s.mu.RLock()
+	before, scanned := s.tabs, !s.scannedAt.IsZero()
+	s.mu.RUnlock()
+	var reaction openReaction
+	if scanned {
+		if opened := openedTabs(before, tabs); len(opened) > 0 {
+			reaction = evaluateOpened(opened, tabs)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(reaction.opened) > 0 {
+		// Closing rescans, so the reaction can't wait for the scan
+		go s.act(reaction)
+	}
+	for url, tags := range reaction.tags {
+		s.openTags[url] = append(s.openTags[url], tags...)
+	}
+	s.tagOpened(tabs)
+	if !s.scannedAt.IsZero() {
+		s.events.broadcast(diffTabs(s.tabs, tabs))
+	}
+	s.tabs = tabs
+	s.scannedAt = time.Now()
+}
+
+// run scans every interval, forever.
+func (s *scanner) run(interval time.Duration, enforceBudget bool) {
+	for range time.Tick(interval) {
+		s.scan()
+		s.closeBlocked()
+		s.checkBudget(enforceBudget)
+		s.flushArchiveQueue()
+		s.checkGrowth()
+		s.checkAutosave()
+	}
+}
+
+// runServe is the serve subcommand: a daemon that rescans Safari on an
+// interval and serves what it finds over HTTP.
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:9413", tr("Address to listen on"))
+	interval := flags.Duration("interval", time.Minute, tr("How often to rescan Safari"))
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	flags.StringVar(&archiveFile, "archive-file", defaultArchiveFile(), tr("Markdown file that archived tabs are appended to, or a Slack or Discord webhook URL to post them to"))
+	grpcAddr := flags.String("grpc-addr", "127.0.0.1:9414", tr("Address to serve the gRPC API on, empty to disable it"))
+	flags.IntVar(&metricsTopDomains, "top-domains", metricsTopDomains, tr("Number of domains to report tab counts for"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.IntVar(&tabBudget, "budget", 0, tr("Tab budget, the most tabs to keep open, 0 for none"))
+	enforceBudget := flags.Bool("enforce", false, tr("Archive the tabs left alone longest whenever more tabs than the budget are open"))
+	flags.StringVar(&autosave.Every, "autosave", "", tr("Save the open tabs on a schedule: hourly or daily"))
+	flags.Parse(args)
+
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flags, cfg, ageDays)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+	if note := unavailableNote(); note != "" {
+		log.Print(note)
+	}
+	if *ageDays < 1 {
+		fmt.Fprintln(os.Stderr, tr("Error: age must be at least 1 day"))
+		os.Exit(1)
+	}
+	if *interval < time.Second {
+		fmt.Fprintln(os.Stderr, tr("Error: interval must be at least 1s"))
+		os.Exit(1)
+	}
+	if err := autosave.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *enforceBudget && tabBudget <= 0 {
+		fmt.Fprintln(os.Stderr, tr("Error: -enforce needs a tab budget"))
+		os.Exit(1)
+	}
+
+	s := &scanner{ageDays: *ageDays, events: newEventHub(), openTags: make(map[string][]string)}
+	s.scan()
+	s.closeBlocked()
+	s.checkBudget(*enforceBudget)
+	s.checkAutosave()
+	go s.run(*interval, *enforceBudget)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /api/tabs", s.handleTabs)
+	mux.HandleFunc("POST /api/close", s.handleClose)
+	mux.HandleFunc("POST /api/sessions", s.handleSaveSession)
+	mux.Handle("GET /", webUI())
+
+	if *grpcAddr != "" {
+		go func() {
+			if err := serveGRPC(*grpcAddr, s); err != nil {
+				fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+				os.Exit(1)
+			}
+		}()
+	}
+
+	log.Print(tr("Serving on http://%s, rescanning every %s", *addr, *interval))
+	if err := http.ListenAndServe(*addr, localOnly(mux)); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The operations serve mode offers, shared by the REST and gRPC APIs.
+
+// snapshot returns the latest tabs and when they were read.
+func (s *scanner) snapshot() ([]Tab, time.Time, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tabs, s.scannedAt, s.scanErrors
+}
+
+// subscribe returns the latest tabs and a channel of the events after them.
+// Subscribing under the scanner's lock means no scan falls in between.
+func (s *scanner) subscribe() ([]Tab, chan []tabEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tabs, s.events.subscribe()
+}
+
+var closeMu sync.Mutex // One close at a time, whoever asks
+
+// closeURLs closes the open tabs with the given URLs, archiving them first
+// if asked to, and rescans. Pinned and protected tabs are left open.
+func (s *scanner) closeURLs(urls []string, archive bool) (int, error) {
+	closeMu.Lock()
+	defer closeMu.Unlock()
+
+	wanted := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		wanted[u] = true
+	}
+	tabs, _, _ := s.snapshot()
+	var toClose []Tab
+	for _, tab := range tabs {
+		if wanted[tab.URL] && !tab.locked() {
+			toClose = append(toClose, tab)
+		}
+	}
+	if len(toClose) == 0 {
+		return 0, nil
+	}
+
+	var cmd tea.Cmd
+	if archive {
+		cmd = archiveTabsAsync(archiverFor(archiveFile), toClose, nil, nil)
+	} else {
+		cmd = closeTabsAsync(toClose, nil)
+	}
+	defer s.scan()
+	switch msg := cmd().(type) {
+	case archiveFailedMsg:
+		return 0, msg.err
+	case closeAbortedMsg:
+		return 0, msg.err
+	case closingCompleteMsg:
+		return msg.count, nil
+	}
+	return 0, nil
+}
+
+// closeTabs closes tabs of the latest scan, archiving them first if asked
+// to, and rescans. Each is closed at its place if it's still there with its
+// URL, see matchOpenTabs, so unlike closeURLs other tabs with the same URL
+// stay open.
+func (s *scanner) closeTabs(tabs []Tab, archive bool) (int, error) {
+	if len(tabs) == 0 {
+		return 0, nil
+	}
+	closeMu.Lock()
+	defer closeMu.Unlock()
+
+	var cmd tea.Cmd
+	if archive {
+		cmd = archiveTabsAsync(archiverFor(archiveFile), tabs, nil, nil)
+	} else {
+		cmd = closeTabsAsync(tabs, nil)
+	}
+	defer s.scan()
+	switch msg := cmd().(type) {
+	case archiveFailedMsg:
+		return 0, msg.err
+	case closeAbortedMsg:
+		return 0, msg.err
+	case closingCompleteMsg:
+		return msg.count, nil
+	}
+	return 0, nil
+}
+
+var errNoScan = errors.New("Safari has not been scanned yet")
+
+// saveSession saves the latest scan as a session.
+func (s *scanner) saveSession(name string) (session, error) {
+	tabs, scannedAt, _ := s.snapshot()
+	if scannedAt.IsZero() {
+		return session{}, errNoScan
+	}
+	return saveSession(name, tabs)
+}
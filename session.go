@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A session is a saved set of tabs that can be looked at or reopened later.
+type session struct {
+	Name    string      `json:"name"`
+	SavedAt time.Time   `json:"saved_at"`
+	Tabs    []tabRecord `json:"tabs"`
+}
+
+// sessionsDir returns the directory sessions are saved in, creating it if
+// needed.
+func sessionsDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "sessions")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// saveSession saves tabs as a session, replacing any session with the same
+// name. An empty name defaults to the current date and time.
+func saveSession(name string, tabs []Tab) (session, error) {
+	now := time.Now()
+	if name == "" {
+		name = now.Format("2006-01-02 15.04.05")
+	}
+	if err := checkSessionName(name); err != nil {
+		return session{}, err
+	}
+
+	s := session{Name: name, SavedAt: now, Tabs: make([]tabRecord, len(tabs))}
+	for i, tab := range tabs {
+		s.Tabs[i] = newTabRecord(tab)
+	}
+
+	return s, stateStore.SaveSession(s)
+}
+
+// checkSessionName refuses names that aren't a plain file name in the
+// sessions folder, like "../config".
+func checkSessionName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, ".") {
+		return errors.New(tr("session names can't contain / \\ : or start with a dot"))
+	}
+	return nil
+}
+
+// deleteSession removes a saved session by name.
+func deleteSession(name string) error {
+	return stateStore.DeleteSession(name)
+}
+
+// loadSession reads a saved session by name.
+func loadSession(name string) (session, error) {
+	return stateStore.LoadSession(name)
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The session browser lists the saved sessions, from exports, quitting,
+// focus mode and large closes, with their tab counts and dates, and
+// previews the tabs of the one under the cursor. A session can be restored
+// whole or only the tabs picked from it, exported as a Markdown link list,
+// or deleted. It opens with O from the list, or on its own with the
+// sessions subcommand.
+
+// sessionBrowser is the state of the session browser.
+type sessionBrowser struct {
+	sessions   []session
+	cursor     int    // Session under the cursor
+	tab        int    // Cursor among the tabs of that session
+	onTabs     bool   // The tabs have the focus rather than the sessions
+	picked     bitset // Tabs picked for restoring, of the session under the cursor
+	background bool   // Restore without bringing Safari to the front
+	deleting   bool   // Asking whether to delete the session under the cursor
+	restoring  bool
+	status     string // Result of the last action
+}
+
+// sessionRestoredMsg reports a restore started from the browser.
+type sessionRestoredMsg struct {
+	name  string
+	count int
+	err   error
+}
+
+// loadSessions reads every saved session, the most recent first.
+func loadSessions() ([]session, error) {
+	names, err := sessionNames()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]session, 0, len(names))
+	for _, name := range names {
+		s, err := loadSession(name)
+		if err != nil {
+			return nil, err
+		}
+		// The file name is what loading and deleting go by
+		s.Name = name
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// newSessionBrowser loads the sessions into a browser.
+func newSessionBrowser(background bool) (*sessionBrowser, error) {
+	sessions, err := loadSessions()
+	if err != nil {
+		return nil, err
+	}
+	b := &sessionBrowser{sessions: sessions, background: background}
+	b.moveTo(0)
+	return b, nil
+}
+
+// moveTo puts the cursor on session n, with none of its tabs picked.
+func (b *sessionBrowser) moveTo(n int) {
+	b.cursor = max(0, min(n, len(b.sessions)-1))
+	b.tab = 0
+	b.picked = newBitset(len(b.current().Tabs))
+	b.deleting = false
+}
+
+// current returns the session under the cursor, or an empty one if there
+// are none.
+func (b *sessionBrowser) current() session {
+	if len(b.sessions) == 0 {
+		return session{}
+	}
+	return b.sessions[b.cursor]
+}
+
+// update handles a key. It reports false once the browser is left.
+func (b *sessionBrowser) update(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if b.deleting {
+		if msg.String() == tr("y") {
+			b.deleteCurrent()
+		} else {
+			b.status = tr("Nothing deleted.")
+		}
+		b.deleting = false
+		return nil, true
+	}
+
+	s := b.current()
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "O"))):
+		return nil, false
+	case len(b.sessions) == 0:
+		return nil, true
+	case key.Matches(msg, key.NewBinding(key.WithKeys("tab"))):
+		b.onTabs = !b.onTabs && len(s.Tabs) > 0
+	case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+		if b.onTabs {
+			b.tab = max(0, b.tab-1)
+		} else {
+			b.moveTo(b.cursor - 1)
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+		if b.onTabs {
+			b.tab = max(0, min(len(s.Tabs)-1, b.tab+1))
+		} else {
+			b.moveTo(b.cursor + 1)
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
+		if b.onTabs && len(s.Tabs) > 0 {
+			b.picked.set(b.tab, !b.picked.has(b.tab))
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("r"))):
+		return b.restore(s.Tabs), true
+	case key.Matches(msg, key.NewBinding(key.WithKeys("s"))):
+		var picked []tabRecord
+		for i, record := range s.Tabs {
+			if b.picked.has(i) {
+				picked = append(picked, record)
+			}
+		}
+		if len(picked) == 0 {
+			b.status = tr("Pick tabs with tab and space first.")
+			return nil, true
+		}
+		return b.restore(picked), true
+	case key.Matches(msg, key.NewBinding(key.WithKeys("e"))):
+		path, err := exportSession(s)
+		if err != nil {
+			b.status = tr("Exporting failed: %v", err)
+		} else {
+			b.status = tr("Exported %d tabs to %s.", len(s.Tabs), path)
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("d"))):
+		b.deleting = true
+	}
+	return nil, true
+}
+
+// restore opens tabs of the session under the cursor in a new window.
+func (b *sessionBrowser) restore(records []tabRecord) tea.Cmd {
+	if b.restoring {
+		b.status = tr("Still restoring...")
+		return nil
+	}
+	b.restoring = true
+	name := b.current().Name
+	b.status = tr("Restoring %d tabs of session %q...", len(records), name)
+	urls := make([]string, len(records))
+	for i, record := range records {
+		urls[i] = record.URL
+	}
+	background := b.background
+	return func() tea.Msg {
+		opened, err := openURLs(urls, background)
+		return sessionRestoredMsg{name: name, count: opened, err: err}
+	}
+}
+
+// restored reports how a restore went.
+func (b *sessionBrowser) restored(msg sessionRestoredMsg) {
+	b.restoring = false
+	if msg.err != nil {
+		b.status = tr("Restoring failed after %d tabs: %v", msg.count, msg.err)
+		return
+	}
+	b.status = tr("Opened %d tabs of session %q.", msg.count, msg.name)
+}
+
+// deleteCurrent removes the session under the cursor.
+func (b *sessionBrowser) deleteCurrent() {
+	name := b.current().Name
+	if err := deleteSession(name); err != nil {
+		b.status = tr("Deleting failed: %v", err)
+		return
+	}
+	b.sessions = append(b.sessions[:b.cursor], b.sessions[b.cursor+1:]...)
+	b.onTabs = false
+	b.moveTo(b.cursor)
+	b.status = tr("Deleted session %q.", name)
+}
+
+// view shows the sessions beside the tabs of the one under the cursor.
+func (b *sessionBrowser) view(width, height int) string {
+	width = max(2*minTextWidth, width-2)
+	rows := max(3, height-2)
+	header := titleStyle.Render(tr("Saved sessions - %d", len(b.sessions)))
+	if len(b.sessions) == 0 {
+		return header + "\n\n" + titleStyle.Render(helpStyle.Render(tr("No saved sessions in %s.", stateStore.Location()))) + "\n\n" +
+			helpStyle.Render(" "+tr("esc: back"))
+	}
+
+	// scrolled renders the rows around the cursor that fit, marking the
+	// cursor if the pane has the focus
+	scrolled := func(n, cursor int, focused bool, row func(i int) string) []string {
+		var lines []string
+		start := max(0, min(cursor-rows/2, n-rows))
+		for i := start; i < min(n, start+rows); i++ {
+			prefix := strings.Repeat(" ", textWidth(sym.cursor))
+			if focused && i == cursor {
+				prefix = sym.cursor
+			}
+			lines = append(lines, prefix+row(i))
+		}
+		return lines
+	}
+
+	listWidth := max(minTextWidth, width*2/5)
+	tabsWidth := max(minTextWidth, width-listWidth-2)
+	cursorWidth := textWidth(sym.cursor)
+
+	list := scrolled(len(b.sessions), b.cursor, !b.onTabs, func(i int) string {
+		s := b.sessions[i]
+		when := tr("%d tabs", len(s.Tabs)) + sym.separator + shortDateTime(s.SavedAt)
+		nameWidth := max(minTextWidth, listWidth-cursorWidth-textWidth(when)-1)
+		style := normalStyle
+		if i == b.cursor {
+			style = style.Bold(true)
+		}
+		return style.Render(padRight(truncateEnd(s.Name, nameWidth), nameWidth)) + " " + helpStyle.Render(when)
+	})
+
+	s := b.current()
+	tabs := scrolled(len(s.Tabs), b.tab, b.onTabs, func(i int) string {
+		check := sym.unchecked
+		if b.picked.has(i) {
+			check = sym.checked
+		}
+		record := s.Tabs[i]
+		name := singleLine(record.Title)
+		if strings.TrimSpace(name) == "" {
+			name = displayURL(record.URL)
+		}
+		domain := domainLabel(extractDomain(record.URL))
+		nameWidth := max(minTextWidth, tabsWidth-cursorWidth-textWidth(check+" "+sym.separator+domain))
+		return check + " " + normalStyle.Render(truncateEnd(name, nameWidth)) + helpStyle.Render(sym.separator+domain)
+	})
+
+	hints := []string{
+		sym.up + "/" + sym.down + ": " + tr("move"),
+		tr("tab: sessions/tabs"),
+		tr("space: pick"),
+		tr("r: restore all"),
+		tr("s: restore picked"),
+		tr("e: export"),
+		tr("d: delete"),
+		tr("esc: back"),
+	}
+	status := b.status
+	if b.deleting {
+		status = tr("Delete session %q with its %d tabs? Type y to confirm.", s.Name, len(s.Tabs))
+	}
+	lines := []string{
+		header,
+		"",
+		joinColumns("  ", []int{listWidth}, list, tabs),
+		"",
+		helpStyle.Render(" " + strings.Join(hints, sym.separator)),
+	}
+	if status != "" {
+		lines = append(lines, messageStyle.Render(" "+status))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportSession writes a session as a Markdown link list to the documents
+// folder, and returns the file's path.
+func exportSession(s session) (string, error) {
+	path := filepath.Join(filepath.Dir(defaultArchiveFile()), s.Name+".md")
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n\n", s.Name, tr("Saved %s", fullDateTime(s.SavedAt)))
+	for _, record := range s.Tabs {
+		fmt.Fprintf(&b, "- %s\n", markdownLink(Tab{Title: record.Title, URL: record.URL}))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// startSessions opens the session browser from the list.
+func (m *model) startSessions() tea.Cmd {
+	b, err := newSessionBrowser(false)
+	if err != nil {
+		return m.showToast(tr("Could not read sessions: %v", err))
+	}
+	m.sessions = b
+	return nil
+}
+
+// updateSessions handles a key in the session browser.
+func (m *model) updateSessions(msg tea.KeyMsg) tea.Cmd {
+	if key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))) && !m.sessions.deleting {
+		return m.requestQuit()
+	}
+	cmd, open := m.sessions.update(msg)
+	if !open {
+		m.sessions = nil
+	}
+	return cmd
+}
+
+// sessionsProgram runs the session browser on its own.
+type sessionsProgram struct {
+	browser *sessionBrowser
+	width   int
+	height  int
+}
+
+func (p sessionsProgram) Init() tea.Cmd { return nil }
+
+func (p sessionsProgram) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width, p.height = msg.Width, msg.Height
+	case sessionRestoredMsg:
+		p.browser.restored(msg)
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))) && !p.browser.deleting {
+			return p, tea.Quit
+		}
+		cmd, open := p.browser.update(msg)
+		if !open {
+			return p, tea.Quit
+		}
+		return p, cmd
+	}
+	return p, nil
+}
+
+func (p sessionsProgram) View() string {
+	return p.browser.view(p.width, p.height-4)
+}
+
+// runSessions is the sessions subcommand: the session browser on its own.
+func runSessions(args []string) {
+	flags := flag.NewFlagSet("sessions", flag.ExitOnError)
+	background := flags.Bool("background", false, tr("Open the tabs without bringing Safari to the front, in a window behind the others"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args)
+
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	b, err := newSessionBrowser(*background)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	if _, err := tea.NewProgram(sessionsProgram{browser: b}, tea.WithAltScreen()).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error running program: %v", err))
+		os.Exit(1)
+	}
+}
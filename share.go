@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shareConfig sets where the "Share as link list" action posts the selection:
+// a paste command if one is set, otherwise a GitHub Gist.
+type shareConfig struct {
+	GistToken    string `json:"gist_token"`    // GitHub token with the gist scope
+	GistPublic   bool   `json:"gist_public"`   // Create public gists instead of secret ones
+	PasteCommand string `json:"paste_command"` // Shell command that reads markdown on stdin and prints a URL
+}
+
+var share shareConfig // Set from config.json
+
+const gistsURL = "https://api.github.com/gists"
+
+// tabsSharedMsg reports the link list posted by shareTabsAsync.
+type tabsSharedMsg struct {
+	url   string
+	count int
+	err   error
+}
+
+// shareMarkdown formats tabs as a titled markdown list of links.
+func shareMarkdown(tabs []Tab) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", tr("%d tabs, %s", len(tabs), time.Now().Format("2006-01-02 15:04")))
+	for _, tab := range tabs {
+		fmt.Fprintf(&b, "- %s\n", markdownLink(tab))
+	}
+	return b.String()
+}
+
+// shareTabsAsync posts the tabs as a markdown list and returns its URL.
+func shareTabsAsync(tabs []Tab) tea.Cmd {
+	return func() tea.Msg {
+		markdown := shareMarkdown(tabs)
+		var url string
+		var err error
+		switch {
+		case share.PasteCommand != "":
+			url, err = pasteMarkdown(markdown)
+		case share.GistToken != "":
+			url, err = postGist(markdown)
+		default:
+			err = fmt.Errorf("set share.gist_token or share.paste_command in config.json")
+		}
+		return tabsSharedMsg{url: url, count: len(tabs), err: err}
+	}
+}
+
+// pasteMarkdown runs the paste command with the markdown on stdin, taking
+// the last line it prints for the URL.
+func pasteMarkdown(markdown string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", share.PasteCommand)
+	cmd.Stdin = strings.NewReader(markdown)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return "", fmt.Errorf("paste command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("paste command failed: %w", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	url := strings.TrimSpace(lines[len(lines)-1])
+	if url == "" {
+		return "", fmt.Errorf("paste command printed no URL")
+	}
+	return url, nil
+}
+
+// postGist creates a gist with the markdown as its only file.
+func postGist(markdown string) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"description": tr("Tabs shared from Safari"),
+		"public":      share.GistPublic,
+		"files": map[string]any{
+			"tabs.md": map[string]string{"content": markdown},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", gistsURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+share.GistToken)
+	req.Header.Set("User-Agent", "safari-tab-manager/"+Version)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not create gist: %w", err)
+	}
+	defer resp.Body.Close()
+	var gist struct {
+		HTMLURL string `json:"html_url"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&gist)
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("could not create gist: %s %s", resp.Status, gist.Message)
+	}
+	return gist.HTMLURL, nil
+}
+
+// shareTabs starts posting the tabs; the URL is copied once it's back.
+func (m *model) shareTabs(tabs []Tab) tea.Cmd {
+	m.actionMenu = ""
+	return tea.Batch(m.showToast(tr("Sharing %d tabs...", len(tabs))), shareTabsAsync(tabs))
+}
+
+// copyShared copies the URL of a shared link list to the clipboard.
+func (m *model) copyShared(msg tabsSharedMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.showToast(tr("Sharing failed: %v", msg.err))
+	}
+	cmd := exec.Command("pbcopy")
+	cmd.Stdin = strings.NewReader(msg.url)
+	if err := cmd.Run(); err != nil {
+		return m.showToast(tr("Shared %d tabs at %s", msg.count, msg.url))
+	}
+	return m.showToast(tr("Shared %d tabs; copied %s", msg.count, msg.url))
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Researching a purchase leaves a tab per product. Product pages on common
+// shops are recognized by URL, and with -prices their product name and
+// price are read from the page, so the list says what each one was. The
+// "Wishlist" action writes them to a Markdown wishlist and closes them.
+
+var fetchPrices bool // Set by the -prices flag
+
+var wishlistFile string // Set from config.json
+
+// priceTTL is how long fetched prices are cached; they change more often
+// than other fetched data.
+const priceTTL = 24 * time.Hour
+
+const fetchProductBytes = 2 * 1024 * 1024
+
+var (
+	// Product paths by shop domain, subdomains and country domains included
+	productPaths = map[string]*regexp.Regexp{
+		"amazon":     regexp.MustCompile(`/(dp|gp/product|gp/aw/d)/[A-Z0-9]{10}`),
+		"ebay":       regexp.MustCompile(`^/itm/`),
+		"etsy":       regexp.MustCompile(`^/(\w+/)?listing/\d+`),
+		"walmart":    regexp.MustCompile(`^/ip/`),
+		"target":     regexp.MustCompile(`^/p/`),
+		"bestbuy":    regexp.MustCompile(`^/site/.+\.p$`),
+		"aliexpress": regexp.MustCompile(`^/item/`),
+		"ikea":       regexp.MustCompile(`/p/`),
+	}
+	// Shopify and many other shops put products under /products/
+	genericProductPath = regexp.MustCompile(`^/(\w{2}(-\w{2})?/)?products?/[^/]+`)
+
+	// Price and name metadata: Open Graph and schema.org, as meta tags or
+	// JSON-LD
+	priceMetaRegexp    = regexp.MustCompile(`(?i)<meta\s+(?:property|itemprop|name)=["'](?:product:price:amount|og:price:amount|price)["']\s+content=["']([0-9.,]+)["']`)
+	currencyMetaRegexp = regexp.MustCompile(`(?i)<meta\s+(?:property|itemprop|name)=["'](?:product:price:currency|og:price:currency|priceCurrency)["']\s+content=["']([A-Z]{3})["']`)
+	priceJSONRegexp    = regexp.MustCompile(`"price"\s*:\s*"?([0-9]+(?:[.,][0-9]+)?)"?`)
+	currencyJSONRegexp = regexp.MustCompile(`"priceCurrency"\s*:\s*"([A-Z]{3})"`)
+	nameMetaRegexp     = regexp.MustCompile(`(?i)<meta\s+property=["']og:title["']\s+content=["']([^"']+)["']`)
+)
+
+type cachedProduct struct {
+	Name      string    `json:"name"`
+	Price     string    `json:"price"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// isProduct reports whether a URL is a product page of a shop.
+func isProduct(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for shop, path := range productPaths {
+		if strings.Contains("."+host+".", "."+shop+".") {
+			return path.MatchString(u.Path)
+		}
+	}
+	return genericProductPath.MatchString(u.Path)
+}
+
+// markProducts sets Product on tabs of product pages.
+func markProducts(tabs []Tab) {
+	for i := range tabs {
+		tabs[i].Product = isProduct(tabs[i].URL)
+	}
+}
+
+// extractProduct reads a product's name and price, like "19.99 USD", from
+// its page. Either is empty if the page doesn't say.
+func extractProduct(page []byte) (name, price string) {
+	if m := nameMetaRegexp.FindSubmatch(page); m != nil {
+		name = strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+	}
+
+	var amount, currency string
+	if m := priceMetaRegexp.FindSubmatch(page); m != nil {
+		amount = string(m[1])
+	} else if m := priceJSONRegexp.FindSubmatch(page); m != nil {
+		amount = string(m[1])
+	}
+	if m := currencyMetaRegexp.FindSubmatch(page); m != nil {
+		currency = string(m[1])
+	} else if m := currencyJSONRegexp.FindSubmatch(page); m != nil {
+		currency = string(m[1])
+	}
+	if amount != "" {
+		price = strings.TrimSpace(amount + " " + currency)
+	}
+	return name, price
+}
+
+// addProductDetails fetches product pages for their name and price. Results
+// are cached on disk.
+func addProductDetails(tabs []Tab) []Tab {
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("Warning: could not get cache directory: %v", err)
+		return tabs
+	}
+
+	cachePath := filepath.Join(dir, "products.json")
+	cache := make(map[string]cachedProduct)
+	if err := loadJSON(cachePath, &cache); err != nil {
+		log.Printf("Warning: could not read product cache: %v", err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+
+	for i := range tabs {
+		if !tabs[i].Product {
+			continue
+		}
+
+		if cached, ok := cache[tabs[i].URL]; ok && time.Since(cached.FetchedAt) < priceTTL {
+			tabs[i].ProductName, tabs[i].Price = cached.Name, cached.Price
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			page, err := fetchPage(tabs[i].URL, fetchProductBytes)
+			if err != nil {
+				log.Printf("Warning: could not fetch %s: %v", tabs[i].URL, err)
+				return
+			}
+
+			name, price := extractProduct(page)
+			mu.Lock()
+			cache[tabs[i].URL] = cachedProduct{Name: name, Price: price, FetchedAt: time.Now()}
+			mu.Unlock()
+
+			tabs[i].ProductName, tabs[i].Price = name, price
+		}(i)
+	}
+	wg.Wait()
+
+	if err := saveJSON(cachePath, cache); err != nil {
+		log.Printf("Warning: could not write product cache: %v", err)
+	}
+
+	return tabs
+}
+
+// productInfo describes a product tab, for display.
+func productInfo(tab *Tab) string {
+	if tab.Price != "" {
+		return tr("product, %s", tab.Price)
+	}
+	return tr("product")
+}
+
+// defaultWishlistFile is where "Wishlist" sends products unless wishlist is
+// set.
+func defaultWishlistFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "wishlist.md"
+	}
+	return filepath.Join(home, "Documents", "Wishlist.md")
+}
+
+// wishlistArchiver appends products to the wishlist with their price, by
+// shop.
+type wishlistArchiver struct {
+	path string
+}
+
+func (a wishlistArchiver) Name() string {
+	return filepath.Base(a.path)
+}
+
+func (a wishlistArchiver) Target() string {
+	return "wishlist:" + a.path
+}
+
+func (a wishlistArchiver) Archive(tabs []Tab) error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", tr("Added %s", time.Now().Format("2006-01-02 15:04")))
+	for _, tab := range tabs {
+		if tab.ProductName != "" {
+			tab.Title = tab.ProductName
+		}
+		fmt.Fprintf(&b, "- %s (%s", markdownLink(tab), extractDomain(tab.URL))
+		if tab.Price != "" {
+			fmt.Fprintf(&b, ", %s", tab.Price)
+		}
+		b.WriteString(")\n")
+	}
+
+	_, err = f.WriteString(b.String())
+	return err
+}
+
+// wishlist sends the products among tabs to the wishlist and closes them.
+// Other tabs are left alone.
+func (m *model) wishlist(tabs []Tab) tea.Cmd {
+	path := wishlistFile
+	if path == "" {
+		path = defaultWishlistFile()
+	}
+	return m.archiveOnly(wishlistArchiver{path: path}, tabs, func(t Tab) bool { return t.Product }, tr("No product pages selected."))
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// state export bundles everything the tool knows about you into one zip
+// file, for moving to a new Mac or keeping a backup of your own: the
+// config directory, with config.json and its protected domains, the rules
+// script, categories, macros and autosaves, and the contents of the state
+// store, whichever it is:
+//
+//	manifest.json       when and from which version it was exported
+//	config/...          the files of the config directory
+//	sessions/NAME.json  each saved session
+//	tags.json           tags set from the action menu, by URL
+//	actions.json        the action log
+//	runs.json           the run history
+//	stats.json          the running totals of tabs closed and archived
+//
+// The store's contents go as JSON rather than as its files, so a bundle
+// exported with one store imports into the other. state import adds to
+// what's there rather than replacing it, so importing twice is harmless.
+
+// stateManifest describes a state bundle.
+type stateManifest struct {
+	Version    string    `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// runState is the state subcommand, with export and import.
+func runState(args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, tr("Usage: safari-tab-manager state export [-o FILE]"))
+		fmt.Fprintln(os.Stderr, tr("       safari-tab-manager state import [-overwrite] FILE"))
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("state "+args[0], flag.ExitOnError)
+	output := flags.String("o", "", tr("File to export to, safari-tab-manager-state with the date in the current directory by default"))
+	overwrite := flags.Bool("overwrite", false, tr("Replace config files and sessions that differ from the imported ones"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	flags.Parse(args[1:])
+
+	if _, err := setupConfig(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	if args[0] == "export" {
+		path := *output
+		if path == "" {
+			path = "safari-tab-manager-state-" + time.Now().Format("2006-01-02") + ".zip"
+		}
+		if err := exportState(expandHome(path)); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		fmt.Println(tr("Exported the state to %s.", path))
+		return
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, tr("Usage: safari-tab-manager state import [-overwrite] FILE"))
+		os.Exit(2)
+	}
+	result, err := importState(expandHome(flags.Arg(0)), *profile, *overwrite)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(tr("Imported %d config files, %d sessions, %d tags, %d logged actions and %d runs.",
+		result.files, result.sessions, result.tags, result.actions, result.runs))
+	for _, skipped := range result.skipped {
+		fmt.Println(tr("Kept your %s, which differs from the imported one; -overwrite replaces it.", skipped))
+	}
+}
+
+// storeFiles are the files of the config directory that hold the store's
+// contents, which are exported through the store instead.
+var storeFiles = []string{"state.db", "state.db-journal", "tags.json", "sessions"}
+
+// exportState writes the state bundle to path.
+func exportState(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", path, err)
+	}
+	if err := writeStateBundle(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("could not export the state: %w", err)
+	}
+	return f.Close()
+}
+
+// writeStateBundle writes the zip file of the state to w.
+func writeStateBundle(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	addJSON := func(name string, v any) error {
+		entry, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = entry.Write(data)
+		return err
+	}
+
+	if err := addJSON("manifest.json", stateManifest{Version: Version, ExportedAt: time.Now()}); err != nil {
+		return err
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if slices.Contains(storeFiles, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		entry, err := zw.Create(path.Join("config", filepath.ToSlash(rel)))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(entry, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	names, err := stateStore.SessionNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		s, err := stateStore.LoadSession(name)
+		if err != nil {
+			return err
+		}
+		s.Name = name
+		if err := addJSON("sessions/"+name+".json", s); err != nil {
+			return err
+		}
+	}
+
+	tags, err := stateStore.LoadTags()
+	if err != nil {
+		return err
+	}
+	actions, err := stateStore.LoadActions()
+	if err != nil {
+		return err
+	}
+	runs, err := stateStore.LoadRuns()
+	if err != nil {
+		return err
+	}
+	stats, err := loadStats()
+	if err != nil {
+		return err
+	}
+	for name, v := range map[string]any{"tags.json": tags, "actions.json": actions, "runs.json": runs, "stats.json": stats} {
+		if err := addJSON(name, v); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// importResult counts what an import added.
+type importResult struct {
+	files, sessions, tags, actions, runs int
+	skipped                              []string // Config files and sessions kept as they were
+}
+
+// importState adds the state bundle at path to the current state. Config
+// files and sessions that exist already and differ are kept unless
+// overwrite is set; tags, actions and runs are added where missing. The
+// rest goes into the store the config names once the config files are in,
+// read with profile.
+func importState(path, profile string, overwrite bool) (importResult, error) {
+	var result importResult
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return result, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+	if entries["manifest.json"] == nil {
+		return result, fmt.Errorf("%s is not a state export", path)
+	}
+	readJSON := func(name string, v any) error {
+		f := entries[name]
+		if f == nil {
+			return nil
+		}
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if err := json.NewDecoder(r).Decode(v); err != nil {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+		return nil
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return result, err
+	}
+	for _, f := range zr.File {
+		rel, ok := strings.CutPrefix(f.Name, "config/")
+		if !ok || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		// Entries can't be written outside the config directory
+		if !filepath.IsLocal(rel) {
+			return result, fmt.Errorf("%s has an entry outside the config directory: %s", path, f.Name)
+		}
+		written, kept, err := importFile(f, filepath.Join(dir, filepath.FromSlash(rel)), overwrite)
+		if err != nil {
+			return result, err
+		}
+		if written {
+			result.files++
+		}
+		if kept {
+			result.skipped = append(result.skipped, rel)
+		}
+	}
+	if result.files > 0 {
+		cfg, err := loadConfig(profile)
+		if err != nil {
+			return result, err
+		}
+		if stateStore, err = openStore(cfg.Store); err != nil {
+			return result, err
+		}
+	}
+
+	existing, err := stateStore.SessionNames()
+	if err != nil {
+		return result, err
+	}
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, "sessions/")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, ".json")
+		if err := checkSessionName(name); err != nil {
+			return result, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		var s session
+		if err := readJSON(f.Name, &s); err != nil {
+			return result, err
+		}
+		s.Name = name
+		if slices.Contains(existing, name) {
+			current, err := stateStore.LoadSession(name)
+			// The database keeps times to the millisecond
+			if err == nil && current.SavedAt.UnixMilli() == s.SavedAt.UnixMilli() {
+				continue
+			}
+			if !overwrite {
+				result.skipped = append(result.skipped, tr("session %q", name))
+				continue
+			}
+		}
+		if err := stateStore.SaveSession(s); err != nil {
+			return result, err
+		}
+		result.sessions++
+	}
+
+	if result.tags, err = importTags(readJSON); err != nil {
+		return result, err
+	}
+	if result.actions, err = importActions(readJSON); err != nil {
+		return result, err
+	}
+	if result.runs, err = importRuns(readJSON); err != nil {
+		return result, err
+	}
+	var stats tabStats
+	if err := readJSON("stats.json", &stats); err != nil {
+		return result, err
+	}
+	return result, mergeStats(stats)
+}
+
+// importFile writes a config file from the bundle to dest. A different
+// file there already is kept, unless overwrite is set. It reports whether
+// it wrote the file, and whether it kept a different one.
+func importFile(f *zip.File, dest string, overwrite bool) (written, kept bool, err error) {
+	if _, err := os.Stat(dest); err == nil {
+		if same, err := sameFile(f, dest); err != nil || same {
+			return false, false, err
+		}
+		if !overwrite {
+			return false, true, nil
+		}
+	}
+	r, err := f.Open()
+	if err != nil {
+		return false, false, err
+	}
+	defer r.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return false, false, err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return false, false, err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return false, false, err
+	}
+	return true, false, out.Close()
+}
+
+// sameFile reports whether the file at path has the contents of f.
+func sameFile(f *zip.File, path string) (bool, error) {
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	r, err := f.Open()
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	imported, err := io.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+	return string(current) == string(imported), nil
+}
+
+// importTags adds the bundle's tags to the store, returning how many tags
+// of pages weren't there yet.
+func importTags(readJSON func(string, any) error) (int, error) {
+	var tags map[string][]string
+	if err := readJSON("tags.json", &tags); err != nil {
+		return 0, err
+	}
+	current, err := stateStore.LoadTags()
+	if err != nil {
+		return 0, err
+	}
+	added := make(map[string][]string) // URLs by tag
+	var count int
+	for url, urlTags := range tags {
+		for _, tag := range urlTags {
+			if !slices.Contains(current[url], tag) {
+				added[tag] = append(added[tag], url)
+				count++
+			}
+		}
+	}
+	for tag, urls := range added {
+		if err := stateStore.AddTags(tag, urls); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// importActions adds the bundle's logged actions that aren't in the
+// action log yet.
+func importActions(readJSON func(string, any) error) (int, error) {
+	var actions []loggedAction
+	if err := readJSON("actions.json", &actions); err != nil {
+		return 0, err
+	}
+	current, err := stateStore.LoadActions()
+	if err != nil {
+		return 0, err
+	}
+	type key struct {
+		time        int64
+		action, url string
+	}
+	seen := make(map[key]bool)
+	for _, a := range current {
+		seen[key{a.Time.UnixMilli(), a.Action, a.URL}] = true
+	}
+	var added []loggedAction
+	for _, a := range actions {
+		if !seen[key{a.Time.UnixMilli(), a.Action, a.URL}] {
+			added = append(added, a)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	return len(added), stateStore.AddActions(added)
+}
+
+// importRuns adds the bundle's runs that aren't in the run history yet.
+func importRuns(readJSON func(string, any) error) (int, error) {
+	var runs []loggedRun
+	if err := readJSON("runs.json", &runs); err != nil {
+		return 0, err
+	}
+	current, err := stateStore.LoadRuns()
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[int64]bool)
+	for _, run := range current {
+		seen[run.Time.UnixMilli()] = true
+	}
+	var added []loggedRun
+	for _, run := range runs {
+		if !seen[run.Time.UnixMilli()] {
+			added = append(added, run)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	return len(added), stateStore.AddRuns(added)
+}
+
+// mergeStats raises the running totals to the imported ones. Taking the
+// larger rather than adding them up keeps a second import from counting
+// the same tabs twice.
+func mergeStats(imported tabStats) error {
+	current, err := loadStats()
+	if err != nil {
+		return err
+	}
+	recordStats(max(0, int(imported.Closed-current.Closed)), max(0, int(imported.Archived-current.Archived)))
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// tabStats are running totals kept across runs, so serve mode can report
+// closes and archives made from the interactive list as well.
+type tabStats struct {
+	Closed   int64 `json:"closed"`
+	Archived int64 `json:"archived"`
+}
+
+var statsMu sync.Mutex
+
+func statsPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stats.json"), nil
+}
+
+// loadStats reads the running totals. Missing stats are all zero.
+func loadStats() (tabStats, error) {
+	var stats tabStats
+	path, err := statsPath()
+	if err != nil {
+		return stats, err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	return stats, loadJSON(path, &stats)
+}
+
+// recordStats adds to the running totals. Failing to save them only costs
+// accuracy, so errors are logged.
+func recordStats(closed, archived int) {
+	path, err := statsPath()
+	if err != nil {
+		log.Printf("Warning: could not record stats: %v", err)
+		return
+	}
+
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	var stats tabStats
+	if err := loadJSON(path, &stats); err != nil {
+		log.Printf("Warning: could not read stats: %v", err)
+	}
+	stats.Closed += int64(closed)
+	stats.Archived += int64(archived)
+	if err := saveJSON(path, stats); err != nil {
+		log.Printf("Warning: could not save stats: %v", err)
+	}
+}
+
+// maxLoggedActions is how many actions the action log keeps; older ones are
+// dropped.
+const maxLoggedActions = 10000
+
+// loggedAction is a tab closed or archived, as kept in the action log for
+// stats -export-db.
+type loggedAction struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"` // "close" or "archive"
+	URL    string    `json:"url"`
+	Title  string    `json:"title"`
+}
+
+func actionLogPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "actions.json"), nil
+}
+
+// loadActionLog reads the action log, oldest first.
+func loadActionLog() ([]loggedAction, error) {
+	return stateStore.LoadActions()
+}
+
+// logActions adds tabs to the action log. Like the totals, failing to save
+// it is only logged.
+func logActions(action string, tabs []Tab) {
+	if len(tabs) == 0 {
+		return
+	}
+	now := time.Now()
+	actions := make([]loggedAction, len(tabs))
+	for i, tab := range tabs {
+		actions[i] = loggedAction{Time: now, Action: action, URL: tab.URL, Title: tab.Title}
+	}
+	if err := stateStore.AddActions(actions); err != nil {
+		log.Printf("Warning: could not save action log: %v", err)
+	}
+}
+
+// maxLoggedRuns is how many runs the run history keeps.
+const maxLoggedRuns = 10000
+
+// loggedRun is the counts of one scan, as kept in the run history for stats
+// -timeseries, with the running totals at the time.
+type loggedRun struct {
+	Time time.Time `json:"time"`
+	tabCounts
+	Closed   int64 `json:"closed"`
+	Archived int64 `json:"archived"`
+}
+
+func runLogPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "runs.json"), nil
+}
+
+// loadRunLog reads the run history, oldest first.
+func loadRunLog() ([]loggedRun, error) {
+	return stateStore.LoadRuns()
+}
+
+// newRun is a scan of tabs as the run history has it, with the closes and
+// archives counted so far.
+func newRun(tabs []Tab) loggedRun {
+	stats, err := loadStats()
+	if err != nil {
+		log.Printf("Warning: could not read stats: %v", err)
+	}
+	return loggedRun{
+		Time:      time.Now(),
+		tabCounts: countTabs(withoutPinned(openTabs(tabs))),
+		Closed:    stats.Closed,
+		Archived:  stats.Archived,
+	}
+}
+
+// logRun adds a scan of every window to the run history. Pinned tabs are
+// left out, as in count. A scan of one window isn't comparable with the
+// others, so it isn't logged.
+func logRun(tabs []Tab) {
+	if onlyWindowID != 0 {
+		return
+	}
+	if err := stateStore.AddRuns([]loggedRun{newRun(tabs)}); err != nil {
+		log.Printf("Warning: could not save run history: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// stats -export-db writes tab data to a SQLite database of its own for
+// analysis with any SQL tool. Every export adds a run with the tabs open
+// at the time, and brings the closes and archives from the action log up
+// to date, so exporting on a schedule builds up a history:
+//
+//	runs     one row per export, with its counts
+//	domains  every domain seen, referenced by tabs and actions
+//	tabs     the tabs open in each run
+//	actions  tabs closed or archived, from the action log
+//
+// Times are UTC, in ISO 8601 text that SQLite's date functions read.
+
+const statsSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	scanned_at TEXT NOT NULL,
+	age_days INTEGER NOT NULL,
+	tabs INTEGER NOT NULL,
+	duplicates INTEGER NOT NULL,
+	old INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS domains (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS tabs (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	window INTEGER NOT NULL,
+	tab INTEGER NOT NULL,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL,
+	domain_id INTEGER NOT NULL REFERENCES domains(id),
+	category TEXT,
+	language TEXT,
+	duplicate INTEGER NOT NULL,
+	old INTEGER NOT NULL,
+	last_visit TEXT,
+	last_active TEXT,
+	first_seen TEXT,
+	PRIMARY KEY (run_id, window, tab)
+);
+CREATE TABLE IF NOT EXISTS actions (
+	id INTEGER PRIMARY KEY,
+	acted_at TEXT NOT NULL,
+	action TEXT NOT NULL,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL,
+	domain_id INTEGER NOT NULL REFERENCES domains(id),
+	UNIQUE (acted_at, action, url)
+);
+CREATE INDEX IF NOT EXISTS tabs_domain ON tabs(domain_id);
+CREATE INDEX IF NOT EXISTS actions_domain ON actions(domain_id);
+`
+
+// runStats is the stats subcommand: it prints the running totals, with
+// -timeseries the run history, or with -export-db adds a run to a SQLite
+// database.
+func runStats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	exportDB := flags.String("export-db", "", tr("SQLite database to add the open tabs and logged actions to"))
+	ageDays := flags.Int("age", 30, tr("Age threshold in days for highlighting old tabs"))
+	preview := flags.Bool("preview", false, tr("Use Safari Technology Preview instead of Safari"))
+	profile := flags.String("profile", "", tr("Use the named profile from config.json"))
+	timeseries := flags.String("timeseries", "", tr("Print the run history as time series: json"))
+	flags.Parse(args)
+
+	if *timeseries != "" && *timeseries != "json" {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", fmt.Errorf("unknown time series format %q, want json", *timeseries)))
+		os.Exit(1)
+	}
+
+	cfg, err := setupConfig(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	applyConfigDefaults(flags, cfg, ageDays)
+	if *preview {
+		safariApp = "Safari Technology Preview"
+	}
+
+	if *timeseries != "" {
+		runs, err := loadRunLog()
+		if err == nil {
+			err = writeTimeSeries(os.Stdout, runs)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *exportDB == "" {
+		stats, err := loadStats()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+			os.Exit(1)
+		}
+		fmt.Println(tr("%d tabs closed, %d archived.", stats.Closed, stats.Archived))
+		return
+	}
+
+	tabs, _, err := getSafariTabs(*ageDays)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	tabs = findDuplicates(withoutPinned(tabs))
+	logRun(tabs)
+	actions, err := loadActionLog()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+
+	added, err := exportStats(expandHome(*exportDB), tabs, actions, *ageDays, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Error: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(tr("Added a run of %d tabs and %d new actions to %s.", len(tabs), added, *exportDB))
+}
+
+// exportStats adds a run with tabs and the actions not exported yet to the
+// database at path, creating it if needed. It returns how many actions
+// were new.
+func exportStats(path string, tabs []Tab, actions []loggedAction, ageDays int, now time.Time) (int, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(statsSchema); err != nil {
+		return 0, fmt.Errorf("could not create tables in %s: %w", path, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	domainIDs := make(map[string]int64)
+	domainID := func(url string) (int64, error) {
+		name := extractDomain(url)
+		if id, ok := domainIDs[name]; ok {
+			return id, nil
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO domains (name) VALUES (?)`, name); err != nil {
+			return 0, err
+		}
+		var id int64
+		if err := tx.QueryRow(`SELECT id FROM domains WHERE name = ?`, name).Scan(&id); err != nil {
+			return 0, err
+		}
+		domainIDs[name] = id
+		return id, nil
+	}
+
+	counts := countTabs(tabs)
+	result, err := tx.Exec(`INSERT INTO runs (scanned_at, age_days, tabs, duplicates, old) VALUES (?, ?, ?, ?, ?)`,
+		sqlTime(now), ageDays, counts.Tabs, counts.Duplicates, counts.Old)
+	if err != nil {
+		return 0, err
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, tab := range tabs {
+		id, err := domainID(tab.URL)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`INSERT INTO tabs (run_id, window, tab, url, title, domain_id, category, language, duplicate, old, last_visit, last_active, first_seen)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, tab.WindowIndex, tab.TabIndex, tab.URL, tab.Title, id, sqlText(tab.Category), sqlText(tab.Language),
+			tab.DuplicateOf != 0, tab.IsOld, sqlTime(tab.LastVisit), sqlTime(tab.LastActivated), sqlTime(tab.FirstSeen)); err != nil {
+			return 0, err
+		}
+	}
+
+	var added int
+	for _, action := range actions {
+		id, err := domainID(action.URL)
+		if err != nil {
+			return 0, err
+		}
+		result, err := tx.Exec(`INSERT OR IGNORE INTO actions (acted_at, action, url, title, domain_id) VALUES (?, ?, ?, ?, ?)`,
+			sqlTime(action.Time), action.Action, action.URL, action.Title, id)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			added += int(n)
+		}
+	}
+
+	return added, tx.Commit()
+}
+
+// sqlTime formats a time for the database, or NULL for the zero time.
+func sqlTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+const sqlTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// parseSQLTime reads a time written by sqlTime, in the local time zone.
+func parseSQLTime(text string) (time.Time, error) {
+	t, err := time.Parse(sqlTimeLayout, text)
+	if err != nil {
+		return t, fmt.Errorf("could not read time %q: %w", text, err)
+	}
+	return t.Local(), nil
+}
+
+// sqlText is NULL for an empty string.
+func sqlText(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
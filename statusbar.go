@@ -0,0 +1,243 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const toastDuration = 3 * time.Second
+
+var statusBarStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Background(lipgloss.Color("237"))
+
+// toastExpiredMsg clears a toast unless a newer one replaced it
+type toastExpiredMsg struct {
+	id int
+}
+
+// showToast shows a transient message in the status bar.
+func (m *model) showToast(text string) tea.Cmd {
+	m.toastID++
+	m.toast = text
+	m.announce(text)
+	id := m.toastID
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// selectWhere selects every tab matching match, skipping tabs that play
+// audio or video or are pinned or protected, and returns a toast reporting
+// how many were newly selected. The toast format takes the count.
+func (m *model) selectWhere(toast string, match func(Tab) bool) tea.Cmd {
+	count := 0
+	for i := range m.tabs {
+		if match(m.tabs[i]) && !m.tabs[i].playing() && !m.tabs[i].locked() && !m.selected.has(i) {
+			m.selected.set(i, true)
+			count++
+		}
+	}
+	return m.showToast(tr(toast, count))
+}
+
+// statusBar renders the current modes, counts for the tabs shown and the
+// current toast, followed by key hints for the focused tab.
+func (m model) statusBar() string {
+	filter := tr("all")
+	if m.filter.active() {
+		filter = m.filter.String()
+	}
+	sortBy := sortOrders[0]
+	if m.sortBy != "" {
+		sortBy = m.sortBy
+	}
+	view := tr("truncate")
+	if m.delegate.wrap {
+		view = tr("wrap")
+	}
+
+	shown, excluded, selected, duplicates, old := 0, 0, 0, 0, 0
+	for i, tab := range m.tabs {
+		if m.excludeReason(tab) != "" {
+			excluded++
+			continue
+		}
+		shown++
+		if m.selected.has(i) {
+			selected++
+		}
+		if tab.DuplicateOf != 0 {
+			duplicates++
+		}
+		if tab.IsOld {
+			old++
+		}
+	}
+
+	status := strings.Join([]string{
+		tr("filter: %s", filter),
+		tr("view: %s", view),
+		tr("sort: %s", sortBy),
+		tr("%d/%d shown", shown, len(m.tabs)),
+		tr("%d selected", selected),
+		tr("%d duplicates", duplicates),
+		tr("%d old", old),
+	}, sym.separator)
+	if m.groupBy != "" {
+		status += sym.separator + tr("group: %s", m.groupBy)
+	}
+	if excluded > 0 {
+		status += sym.separator + tr("%d excluded", excluded)
+	}
+	if m.stages != nil {
+		status += sym.separator + m.stages.status()
+	}
+	if m.recording {
+		status += sym.separator + tr("recording macro (%d keys)", len(m.macroKeys))
+	}
+	if m.toast != "" {
+		status += sym.separator + messageStyle.Render(m.toast)
+	}
+
+	bar := statusBarStyle.Width(m.list.Width()).Render(truncateEnd(" "+status, max(minTextWidth, m.list.Width())))
+	return bar + "\n" + helpStyle.Render(" "+strings.Join(m.keyHints(), sym.separator))
+}
+
+// keyHints returns the keys relevant to the focused tab and the current
+// state, or every key when full help is toggled on.
+func (m model) keyHints() []string {
+	if m.showHelp {
+		return []string{
+			tr("k/%s j/%s: navigate", sym.up, sym.down),
+			tr("space: toggle"),
+			tr("enter: actions on the selection"),
+			tr("a: select all duplicates"),
+			tr("d: jump between duplicate and original"),
+			tr("D: review duplicates side by side"),
+			tr("o: select all old"),
+			tr("e: select all expired"),
+			tr("R: select all resolved"),
+			tr("x: select stale searches"),
+			tr("V: select videos"),
+			tr("$: select product pages"),
+			tr("r: select long reads"),
+			tr("H: select the heaviest tabs"),
+			tr("b: select the tab's burst"),
+			tr("p: select the tab's project"),
+			tr("i: select the tab's docs"),
+			tr("f: filter category"),
+			tr("L: filter language"),
+			tr("1-5: filter by top domain"),
+			tr("s: select shown"),
+			tr("w: wrap/truncate"),
+			tr("S: cycle sort order"),
+			tr("g: cycle grouping"),
+			tr("v: saved views"),
+			tr("t: stats by window and domain"),
+			tr("M: move tabs between windows"),
+			tr("O: browse saved sessions"),
+			tr("W: label windows by project"),
+			tr("U: restore suspended tabs"),
+			tr("h: show/hide excluded"),
+			tr("n: deselect all"),
+			tr("m: record macro"),
+			tr("@: play macro"),
+			tr("c: close selected"),
+			tr("A: archive selected"),
+			tr("?: fewer keys"),
+			tr("q: quit"),
+		}
+	}
+
+	hints := []string{tr("space: toggle")}
+
+	if focused, ok := m.list.SelectedItem().(item); ok {
+		if focused.tab().DuplicateOf != 0 {
+			hints = append(hints, tr("a: select all duplicates"), tr("d: go to original"), tr("D: review duplicates"))
+			if focused.tab().SearchQuery != "" {
+				hints = append(hints, tr("x: select stale searches"))
+			}
+		} else if focused.group >= 0 {
+			hints = append(hints, tr("d: go to duplicate"))
+		}
+		if focused.tab().IsOld {
+			hints = append(hints, tr("o: select all old"))
+		}
+		if focused.tab().Expired != "" {
+			hints = append(hints, tr("e: select all expired"))
+		}
+		if focused.tab().Resolved != "" {
+			hints = append(hints, tr("R: select all resolved"))
+		}
+		if focused.tab().Video {
+			hints = append(hints, tr("V: select videos"))
+		}
+		if focused.tab().Product {
+			hints = append(hints, tr("$: select product pages"))
+		}
+		if focused.tab().ReadingMinutes >= longReadMinutes {
+			hints = append(hints, tr("r: select long reads"))
+		}
+		if focused.tab().heavy() {
+			hints = append(hints, tr("H: select the heaviest tabs"))
+		}
+		if focused.tab().SuspendedURL != "" {
+			hints = append(hints, tr("U: restore suspended tabs"))
+		}
+		if !focused.tab().Burst.IsZero() {
+			hints = append(hints, tr("b: select burst"))
+		}
+		if focused.tab().Project != "" {
+			hints = append(hints, tr("p: select project"))
+		}
+		if focused.tab().DocSet != "" {
+			hints = append(hints, tr("i: select docs"))
+		}
+	}
+
+	if m.filter.active() {
+		hints = append(hints, tr("s: select shown"), tr("f: next category"))
+	} else if len(tabCategories(m.tabs)) > 0 {
+		hints = append(hints, tr("f: filter category"))
+	}
+	if m.filter.language != "" {
+		hints = append(hints, tr("L: next language"))
+	} else if len(tabLanguages(m.tabs)) > 1 {
+		hints = append(hints, tr("L: filter language"))
+	}
+
+	if m.showExcluded {
+		hints = append(hints, tr("h: hide excluded"))
+	} else if m.hasExcluded() {
+		hints = append(hints, tr("h: show excluded"))
+	}
+
+	if selected := m.countSelected(); selected > 0 {
+		hints = append(hints,
+			tr("enter: actions"),
+			tr("c: close %d", selected),
+			tr("A: archive %d", selected),
+			tr("n: deselect all"),
+		)
+	}
+
+	if m.recording {
+		hints = append(hints, tr("m: stop recording"))
+	}
+	if len(m.views) > 0 {
+		hints = append(hints, tr("v: views"))
+	}
+
+	return append(hints, tr("?: all keys"), tr("q: quit"))
+}
+
+func (m model) hasExcluded() bool {
+	for _, tab := range m.tabs {
+		if m.excludeReason(tab) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The tool's own state, the saved sessions, the tags set from the action
+// menu, the action log and the run history, goes through a store. "store"
+// in the config picks one: "files", the default, keeps JSON files that can
+// be read and edited by hand, "sqlite" keeps a single database that
+// several processes, like serve and the list, can write at once without
+// losing each other's changes.
+
+// A store keeps the tool's own state.
+type store interface {
+	Location() string // Where the state is kept, for messages
+
+	SessionNames() ([]string, error) // The most recent first
+	LoadSession(name string) (session, error)
+	SaveSession(s session) error
+	DeleteSession(name string) error
+
+	LoadTags() (map[string][]string, error) // By URL
+	AddTags(tag string, urls []string) error
+
+	LoadActions() ([]loggedAction, error) // Oldest first
+	AddActions(actions []loggedAction) error
+
+	LoadRuns() ([]loggedRun, error) // Oldest first
+	AddRuns(runs []loggedRun) error
+
+	PruneActions(before time.Time) (int, error) // Drops the actions from before a time
+	PruneRuns(before time.Time) (int, error)    // Drops the runs from before a time
+	Compact() error                             // Gives back the room pruning freed
+	Paths() []string                            // The files pruning shrinks
+}
+
+var stateStore store = fileStore{} // Set from config.json
+
+// openStore opens the store the config names.
+func openStore(name string) (store, error) {
+	switch name {
+	case "", "files":
+		return fileStore{}, nil
+	case "sqlite":
+		return openSQLiteStore()
+	}
+	return nil, fmt.Errorf("unknown store %q, want files or sqlite", name)
+}
+
+// fileStore keeps sessions as a JSON file each in the sessions folder of
+// the config directory, tags in tags.json next to it, and the action log
+// and run history in the cache directory.
+type fileStore struct{}
+
+func (fileStore) Location() string {
+	dir, err := sessionsDir()
+	if err != nil {
+		return "sessions"
+	}
+	return filepath.Clean(dir)
+}
+
+// SessionNames goes by the files' modification times.
+func (fileStore) SessionNames() ([]string, error) {
+	dir, err := sessionsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	modified := make(map[string]time.Time)
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			modified[name] = info.ModTime()
+		}
+		names = append(names, name)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return modified[names[i]].After(modified[names[j]])
+	})
+	return names, nil
+}
+
+func (fileStore) LoadSession(name string) (session, error) {
+	var s session
+	if err := checkSessionName(name); err != nil {
+		return s, fmt.Errorf("could not read session %s: %w", name, err)
+	}
+	dir, err := sessionsDir()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return s, fmt.Errorf("could not read session %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("could not read session %s: %w", name, err)
+	}
+	return s, nil
+}
+
+func (fileStore) SaveSession(s session) error {
+	if err := checkSessionName(s.Name); err != nil {
+		return fmt.Errorf("could not save session %s: %w", s.Name, err)
+	}
+	dir, err := sessionsDir()
+	if err != nil {
+		return err
+	}
+	if err := saveJSON(filepath.Join(dir, s.Name+".json"), s); err != nil {
+		return fmt.Errorf("could not save session %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+func (fileStore) DeleteSession(name string) error {
+	if err := checkSessionName(name); err != nil {
+		return fmt.Errorf("could not delete session %s: %w", name, err)
+	}
+	dir, err := sessionsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, name+".json")); err != nil {
+		return fmt.Errorf("could not delete session %s: %w", name, err)
+	}
+	return nil
+}
+
+func tagsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tags.json"), nil
+}
+
+func (fileStore) LoadTags() (map[string][]string, error) {
+	tags := make(map[string][]string)
+	path, err := tagsPath()
+	if err != nil {
+		return tags, err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	return tags, loadJSON(path, &tags)
+}
+
+func (fileStore) AddTags(tag string, urls []string) error {
+	path, err := tagsPath()
+	if err != nil {
+		return err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	tags := make(map[string][]string)
+	if err := loadJSON(path, &tags); err != nil {
+		return err
+	}
+	for _, url := range urls {
+		if !slices.Contains(tags[url], tag) {
+			tags[url] = append(tags[url], tag)
+		}
+	}
+	return saveJSON(path, tags)
+}
+
+func (fileStore) LoadActions() ([]loggedAction, error) {
+	path, err := actionLogPath()
+	if err != nil {
+		return nil, err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	var actions []loggedAction
+	return actions, loadJSON(path, &actions)
+}
+
+// AddActions drops the oldest actions past maxLoggedActions.
+func (fileStore) AddActions(added []loggedAction) error {
+	path, err := actionLogPath()
+	if err != nil {
+		return err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	var actions []loggedAction
+	if err := loadJSON(path, &actions); err != nil {
+		return fmt.Errorf("could not read action log: %w", err)
+	}
+	actions = append(actions, added...)
+	if len(actions) > maxLoggedActions {
+		actions = actions[len(actions)-maxLoggedActions:]
+	}
+	return saveJSON(path, actions)
+}
+
+func (fileStore) LoadRuns() ([]loggedRun, error) {
+	path, err := runLogPath()
+	if err != nil {
+		return nil, err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	var runs []loggedRun
+	return runs, loadJSON(path, &runs)
+}
+
+// AddRuns drops the oldest runs past maxLoggedRuns.
+func (fileStore) AddRuns(added []loggedRun) error {
+	path, err := runLogPath()
+	if err != nil {
+		return err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	var runs []loggedRun
+	if err := loadJSON(path, &runs); err != nil {
+		return fmt.Errorf("could not read run history: %w", err)
+	}
+	runs = append(runs, added...)
+	if len(runs) > maxLoggedRuns {
+		runs = runs[len(runs)-maxLoggedRuns:]
+	}
+	return saveJSON(path, runs)
+}
+
+func (fileStore) PruneActions(before time.Time) (int, error) {
+	path, err := actionLogPath()
+	if err != nil {
+		return 0, err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	var actions []loggedAction
+	if err := loadJSON(path, &actions); err != nil {
+		return 0, fmt.Errorf("could not read action log: %w", err)
+	}
+	kept := slices.DeleteFunc(slices.Clone(actions), func(a loggedAction) bool { return a.Time.Before(before) })
+	if len(kept) == len(actions) {
+		return 0, nil
+	}
+	return len(actions) - len(kept), saveJSON(path, kept)
+}
+
+func (fileStore) PruneRuns(before time.Time) (int, error) {
+	path, err := runLogPath()
+	if err != nil {
+		return 0, err
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	var runs []loggedRun
+	if err := loadJSON(path, &runs); err != nil {
+		return 0, fmt.Errorf("could not read run history: %w", err)
+	}
+	kept := slices.DeleteFunc(slices.Clone(runs), func(r loggedRun) bool { return r.Time.Before(before) })
+	if len(kept) == len(runs) {
+		return 0, nil
+	}
+	return len(runs) - len(kept), saveJSON(path, kept)
+}
+
+// Compact has nothing to do: pruning rewrites the files.
+func (fileStore) Compact() error {
+	return nil
+}
+
+func (fileStore) Paths() []string {
+	var paths []string
+	for _, path := range []func() (string, error){tagsPath, actionLogPath, runLogPath} {
+		if p, err := path(); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// copyState copies everything in one store into another, for switching
+// stores without leaving the state behind.
+func copyState(from, to store) error {
+	names, err := from.SessionNames()
+	if err != nil {
+		return err
+	}
+	// Oldest first, so the most recent stays the most recent
+	for i := len(names) - 1; i >= 0; i-- {
+		s, err := from.LoadSession(names[i])
+		if err != nil {
+			return err
+		}
+		s.Name = names[i]
+		if err := to.SaveSession(s); err != nil {
+			return err
+		}
+	}
+
+	tags, err := from.LoadTags()
+	if err != nil {
+		return err
+	}
+	byTag := make(map[string][]string)
+	for url, urlTags := range tags {
+		for _, tag := range urlTags {
+			byTag[tag] = append(byTag[tag], url)
+		}
+	}
+	for tag, urls := range byTag {
+		if err := to.AddTags(tag, urls); err != nil {
+			return err
+		}
+	}
+
+	actions, err := from.LoadActions()
+	if err != nil {
+		return err
+	}
+	if err := to.AddActions(actions); err != nil {
+		return err
+	}
+	runs, err := from.LoadRuns()
+	if err != nil {
+		return err
+	}
+	return to.AddRuns(runs)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// sqliteStore keeps the state in state.db in the config directory:
+//
+//	sessions  one row per session, its tabs as JSON
+//	tags      tags set from the action menu, by URL
+//	actions   tabs closed or archived, as in actions.json
+//	runs      the run history, as in runs.json
+//
+// A new database starts with whatever the files hold, so switching to it
+// carries the state over. Times are UTC, in the same text as stats
+// -export-db.
+
+const stateSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	name TEXT PRIMARY KEY,
+	saved_at TEXT NOT NULL,
+	tabs TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS tags (
+	url TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	PRIMARY KEY (url, tag)
+);
+CREATE TABLE IF NOT EXISTS actions (
+	id INTEGER PRIMARY KEY,
+	acted_at TEXT NOT NULL,
+	action TEXT NOT NULL,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	scanned_at TEXT NOT NULL,
+	tabs INTEGER NOT NULL,
+	duplicates INTEGER NOT NULL,
+	old INTEGER NOT NULL,
+	closed INTEGER NOT NULL,
+	archived INTEGER NOT NULL
+);
+`
+
+type sqliteStore struct {
+	db   sqlRunner // The database, or the transaction filling a new one
+	path string
+}
+
+// sqlRunner runs statements, on a database or in a transaction.
+type sqlRunner interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// openSQLiteStore opens state.db, creating it from the files if it's new.
+func openSQLiteStore() (*sqliteStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "state.db")
+	// Waiting out another process's write beats failing it. The pragma goes
+	// in the name so every connection in the pool gets it, not just the one
+	// that happens to run it. Transactions take the write lock as they
+	// begin, so two processes can't both find the database new
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	if err := createState(db, path); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db, path: path}, nil
+}
+
+// createState creates the tables and, if the database is new, copies the
+// files' state in, all in one transaction: a process opening the database
+// at the same time waits, then finds it filled.
+func createState(db *sql.DB, path string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer tx.Rollback()
+
+	var tables int
+	if err := tx.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables); err != nil {
+		return fmt.Errorf("could not open %s: %w", path, err)
+	}
+	if _, err := tx.Exec(stateSchema); err != nil {
+		return fmt.Errorf("could not create tables in %s: %w", path, err)
+	}
+	if tables == 0 {
+		if err := copyState(fileStore{}, &sqliteStore{db: tx, path: path}); err != nil {
+			return fmt.Errorf("could not copy the state into %s: %w", path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not create %s: %w", path, err)
+	}
+	return nil
+}
+
+// batch runs f in a transaction, or in the one the store is in already.
+func (s *sqliteStore) batch(f func(tx sqlRunner) error) error {
+	db, ok := s.db.(*sql.DB)
+	if !ok {
+		return f(s.db)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Location() string {
+	return s.path
+}
+
+func (s *sqliteStore) SessionNames() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM sessions ORDER BY saved_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (s *sqliteStore) LoadSession(name string) (session, error) {
+	saved := session{Name: name}
+	var savedAt, tabs string
+	err := s.db.QueryRow(`SELECT saved_at, tabs FROM sessions WHERE name = ?`, name).Scan(&savedAt, &tabs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return saved, fmt.Errorf("could not read session %s: no such session", name)
+	}
+	if err != nil {
+		return saved, fmt.Errorf("could not read session %s: %w", name, err)
+	}
+	if saved.SavedAt, err = parseSQLTime(savedAt); err != nil {
+		return saved, fmt.Errorf("could not read session %s: %w", name, err)
+	}
+	if err := json.Unmarshal([]byte(tabs), &saved.Tabs); err != nil {
+		return saved, fmt.Errorf("could not read session %s: %w", name, err)
+	}
+	return saved, nil
+}
+
+func (s *sqliteStore) SaveSession(saved session) error {
+	tabs, err := json.Marshal(saved.Tabs)
+	if err != nil {
+		return fmt.Errorf("could not save session %s: %w", saved.Name, err)
+	}
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO sessions (name, saved_at, tabs) VALUES (?, ?, ?)`,
+		saved.Name, sqlTime(saved.SavedAt), string(tabs)); err != nil {
+		return fmt.Errorf("could not save session %s: %w", saved.Name, err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) DeleteSession(name string) error {
+	result, err := s.db.Exec(`DELETE FROM sessions WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("could not delete session %s: %w", name, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("could not delete session %s: no such session", name)
+	}
+	return nil
+}
+
+func (s *sqliteStore) LoadTags() (map[string][]string, error) {
+	tags := make(map[string][]string)
+	rows, err := s.db.Query(`SELECT url, tag FROM tags ORDER BY rowid`)
+	if err != nil {
+		return tags, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url, tag string
+		if err := rows.Scan(&url, &tag); err != nil {
+			return tags, err
+		}
+		tags[url] = append(tags[url], tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *sqliteStore) AddTags(tag string, urls []string) error {
+	return s.batch(func(tx sqlRunner) error {
+		for _, url := range urls {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (url, tag) VALUES (?, ?)`, url, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStore) LoadActions() ([]loggedAction, error) {
+	rows, err := s.db.Query(`SELECT acted_at, action, url, title FROM actions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var actions []loggedAction
+	for rows.Next() {
+		var action loggedAction
+		var actedAt string
+		if err := rows.Scan(&actedAt, &action.Action, &action.URL, &action.Title); err != nil {
+			return nil, err
+		}
+		if action.Time, err = parseSQLTime(actedAt); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
+
+// AddActions drops the oldest actions past maxLoggedActions.
+func (s *sqliteStore) AddActions(actions []loggedAction) error {
+	return s.batch(func(tx sqlRunner) error {
+		for _, action := range actions {
+			if _, err := tx.Exec(`INSERT INTO actions (acted_at, action, url, title) VALUES (?, ?, ?, ?)`,
+				sqlTime(action.Time), action.Action, action.URL, action.Title); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`DELETE FROM actions WHERE id <= (SELECT max(id) FROM actions) - ?`, maxLoggedActions)
+		return err
+	})
+}
+
+func (s *sqliteStore) LoadRuns() ([]loggedRun, error) {
+	rows, err := s.db.Query(`SELECT scanned_at, tabs, duplicates, old, closed, archived FROM runs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []loggedRun
+	for rows.Next() {
+		var run loggedRun
+		var scannedAt string
+		if err := rows.Scan(&scannedAt, &run.Tabs, &run.Duplicates, &run.Old, &run.Closed, &run.Archived); err != nil {
+			return nil, err
+		}
+		if run.Time, err = parseSQLTime(scannedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (s *sqliteStore) PruneActions(before time.Time) (int, error) {
+	return s.prune(`DELETE FROM actions WHERE acted_at < ?`, before)
+}
+
+func (s *sqliteStore) PruneRuns(before time.Time) (int, error) {
+	return s.prune(`DELETE FROM runs WHERE scanned_at < ?`, before)
+}
+
+// prune runs a delete of the rows from before a time, returning how many
+// it deleted.
+func (s *sqliteStore) prune(query string, before time.Time) (int, error) {
+	result, err := s.db.Exec(query, sqlTime(before))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// Compact rebuilds the database, since SQLite keeps deleted rows' pages
+// for reuse rather than giving them back.
+func (s *sqliteStore) Compact() error {
+	_, err := s.db.Exec(`VACUUM`)
+	return err
+}
+
+func (s *sqliteStore) Paths() []string {
+	return []string{s.path}
+}
+
+// AddRuns drops the oldest runs past maxLoggedRuns.
+func (s *sqliteStore) AddRuns(runs []loggedRun) error {
+	return s.batch(func(tx sqlRunner) error {
+		for _, run := range runs {
+			if _, err := tx.Exec(`INSERT INTO runs (scanned_at, tabs, duplicates, old, closed, archived) VALUES (?, ?, ?, ?, ?, ?)`,
+				sqlTime(run.Time), run.Tabs, run.Duplicates, run.Old, run.Closed, run.Archived); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`DELETE FROM runs WHERE id <= (SELECT max(id) FROM runs) - ?`, maxLoggedRuns)
+		return err
+	})
+}
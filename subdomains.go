@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Whether news.ycombinator.com and ycombinator.com are one domain depends
+// on who asks. By default every host is a domain of its own; "subdomains"
+// in the config can group hosts by the domain registered for them instead,
+// looked up in the public suffix list, so the domain grouping, the domain
+// stats and their selection, and duplicate similarity all treat a site's
+// subdomains as one. Domains whose subdomains belong to different people,
+// like notion.site, can be kept apart, and others always grouped.
+
+// Subdomain grouping policies.
+const (
+	subdomainsHost = "host" // Every host is a domain of its own
+	subdomainsSite = "site" // Hosts group under the domain registered for them
+)
+
+// subdomainsConfig decides which hosts group together.
+type subdomainsConfig struct {
+	Group    string   `json:"group"`    // subdomainsHost or subdomainsSite, default host
+	Separate []string `json:"separate"` // Domains whose subdomains are each a site of their own, like notion.site
+	Together []string `json:"together"` // Domains whose subdomains always group together
+}
+
+var subdomains subdomainsConfig // Set from config.json
+
+// validate checks the policy, and writes the domains the way extractDomain
+// does.
+func (c *subdomainsConfig) validate() error {
+	if c.Group != "" && c.Group != subdomainsHost && c.Group != subdomainsSite {
+		return fmt.Errorf("unknown subdomains group %q, want host or site", c.Group)
+	}
+	for _, list := range [][]string{c.Separate, c.Together} {
+		for i, domain := range list {
+			list[i] = unicodeHost(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www."))
+		}
+	}
+	return nil
+}
+
+// siteOf returns the domain a URL groups under, see siteDomain.
+func siteOf(url string) string {
+	return siteDomain(extractDomain(url))
+}
+
+// siteDomain returns the domain a host, as extractDomain returns it, groups
+// under by the subdomains policy. Addresses, and hosts the public suffix
+// list knows nothing of, group alone.
+func siteDomain(host string) string {
+	if host == "" {
+		return ""
+	}
+	name, _ := splitPort(host)
+	for _, domain := range subdomains.Separate {
+		if prefix, ok := strings.CutSuffix(name, "."+domain); ok {
+			return prefix[strings.LastIndex(prefix, ".")+1:] + "." + domain
+		}
+	}
+	for _, domain := range subdomains.Together {
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return domain
+		}
+	}
+	if subdomains.Group != subdomainsSite || net.ParseIP(name) != nil {
+		return host
+	}
+	site, err := publicsuffix.EffectiveTLDPlusOne(asciiHost(name))
+	if err != nil {
+		return host
+	}
+	return unicodeHost(site)
+}
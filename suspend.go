@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Suspending a tab swaps its page for a placeholder page in the cache
+// directory that shows the page's title and a link back to it, so Safari
+// frees the memory the page took while the tab stays where it was. One
+// placeholder page serves every tab: the original URL and title follow its
+// URL after the #, so they are never lost, and the list shows suspended
+// tabs by them, in a category of their own. Restoring points suspended
+// tabs back at their pages.
+
+// suspendedCategory is the category of suspended tabs.
+const suspendedCategory = "suspended"
+
+// tabsSuspendedMsg reports how many tabs were suspended, or restored.
+type tabsSuspendedMsg struct {
+	count   int
+	restore bool
+	err     error
+}
+
+func placeholderPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "suspended.html"), nil
+}
+
+// placeholderPage only ever links to web pages, so the part of its URL
+// after the # can't make it run a script.
+var placeholderPage = template.Must(template.New("placeholder").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title></title>
+<style>body { font: 15px -apple-system, sans-serif; max-width: 42em; margin: 3em auto; } p { color: #666; }</style>
+</head>
+<body>
+<h1 id="title"></h1>
+<a id="link"></a>
+<p>{{.Note}}</p>
+<script>
+var params = new URLSearchParams(location.hash.slice(1));
+var url = params.get("url") || "", title = params.get("title") || url;
+document.title = title;
+document.getElementById("title").textContent = title;
+var link = document.getElementById("link");
+link.textContent = url;
+if (/^https?:/i.test(url)) link.href = url;
+</script>
+</body>
+</html>
+`))
+
+// writePlaceholder writes the placeholder page and returns its URL.
+func writePlaceholder() (string, error) {
+	path, err := placeholderPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	var page strings.Builder
+	if err := placeholderPage.Execute(&page, map[string]string{"Note": tr("Suspended to free memory. Follow the link to load the page again.")}); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(page.String()), 0o644); err != nil {
+		return "", err
+	}
+	return fileURL(path), nil
+}
+
+// suspendedURL returns the placeholder URL standing in for a tab.
+func suspendedURL(placeholder string, tab Tab) string {
+	return placeholder + "#" + url.Values{"url": {tab.URL}, "title": {tab.Title}}.Encode()
+}
+
+// originalURL returns the URL of the page a suspended tab stands in for,
+// or false if the URL isn't a placeholder.
+func originalURL(u string) (string, bool) {
+	path, err := placeholderPath()
+	if err != nil {
+		return "", false
+	}
+	fragment, ok := strings.CutPrefix(u, fileURL(path)+"#")
+	if !ok {
+		return "", false
+	}
+	values, err := url.ParseQuery(fragment)
+	if err != nil || values.Get("url") == "" {
+		return "", false
+	}
+	return values.Get("url"), true
+}
+
+// markSuspended sets SuspendedURL on suspended tabs, and puts them in
+// suspendedCategory.
+func markSuspended(tabs []Tab) {
+	for i := range tabs {
+		if original, ok := originalURL(tabs[i].URL); ok {
+			tabs[i].SuspendedURL = original
+			tabs[i].Category = suspendedCategory
+		}
+	}
+}
+
+// suspend suspends the open tabs among tabs that aren't suspended yet.
+func (m *model) suspend(tabs []Tab) tea.Cmd {
+	m.actionMenu = ""
+	var suspending []Tab
+	for _, tab := range openTabs(tabs) {
+		if tab.SuspendedURL == "" {
+			suspending = append(suspending, tab)
+		}
+	}
+	if len(suspending) == 0 {
+		return m.showToast(tr("No open tabs to suspend."))
+	}
+	return tea.Batch(m.showToast(tr("Suspending %d tabs...", len(suspending))), suspendTabsAsync(suspending))
+}
+
+// restoreSuspended restores the selected suspended tabs, or with none of
+// them selected, every suspended tab.
+func (m *model) restoreSuspended() tea.Cmd {
+	var all, selected []Tab
+	for i, tab := range m.tabs {
+		if tab.SuspendedURL == "" {
+			continue
+		}
+		all = append(all, tab)
+		if m.selected.has(i) {
+			selected = append(selected, tab)
+		}
+	}
+	if len(all) == 0 {
+		return m.showToast(tr("No suspended tabs to restore."))
+	}
+	if len(selected) == 0 {
+		selected = all
+	}
+	return tea.Batch(m.showToast(tr("Restoring %d tabs...", len(selected))), restoreTabsAsync(selected))
+}
+
+// suspendTabsAsync points each tab at the placeholder page.
+func suspendTabsAsync(tabsToSuspend []Tab) tea.Cmd {
+	return func() tea.Msg {
+		placeholder, err := writePlaceholder()
+		if err != nil {
+			return tabsSuspendedMsg{err: fmt.Errorf("could not write the placeholder page: %w", err)}
+		}
+		count, err := navigateTabs(tabsToSuspend, func(tab Tab) string {
+			return suspendedURL(placeholder, tab)
+		})
+		return tabsSuspendedMsg{count: count, err: err}
+	}
+}
+
+// restoreTabsAsync points suspended tabs back at their pages.
+func restoreTabsAsync(tabsToRestore []Tab) tea.Cmd {
+	return func() tea.Msg {
+		count, err := navigateTabs(tabsToRestore, func(tab Tab) string {
+			return tab.SuspendedURL
+		})
+		return tabsSuspendedMsg{count: count, restore: true, err: err}
+	}
+}
+
+// navigateTabs points each tab at the URL target gives it, finding the
+// tabs in Safari's current state like closing does, so of two copies of a
+// page the one picked changes, and returns how many it changed.
+func navigateTabs(tabs []Tab, target func(Tab) string) (int, error) {
+	matched, err := matchOpenTabs(tabs)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	p := newPacer()
+	var took time.Duration
+	for _, tab := range matched {
+		if count > 0 {
+			p.pace(took)
+		}
+
+		began := time.Now()
+		script := fmt.Sprintf(`tell application %s to set URL of tab %d of window %d to %s`, appleScriptString(safariApp), tab.tab, tab.window, appleScriptString(target(tab.listed)))
+		output, err := exec.Command("osascript", "-e", script).CombinedOutput()
+		took = time.Since(began)
+		if err != nil {
+			log.Printf("Warning: could not change tab %d in window %d: %v: %s", tab.tab, tab.window, err, output)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tabs come from Safari as a JSON envelope with a protocol version, so
+// titles and URLs can hold any character, and fields can be added to the
+// script without breaking what reads it. A change that would break an older
+// reader bumps tabsProtocolVersion instead, and readers refuse versions
+// they don't know rather than misreading them. Errors inside the script
+// come back in the envelope too, with AppleScript's error number.
+
+// tabsProtocolVersion is the version of the envelope tabsScript writes.
+const tabsProtocolVersion = 1
+
+// tabStateScript reports the page's ready state, whether any unmuted media
+// element is playing and the state of the first video, as JSON.
+const tabStateScript = `(function () { var media = Array.prototype.slice.call(document.querySelectorAll('audio,video')); var v = media.filter(function (m) { return m.tagName == 'VIDEO' && isFinite(m.duration) && m.duration > 0; })[0]; return JSON.stringify({ready: document.readyState, audio: media.some(function (m) { return !m.paused && !m.muted && m.volume > 0; }), video: v ? {playing: !(v.paused || v.ended), position: Math.floor(v.currentTime), duration: Math.floor(v.duration)} : null}); })()`
+
+// tabStateBudget is the most time one scan spends reading tab state. Each
+// tab takes a JavaScript call, and a page that hangs takes its whole
+// timeout, so with thousands of tabs the later ones are listed without
+// their state, as without JavaScript from Apple Events.
+const tabStateBudget = 10 * time.Second
+
+// tabsScript lists the tabs of every window, or only of the window whose
+// id is the first argument if it isn't 0, running the second argument in
+// each tab for its state until the third argument's seconds are up. The
+// app is formatted in, since its terminology is looked up when the script
+// is compiled. The state is passed along as a string, so a page can't
+// break the envelope.
+const tabsScript = `
+on run argv
+	set onlyWindow to (item 1 of argv) as integer
+	set stateScript to item 2 of argv
+	set stateSeconds to (item 3 of argv) as integer
+	set started to current date
+	try
+		set tabItems to {}
+		tell application %s
+			repeat with w from 1 to count of windows
+				if onlyWindow is 0 or id of window w is onlyWindow then
+					repeat with t from 1 to count of tabs of window w
+						set tabState to missing value
+						if (current date) - started < stateSeconds then
+							try
+								with timeout of 2 seconds
+									set tabState to do JavaScript stateScript in tab t of window w
+								end timeout
+							end try
+						end if
+						set end of tabItems to "{\"window\":" & w & ",\"tab\":" & t & ",\"title\":" & my jsonString(name of tab t of window w) & ",\"url\":" & my jsonString(URL of tab t of window w) & ",\"state\":" & my jsonString(tabState) & "}"
+					end repeat
+				end if
+			end repeat
+		end tell
+		set AppleScript's text item delimiters to ","
+		set joined to tabItems as text
+		set AppleScript's text item delimiters to ""
+		return "{\"version\":1,\"tabs\":[" & joined & "]}"
+	on error message number code
+		return "{\"version\":1,\"error\":{\"number\":" & code & ",\"message\":" & my jsonString(message) & "}}"
+	end try
+end run
+
+on jsonString(value)
+	if value is missing value then return "null"
+	set value to value as text
+	set value to my replaceText(value, "\\", "\\\\")
+	set value to my replaceText(value, "\"", "\\\"")
+	set value to my replaceText(value, linefeed, "\\n")
+	set value to my replaceText(value, return, "\\r")
+	set value to my replaceText(value, tab, "\\t")
+	return "\"" & value & "\""
+end jsonString
+
+on replaceText(value, find, replacement)
+	set AppleScript's text item delimiters to find
+	set parts to text items of value
+	set AppleScript's text item delimiters to replacement
+	set value to parts as text
+	set AppleScript's text item delimiters to ""
+	return value
+end replaceText
+`
+
+// tabsReply is the envelope tabsScript writes.
+type tabsReply struct {
+	Version int          `json:"version"`
+	Tabs    []scriptTab  `json:"tabs"`
+	Error   *scriptError `json:"error"`
+}
+
+// scriptTab is a tab as tabsScript reports it.
+type scriptTab struct {
+	Window int    `json:"window"`
+	Tab    int    `json:"tab"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"` // tabStateScript's JSON, empty without JavaScript from Apple Events
+}
+
+// tabState is what tabStateScript reports.
+type tabState struct {
+	Ready string `json:"ready"`
+	Audio bool   `json:"audio"`
+	Video *struct {
+		Playing  bool `json:"playing"`
+		Position int  `json:"position"`
+		Duration int  `json:"duration"`
+	} `json:"video"`
+}
+
+// scriptError is an error raised inside a script, with AppleScript's
+// error number, like -1743 when the Automation permission is missing.
+type scriptError struct {
+	Number  int    `json:"number"`
+	Message string `json:"message"`
+}
+
+func (e *scriptError) Error() string {
+	return fmt.Sprintf("%s (%s, %d)", e.Message, e.code(), e.Number)
+}
+
+// code names the error numbers that have a known cause.
+func (e *scriptError) code() string {
+	switch e.Number {
+	case -1743:
+		return "not authorized"
+	case -600:
+		return "not running"
+	case -1712:
+		return "timed out"
+	}
+	return "script error"
+}
+
+// parseTabsReply reads tabsScript's envelope.
+func parseTabsReply(output []byte) ([]Tab, error) {
+	// Titles may hold control characters the script doesn't escape; the
+	// envelope itself has none
+	cleaned := strings.Map(func(r rune) rune {
+		if r < 0x20 {
+			return ' '
+		}
+		return r
+	}, string(output))
+
+	var reply tabsReply
+	if err := json.Unmarshal([]byte(cleaned), &reply); err != nil {
+		return nil, fmt.Errorf("unreadable reply from the tab script: %w", err)
+	}
+	if reply.Version != tabsProtocolVersion {
+		return nil, fmt.Errorf("the tab script speaks protocol version %d, this build reads version %d", reply.Version, tabsProtocolVersion)
+	}
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+
+	tabs := make([]Tab, 0, len(reply.Tabs))
+	for _, t := range reply.Tabs {
+		tab := Tab{WindowIndex: t.Window, TabIndex: t.Tab, Title: t.Title, URL: t.URL}
+		var state tabState
+		if t.State != "" && json.Unmarshal([]byte(t.State), &state) == nil {
+			tab.Loading = state.Ready != "" && state.Ready != "complete"
+			tab.PlaysAudio = state.Audio
+			if state.Video != nil {
+				tab.PlaysVideo = state.Video.Playing
+				tab.VideoPosition = state.Video.Position
+				tab.VideoSeconds = state.Video.Duration
+			}
+		}
+		tabs = append(tabs, tab)
+	}
+	return tabs, nil
+}
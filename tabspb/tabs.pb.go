@@ -0,0 +1,802 @@
+// The gRPC API served by `safari-tab-manager serve`, for programmatic
+// consumers that prefer typed clients over the REST API. Regenerate the Go
+// code with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative tabspb/tabs.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        v5.29.3
+// source: tabspb/tabs.proto
+
+package tabspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TabEvent_Type int32
+
+const (
+	TabEvent_TYPE_UNSPECIFIED TabEvent_Type = 0
+	TabEvent_TYPE_SNAPSHOT    TabEvent_Type = 1
+	TabEvent_TYPE_ADDED       TabEvent_Type = 2
+	TabEvent_TYPE_REMOVED     TabEvent_Type = 3
+	TabEvent_TYPE_CHANGED     TabEvent_Type = 4
+)
+
+// Enum value maps for TabEvent_Type.
+var (
+	TabEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_SNAPSHOT",
+		2: "TYPE_ADDED",
+		3: "TYPE_REMOVED",
+		4: "TYPE_CHANGED",
+	}
+	TabEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_SNAPSHOT":    1,
+		"TYPE_ADDED":       2,
+		"TYPE_REMOVED":     3,
+		"TYPE_CHANGED":     4,
+	}
+)
+
+func (x TabEvent_Type) Enum() *TabEvent_Type {
+	p := new(TabEvent_Type)
+	*p = x
+	return p
+}
+
+func (x TabEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TabEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_tabspb_tabs_proto_enumTypes[0].Descriptor()
+}
+
+func (TabEvent_Type) Type() protoreflect.EnumType {
+	return &file_tabspb_tabs_proto_enumTypes[0]
+}
+
+func (x TabEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TabEvent_Type.Descriptor instead.
+func (TabEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type Tab struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Title          string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Url            string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Window         int32                  `protobuf:"varint,3,opt,name=window,proto3" json:"window,omitempty"`
+	Tab            int32                  `protobuf:"varint,4,opt,name=tab,proto3" json:"tab,omitempty"`
+	Duplicate      bool                   `protobuf:"varint,5,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+	Old            bool                   `protobuf:"varint,6,opt,name=old,proto3" json:"old,omitempty"`
+	Category       string                 `protobuf:"bytes,7,opt,name=category,proto3" json:"category,omitempty"`
+	LastVisit      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=last_visit,json=lastVisit,proto3" json:"last_visit,omitempty"`
+	ReadingMinutes int32                  `protobuf:"varint,9,opt,name=reading_minutes,json=readingMinutes,proto3" json:"reading_minutes,omitempty"`
+	Protected      bool                   `protobuf:"varint,10,opt,name=protected,proto3" json:"protected,omitempty"`
+	Tags           []string               `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Would be preselected in the interactive list.
+	Suggested     bool `protobuf:"varint,12,opt,name=suggested,proto3" json:"suggested,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tab) Reset() {
+	*x = Tab{}
+	mi := &file_tabspb_tabs_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tab) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tab) ProtoMessage() {}
+
+func (x *Tab) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tab.ProtoReflect.Descriptor instead.
+func (*Tab) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Tab) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Tab) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Tab) GetWindow() int32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+func (x *Tab) GetTab() int32 {
+	if x != nil {
+		return x.Tab
+	}
+	return 0
+}
+
+func (x *Tab) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+func (x *Tab) GetOld() bool {
+	if x != nil {
+		return x.Old
+	}
+	return false
+}
+
+func (x *Tab) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Tab) GetLastVisit() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastVisit
+	}
+	return nil
+}
+
+func (x *Tab) GetReadingMinutes() int32 {
+	if x != nil {
+		return x.ReadingMinutes
+	}
+	return 0
+}
+
+func (x *Tab) GetProtected() bool {
+	if x != nil {
+		return x.Protected
+	}
+	return false
+}
+
+func (x *Tab) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Tab) GetSuggested() bool {
+	if x != nil {
+		return x.Suggested
+	}
+	return false
+}
+
+type ListTabsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTabsRequest) Reset() {
+	*x = ListTabsRequest{}
+	mi := &file_tabspb_tabs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTabsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTabsRequest) ProtoMessage() {}
+
+func (x *ListTabsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTabsRequest.ProtoReflect.Descriptor instead.
+func (*ListTabsRequest) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{1}
+}
+
+type ListTabsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tabs          []*Tab                 `protobuf:"bytes,1,rep,name=tabs,proto3" json:"tabs,omitempty"`
+	AgeDays       int32                  `protobuf:"varint,2,opt,name=age_days,json=ageDays,proto3" json:"age_days,omitempty"`
+	ScannedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=scanned_at,json=scannedAt,proto3" json:"scanned_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTabsResponse) Reset() {
+	*x = ListTabsResponse{}
+	mi := &file_tabspb_tabs_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTabsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTabsResponse) ProtoMessage() {}
+
+func (x *ListTabsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTabsResponse.ProtoReflect.Descriptor instead.
+func (*ListTabsResponse) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTabsResponse) GetTabs() []*Tab {
+	if x != nil {
+		return x.Tabs
+	}
+	return nil
+}
+
+func (x *ListTabsResponse) GetAgeDays() int32 {
+	if x != nil {
+		return x.AgeDays
+	}
+	return 0
+}
+
+func (x *ListTabsResponse) GetScannedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ScannedAt
+	}
+	return nil
+}
+
+type StreamTabsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTabsRequest) Reset() {
+	*x = StreamTabsRequest{}
+	mi := &file_tabspb_tabs_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTabsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTabsRequest) ProtoMessage() {}
+
+func (x *StreamTabsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTabsRequest.ProtoReflect.Descriptor instead.
+func (*StreamTabsRequest) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{3}
+}
+
+type TabEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  TabEvent_Type          `protobuf:"varint,1,opt,name=type,proto3,enum=safaritabmanager.v1.TabEvent_Type" json:"type,omitempty"`
+	// The tab added, removed or changed.
+	Tab *Tab `protobuf:"bytes,2,opt,name=tab,proto3" json:"tab,omitempty"`
+	// Every tab, for snapshots.
+	Tabs []*Tab `protobuf:"bytes,3,rep,name=tabs,proto3" json:"tabs,omitempty"`
+	// Tabs open after the change.
+	Count         int32                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TabEvent) Reset() {
+	*x = TabEvent{}
+	mi := &file_tabspb_tabs_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TabEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TabEvent) ProtoMessage() {}
+
+func (x *TabEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TabEvent.ProtoReflect.Descriptor instead.
+func (*TabEvent) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TabEvent) GetType() TabEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return TabEvent_TYPE_UNSPECIFIED
+}
+
+func (x *TabEvent) GetTab() *Tab {
+	if x != nil {
+		return x.Tab
+	}
+	return nil
+}
+
+func (x *TabEvent) GetTabs() []*Tab {
+	if x != nil {
+		return x.Tabs
+	}
+	return nil
+}
+
+func (x *TabEvent) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *TabEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type CloseTabsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Urls  []string               `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	// Archive the tabs to the archive file before closing them.
+	Archive       bool `protobuf:"varint,2,opt,name=archive,proto3" json:"archive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseTabsRequest) Reset() {
+	*x = CloseTabsRequest{}
+	mi := &file_tabspb_tabs_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseTabsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseTabsRequest) ProtoMessage() {}
+
+func (x *CloseTabsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseTabsRequest.ProtoReflect.Descriptor instead.
+func (*CloseTabsRequest) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CloseTabsRequest) GetUrls() []string {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *CloseTabsRequest) GetArchive() bool {
+	if x != nil {
+		return x.Archive
+	}
+	return false
+}
+
+type CloseTabsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Closed        int32                  `protobuf:"varint,1,opt,name=closed,proto3" json:"closed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseTabsResponse) Reset() {
+	*x = CloseTabsResponse{}
+	mi := &file_tabspb_tabs_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseTabsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseTabsResponse) ProtoMessage() {}
+
+func (x *CloseTabsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseTabsResponse.ProtoReflect.Descriptor instead.
+func (*CloseTabsResponse) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CloseTabsResponse) GetClosed() int32 {
+	if x != nil {
+		return x.Closed
+	}
+	return 0
+}
+
+type SaveSessionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to the current date and time.
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveSessionRequest) Reset() {
+	*x = SaveSessionRequest{}
+	mi := &file_tabspb_tabs_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveSessionRequest) ProtoMessage() {}
+
+func (x *SaveSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveSessionRequest.ProtoReflect.Descriptor instead.
+func (*SaveSessionRequest) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SaveSessionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SaveSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tabs          int32                  `protobuf:"varint,2,opt,name=tabs,proto3" json:"tabs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveSessionResponse) Reset() {
+	*x = SaveSessionResponse{}
+	mi := &file_tabspb_tabs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveSessionResponse) ProtoMessage() {}
+
+func (x *SaveSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tabspb_tabs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveSessionResponse.ProtoReflect.Descriptor instead.
+func (*SaveSessionResponse) Descriptor() ([]byte, []int) {
+	return file_tabspb_tabs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SaveSessionResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SaveSessionResponse) GetTabs() int32 {
+	if x != nil {
+		return x.Tabs
+	}
+	return 0
+}
+
+var File_tabspb_tabs_proto protoreflect.FileDescriptor
+
+var file_tabspb_tabs_proto_rawDesc = string([]byte{
+	0x0a, 0x11, 0x74, 0x61, 0x62, 0x73, 0x70, 0x62, 0x2f, 0x74, 0x61, 0x62, 0x73, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x13, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61,
+	0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd7, 0x02, 0x0a, 0x03, 0x54, 0x61,
+	0x62, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f,
+	0x77, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x62, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03,
+	0x74, 0x61, 0x62, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x6f, 0x6c, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03,
+	0x6f, 0x6c, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12,
+	0x39, 0x0a, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x76, 0x69, 0x73, 0x69, 0x74, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x09, 0x6c, 0x61, 0x73, 0x74, 0x56, 0x69, 0x73, 0x69, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0e, 0x72, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4d, 0x69, 0x6e, 0x75,
+	0x74, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x65,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x75, 0x67, 0x67, 0x65, 0x73, 0x74,
+	0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x75, 0x67, 0x67, 0x65, 0x73,
+	0x74, 0x65, 0x64, 0x22, 0x11, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x61, 0x62, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x96, 0x01, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x54,
+	0x61, 0x62, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x04, 0x74,
+	0x61, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x61, 0x66, 0x61,
+	0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x54, 0x61, 0x62, 0x52, 0x04, 0x74, 0x61, 0x62, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x67, 0x65,
+	0x5f, 0x64, 0x61, 0x79, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x61, 0x67, 0x65,
+	0x44, 0x61, 0x79, 0x73, 0x12, 0x39, 0x0a, 0x0a, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x5f,
+	0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x41, 0x74, 0x22,
+	0x13, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x61, 0x62, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0xc7, 0x02, 0x0a, 0x08, 0x54, 0x61, 0x62, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x36, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x22, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x62, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x54,
+	0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x2a, 0x0a, 0x03, 0x74, 0x61, 0x62,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74,
+	0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x62,
+	0x52, 0x03, 0x74, 0x61, 0x62, 0x12, 0x2c, 0x0a, 0x04, 0x74, 0x61, 0x62, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d,
+	0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x62, 0x52, 0x04, 0x74,
+	0x61, 0x62, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x63, 0x0a, 0x04, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x53, 0x4e, 0x41, 0x50, 0x53, 0x48, 0x4f, 0x54, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x41, 0x44, 0x44, 0x45, 0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x44, 0x10, 0x04, 0x22, 0x40,
+	0x0a, 0x10, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x61, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x72, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x04, 0x75, 0x72, 0x6c, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65,
+	0x22, 0x2b, 0x0a, 0x11, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x61, 0x62, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x22, 0x28, 0x0a,
+	0x12, 0x53, 0x61, 0x76, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x3d, 0x0a, 0x13, 0x53, 0x61, 0x76, 0x65, 0x53,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x62, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x74, 0x61, 0x62, 0x73, 0x32, 0xfa, 0x02, 0x0a, 0x0a, 0x54, 0x61, 0x62, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x57, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x61, 0x62,
+	0x73, 0x12, 0x24, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e,
+	0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x61, 0x62, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69,
+	0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x54, 0x61, 0x62, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x55,
+	0x0a, 0x0a, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x61, 0x62, 0x73, 0x12, 0x26, 0x2e, 0x73,
+	0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x61, 0x62, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62,
+	0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x62, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x30, 0x01, 0x12, 0x5a, 0x0a, 0x09, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x61,
+	0x62, 0x73, 0x12, 0x25, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61,
+	0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x61,
+	0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x73, 0x61, 0x66, 0x61,
+	0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x61, 0x62, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x60, 0x0a, 0x0b, 0x53, 0x61, 0x76, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x27, 0x2e, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x73, 0x61, 0x66, 0x61,
+	0x72, 0x69, 0x74, 0x61, 0x62, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x61, 0x76, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x42, 0x1b, 0x5a, 0x19, 0x73, 0x61, 0x66, 0x61, 0x72, 0x69, 0x2d, 0x74, 0x61,
+	0x62, 0x2d, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2f, 0x74, 0x61, 0x62, 0x73, 0x70, 0x62,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
+
+var (
+	file_tabspb_tabs_proto_rawDescOnce sync.Once
+	file_tabspb_tabs_proto_rawDescData []byte
+)
+
+func file_tabspb_tabs_proto_rawDescGZIP() []byte {
+	file_tabspb_tabs_proto_rawDescOnce.Do(func() {
+		file_tabspb_tabs_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tabspb_tabs_proto_rawDesc), len(file_tabspb_tabs_proto_rawDesc)))
+	})
+	return file_tabspb_tabs_proto_rawDescData
+}
+
+var file_tabspb_tabs_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_tabspb_tabs_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_tabspb_tabs_proto_goTypes = []any{
+	(TabEvent_Type)(0),            // 0: safaritabmanager.v1.TabEvent.Type
+	(*Tab)(nil),                   // 1: safaritabmanager.v1.Tab
+	(*ListTabsRequest)(nil),       // 2: safaritabmanager.v1.ListTabsRequest
+	(*ListTabsResponse)(nil),      // 3: safaritabmanager.v1.ListTabsResponse
+	(*StreamTabsRequest)(nil),     // 4: safaritabmanager.v1.StreamTabsRequest
+	(*TabEvent)(nil),              // 5: safaritabmanager.v1.TabEvent
+	(*CloseTabsRequest)(nil),      // 6: safaritabmanager.v1.CloseTabsRequest
+	(*CloseTabsResponse)(nil),     // 7: safaritabmanager.v1.CloseTabsResponse
+	(*SaveSessionRequest)(nil),    // 8: safaritabmanager.v1.SaveSessionRequest
+	(*SaveSessionResponse)(nil),   // 9: safaritabmanager.v1.SaveSessionResponse
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_tabspb_tabs_proto_depIdxs = []int32{
+	10, // 0: safaritabmanager.v1.Tab.last_visit:type_name -> google.protobuf.Timestamp
+	1,  // 1: safaritabmanager.v1.ListTabsResponse.tabs:type_name -> safaritabmanager.v1.Tab
+	10, // 2: safaritabmanager.v1.ListTabsResponse.scanned_at:type_name -> google.protobuf.Timestamp
+	0,  // 3: safaritabmanager.v1.TabEvent.type:type_name -> safaritabmanager.v1.TabEvent.Type
+	1,  // 4: safaritabmanager.v1.TabEvent.tab:type_name -> safaritabmanager.v1.Tab
+	1,  // 5: safaritabmanager.v1.TabEvent.tabs:type_name -> safaritabmanager.v1.Tab
+	10, // 6: safaritabmanager.v1.TabEvent.time:type_name -> google.protobuf.Timestamp
+	2,  // 7: safaritabmanager.v1.TabService.ListTabs:input_type -> safaritabmanager.v1.ListTabsRequest
+	4,  // 8: safaritabmanager.v1.TabService.StreamTabs:input_type -> safaritabmanager.v1.StreamTabsRequest
+	6,  // 9: safaritabmanager.v1.TabService.CloseTabs:input_type -> safaritabmanager.v1.CloseTabsRequest
+	8,  // 10: safaritabmanager.v1.TabService.SaveSession:input_type -> safaritabmanager.v1.SaveSessionRequest
+	3,  // 11: safaritabmanager.v1.TabService.ListTabs:output_type -> safaritabmanager.v1.ListTabsResponse
+	5,  // 12: safaritabmanager.v1.TabService.StreamTabs:output_type -> safaritabmanager.v1.TabEvent
+	7,  // 13: safaritabmanager.v1.TabService.CloseTabs:output_type -> safaritabmanager.v1.CloseTabsResponse
+	9,  // 14: safaritabmanager.v1.TabService.SaveSession:output_type -> safaritabmanager.v1.SaveSessionResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_tabspb_tabs_proto_init() }
+func file_tabspb_tabs_proto_init() {
+	if File_tabspb_tabs_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tabspb_tabs_proto_rawDesc), len(file_tabspb_tabs_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tabspb_tabs_proto_goTypes,
+		DependencyIndexes: file_tabspb_tabs_proto_depIdxs,
+		EnumInfos:         file_tabspb_tabs_proto_enumTypes,
+		MessageInfos:      file_tabspb_tabs_proto_msgTypes,
+	}.Build()
+	File_tabspb_tabs_proto = out.File
+	file_tabspb_tabs_proto_goTypes = nil
+	file_tabspb_tabs_proto_depIdxs = nil
+}
@@ -0,0 +1,256 @@
+// The gRPC API served by `safari-tab-manager serve`, for programmatic
+// consumers that prefer typed clients over the REST API. Regenerate the Go
+// code with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative tabspb/tabs.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: tabspb/tabs.proto
+
+package tabspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TabService_ListTabs_FullMethodName    = "/safaritabmanager.v1.TabService/ListTabs"
+	TabService_StreamTabs_FullMethodName  = "/safaritabmanager.v1.TabService/StreamTabs"
+	TabService_CloseTabs_FullMethodName   = "/safaritabmanager.v1.TabService/CloseTabs"
+	TabService_SaveSession_FullMethodName = "/safaritabmanager.v1.TabService/SaveSession"
+)
+
+// TabServiceClient is the client API for TabService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TabServiceClient interface {
+	// ListTabs returns the latest scan.
+	ListTabs(ctx context.Context, in *ListTabsRequest, opts ...grpc.CallOption) (*ListTabsResponse, error)
+	// StreamTabs sends a snapshot, then the changes found by every scan.
+	StreamTabs(ctx context.Context, in *StreamTabsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TabEvent], error)
+	// CloseTabs closes the open tabs with the given URLs. Protected tabs stay
+	// open, and a pre_close hook can cancel the whole close.
+	CloseTabs(ctx context.Context, in *CloseTabsRequest, opts ...grpc.CallOption) (*CloseTabsResponse, error)
+	// SaveSession saves the open tabs as a named session.
+	SaveSession(ctx context.Context, in *SaveSessionRequest, opts ...grpc.CallOption) (*SaveSessionResponse, error)
+}
+
+type tabServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTabServiceClient(cc grpc.ClientConnInterface) TabServiceClient {
+	return &tabServiceClient{cc}
+}
+
+func (c *tabServiceClient) ListTabs(ctx context.Context, in *ListTabsRequest, opts ...grpc.CallOption) (*ListTabsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTabsResponse)
+	err := c.cc.Invoke(ctx, TabService_ListTabs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tabServiceClient) StreamTabs(ctx context.Context, in *StreamTabsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TabEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TabService_ServiceDesc.Streams[0], TabService_StreamTabs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTabsRequest, TabEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TabService_StreamTabsClient = grpc.ServerStreamingClient[TabEvent]
+
+func (c *tabServiceClient) CloseTabs(ctx context.Context, in *CloseTabsRequest, opts ...grpc.CallOption) (*CloseTabsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseTabsResponse)
+	err := c.cc.Invoke(ctx, TabService_CloseTabs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tabServiceClient) SaveSession(ctx context.Context, in *SaveSessionRequest, opts ...grpc.CallOption) (*SaveSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveSessionResponse)
+	err := c.cc.Invoke(ctx, TabService_SaveSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TabServiceServer is the server API for TabService service.
+// All implementations must embed UnimplementedTabServiceServer
+// for forward compatibility.
+type TabServiceServer interface {
+	// ListTabs returns the latest scan.
+	ListTabs(context.Context, *ListTabsRequest) (*ListTabsResponse, error)
+	// StreamTabs sends a snapshot, then the changes found by every scan.
+	StreamTabs(*StreamTabsRequest, grpc.ServerStreamingServer[TabEvent]) error
+	// CloseTabs closes the open tabs with the given URLs. Protected tabs stay
+	// open, and a pre_close hook can cancel the whole close.
+	CloseTabs(context.Context, *CloseTabsRequest) (*CloseTabsResponse, error)
+	// SaveSession saves the open tabs as a named session.
+	SaveSession(context.Context, *SaveSessionRequest) (*SaveSessionResponse, error)
+	mustEmbedUnimplementedTabServiceServer()
+}
+
+// UnimplementedTabServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTabServiceServer struct{}
+
+func (UnimplementedTabServiceServer) ListTabs(context.Context, *ListTabsRequest) (*ListTabsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTabs not implemented")
+}
+func (UnimplementedTabServiceServer) StreamTabs(*StreamTabsRequest, grpc.ServerStreamingServer[TabEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTabs not implemented")
+}
+func (UnimplementedTabServiceServer) CloseTabs(context.Context, *CloseTabsRequest) (*CloseTabsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseTabs not implemented")
+}
+func (UnimplementedTabServiceServer) SaveSession(context.Context, *SaveSessionRequest) (*SaveSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveSession not implemented")
+}
+func (UnimplementedTabServiceServer) mustEmbedUnimplementedTabServiceServer() {}
+func (UnimplementedTabServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeTabServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TabServiceServer will
+// result in compilation errors.
+type UnsafeTabServiceServer interface {
+	mustEmbedUnimplementedTabServiceServer()
+}
+
+func RegisterTabServiceServer(s grpc.ServiceRegistrar, srv TabServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTabServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TabService_ServiceDesc, srv)
+}
+
+func _TabService_ListTabs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTabsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TabServiceServer).ListTabs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TabService_ListTabs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TabServiceServer).ListTabs(ctx, req.(*ListTabsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TabService_StreamTabs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTabsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TabServiceServer).StreamTabs(m, &grpc.GenericServerStream[StreamTabsRequest, TabEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TabService_StreamTabsServer = grpc.ServerStreamingServer[TabEvent]
+
+func _TabService_CloseTabs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseTabsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TabServiceServer).CloseTabs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TabService_CloseTabs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TabServiceServer).CloseTabs(ctx, req.(*CloseTabsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TabService_SaveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TabServiceServer).SaveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TabService_SaveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TabServiceServer).SaveSession(ctx, req.(*SaveSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TabService_ServiceDesc is the grpc.ServiceDesc for TabService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TabService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "safaritabmanager.v1.TabService",
+	HandlerType: (*TabServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTabs",
+			Handler:    _TabService_ListTabs_Handler,
+		},
+		{
+			MethodName: "CloseTabs",
+			Handler:    _TabService_CloseTabs_Handler,
+		},
+		{
+			MethodName: "SaveSession",
+			Handler:    _TabService_SaveSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTabs",
+			Handler:       _TabService_StreamTabs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "tabspb/tabs.proto",
+}
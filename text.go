@@ -0,0 +1,243 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// Width-aware text helpers. Widths are in terminal cells, so CJK characters
+// and emoji count as two. Text is measured and cut a grapheme cluster at a
+// time, what a terminal draws as one character, so an accented letter
+// stays with its accent and an emoji made of several code points, like a
+// flag, a family or a heart with its emoji variation selector, counts as
+// the two cells it takes. lipgloss and runewidth.StringWidth count those
+// code points one by one, so layouts that line up titles pad them with
+// padRight and joinColumns rather than lipgloss widths.
+
+// clusterWidth returns how many cells one grapheme cluster takes.
+func clusterWidth(cluster string) int {
+	width := 0
+	for _, r := range cluster {
+		if width = runewidth.RuneWidth(r); width > 0 {
+			break
+		}
+	}
+	if width == 1 && len([]rune(cluster)) > 1 {
+		first := []rune(cluster)[0]
+		if strings.ContainsAny(cluster, "\uFE0F\u200D") || first >= 0x1F1E6 && first <= 0x1F1FF {
+			return 2
+		}
+	}
+	return width
+}
+
+// textWidth returns how many cells s takes, skipping the escape sequences
+// of styled text.
+func textWidth(s string) int {
+	width := 0
+	for s != "" {
+		if s[0] == '\x1b' {
+			s = skipEscape(s)
+			continue
+		}
+		var cluster string
+		cluster, s, _, _ = uniseg.FirstGraphemeClusterInString(s, -1)
+		width += clusterWidth(cluster)
+	}
+	return width
+}
+
+// skipEscape drops the ANSI escape sequence s starts with.
+func skipEscape(s string) string {
+	if len(s) < 2 || s[1] != '[' {
+		return s[min(len(s), 2):]
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return s[i+1:]
+		}
+	}
+	return ""
+}
+
+// clusters splits plain text into grapheme clusters.
+func clusters(s string) []string {
+	var parts []string
+	state := -1
+	for s != "" {
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		parts = append(parts, cluster)
+	}
+	return parts
+}
+
+// cutWidth returns the longest start of s at most width cells wide.
+func cutWidth(s string, width int) string {
+	used, end := 0, 0
+	for _, cluster := range clusters(s) {
+		w := clusterWidth(cluster)
+		if used+w > width {
+			break
+		}
+		used += w
+		end += len(cluster)
+	}
+	return s[:end]
+}
+
+// tailWidth returns the longest end of s at most width cells wide.
+func tailWidth(s string, width int) string {
+	parts := clusters(s)
+	used, start := 0, len(s)
+	for i := len(parts) - 1; i >= 0; i-- {
+		w := clusterWidth(parts[i])
+		if used+w > width {
+			break
+		}
+		used += w
+		start -= len(parts[i])
+	}
+	return s[start:]
+}
+
+// truncateEnd shortens s to at most width cells, ending with an ellipsis.
+func truncateEnd(s string, width int) string {
+	if textWidth(s) <= width {
+		return s
+	}
+	return cutWidth(s, width-textWidth(sym.ellipsis)) + sym.ellipsis
+}
+
+// truncateMiddle shortens s to at most width cells by replacing its middle
+// with an ellipsis, keeping both the start and the end. This suits URLs,
+// where the domain and the last path segment carry the most meaning.
+func truncateMiddle(s string, width int) string {
+	if textWidth(s) <= width {
+		return s
+	}
+	ellipsisWidth := textWidth(sym.ellipsis)
+	if width <= ellipsisWidth {
+		return cutWidth(s, width)
+	}
+
+	headWidth := (width - ellipsisWidth + 1) / 2
+	tail := width - ellipsisWidth - headWidth
+	return cutWidth(s, headWidth) + sym.ellipsis + tailWidth(s, tail)
+}
+
+// wrapText breaks s into lines of at most width cells, preferring to break
+// at spaces. Text beyond maxLines is truncated with an ellipsis on the last
+// line.
+func wrapText(s string, width, maxLines int) []string {
+	var lines []string
+	for s != "" && len(lines) < maxLines-1 {
+		if textWidth(s) <= width {
+			break
+		}
+
+		line := cutWidth(s, width)
+		if line == "" {
+			break
+		}
+		if space := strings.LastIndex(line, " "); space > 0 {
+			line = line[:space]
+		}
+		lines = append(lines, line)
+		s = strings.TrimLeft(s[len(line):], " ")
+	}
+	return append(lines, truncateEnd(s, width))
+}
+
+// wrapURL hard-wraps a URL over at most maxLines lines; anything that still
+// doesn't fit is shortened in the middle of the last line.
+func wrapURL(s string, width, maxLines int) []string {
+	var lines []string
+	for len(lines) < maxLines-1 && textWidth(s) > width {
+		line := cutWidth(s, width)
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+		s = s[len(line):]
+	}
+	return append(lines, truncateMiddle(s, width))
+}
+
+// padRight pads s, which may be styled, with spaces to width cells.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-textWidth(s)))
+}
+
+// joinColumns lays out blocks of lines side by side, each padded to its
+// width, with gap between them.
+func joinColumns(gap string, widths []int, columns ...[]string) string {
+	rows := 0
+	for _, column := range columns {
+		rows = max(rows, len(column))
+	}
+	lines := make([]string, rows)
+	for n := range lines {
+		var line strings.Builder
+		for c, column := range columns {
+			var cell string
+			if n < len(column) {
+				cell = column[n]
+			}
+			if c > 0 {
+				line.WriteString(gap)
+			}
+			if c < len(columns)-1 {
+				cell = padRight(cell, widths[c])
+			}
+			line.WriteString(cell)
+		}
+		lines[n] = line.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderLines styles each line of s on its own, so lipgloss doesn't pad
+// the shorter ones to the width it measures for the longest.
+func renderLines(style lipgloss.Style, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// singleLine makes a title safe to show on one line of a layout: control
+// characters become spaces, and the bidirectional controls that would
+// reorder the rest of the line, or the layout around it, are dropped.
+func singleLine(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return ' '
+		case r >= '\u202A' && r <= '\u202E', r >= '\u2066' && r <= '\u2069':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// editText applies a key typed into a one-line text prompt to s.
+func editText(s string, msg tea.KeyMsg) string {
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if runes := []rune(s); len(runes) > 0 {
+			return string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		return s + " "
+	case tea.KeyRunes:
+		return s + string(msg.Runes)
+	}
+	return s
+}
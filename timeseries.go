@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// stats -timeseries prints the run history in the time series format of
+// Grafana's JSON datasource, one series per metric with [value, time in
+// milliseconds] points, so tab debt can be graphed without running serve
+// mode for Prometheus to scrape:
+//
+//	[{"target": "tabs", "datapoints": [[42, 1714730400000], ...]}, ...]
+//
+// The series are named after the Prometheus metrics they match.
+
+// timeSeries is one metric over time.
+type timeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// runTimeSeries turns the run history into a series per metric.
+func runTimeSeries(runs []loggedRun) []timeSeries {
+	metrics := []struct {
+		name  string
+		value func(loggedRun) int64
+	}{
+		{"safari_tabs", func(r loggedRun) int64 { return int64(r.Tabs) }},
+		{"safari_duplicate_tabs", func(r loggedRun) int64 { return int64(r.Duplicates) }},
+		{"safari_old_tabs", func(r loggedRun) int64 { return int64(r.Old) }},
+		{"safari_tabs_closed_total", func(r loggedRun) int64 { return r.Closed }},
+		{"safari_tabs_archived_total", func(r loggedRun) int64 { return r.Archived }},
+	}
+
+	series := make([]timeSeries, len(metrics))
+	for i, metric := range metrics {
+		series[i] = timeSeries{Target: metric.name, Datapoints: make([][2]float64, 0, len(runs))}
+		for _, run := range runs {
+			series[i].Datapoints = append(series[i].Datapoints, [2]float64{float64(metric.value(run)), float64(run.Time.UnixMilli())})
+		}
+	}
+	return series
+}
+
+// writeTimeSeries writes the run history as Grafana JSON time series.
+func writeTimeSeries(w io.Writer, runs []loggedRun) error {
+	return json.NewEncoder(w).Encode(runTimeSeries(runs))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// trackerConfig lists the issue trackers whose tickets -check-resolved
+// looks up, so tabs of finished tickets can be marked done.
+type trackerConfig struct {
+	Jira        []jiraConfig `json:"jira"`
+	LinearToken string       `json:"linear_token"` // Personal API key from Linear's settings
+}
+
+// jiraConfig is a Jira site and how to sign in to it.
+type jiraConfig struct {
+	Host  string `json:"host"`  // e.g. "acme.atlassian.net"
+	Email string `json:"email"` // Jira Cloud: the account's email, used with an API token
+	Token string `json:"token"` // API token for Jira Cloud, or a personal access token for Jira Server
+}
+
+var trackers trackerConfig // Set from config.json
+
+var (
+	jiraKeyRegexp     = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+	jiraBrowseRegexp  = regexp.MustCompile(`/browse/([A-Z][A-Z0-9_]+-[0-9]+)`)
+	linearIssueRegexp = regexp.MustCompile(`^/[^/]+/issue/([A-Za-z0-9]+-[0-9]+)`)
+)
+
+// trackerLookup returns the lookup for a Jira or Linear ticket URL.
+func trackerLookup(u *url.URL) *stateLookup {
+	host := strings.ToLower(u.Hostname())
+	if host == "linear.app" && trackers.LinearToken != "" {
+		if m := linearIssueRegexp.FindStringSubmatch(u.Path); m != nil {
+			return linearLookup(strings.ToUpper(m[1]))
+		}
+		return nil
+	}
+	for _, jira := range trackers.Jira {
+		if strings.EqualFold(jira.Host, host) {
+			return jiraLookup(jira, u)
+		}
+	}
+	return nil
+}
+
+// jiraLookup looks up a ticket shown at /browse/KEY, or picked on a board
+// with ?selectedIssue=KEY.
+func jiraLookup(jira jiraConfig, u *url.URL) *stateLookup {
+	key := u.Query().Get("selectedIssue")
+	if m := jiraBrowseRegexp.FindStringSubmatch(u.Path); m != nil {
+		key = m[1]
+	}
+	if !jiraKeyRegexp.MatchString(key) {
+		return nil
+	}
+
+	apiURL := fmt.Sprintf("https://%s/rest/api/2/issue/%s?fields=status", jira.Host, key)
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Accept", "application/json")
+	if jira.Email != "" {
+		req.SetBasicAuth(jira.Email, jira.Token)
+	} else if jira.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+jira.Token)
+	}
+	return &stateLookup{key: apiURL, req: req, parse: parseJiraState}
+}
+
+// parseJiraState reads a ticket's status category, which is "done" for
+// Done, Closed, Resolved and whatever other final statuses a project has.
+func parseJiraState(body io.Reader) (string, error) {
+	var issue struct {
+		Fields struct {
+			Status struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	if err := json.NewDecoder(body).Decode(&issue); err != nil {
+		return "", err
+	}
+	if issue.Fields.Status.StatusCategory.Key == "done" {
+		return "done", nil
+	}
+	return "open", nil
+}
+
+// linearLookup looks up a Linear issue by its identifier, like ENG-123,
+// through the GraphQL API.
+func linearLookup(identifier string) *stateLookup {
+	query, err := json.Marshal(map[string]any{
+		"query":     `query($id: String!) { issue(id: $id) { state { type } } }`,
+		"variables": map[string]string{"id": identifier},
+	})
+	if err != nil {
+		return nil
+	}
+	req, err := http.NewRequest("POST", "https://api.linear.app/graphql", bytes.NewReader(query))
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", trackers.LinearToken)
+	return &stateLookup{key: "linear:" + identifier, req: req, parse: parseLinearState}
+}
+
+// parseLinearState reads a Linear issue's workflow state type: completed
+// issues are done, canceled ones closed.
+func parseLinearState(body io.Reader) (string, error) {
+	var result struct {
+		Data struct {
+			Issue *struct {
+				State struct {
+					Type string `json:"type"`
+				} `json:"state"`
+			} `json:"issue"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.Errors) > 0 {
+		return "", fmt.Errorf("%s", result.Errors[0].Message)
+	}
+	if result.Data.Issue == nil {
+		return "", fmt.Errorf("no such issue")
+	}
+	switch result.Data.Issue.State.Type {
+	case "completed":
+		return "done", nil
+	case "canceled":
+		return "closed", nil
+	}
+	return "open", nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// The same page can reach Safari under URLs that differ only in how they
+// are written: an internationalized domain in Unicode or in punycode
+// ("bücher.de" or "xn--bcher-kva.de"), and a path with its characters
+// percent-encoded or not, in upper or lower case hex. Duplicates are found
+// by comparing URLs in one canonical form, and the list shows them in a
+// readable one. Everything that compares or groups URLs, dedupe, grouping
+// and stats, parses them with parseURL.
+
+// defaultPorts are the ports URLs of a scheme go to when they name none.
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21", "ws": "80", "wss": "443"}
+
+// parseURL parses a URL with its host in lowercase, without the scheme's
+// default port, and its path cleaned, see cleanPath. A bare host, like
+// "example.com", is read as one.
+func parseURL(raw string) (*url.URL, bool) {
+	u, err := url.Parse(raw)
+	if err == nil && u.Scheme == "" && u.Host == "" && !strings.HasPrefix(raw, "/") {
+		u, err = url.Parse("//" + raw)
+	}
+	if err != nil {
+		return nil, false
+	}
+	u.Host = strings.ToLower(u.Host)
+	if _, port := splitPort(u.Host); port == ":"+defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, port)
+	}
+	if u.RawPath != "" {
+		u.RawPath = cleanPath(u.RawPath)
+	}
+	u.Path = cleanPath(u.Path)
+	return u, true
+}
+
+// cleanPath resolves "." and ".." in a path and drops doubled slashes,
+// keeping a trailing slash, since pages may tell it apart.
+func cleanPath(p string) string {
+	if p == "" || p == "/" {
+		return p
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// canonicalURL returns the form of a URL duplicates are compared in: as
+// parseURL reads it, with the scheme in lowercase, the host in punycode, an
+// empty path as "/" and percent-encoding normalized, see normalizeEscapes.
+// URLs without a scheme or host are returned as they are.
+func canonicalURL(raw string) string {
+	u, ok := parseURL(raw)
+	if !ok || u.Scheme == "" || u.Host == "" {
+		return raw
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(u.Scheme) + "://")
+	if u.User != nil {
+		b.WriteString(u.User.String() + "@")
+	}
+	b.WriteString(asciiHost(u.Host))
+	if p := u.EscapedPath(); p != "" {
+		b.WriteString(normalizeEscapes(p))
+	} else {
+		b.WriteString("/")
+	}
+	if u.ForceQuery || u.RawQuery != "" {
+		b.WriteString("?" + normalizeEscapes(u.RawQuery))
+	}
+	if u.Fragment != "" {
+		b.WriteString("#" + normalizeEscapes(u.EscapedFragment()))
+	}
+	return b.String()
+}
+
+// displayURL returns a URL the way people read it: the host in Unicode,
+// and percent-encoded letters of other scripts decoded. Encoded ASCII, like
+// "%20" or "%2F", stays encoded, since decoding it could change what the
+// URL says.
+func displayURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	at := strings.Index(raw, u.Host)
+	if at < 0 {
+		return raw
+	}
+	return raw[:at] + unicodeHost(u.Host) + decodeEscapes(raw[at+len(u.Host):])
+}
+
+// asciiHost returns a host, with its port if it has one, in lowercase
+// punycode. Hosts IDNA rejects are only lowercased.
+func asciiHost(host string) string {
+	host = strings.ToLower(host)
+	if isASCII(host) {
+		return host
+	}
+	name, port := splitPort(host)
+	ascii, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return host
+	}
+	return ascii + port
+}
+
+// unicodeHost returns a host, with its port if it has one, in lowercase
+// Unicode. Hosts IDNA rejects are only lowercased.
+func unicodeHost(host string) string {
+	host = strings.ToLower(host)
+	if isASCII(host) && !strings.Contains(host, "xn--") {
+		return host
+	}
+	name, port := splitPort(host)
+	unicodeName, err := idna.Display.ToUnicode(name)
+	if err != nil {
+		return host
+	}
+	return unicodeName + port
+}
+
+// splitPort splits ":port" off a host.
+func splitPort(host string) (name, port string) {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		return h, ":" + p
+	}
+	return host, ""
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeEscapes writes percent-encoding one way: unreserved characters
+// (letters, digits, "-", ".", "_" and "~") decoded, other encoded bytes in
+// uppercase hex, and raw non-ASCII bytes encoded.
+func normalizeEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if decoded, ok := unescapeAt(s, i); ok {
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				b.WriteString(escapeByte(decoded))
+			}
+			i += 2
+			continue
+		}
+		if c >= utf8.RuneSelf {
+			b.WriteString(escapeByte(c))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// decodeEscapes decodes the percent-encoded printable non-ASCII
+// characters in s, leaving every other escape as it is.
+func decodeEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		// Collect a run of escapes, since one character takes several
+		var run []byte
+		j := i
+		for {
+			decoded, ok := unescapeAt(s, j)
+			if !ok {
+				break
+			}
+			run = append(run, decoded)
+			j += 3
+		}
+		if len(run) == 0 {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		for len(run) > 0 {
+			r, size := utf8.DecodeRune(run)
+			if r >= utf8.RuneSelf && r != utf8.RuneError && unicode.IsPrint(r) && !unicode.IsSpace(r) {
+				b.WriteRune(r)
+			} else {
+				b.WriteString(s[i : i+3*size])
+			}
+			run = run[size:]
+			i += 3 * size
+		}
+	}
+	return b.String()
+}
+
+// unescapeAt decodes the escape at s[i], if there is one.
+func unescapeAt(s string, i int) (byte, bool) {
+	if i+2 >= len(s) || s[i] != '%' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(n), true
+}
+
+func escapeByte(c byte) string {
+	const hex = "0123456789ABCDEF"
+	return string([]byte{'%', hex[c>>4], hex[c&15]})
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
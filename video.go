@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Video tabs pile up as things to watch later. They are recognized by URL,
+// and what can be found out about them is shown: the length, from the page
+// with -reading-time or from the player with JavaScript from Apple Events
+// allowed, and how far they were watched, from the player or from a
+// timestamp in the URL. A playing video, even a muted one, is never
+// selected in bulk, and "Watch later" moves videos to a list instead of just
+// closing them.
+
+var watchLaterFile string // Set from config.json
+
+// watchedFraction is how much of a video must have been played for it to
+// count as watched; the rest is usually credits or an end screen.
+const watchedFraction = 0.9
+
+var (
+	// A duration in the page's metadata, like PT1H2M3S
+	videoDurationMetaRegexp = regexp.MustCompile(`(?i)<meta\s+itemprop=["']duration["']\s+content=["']PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?["']`)
+	// YouTube's player data
+	videoLengthSecondsRegexp = regexp.MustCompile(`"lengthSeconds":"(\d+)"`)
+	// A timestamp like 1h2m3s, 2m, 90s or 90
+	timestampRegexp = regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s?)?$`)
+)
+
+// isVideo reports whether a URL plays a video on YouTube, Vimeo, Twitch,
+// Dailymotion or Netflix.
+func isVideo(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := u.Path
+	switch host {
+	case "youtube.com", "m.youtube.com", "music.youtube.com":
+		return (path == "/watch" && u.Query().Get("v") != "") ||
+			strings.HasPrefix(path, "/shorts/") || strings.HasPrefix(path, "/live/")
+	case "youtu.be":
+		return len(path) > 1
+	case "vimeo.com":
+		first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		_, err := strconv.Atoi(first)
+		return err == nil
+	case "twitch.tv", "m.twitch.tv":
+		return strings.HasPrefix(path, "/videos/")
+	case "dailymotion.com":
+		return strings.HasPrefix(path, "/video/")
+	case "netflix.com":
+		return strings.HasPrefix(path, "/watch/")
+	}
+	return false
+}
+
+// parseTimestamp returns the seconds in a timestamp like 1h2m3s or 90, or 0.
+func parseTimestamp(s string) int {
+	m := timestampRegexp.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return 0
+	}
+	var seconds int
+	for i, unit := range []int{3600, 60, 1} {
+		n, _ := strconv.Atoi(m[i+1])
+		seconds += n * unit
+	}
+	return seconds
+}
+
+// urlPosition returns the position a video URL starts at, from its t or
+// start parameter or a #t= fragment, or 0.
+func urlPosition(rawURL string) int {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	for _, param := range []string{"t", "start"} {
+		if t := u.Query().Get(param); t != "" {
+			return parseTimestamp(t)
+		}
+	}
+	if t, ok := strings.CutPrefix(u.Fragment, "t="); ok {
+		return parseTimestamp(t)
+	}
+	return 0
+}
+
+// videoSeconds reads a video's length from its page, or returns 0.
+func videoSeconds(page []byte) int {
+	if m := videoLengthSecondsRegexp.FindSubmatch(page); m != nil {
+		seconds, _ := strconv.Atoi(string(m[1]))
+		return seconds
+	}
+	if m := videoDurationMetaRegexp.FindSubmatch(page); m != nil {
+		var seconds int
+		for i, unit := range []int{3600, 60, 1} {
+			n, _ := strconv.Atoi(string(m[i+1]))
+			seconds += n * unit
+		}
+		return seconds
+	}
+	return 0
+}
+
+// markVideos sets Video on tabs of videos, and takes the watched position
+// from the URL where the player didn't report one.
+func markVideos(tabs []Tab) {
+	for i := range tabs {
+		tabs[i].Video = isVideo(tabs[i].URL)
+		if tabs[i].Video && tabs[i].VideoPosition == 0 {
+			tabs[i].VideoPosition = urlPosition(tabs[i].URL)
+		}
+	}
+}
+
+// watched reports whether most of a video has been played.
+func (t Tab) watched() bool {
+	return t.VideoSeconds > 0 && float64(t.VideoPosition) >= watchedFraction*float64(t.VideoSeconds)
+}
+
+// playing reports whether a tab plays audio or is a video that's playing,
+// so it's in use and left out of bulk selection. Muted videos playing on
+// other pages are usually decoration and don't count.
+func (t Tab) playing() bool {
+	return t.PlaysAudio || (t.Video && t.PlaysVideo)
+}
+
+// formatClock formats seconds as 1:02:03 or 2:03.
+func formatClock(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// videoProgress describes a video's length and how far it was watched, or
+// is empty if neither is known.
+func videoProgress(tab *Tab) string {
+	switch {
+	case tab.watched():
+		return tr("watched")
+	case tab.VideoPosition > 0 && tab.VideoSeconds > 0:
+		return tr("%s of %s watched", formatClock(tab.VideoPosition), formatClock(tab.VideoSeconds))
+	case tab.VideoPosition > 0:
+		return tr("watched to %s", formatClock(tab.VideoPosition))
+	case tab.VideoSeconds > 0:
+		return formatClock(tab.VideoSeconds)
+	}
+	return ""
+}
+
+// videoInfo describes a video tab, for display.
+func videoInfo(tab *Tab) string {
+	if progress := videoProgress(tab); progress != "" {
+		return tr("video, %s", progress)
+	}
+	return tr("video")
+}
+
+// defaultWatchLaterFile is where "Watch later" sends videos unless
+// watch_later is set.
+func defaultWatchLaterFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "watch-later.md"
+	}
+	return filepath.Join(home, "Documents", "Watch Later.md")
+}
+
+// watchLaterArchiver appends videos to the watch later list, with their
+// length and how far they were watched.
+type watchLaterArchiver struct {
+	path string
+}
+
+func (a watchLaterArchiver) Name() string {
+	return filepath.Base(a.path)
+}
+
+func (a watchLaterArchiver) Target() string {
+	return "watch-later:" + a.path
+}
+
+func (a watchLaterArchiver) Archive(tabs []Tab) error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", tr("Added %s", time.Now().Format("2006-01-02 15:04")))
+	for _, tab := range tabs {
+		fmt.Fprintf(&b, "- %s", markdownLink(tab))
+		if progress := videoProgress(&tab); progress != "" {
+			fmt.Fprintf(&b, " (%s)", progress)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err = f.WriteString(b.String())
+	return err
+}
+
+// watchLater sends the videos among tabs to the watch later list and closes
+// them. Other tabs are left alone.
+func (m *model) watchLater(tabs []Tab) tea.Cmd {
+	path := watchLaterFile
+	if path == "" {
+		path = defaultWatchLaterFile()
+	}
+	return m.archiveOnly(watchLaterArchiver{path: path}, tabs, func(t Tab) bool { return t.Video }, tr("No videos selected."))
+}
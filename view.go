@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A savedView is a named combination of filter, sort order and grouping,
+// kept in config.json and picked from the view menu or with -view.
+type savedView struct {
+	Name   string `json:"name"`
+	Filter string `json:"filter,omitempty"` // -only terms separated by spaces, e.g. "old category:news"
+	Sort   string `json:"sort,omitempty"`   // One of sortOrders, window order by default
+	Group  string `json:"group,omitempty"`  // One of groupings, ungrouped by default
+}
+
+// sortOrders are the orders the list can be sorted in. The first is the
+// order Safari reports the tabs in.
+var sortOrders = []string{"window", "age", "domain", "title", "memory"}
+
+// groupings are what the list can be grouped by. The first is no grouping.
+var groupings = []string{"", "domain", "window", "category", "burst", "project", "search", "docs", "language"}
+
+// filter parses the view's filter terms.
+func (v savedView) filter() (tabFilter, error) {
+	var f tabFilter
+	for _, term := range strings.Fields(v.Filter) {
+		if err := f.add(term); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// validate checks the view's filter, sort order and grouping.
+func (v savedView) validate() error {
+	if _, err := v.filter(); err != nil {
+		return fmt.Errorf("view %q: %w", v.Name, err)
+	}
+	if v.Sort != "" && !slices.Contains(sortOrders, v.Sort) {
+		return fmt.Errorf("view %q: unknown sort %q, want %s", v.Name, v.Sort, strings.Join(sortOrders, ", "))
+	}
+	if !slices.Contains(groupings, v.Group) {
+		return fmt.Errorf("view %q: unknown group %q, want %s", v.Name, v.Group, strings.Join(groupings[1:], ", "))
+	}
+	return nil
+}
+
+// describe summarizes the view for the view menu.
+func (v savedView) describe() string {
+	parts := []string{tr("filter: %s", v.Filter), tr("sort: %s", v.Sort), tr("group: %s", v.Group)}
+	if v.Filter == "" {
+		parts[0] = tr("filter: %s", tr("all"))
+	}
+	if v.Sort == "" {
+		parts[1] = tr("sort: %s", sortOrders[0])
+	}
+	if v.Group == "" {
+		parts[2] = tr("group: %s", tr("none"))
+	}
+	return strings.Join(parts, sym.separator)
+}
+
+// findView looks a view up by name, ignoring case.
+func findView(views []savedView, name string) (savedView, bool) {
+	for _, v := range views {
+		if strings.EqualFold(v.Name, name) {
+			return v, true
+		}
+	}
+	return savedView{}, false
+}
+
+// saveView adds a view to config.json, replacing any view with the same
+// name.
+func saveView(v savedView) ([]savedView, error) {
+	var views []savedView
+	err := editConfig(func(settings map[string]json.RawMessage) error {
+		if raw, ok := settings["views"]; ok {
+			if err := json.Unmarshal(raw, &views); err != nil {
+				return fmt.Errorf("could not read views in config.json: %w", err)
+			}
+		}
+
+		replaced := false
+		for i := range views {
+			if strings.EqualFold(views[i].Name, v.Name) {
+				views[i] = v
+				replaced = true
+			}
+		}
+		if !replaced {
+			views = append(views, v)
+		}
+
+		raw, err := json.Marshal(views)
+		settings["views"] = raw
+		return err
+	})
+	return views, err
+}
+
+// currentView is the filter, sort order and grouping the list shows now.
+func (m model) currentView(name string) savedView {
+	v := savedView{Name: name, Filter: m.filter.String(), Sort: m.sortBy, Group: m.groupBy}
+	if v.Sort == sortOrders[0] {
+		v.Sort = ""
+	}
+	return v
+}
+
+// applyView switches the list to a view.
+func (m *model) applyView(v savedView) error {
+	if err := v.validate(); err != nil {
+		return err
+	}
+	m.filter, _ = v.filter()
+	m.sortBy = v.Sort
+	m.setGrouping(v.Group)
+	return nil
+}
+
+// setGrouping groups the list, giving every item a line for group headings.
+func (m *model) setGrouping(group string) {
+	m.groupBy = group
+	m.delegate.grouped = group != ""
+	m.list.SetDelegate(m.delegate)
+	m.refreshItems()
+}
+
+// nextSortOrder cycles through sortOrders.
+func (m *model) nextSortOrder() {
+	i := slices.Index(sortOrders, m.sortBy)
+	m.sortBy = sortOrders[(i+1)%len(sortOrders)]
+	m.refreshItems()
+}
+
+// nextGrouping cycles through groupings.
+func (m *model) nextGrouping() {
+	i := slices.Index(groupings, m.groupBy)
+	m.setGrouping(groupings[(i+1)%len(groupings)])
+}
+
+// groupLabel names the group a tab belongs to.
+func groupLabel(tab *Tab, group string) string {
+	switch group {
+	case "domain":
+		return domainLabel(siteOf(tab.URL))
+	case "window":
+		if tab.Source == "" && (tab.Display != "" || tab.DisplayGone) {
+			return tr("%s on %s", tab.container(), displayLabel(tab.Display, tab.DisplayGone))
+		}
+		return tab.container()
+	case "category":
+		if tab.Category == "" {
+			return tr("uncategorized")
+		}
+		return tab.Category
+	case "burst":
+		return burstLabel(tab.Burst)
+	case "project":
+		return projectLabel(tab.Project)
+	case "search":
+		return searchLabel(tab.SearchQuery)
+	case "docs":
+		return docSetLabel(tab.DocSet)
+	case "language":
+		return languageLabel(tab.Language)
+	}
+	return ""
+}
+
+// orderItems sorts the items by sortBy, then gathers them into groups in
+// the order the groups first appear, and labels the first item of each.
+// Sort keys and labels are worked out once per tab, not per comparison.
+func orderItems(items []item, sortBy, groupBy string) {
+	if len(items) == 0 {
+		return
+	}
+	tabs := items[0].tabs
+
+	if sortBy == "age" {
+		// Never visited tabs first, then the least recently visited
+		sort.SliceStable(items, func(a, b int) bool {
+			return items[a].tab().lastActive().Before(items[b].tab().lastActive())
+		})
+	} else if sortBy == "memory" {
+		// Heaviest first, tabs of unknown cost last
+		sort.SliceStable(items, func(a, b int) bool {
+			return items[a].tab().MemoryMB > items[b].tab().MemoryMB
+		})
+	} else if sortBy == "domain" || sortBy == "title" {
+		keys := make([]string, len(tabs))
+		for _, it := range items {
+			if sortBy == "domain" {
+				keys[it.index] = siteOf(it.tab().URL)
+			} else {
+				keys[it.index] = strings.ToLower(it.tab().Title)
+			}
+		}
+		sort.SliceStable(items, func(a, b int) bool {
+			return keys[items[a].index] < keys[items[b].index]
+		})
+	}
+	if groupBy == "" {
+		return
+	}
+
+	labels := make([]string, len(tabs))
+	first := make(map[string]int)
+	sizes := make(map[string]int)
+	for i, it := range items {
+		label := groupLabel(it.tab(), groupBy)
+		labels[it.index] = label
+		if _, ok := first[label]; !ok {
+			first[label] = i
+		}
+		sizes[label]++
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		return first[labels[items[a].index]] < first[labels[items[b].index]]
+	})
+	for i := range items {
+		label := labels[items[i].index]
+		if i == 0 || label != labels[items[i-1].index] {
+			items[i].heading = label + " (" + strconv.Itoa(sizes[label]) + ")"
+		}
+	}
+}
+
+// updateViewMenu handles a key while the view menu is open: a number picks
+// a saved view, 0 shows all tabs and + names the current view to save it.
+func (m *model) updateViewMenu(msg tea.KeyMsg) tea.Cmd {
+	if m.naming {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.naming = false
+		case tea.KeyEnter:
+			name := strings.TrimSpace(m.viewName)
+			if name == "" {
+				return nil
+			}
+			views, err := saveView(m.currentView(name))
+			if err != nil {
+				return m.showToast(tr("Could not save view: %v", err))
+			}
+			m.views = views
+			m.naming = false
+			m.viewMenu = false
+			return m.showToast(tr("Saved view %q.", name))
+		default:
+			m.viewName = editText(m.viewName, msg)
+		}
+		return nil
+	}
+
+	switch name := msg.String(); {
+	case name == "esc" || name == "v":
+		m.viewMenu = false
+
+	case name == "+":
+		m.naming = true
+		m.viewName = ""
+
+	case name == "0":
+		m.viewMenu = false
+		m.applyView(savedView{})
+		return m.showToast(tr("Showing all tabs."))
+
+	case len(name) == 1 && name >= "1" && name <= "9":
+		n := int(name[0] - '1')
+		if n >= len(m.views) {
+			return nil
+		}
+		m.viewMenu = false
+		if err := m.applyView(m.views[n]); err != nil {
+			return m.showToast(tr("Error: %v", err))
+		}
+		return m.showToast(tr("Showing view %q.", m.views[n].Name))
+	}
+	return nil
+}
+
+// viewMenuView lists the saved views to pick from.
+func (m model) viewMenuView() string {
+	lines := []string{titleStyle.Render(tr("Views")), ""}
+	lines = append(lines, "  0. "+tr("All tabs"))
+	for i, v := range m.views {
+		if i == 9 {
+			break // Only single digits can pick a view
+		}
+		lines = append(lines, fmt.Sprintf("  %d. %s", i+1, v.Name), helpStyle.Render("     "+v.describe()))
+	}
+	if len(m.views) == 0 {
+		lines = append(lines, "", helpStyle.Render("  "+tr("No saved views yet. Set a filter, sort order and grouping, then press + to save them.")))
+	}
+
+	lines = append(lines, "")
+	if m.naming {
+		lines = append(lines, "  "+tr("Name: %s", m.viewName+"_"), helpStyle.Render("  "+m.currentView("").describe()))
+		lines = append(lines, "", helpStyle.Render(" "+strings.Join([]string{tr("enter: save"), tr("esc: cancel")}, sym.separator)))
+	} else {
+		hints := []string{tr("1-9: show view"), tr("0: all tabs"), tr("+: save current view"), tr("esc: back to the list")}
+		lines = append(lines, helpStyle.Render(" "+strings.Join(hints, sym.separator)))
+	}
+	if m.toast != "" {
+		lines = append(lines, messageStyle.Render(" "+m.toast))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,180 @@
+"use strict";
+
+// Mirrors the interactive list: tabs suggested for closing start selected,
+// and the selection survives rescans.
+
+let tabs = [];
+let selected = new Set();
+let seen = new Set();
+
+const $ = (id) => document.getElementById(id);
+
+function shown() {
+  const query = $("search").value.toLowerCase();
+  const category = $("category").value;
+  return tabs.filter((tab) =>
+    (!query || tab.title.toLowerCase().includes(query) || tab.url.toLowerCase().includes(query)) &&
+    (!category || tab.category === category) &&
+    (!$("only-duplicates").checked || tab.duplicate) &&
+    (!$("only-old").checked || tab.old));
+}
+
+function key(tab) {
+  return tab.window + ":" + tab.tab + ":" + tab.url;
+}
+
+function daysAgo(date) {
+  return Math.floor((Date.now() - new Date(date)) / 86400000);
+}
+
+function render() {
+  const duplicates = tabs.filter((tab) => tab.duplicate).length;
+  const old = tabs.filter((tab) => tab.old).length;
+  $("summary").textContent = `${tabs.length} tabs, ${duplicates} duplicates, ${old} old, ${selected.size} selected`;
+
+  const categories = [...new Set(tabs.map((tab) => tab.category).filter(Boolean))].sort();
+  const current = $("category").value;
+  $("category").replaceChildren(new Option("All categories", ""), ...categories.map((c) => new Option(c, c)));
+  $("category").value = categories.includes(current) ? current : "";
+
+  const list = shown().map((tab) => {
+    const li = document.createElement("li");
+    li.classList.toggle("duplicate", tab.duplicate);
+    li.classList.toggle("old", tab.old);
+    li.classList.toggle("protected", !!tab.protected);
+
+    const box = document.createElement("input");
+    box.type = "checkbox";
+    box.checked = selected.has(key(tab));
+    box.disabled = !!tab.protected;
+    box.addEventListener("change", () => {
+      box.checked ? selected.add(key(tab)) : selected.delete(key(tab));
+      render();
+    });
+
+    const details = document.createElement("div");
+    details.className = "details";
+    const title = document.createElement("div");
+    title.className = "title";
+    title.textContent = tab.title || tab.url;
+    const url = document.createElement("a");
+    url.className = "url";
+    url.href = tab.url;
+    url.textContent = tab.url;
+    url.target = "_blank";
+    url.rel = "noreferrer";
+    const info = document.createElement("div");
+    info.className = "info";
+    const notes = [`Window ${tab.window}, Tab ${tab.tab}`];
+    if (tab.duplicate) notes.push("duplicate");
+    if (tab.old && tab.last_visit) notes.push(`last visited ${daysAgo(tab.last_visit)} days ago`);
+    if (tab.reading_minutes) notes.push(`~${tab.reading_minutes} min read`);
+    if (tab.category) notes.push(tab.category);
+    if (tab.protected) notes.push("protected");
+    for (const tag of tab.tags || []) notes.push("#" + tag);
+    info.textContent = notes.join(" · ");
+
+    details.append(title, url, info);
+    li.append(box, details);
+    return li;
+  });
+  $("tabs").replaceChildren(...list);
+}
+
+async function load() {
+  const response = await fetch("api/tabs");
+  const data = await response.json();
+  tabs = data.tabs;
+
+  const keys = new Set(tabs.map(key));
+  selected = new Set([...selected].filter((k) => keys.has(k)));
+  for (const tab of tabs) {
+    if (!seen.has(key(tab)) && tab.suggested && !tab.protected) selected.add(key(tab));
+  }
+  seen = keys;
+  render();
+}
+
+function selectedTabs() {
+  return tabs.filter((tab) => selected.has(key(tab)));
+}
+
+async function closeSelected(archive) {
+  const chosen = selectedTabs();
+  if (chosen.length === 0) {
+    $("status").textContent = "No tabs selected.";
+    return;
+  }
+  const verb = archive ? "Archive and close" : "Close";
+  if (!confirm(`${verb} ${chosen.length} tabs?`)) return;
+
+  $("status").textContent = "Closing…";
+  const response = await fetch("api/close", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ urls: chosen.map((tab) => tab.url), archive }),
+  });
+  const result = await response.json();
+  $("status").textContent = response.ok ? `Closed ${result.closed} tabs.` : `Nothing was closed: ${result.error}`;
+  if (response.ok) selected.clear();
+  await load();
+}
+
+function download(name, type, text) {
+  const link = document.createElement("a");
+  link.href = URL.createObjectURL(new Blob([text], { type }));
+  link.download = name;
+  link.click();
+  URL.revokeObjectURL(link.href);
+}
+
+function exportTabs() {
+  const chosen = selectedTabs();
+  return chosen.length > 0 ? chosen : shown();
+}
+
+$("export-markdown").addEventListener("click", () => {
+  const lines = exportTabs().map((tab) => `- [${(tab.title || tab.url).replaceAll("]", "\\]")}](${tab.url})`);
+  download("safari-tabs.md", "text/markdown", lines.join("\n") + "\n");
+});
+
+$("export-json").addEventListener("click", () => {
+  download("safari-tabs.json", "application/json", JSON.stringify(exportTabs(), null, 2));
+});
+
+$("close").addEventListener("click", () => closeSelected(false));
+$("archive").addEventListener("click", () => closeSelected(true));
+
+for (const button of document.querySelectorAll("[data-select]")) {
+  button.addEventListener("click", () => {
+    const which = button.dataset.select;
+    if (which === "none") {
+      selected.clear();
+    } else {
+      const match = { duplicates: (t) => t.duplicate, old: (t) => t.old, shown: () => true }[which];
+      for (const tab of shown()) {
+        if (match(tab) && !tab.protected) selected.add(key(tab));
+      }
+    }
+    render();
+  });
+}
+
+for (const id of ["search", "category", "only-duplicates", "only-old"]) {
+  $(id).addEventListener("input", render);
+}
+
+// Reload whenever the scanner reports changes, once per scan
+let reload;
+function listen() {
+  const socket = new WebSocket(location.href.replace(/^http/, "ws").replace(/\/[^/]*$/, "/events"));
+  socket.addEventListener("message", (event) => {
+    if (JSON.parse(event.data).type === "snapshot") return;
+    clearTimeout(reload);
+    reload = setTimeout(load, 200);
+  });
+  socket.addEventListener("close", () => setTimeout(listen, 5000));
+}
+
+load().catch((err) => ($("summary").textContent = `Could not load tabs: ${err}`));
+listen();
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// A webhook archive target is a Slack or Discord incoming webhook URL,
+// used wherever an archive file can be: as -archive-file, in
+// archive_targets or as a rule's archive. The tabs are posted as a message
+// with a list of links per domain, so a team sees what was researched.
+
+// closeWebhook, if set, gets every list of closed tabs, archived or not.
+var closeWebhook string // Set from config.json
+
+// Messages longer than this are split, within Discord's limit of 2000
+// characters; Slack allows much more.
+const (
+	discordMessageLimit = 2000
+	slackMessageLimit   = 12000
+)
+
+// isWebhook reports whether an archive target is a webhook URL rather than
+// a file.
+func isWebhook(target string) bool {
+	return strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "http://")
+}
+
+// archiverFor returns the archiver for an archive target.
+func archiverFor(target string) archiver {
+	if isWebhook(target) {
+		return webhookArchiver{url: target}
+	}
+	return markdownArchiver{path: expandHome(target)}
+}
+
+// webhookArchiver posts archived tabs to a Slack or Discord webhook. Other
+// chat services with Slack-compatible webhooks, like Mattermost, work too.
+type webhookArchiver struct {
+	url string
+}
+
+func (a webhookArchiver) Name() string {
+	if u, err := url.Parse(a.url); err == nil {
+		return u.Host
+	}
+	return a.url
+}
+
+func (a webhookArchiver) Target() string {
+	return a.url
+}
+
+func (a webhookArchiver) discord() bool {
+	host := a.Name()
+	return host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com")
+}
+
+func (a webhookArchiver) Archive(tabs []Tab) error {
+	return a.post(tr("Archived %d tabs, %s", len(tabs), time.Now().Format("2006-01-02 15:04")), tabs)
+}
+
+// post sends tabs, grouped by domain, under a heading, in as many messages
+// as it takes. If a message fails, the error is an unsentError with the
+// tabs of the messages that didn't go out.
+func (a webhookArchiver) post(heading string, tabs []Tab) error {
+	limit, field := slackMessageLimit, "text"
+	if a.discord() {
+		limit, field = discordMessageLimit, "content"
+	}
+	messages := a.messages(heading, tabs, limit)
+	for i, message := range messages {
+		if err := a.send(field, message.text); err != nil {
+			var unsent []Tab
+			for _, m := range messages[i:] {
+				unsent = append(unsent, m.tabs...)
+			}
+			return &unsentError{err: err, unsent: unsent}
+		}
+	}
+	return nil
+}
+
+// send posts one message.
+func (a webhookArchiver) send(field, text string) error {
+	body, err := json.Marshal(map[string]string{field: text})
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not post to %s: %w", a.Name(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("could not post to %s: %s", a.Name(), resp.Status)
+	}
+	return nil
+}
+
+// unsentError is a post that failed after some of its messages may have
+// gone out, with the tabs of those that didn't.
+type unsentError struct {
+	err    error
+	unsent []Tab
+}
+
+func (e *unsentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unsentError) Unwrap() error {
+	return e.err
+}
+
+// unsentTabs returns the tabs an archive that failed with err still has to
+// send: those of the messages a webhook didn't get, or else all of tabs.
+func unsentTabs(err error, tabs []Tab) []Tab {
+	var unsent *unsentError
+	if errors.As(err, &unsent) {
+		return unsent.unsent
+	}
+	return tabs
+}
+
+// webhookMessage is one message of a post, with the tabs it links.
+type webhookMessage struct {
+	text string
+	tabs []Tab
+}
+
+// messages formats the heading and the tabs by domain, in the order the
+// domains first appear, as Slack mrkdwn or Discord markdown, splitting
+// between lines to stay within limit.
+func (a webhookArchiver) messages(heading string, tabs []Tab, limit int) []webhookMessage {
+	var domains []string
+	byDomain := make(map[string][]Tab)
+	for _, tab := range tabs {
+		domain := extractDomain(tab.URL)
+		if _, ok := byDomain[domain]; !ok {
+			domains = append(domains, domain)
+		}
+		byDomain[domain] = append(byDomain[domain], tab)
+	}
+
+	// Each line with the tab it links, if any
+	type line struct {
+		text string
+		tab  *Tab
+	}
+	lines := []line{{text: "*" + heading + "*"}}
+	if a.discord() {
+		lines[0].text = "**" + heading + "**"
+	}
+	for _, domain := range domains {
+		lines = append(lines, line{}, line{text: "*" + domain + "*"})
+		for _, tab := range byDomain[domain] {
+			lines = append(lines, line{text: "• " + a.link(tab), tab: &tab})
+		}
+	}
+
+	var messages []webhookMessage
+	var message webhookMessage
+	var b strings.Builder
+	for _, l := range lines {
+		if b.Len() > 0 && b.Len()+len(l.text)+1 > limit {
+			message.text = b.String()
+			messages = append(messages, message)
+			message = webhookMessage{}
+			b.Reset()
+		}
+		text := l.text
+		if len(text) > limit {
+			text = strings.ToValidUTF8(text[:limit], "")
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(text)
+		if l.tab != nil {
+			message.tabs = append(message.tabs, *l.tab)
+		}
+	}
+	message.text = b.String()
+	return append(messages, message)
+}
+
+// link formats a tab as a link: <url|title> for Slack, and a markdown link,
+// without an embed, for Discord.
+func (a webhookArchiver) link(tab Tab) string {
+	title := tab.Title
+	if title == "" {
+		title = tab.URL
+	}
+	if a.discord() {
+		return fmt.Sprintf("[%s](<%s>)", strings.NewReplacer("[", `\[`, "]", `\]`).Replace(title), tab.URL)
+	}
+	title = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "|", "¦").Replace(title)
+	return fmt.Sprintf("<%s|%s>", tab.URL, title)
+}
+
+// postClosed posts closed tabs to the close webhook, if there is one.
+// Failing to post doesn't undo the close, so it's only logged.
+func postClosed(tabs []Tab) {
+	if closeWebhook == "" || len(tabs) == 0 {
+		return
+	}
+	a := webhookArchiver{url: closeWebhook}
+	if err := a.post(tr("Closed %d tabs, %s", len(tabs), time.Now().Format("2006-01-02 15:04")), tabs); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// The web UI served by serve mode at /, mirroring the interactive list.
+//
+//go:embed web
+var webFiles embed.FS
+
+func webUI() http.Handler {
+	root, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err) // The embedded directory is always there
+	}
+	return http.FileServerFS(root)
+}
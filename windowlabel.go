@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Safari can't name windows, so labeling windows puts a page naming each
+// window's main project in front of its tabs, where the tab bar shows it
+// first: the note set for the project in window_notes, or else a page
+// generated in the cache directory that lists the window's tabs. Labeling
+// again updates the label tabs in place. Label tabs are protected, so
+// cleanups leave them alone.
+
+var windowNotes map[string]string // Set from config.json
+
+// windowsLabeledMsg reports how many windows were labeled.
+type windowsLabeledMsg struct {
+	count int
+	err   error
+}
+
+func windowLabelDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "window-labels"), nil
+}
+
+// isWindowLabel reports whether a URL is a window's label: a generated page
+// or a project's note.
+func isWindowLabel(u string) bool {
+	if dir, err := windowLabelDir(); err == nil && strings.HasPrefix(u, fileURL(dir)+"/") {
+		return true
+	}
+	for _, note := range windowNotes {
+		if u == note {
+			return true
+		}
+	}
+	return false
+}
+
+// fileURL returns the file:// URL of a path.
+func fileURL(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// markWindowLabels protects label tabs.
+func markWindowLabels(tabs []Tab) {
+	for i := range tabs {
+		if isWindowLabel(tabs[i].URL) {
+			tabs[i].Protected = true
+		}
+	}
+}
+
+// plannedLabel is the label planned for a window.
+type plannedLabel struct {
+	window  int
+	project string
+	tabs    []Tab // The window's tabs, for the generated page
+	at      int   // Index of the first unpinned tab, where the label goes
+	replace bool  // The tab at is a label already
+}
+
+// planWindowLabels finds each window's main project: the one most of its
+// unpinned tabs belong to, if at least projectMinTabs do. Windows without
+// one aren't labeled.
+func planWindowLabels(tabs []Tab) []plannedLabel {
+	byWindow := make(map[int][]Tab)
+	var windows []int
+	for _, tab := range openTabs(tabs) {
+		if tab.Pinned {
+			continue
+		}
+		if _, ok := byWindow[tab.WindowIndex]; !ok {
+			windows = append(windows, tab.WindowIndex)
+		}
+		byWindow[tab.WindowIndex] = append(byWindow[tab.WindowIndex], tab)
+	}
+	sort.Ints(windows)
+
+	var labels []plannedLabel
+	for _, window := range windows {
+		windowTabs := byWindow[window]
+		label := plannedLabel{window: window, at: windowTabs[0].TabIndex, replace: isWindowLabel(windowTabs[0].URL)}
+		counts := make(map[string]int)
+		for _, tab := range windowTabs {
+			if tab.Project == "" || isWindowLabel(tab.URL) {
+				continue
+			}
+			counts[tab.Project]++
+			if n := counts[tab.Project]; n > counts[label.project] || (n == counts[label.project] && tab.Project < label.project) {
+				label.project = tab.Project
+			}
+		}
+		if counts[label.project] < projectMinTabs {
+			continue
+		}
+		for _, tab := range windowTabs {
+			if !isWindowLabel(tab.URL) {
+				label.tabs = append(label.tabs, tab)
+			}
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+var labelPage = template.Must(template.New("label").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>body { font: 15px -apple-system, sans-serif; max-width: 42em; margin: 3em auto; } li { margin: .3em 0; }</style>
+</head>
+<body>
+<h1>{{.Project}}</h1>
+<p>{{.Summary}}</p>
+<ul>
+{{range .Tabs}}<li><a href="{{.URL}}">{{.Name}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// writeLabelPage writes the generated label page of a window to name in
+// dir, and returns its URL.
+func writeLabelPage(dir, name string, label plannedLabel) (string, error) {
+	type link struct{ URL, Name string }
+	links := make([]link, len(label.tabs))
+	for i, tab := range label.tabs {
+		links[i] = link{URL: tab.URL, Name: tabName(tab)}
+	}
+	var page strings.Builder
+	err := labelPage.Execute(&page, map[string]any{
+		"Title":   tr("%s (%d tabs)", label.project, len(label.tabs)),
+		"Project": label.project,
+		"Summary": tr("Window %d, labeled %s.", label.window, shortDateTime(time.Now())),
+		"Tabs":    links,
+	})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(page.String()), 0o644); err != nil {
+		return "", err
+	}
+	return fileURL(path), nil
+}
+
+// labelWindowsAsync labels every window that has a main project, going by
+// the tabs as they are now.
+func labelWindowsAsync() tea.Cmd {
+	return func() tea.Msg {
+		raw, err := getSafariTabsRaw()
+		if err != nil {
+			return windowsLabeledMsg{err: err}
+		}
+		tabs, _ := markPinnedTabs(raw)
+		markProjects(tabs)
+		labels := planWindowLabels(tabs)
+		if len(labels) == 0 {
+			return windowsLabeledMsg{}
+		}
+
+		dir, err := windowLabelDir()
+		if err != nil {
+			return windowsLabeledMsg{err: err}
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return windowsLabeledMsg{err: err}
+		}
+
+		labeled := 0
+		names := make(map[string]bool)
+		for _, label := range labels {
+			target, ok := windowNotes[label.project]
+			if !ok {
+				// Windows on the same project get pages of their own, or
+				// they would be duplicates of each other
+				base := labelFileName(label.project)
+				name := base + ".html"
+				for n := 2; names[name]; n++ {
+					name = fmt.Sprintf("%s-%d.html", base, n)
+				}
+				names[name] = true
+				if target, err = writeLabelPage(dir, name, label); err != nil {
+					return windowsLabeledMsg{count: labeled, err: err}
+				}
+			}
+
+			script := fmt.Sprintf(`tell application %s to tell window %d to make new tab at before tab %d with properties {URL:%s}`, appleScriptString(safariApp), label.window, label.at, appleScriptString(target))
+			if label.replace {
+				script = fmt.Sprintf(`tell application %s to set URL of tab %d of window %d to %s`, appleScriptString(safariApp), label.at, label.window, appleScriptString(target))
+			}
+			if output, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+				log.Printf("Warning: could not label window %d: %v: %s", label.window, err, output)
+				continue
+			}
+			labeled++
+		}
+		return windowsLabeledMsg{count: labeled}
+	}
+}
+
+// labelFileName turns a project name into a file name.
+func labelFileName(project string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == ':' || r == ' ' {
+			return '-'
+		}
+		return r
+	}, strings.ToLower(project))
+	if name == "" {
+		return "window"
+	}
+	return name
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// A session can be limited to one Safari window, chosen with -window or
+// from a list at startup, so only its tabs are scanned and shown. Windows
+// are renumbered as they come to the front, so the choice is kept by the
+// window's id rather than its number.
+
+var onlyWindowID int // Set by -window or the window chooser; 0 for every window
+
+// safariWindow is a window as listed by listWindows.
+type safariWindow struct {
+	Index   int
+	ID      int
+	Tabs    int
+	Name    string
+	Display string // See labelDisplays; empty if not worth showing
+}
+
+// listWindows lists Safari's windows with their tab counts and the title of
+// their current tab, without reading every tab.
+func listWindows() ([]safariWindow, error) {
+	script := fmt.Sprintf(`
+	tell application "%s"
+		set output to ""
+		repeat with w from 1 to count of windows
+			try
+				set output to output & w & "|||" & (id of window w) & "|||" & (count of tabs of window w) & "|||" & (name of window w) & "###"
+			end try
+		end repeat
+		return output
+	end tell
+	`, safariApp)
+
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Safari windows: %w", err)
+	}
+
+	var windows []safariWindow
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "###") {
+		parts := strings.SplitN(line, "|||", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		var w safariWindow
+		w.Index, _ = strconv.Atoi(parts[0])
+		w.ID, _ = strconv.Atoi(parts[1])
+		w.Tabs, _ = strconv.Atoi(parts[2])
+		w.Name = parts[3]
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// windowByIndex returns the id of window n, numbered as Safari does,
+// frontmost first.
+func windowByIndex(windows []safariWindow, n int) (int, error) {
+	for _, w := range windows {
+		if w.Index == n {
+			return w.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("there is no window %d; Safari has %d windows", n, len(windows))
+}
+
+// windowLabel describes a window in the chooser.
+func windowLabel(w safariWindow) string {
+	name := w.Name
+	if strings.TrimSpace(name) == "" {
+		name = tr("Untitled")
+	}
+	label := tr("%s (%d tabs)", name, w.Tabs)
+	if w.Display != "" {
+		label += sym.separator + w.Display
+	}
+	return label
+}
+
+// labelDisplays sets the display of each window, if they are on more than
+// one, or on one that is gone.
+func labelDisplays(windows []safariWindow) {
+	displays, err := windowDisplays()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if !spread(displays) {
+		return
+	}
+	for i, w := range windows {
+		d := displays[w.Index]
+		windows[i].Display = displayLabel(d.name, d.gone)
+	}
+}
+
+// windowPicker is the chooser shown before the tabs are read.
+type windowPicker struct {
+	windows []safariWindow
+	cursor  int
+	chosen  int // Window id, 0 for every window
+	done    bool
+	width   int
+}
+
+func (p windowPicker) Init() tea.Cmd {
+	return nil
+}
+
+func (p windowPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(p.windows)-1 {
+				p.cursor++
+			}
+		case "enter", " ":
+			p.chosen, p.done = p.windows[p.cursor].ID, true
+			return p, tea.Quit
+		case "a":
+			p.chosen, p.done = 0, true
+			return p, tea.Quit
+		case "q", "esc", "ctrl+c":
+			return p, tea.Quit
+		default:
+			if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= len(p.windows) {
+				p.chosen, p.done = p.windows[n-1].ID, true
+				return p, tea.Quit
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p windowPicker) View() string {
+	var b strings.Builder
+	b.WriteString("\n" + titleStyle.Render(tr("Choose a window")) + "\n\n")
+	width := max(minTextWidth, p.width-2)
+	for i, w := range p.windows {
+		cursor := strings.Repeat(" ", runewidth.StringWidth(sym.cursor))
+		style := normalStyle
+		if i == p.cursor {
+			cursor = sym.cursor
+			style = style.Bold(true)
+		}
+		line := fmt.Sprintf("%s%d. %s", cursor, i+1, windowLabel(w))
+		b.WriteString("  " + style.Render(truncateEnd(line, width)) + "\n")
+	}
+	help := []string{sym.up + "/" + sym.down + ": " + tr("move"), tr("enter or 1-9: choose"), tr("a: all windows"), tr("q: quit")}
+	b.WriteString("\n  " + helpStyle.Render(strings.Join(help, sym.separator)) + "\n")
+	return b.String()
+}
+
+// pickWindow shows the window chooser and sets onlyWindowID. It returns
+// false if the user quit instead of choosing. With a single window there is
+// nothing to choose.
+func pickWindow() (bool, error) {
+	windows, err := listWindows()
+	if err != nil || len(windows) < 2 {
+		return true, err
+	}
+	labelDisplays(windows)
+	result, err := tea.NewProgram(windowPicker{windows: windows}, tea.WithAltScreen()).Run()
+	if err != nil {
+		return false, err
+	}
+	picker := result.(windowPicker)
+	onlyWindowID = picker.chosen
+	return picker.done, nil
+}
+
+// pickWindowPlain is pickWindow as a numbered prompt, for plain mode.
+func pickWindowPlain(in io.Reader, out io.Writer) (bool, error) {
+	windows, err := listWindows()
+	if err != nil || len(windows) < 2 {
+		return true, err
+	}
+	labelDisplays(windows)
+	reader := bufio.NewReader(in)
+	for i, w := range windows {
+		fmt.Fprintf(out, "%d. %s\n", i+1, windowLabel(w))
+	}
+	for {
+		fmt.Fprintf(out, "%s\n> ", tr("Choose a window by number, a for all windows, or q to quit:"))
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return false, nil
+		}
+		switch line = strings.TrimSpace(line); line {
+		case "a", "":
+			onlyWindowID = 0
+			return true, nil
+		case "q":
+			return false, nil
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(windows) {
+			onlyWindowID = windows[n-1].ID
+			return true, nil
+		}
+	}
+}